@@ -10,8 +10,14 @@ import (
 	"github.com/inconshreveable/log15"
 	"github.com/puppetlabs/errawr-go/v2/pkg/errawr"
 	"github.com/puppetlabs/horsehead/v2/instrumentation/alerts"
+	"github.com/puppetlabs/horsehead/v2/instrumentation/metrics"
+	"github.com/puppetlabs/horsehead/v2/instrumentation/metrics/delegates"
+	metricsserver "github.com/puppetlabs/horsehead/v2/instrumentation/metrics/server"
 	"github.com/puppetlabs/horsehead/v2/logging"
 	"github.com/puppetlabs/horsehead/v2/mainutil"
+	_ "github.com/puppetlabs/horsehead/v2/storage/file"
+	_ "github.com/puppetlabs/horsehead/v2/storage/gcs"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/audit"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/opt"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/sample"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/server"
@@ -28,7 +34,38 @@ func main() {
 
 	var servers []mainutil.CancelableFunc
 
-	// TODO: Add metrics server.
+	mets, err := metrics.NewNamespace("metadata_api", metrics.Options{
+		DelegateType:  delegates.PrometheusDelegate,
+		ErrorBehavior: metrics.ErrorBehaviorLog,
+	})
+	if err != nil {
+		log().Crit("failed to set up metrics", "error", err)
+		os.Exit(1)
+	}
+
+	if cfg.MetricsServerBindAddr != "" {
+		ms := metricsserver.New(mets, metricsserver.Options{
+			BindAddr: cfg.MetricsServerBindAddr,
+		})
+
+		servers = append(servers, ms.Run)
+	}
+
+	// Audit records are written in the background so that a slow sink does
+	// not delay responses.
+	var auditSink *audit.AsyncSink
+	if sink, err := cfg.AuditSink(); err != nil {
+		log().Crit("failed to initialize audit sink", "error", err)
+		os.Exit(1)
+	} else if sink != nil {
+		auditSink = audit.NewAsyncSink(
+			sink,
+			audit.AsyncSinkWithQueueSize(cfg.AuditSinkQueueSize),
+			audit.AsyncSinkWithMetrics(mets),
+		)
+
+		servers = append(servers, auditSink.Run)
+	}
 
 	servers = append(servers, func(ctx context.Context) error {
 		var auth middleware.Authenticator
@@ -69,12 +106,22 @@ func main() {
 				return err
 			}
 
-			auth = middleware.NewKubernetesAuthenticator(
-				cfg.KubernetesClientFactory,
+			authOpts := []middleware.KubernetesAuthenticatorOption{
 				middleware.KubernetesAuthenticatorWithKubernetesIntermediary(kc),
 				middleware.KubernetesAuthenticatorWithChainToVaultTransitIntermediary(vc, cfg.VaultTransitPath, cfg.VaultTransitKey),
 				middleware.KubernetesAuthenticatorWithVaultResolver(cfg.VaultAuthURL, cfg.VaultAuthPath, cfg.VaultAuthRole),
-			)
+				middleware.KubernetesAuthenticatorWithVaultAllowedRoles(cfg.VaultAuthAllowedRoles),
+				middleware.KubernetesAuthenticatorWithVaultTransitEncryption(vc, cfg.VaultTransitPath, cfg.VaultTransitKey),
+			}
+
+			bs, err := cfg.BlobStore()
+			if err != nil {
+				return fmt.Errorf("failed to initialize blob storage: %+v", err)
+			} else if bs != nil {
+				authOpts = append(authOpts, middleware.KubernetesAuthenticatorWithStepOutputBlobStore(bs, cfg.StepOutputBlobThresholdBytes))
+			}
+
+			auth = middleware.NewKubernetesAuthenticator(cfg.KubernetesClientFactory, authOpts...)
 		}
 
 		var serverOpts []server.Option
@@ -82,6 +129,10 @@ func main() {
 			serverOpts = append(serverOpts, server.WithErrorSensitivity(errawr.ErrorSensitivityAll))
 		}
 
+		if auditSink != nil {
+			serverOpts = append(serverOpts, server.WithAuditSink(auditSink))
+		}
+
 		if cfg.SentryDSN != "" {
 			delegate, err := alerts.DelegateToSentry(cfg.SentryDSN)
 			if err != nil {
@@ -18,16 +18,20 @@ import (
 	"github.com/puppetlabs/horsehead/v2/storage"
 	_ "github.com/puppetlabs/horsehead/v2/storage/file"
 	_ "github.com/puppetlabs/horsehead/v2/storage/gcs"
+	"github.com/puppetlabs/relay-core/pkg/activator"
 	"github.com/puppetlabs/relay-core/pkg/admission"
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
 	"github.com/puppetlabs/relay-core/pkg/config"
 	"github.com/puppetlabs/relay-core/pkg/controller/tenant"
 	"github.com/puppetlabs/relay-core/pkg/controller/trigger"
 	"github.com/puppetlabs/relay-core/pkg/controller/workflow"
 	"github.com/puppetlabs/relay-core/pkg/dependency"
+	"github.com/puppetlabs/relay-core/pkg/obj"
 	jose "gopkg.in/square/go-jose.v2"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
@@ -57,6 +61,16 @@ func main() {
 	sentryDSN := fs.String("sentry-dsn", "", "the Sentry DSN to use for error reporting")
 	dynamicRBACBinding := fs.Bool("dynamic-rbac-binding", false, "enable if RBAC rules are set up dynamically for the operator to reduce unhelpful reported errors")
 	toolInjectionImage := fs.String("tool-injection-image", "relaysh/relay-runtime-tools", "image to use for the tool injection suite")
+	webhookVerifierImage := fs.String("webhook-verifier-image", "relaysh/relay-webhook-verifier", "image to use for verifying webhook request signatures")
+	webhookTriggerBackend := fs.String("webhook-trigger-backend", string(relayv1beta1.TriggerBackendKnative), "the backend used to serve webhook triggers for tenants that do not select one (Knative or Kubernetes)")
+	webhookTriggerActivatorBindAddr := fs.String("webhook-trigger-activator-bind-addr", "", "the host:port to bind the webhook trigger activator to; if not specified, the activator is disabled")
+	webhookTriggerActivatorService := fs.String("webhook-trigger-activator-service", "", "the optionally namespaced name of the service in front of the webhook trigger activator")
+	webhookRouter := fs.String("webhook-router", obj.WebhookTriggerRouterAmbassador, "the kind of route used to expose webhook triggers (Ambassador, Ingress or Gateway)")
+	webhookDomain := fs.String("webhook-domain", "", "the DNS suffix of host names assigned to webhook triggers; required for the Ingress and Gateway routers")
+	webhookAmbassadorID := fs.String("webhook-ambassador-id", obj.AmbassadorID, "the Ambassador ID to use for webhook triggers with the Ambassador router")
+	webhookIngressClassName := fs.String("webhook-ingress-class-name", "", "the ingress class to use for webhook triggers with the Ingress router")
+	webhookGateway := fs.String("webhook-gateway", "", "the optionally namespaced name of the gateway to attach webhook trigger routes to with the Gateway router")
+	webhookTriggerActivatorIdleTimeout := fs.Duration("webhook-trigger-activator-idle-timeout", activator.DefaultIdleTimeout, "the time after which an idle webhook trigger is scaled to zero by the activator")
 
 	fs.Parse(os.Args[1:])
 
@@ -78,6 +92,23 @@ func main() {
 		log.Fatal("Error initializing the storage client from the -storage-addr", err)
 	}
 
+	switch backend := relayv1beta1.TriggerBackend(*webhookTriggerBackend); backend {
+	case relayv1beta1.TriggerBackendKnative, relayv1beta1.TriggerBackendKubernetes:
+	default:
+		log.Fatalf("Unknown webhook trigger backend -webhook-trigger-backend %q", backend)
+	}
+
+	if (*webhookTriggerActivatorBindAddr == "") != (*webhookTriggerActivatorService == "") {
+		log.Fatal("The webhook trigger activator requires both -webhook-trigger-activator-bind-addr and -webhook-trigger-activator-service")
+	}
+
+	if _, err := obj.NewWebhookTriggerRouter(*webhookRouter, obj.WebhookTriggerRouterOptions{
+		Domain:  *webhookDomain,
+		Gateway: client.ObjectKey{Name: *webhookGateway},
+	}); err != nil {
+		log.Fatal("Error configuring the webhook router -webhook-router", err)
+	}
+
 	if *webhookServerKeyDir == "" {
 		log.Fatal("The webhook server key directory -webhook-server-key-dir must be specified")
 	}
@@ -163,6 +194,16 @@ func main() {
 		AlertsDelegate:          alertsDelegate,
 		DynamicRBACBinding:      *dynamicRBACBinding,
 		ToolInjectionImage:      *toolInjectionImage,
+		WebhookVerifierImage:    *webhookVerifierImage,
+
+		WebhookTriggerBackend:          relayv1beta1.TriggerBackend(*webhookTriggerBackend),
+		WebhookTriggerActivatorService: *webhookTriggerActivatorService,
+
+		WebhookRouter:           *webhookRouter,
+		WebhookDomain:           *webhookDomain,
+		WebhookAmbassadorID:     *webhookAmbassadorID,
+		WebhookIngressClassName: *webhookIngressClassName,
+		WebhookGateway:          *webhookGateway,
 	}
 
 	dm, err := dependency.NewDependencyManager(cfg, kcc, vc, jwtSigner, blobStore, mets)
@@ -182,6 +223,18 @@ func main() {
 		log.Fatal("Could not add all controllers to operator manager", err)
 	}
 
+	if *webhookTriggerActivatorBindAddr != "" {
+		a := activator.New(
+			dm.Manager.GetClient(),
+			activator.WithBindAddr(*webhookTriggerActivatorBindAddr),
+			activator.WithIdleTimeout(*webhookTriggerActivatorIdleTimeout),
+		)
+
+		if err := dm.Manager.Add(a); err != nil {
+			log.Fatal("Could not add webhook trigger activator to operator manager", err)
+		}
+	}
+
 	dm.Manager.GetWebhookServer().Register("/mutate/pod-enforcement", &webhook.Admission{
 		Handler: admission.NewPodEnforcementHandler(
 			admission.PodEnforcementHandlerWithSandboxing(*tenantSandboxing),
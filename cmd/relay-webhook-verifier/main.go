@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/puppetlabs/horsehead/v2/mainutil"
+	"github.com/puppetlabs/relay-core/pkg/util/lifecycleutil"
+	"github.com/puppetlabs/relay-core/pkg/webhookverifier"
+)
+
+const (
+	EnvPort        = "PORT"
+	EnvUpstreamURL = "RELAY_WEBHOOK_VERIFIER_UPSTREAM_URL"
+	EnvPreset      = "RELAY_WEBHOOK_VERIFIER_PRESET"
+	EnvAlgorithm   = "RELAY_WEBHOOK_VERIFIER_ALGORITHM"
+	EnvHeader      = "RELAY_WEBHOOK_VERIFIER_HEADER"
+	EnvPrefix      = "RELAY_WEBHOOK_VERIFIER_PREFIX"
+	EnvSecret      = "RELAY_WEBHOOK_VERIFIER_SECRET"
+	EnvCapture     = "RELAY_WEBHOOK_VERIFIER_CAPTURE"
+	EnvMetadataAPI = "METADATA_API_URL"
+
+	EnvRequestsPerSecond = "RELAY_WEBHOOK_VERIFIER_REQUESTS_PER_SECOND"
+	EnvBurst             = "RELAY_WEBHOOK_VERIFIER_BURST"
+	EnvMaxBodyBytes      = "RELAY_WEBHOOK_VERIFIER_MAX_BODY_BYTES"
+
+	DefaultPort = "8080"
+)
+
+// config returns the verification configuration, or nil if no secret is
+// configured, in which case the proxy only captures requests.
+func config() (*webhookverifier.Config, error) {
+	secret, ok := os.LookupEnv(EnvSecret)
+	if !ok {
+		return nil, nil
+	}
+
+	var cfg webhookverifier.Config
+
+	if preset := os.Getenv(EnvPreset); preset != "" {
+		var err error
+
+		cfg, err = webhookverifier.NewConfigFromPreset(webhookverifier.Preset(preset))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if alg := os.Getenv(EnvAlgorithm); alg != "" {
+		cfg.Algorithm = webhookverifier.Algorithm(alg)
+	}
+
+	if header := os.Getenv(EnvHeader); header != "" {
+		cfg.Header = header
+	}
+
+	if prefix, ok := os.LookupEnv(EnvPrefix); ok {
+		cfg.Prefix = prefix
+	}
+
+	cfg.Secret = []byte(secret)
+
+	return &cfg, cfg.Validate()
+}
+
+// limits returns the handler options for the configured rate and request body
+// size limits.
+func limits() ([]webhookverifier.HandlerOption, error) {
+	var opts []webhookverifier.HandlerOption
+
+	if v := os.Getenv(EnvRequestsPerSecond); v != "" {
+		rps, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %+v", EnvRequestsPerSecond, err)
+		}
+
+		var burst int64
+		if v := os.Getenv(EnvBurst); v != "" {
+			burst, err = strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %+v", EnvBurst, err)
+			}
+		}
+
+		opts = append(opts, webhookverifier.HandlerWithRateLimit(int32(rps), int32(burst)))
+	}
+
+	if v := os.Getenv(EnvMaxBodyBytes); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %+v", EnvMaxBodyBytes, err)
+		}
+
+		opts = append(opts, webhookverifier.HandlerWithMaxBodyBytes(n))
+	}
+
+	return opts, nil
+}
+
+func main() {
+	cfg, err := config()
+	if err != nil {
+		log.Fatal("Error configuring verification: ", err)
+	}
+
+	upstream, err := url.Parse(os.Getenv(EnvUpstreamURL))
+	if err != nil || upstream.Host == "" {
+		log.Fatalf("Error parsing %s: %+v", EnvUpstreamURL, err)
+	}
+
+	opts, err := limits()
+	if err != nil {
+		log.Fatal("Error configuring limits: ", err)
+	}
+
+	// Requests are reported to the metadata API for delivery statistics and,
+	// if enabled, captured there.
+	if v := os.Getenv(EnvMetadataAPI); v != "" {
+		metadataAPIURL, err := url.Parse(v)
+		if err != nil || metadataAPIURL.Host == "" {
+			log.Fatalf("Error parsing %s: %+v", EnvMetadataAPI, err)
+		}
+
+		opts = append(opts, webhookverifier.HandlerWithRecorder(webhookverifier.NewMetadataAPIRecorder(metadataAPIURL)))
+	}
+
+	if capture, _ := strconv.ParseBool(os.Getenv(EnvCapture)); capture {
+		if os.Getenv(EnvMetadataAPI) == "" {
+			log.Fatalf("%s must be set to capture requests", EnvMetadataAPI)
+		}
+
+		opts = append(opts, webhookverifier.HandlerWithCapture())
+	} else if cfg == nil && os.Getenv(EnvMetadataAPI) == "" {
+		log.Fatalf("Either %s or %s must be set", EnvSecret, EnvMetadataAPI)
+	}
+
+	port := os.Getenv(EnvPort)
+	if port == "" {
+		port = DefaultPort
+	}
+
+	s := &http.Server{
+		Handler: webhookverifier.NewHandler(cfg, upstream, opts...),
+		Addr:    fmt.Sprintf("0.0.0.0:%s", port),
+	}
+
+	os.Exit(mainutil.TrapAndWait(context.Background(), func(ctx context.Context) error {
+		return lifecycleutil.ListenWaitHTTP(ctx, s)
+	}))
+}
@@ -4,6 +4,7 @@ go 1.13
 
 require (
 	cloud.google.com/go v0.57.0
+	github.com/NYTimes/gziphandler v1.1.1
 	github.com/PaesslerAG/gval v1.0.2-0.20190803062529-6fceb06ca162 // indirect
 	github.com/PaesslerAG/jsonpath v0.1.1 // indirect
 	github.com/containerd/continuity v0.0.0-20200413184840-d3ef23f19fbb // indirect
@@ -39,6 +40,7 @@ require (
 	github.com/tektoncd/pipeline v0.12.0
 	github.com/xeipuuv/gojsonschema v1.2.0
 	golang.org/x/net v0.0.0-20200520182314-0ba52f642ac2
+	golang.org/x/time v0.0.0-20191024005414-555d28b269f0
 	google.golang.org/genproto v0.0.0-20200603110839-e855014d5736
 	gopkg.in/ini.v1 v1.48.0 // indirect
 	gopkg.in/square/go-jose.v2 v2.4.1
@@ -0,0 +1,299 @@
+// Package activator scales webhook trigger deployments run by the Kubernetes
+// backend up from zero when a request arrives for them, and back down to zero
+// once they have been idle for a while.
+package activator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/obj"
+	"github.com/puppetlabs/relay-core/pkg/util/lifecycleutil"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	DefaultIdleTimeout  = 5 * time.Minute
+	DefaultReadyTimeout = 2 * time.Minute
+)
+
+var (
+	ErrInvalidHost = errors.New("activator: host is not the service host name of a webhook trigger")
+	ErrNotManaged  = errors.New("activator: deployment is not managed by the activator")
+)
+
+type workload struct {
+	lastRequest time.Time
+	inFlight    int
+}
+
+// Activator proxies requests to webhook trigger deployments, scaling them up
+// from zero replicas as needed.
+//
+// Requests are routed by host name, which must be the in-cluster host name of
+// the webhook trigger service, <name>.<namespace>.svc.cluster.local. The
+// webhook verifier in front of every trigger forwards requests with this host
+// name, whatever router exposes the trigger. Other host names, like the public
+// host name of a trigger, are rejected. Only deployments labeled with
+// obj.WebhookTriggerActivatorLabel are considered.
+type Activator struct {
+	cl           client.Client
+	bindAddr     string
+	idleTimeout  time.Duration
+	readyTimeout time.Duration
+	pollInterval time.Duration
+
+	mut       sync.Mutex
+	workloads map[client.ObjectKey]*workload
+}
+
+var _ http.Handler = &Activator{}
+var _ manager.Runnable = &Activator{}
+
+func (a *Activator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, err := keyFromServiceHost(r.Host)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	d := obj.NewDeployment(key)
+	if ok, err := d.Load(ctx, a.cl); err != nil {
+		log(ctx).Error("failed to load deployment", "deployment", key, "error", err)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	} else if !ok || d.Object.GetLabels()[obj.WebhookTriggerActivatorLabel] != "true" {
+		http.NotFound(w, r)
+		return
+	}
+
+	a.acquire(key)
+	defer a.release(key)
+
+	if err := a.activate(ctx, d); err != nil {
+		log(ctx).Warn("failed to activate deployment", "deployment", key, "error", err)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	upstream := obj.ServiceHost(obj.WebhookTriggerPrivateServiceKey(key))
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			r.URL.Scheme = "http"
+			r.URL.Host = upstream
+			r.Host = upstream
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// Start runs the activator HTTP server and scales idle deployments down until
+// the stop channel is closed.
+func (a *Activator) Start(stop <-chan struct{}) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	go wait.Until(func() { a.deactivateIdle(ctx) }, a.idleTimeout/2, ctx.Done())
+
+	s := &http.Server{
+		Addr:    a.bindAddr,
+		Handler: a,
+	}
+
+	log(ctx).Info("listening for activator connections", "addr", a.bindAddr)
+	return lifecycleutil.ListenWaitHTTP(ctx, s)
+}
+
+func (a *Activator) acquire(key client.ObjectKey) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	wl, found := a.workloads[key]
+	if !found {
+		wl = &workload{}
+		a.workloads[key] = wl
+	}
+
+	wl.lastRequest = time.Now()
+	wl.inFlight++
+}
+
+func (a *Activator) release(key client.ObjectKey) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	wl := a.workloads[key]
+	wl.lastRequest = time.Now()
+	wl.inFlight--
+}
+
+func (a *Activator) activate(ctx context.Context, d *obj.Deployment) error {
+	if err := a.scale(ctx, d, 1, func(replicas int32) bool { return replicas == 0 }); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.readyTimeout)
+	defer cancel()
+
+	return wait.PollImmediateUntil(a.pollInterval, func() (bool, error) {
+		if _, err := d.Load(ctx, a.cl); err != nil {
+			return false, err
+		}
+
+		return d.Object.Status.AvailableReplicas > 0, nil
+	}, ctx.Done())
+}
+
+// scale sets the number of replicas of the deployment if the current number
+// matches the given predicate.
+func (a *Activator) scale(ctx context.Context, d *obj.Deployment, replicas int32, pred func(replicas int32) bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if ok, err := d.Load(ctx, a.cl); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("deployment %s no longer exists", d.Key)
+		} else if d.Object.GetLabels()[obj.WebhookTriggerActivatorLabel] != "true" {
+			return ErrNotManaged
+		}
+
+		current := int32(1)
+		if d.Object.Spec.Replicas != nil {
+			current = *d.Object.Spec.Replicas
+		}
+
+		if !pred(current) {
+			return nil
+		}
+
+		d.Object.Spec.Replicas = &replicas
+		return a.cl.Update(ctx, d.Object)
+	})
+}
+
+func (a *Activator) deactivateIdle(ctx context.Context) {
+	now := time.Now()
+
+	a.mut.Lock()
+
+	var idle []client.ObjectKey
+	for key, wl := range a.workloads {
+		if wl.inFlight == 0 && now.Sub(wl.lastRequest) >= a.idleTimeout {
+			idle = append(idle, key)
+			delete(a.workloads, key)
+		}
+	}
+
+	a.mut.Unlock()
+
+	for _, key := range idle {
+		d := obj.NewDeployment(key)
+
+		// A request may have arrived since we checked, in which case it will
+		// have scaled the deployment up again on its own.
+		if err := a.scale(ctx, d, 0, func(replicas int32) bool { return replicas > 0 }); err != nil && err != ErrNotManaged {
+			log(ctx).Warn("failed to deactivate idle deployment", "deployment", key, "error", err)
+		}
+	}
+
+	// Deployments may have been scaled up by a previous instance of the
+	// activator. We start tracking them now so they are eventually scaled
+	// down.
+	var l appsv1.DeploymentList
+	if err := a.cl.List(ctx, &l, client.MatchingLabels{obj.WebhookTriggerActivatorLabel: "true"}); err != nil {
+		log(ctx).Warn("failed to list activated deployments", "error", err)
+		return
+	}
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	for _, d := range l.Items {
+		if d.Spec.Replicas != nil && *d.Spec.Replicas == 0 {
+			continue
+		}
+
+		key := client.ObjectKey{Namespace: d.GetNamespace(), Name: d.GetName()}
+		if _, found := a.workloads[key]; !found {
+			a.workloads[key] = &workload{lastRequest: now}
+		}
+	}
+}
+
+// keyFromServiceHost returns the key of the service addressed by a host name
+// created by obj.ServiceHost.
+func keyFromServiceHost(host string) (client.ObjectKey, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	suffix := "." + obj.ServiceClusterDomain
+	if !strings.HasSuffix(host, suffix) {
+		return client.ObjectKey{}, ErrInvalidHost
+	}
+
+	parts := strings.Split(strings.TrimSuffix(host, suffix), ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return client.ObjectKey{}, ErrInvalidHost
+	}
+
+	return client.ObjectKey{Namespace: parts[1], Name: parts[0]}, nil
+}
+
+type Option func(a *Activator)
+
+func WithBindAddr(addr string) Option {
+	return func(a *Activator) {
+		a.bindAddr = addr
+	}
+}
+
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(a *Activator) {
+		a.idleTimeout = timeout
+	}
+}
+
+func WithReadyTimeout(timeout time.Duration) Option {
+	return func(a *Activator) {
+		a.readyTimeout = timeout
+	}
+}
+
+func New(cl client.Client, opts ...Option) *Activator {
+	a := &Activator{
+		cl:           cl,
+		bindAddr:     ":8080",
+		idleTimeout:  DefaultIdleTimeout,
+		readyTimeout: DefaultReadyTimeout,
+		pollInterval: 250 * time.Millisecond,
+		workloads:    make(map[client.ObjectKey]*workload),
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
@@ -0,0 +1,68 @@
+package activator
+
+import (
+	"testing"
+
+	"github.com/puppetlabs/relay-core/pkg/obj"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestKeyFromServiceHost(t *testing.T) {
+	tcs := []struct {
+		Host        string
+		ExpectedKey client.ObjectKey
+		ExpectedErr error
+	}{
+		{
+			Host:        "my-trigger.tenant-ns.svc.cluster.local",
+			ExpectedKey: client.ObjectKey{Namespace: "tenant-ns", Name: "my-trigger"},
+		},
+		{
+			Host:        "my-trigger.tenant-ns.svc.cluster.local:80",
+			ExpectedKey: client.ObjectKey{Namespace: "tenant-ns", Name: "my-trigger"},
+		},
+		{
+			Host:        obj.ServiceHost(client.ObjectKey{Namespace: "tenant-ns", Name: "my-trigger"}),
+			ExpectedKey: client.ObjectKey{Namespace: "tenant-ns", Name: "my-trigger"},
+		},
+		{
+			// Public host names do not follow any particular layout.
+			Host:        "my-trigger.tenant-ns.example.com",
+			ExpectedErr: ErrInvalidHost,
+		},
+		{
+			Host:        "hooks.example.com",
+			ExpectedErr: ErrInvalidHost,
+		},
+		{
+			Host:        "my-trigger.tenant-ns",
+			ExpectedErr: ErrInvalidHost,
+		},
+		{
+			Host:        "a.my-trigger.tenant-ns.svc.cluster.local",
+			ExpectedErr: ErrInvalidHost,
+		},
+		{
+			Host:        "tenant-ns.svc.cluster.local",
+			ExpectedErr: ErrInvalidHost,
+		},
+		{
+			Host:        "localhost:8080",
+			ExpectedErr: ErrInvalidHost,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.Host, func(t *testing.T) {
+			key, err := keyFromServiceHost(tc.Host)
+			if tc.ExpectedErr != nil {
+				require.Equal(t, tc.ExpectedErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.ExpectedKey, key)
+		})
+	}
+}
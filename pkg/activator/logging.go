@@ -0,0 +1,15 @@
+package activator
+
+import (
+	"context"
+
+	"github.com/puppetlabs/horsehead/v2/logging"
+)
+
+var (
+	logger = logging.Builder().At("relay-core", "pkg", "activator")
+)
+
+func log(ctx context.Context) logging.Logger {
+	return logger.With(ctx).Build()
+}
@@ -24,6 +24,11 @@ func (eh *VolumeClaimHandler) Handle(ctx context.Context, req admission.Request)
 	}
 
 	if claim, ok := pod.ObjectMeta.GetAnnotations()[model.RelayControllerToolsVolumeClaimAnnotation]; ok {
+		mountPath := pod.ObjectMeta.GetAnnotations()[model.RelayControllerToolsMountPathAnnotation]
+		if mountPath == "" {
+			mountPath = model.ToolInjectionMountPath
+		}
+
 		cs := make([]corev1.Container, 0)
 
 		updated := false
@@ -39,7 +44,7 @@ func (eh *VolumeClaimHandler) Handle(ctx context.Context, req admission.Request)
 			if !hasVolumeMount {
 				c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
 					Name:      model.ToolInjectionMountName,
-					MountPath: model.ToolInjectionMountPath,
+					MountPath: mountPath,
 					ReadOnly:  true,
 				})
 
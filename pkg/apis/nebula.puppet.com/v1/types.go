@@ -98,6 +98,23 @@ type WorkflowRunStatus struct {
 
 	// +optional
 	Conditions map[string]WorkflowRunStatusSummary `json:"conditions,omitempty"`
+
+	// Tenant reports whether the tenant referenced by the run could be found.
+	// A run that refers to a tenant that cannot be found fails without
+	// starting.
+	//
+	// +optional
+	Tenant *WorkflowRunTenantStatus `json:"tenant,omitempty"`
+}
+
+type WorkflowRunTenantStatus struct {
+	relayv1beta1.Condition `json:",inline"`
+
+	// Namespace is the namespace of the tenant, which is not the namespace of
+	// the run if the tenant manages the namespace of the run.
+	//
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 }
 
 type WorkflowRunState struct {
@@ -195,6 +195,11 @@ func (in *WorkflowRunStatus) DeepCopyInto(out *WorkflowRunStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Tenant != nil {
+		in, out := &in.Tenant, &out.Tenant
+		*out = new(WorkflowRunTenantStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowRunStatus.
@@ -230,6 +235,22 @@ func (in *WorkflowRunStatusSummary) DeepCopy() *WorkflowRunStatusSummary {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowRunTenantStatus) DeepCopyInto(out *WorkflowRunTenantStatus) {
+	*out = *in
+	in.Condition.DeepCopyInto(&out.Condition)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowRunTenantStatus.
+func (in *WorkflowRunTenantStatus) DeepCopy() *WorkflowRunTenantStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowRunTenantStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkflowStep) DeepCopyInto(out *WorkflowStep) {
 	*out = *in
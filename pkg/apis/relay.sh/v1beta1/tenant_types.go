@@ -31,13 +31,96 @@ type TenantSpec struct {
 	ToolInjection ToolInjection `json:"toolInjection,omitempty"`
 
 	// TriggerEventSink represents the destination for events received as part
-	// of trigger processing. If not specified, events will be logged and
+	// of trigger processing or emitted by the steps of workflow runs that
+	// reference this tenant. If not specified, events will be logged and
 	// discarded.
 	//
 	// +optional
 	TriggerEventSink TriggerEventSink `json:"triggerEventSink,omitempty"`
+
+	// Secrets configures the backend used to look up secrets and connections
+	// for runs and triggers belonging to this tenant. If not specified, the
+	// backend locations are read from annotations on each run or trigger.
+	//
+	// +optional
+	Secrets TenantSecrets `json:"secrets,omitempty"`
+
+	// DeletionPolicy determines what happens to the resources managed by this
+	// tenant when the tenant is deleted. If not specified, the Delete policy
+	// is used.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Orphan;Drain;Delete
+	DeletionPolicy TenantDeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// DrainTimeout is the maximum amount of time to wait for in-flight
+	// workflow runs to complete when the deletion policy is Drain. If not
+	// specified, the tenant will wait for up to one hour.
+	//
+	// +optional
+	DrainTimeout *metav1.Duration `json:"drainTimeout,omitempty"`
+
+	// TriggerBackend selects the workload type used to serve webhook triggers
+	// belonging to this tenant. If not specified, the backend configured for
+	// the operator is used.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Knative;Kubernetes
+	TriggerBackend TriggerBackend `json:"triggerBackend,omitempty"`
+
+	// Defaults are merged into the configuration of every step and trigger
+	// that belongs to this tenant.
+	//
+	// +optional
+	Defaults TenantDefaults `json:"defaults,omitempty"`
 }
 
+type TenantDefaults struct {
+	// Spec is merged into the spec of each step and trigger using the same
+	// semantics as the merge function. Values specified by the step or
+	// trigger take precedence.
+	//
+	// +optional
+	Spec UnstructuredObject `json:"spec,omitempty"`
+
+	// Env contains environment variables provided to each step and trigger
+	// that does not define a variable of the same name.
+	//
+	// +optional
+	Env UnstructuredObject `json:"env,omitempty"`
+}
+
+type TriggerBackend string
+
+const (
+	// TriggerBackendKnative serves webhook triggers using Knative Serving.
+	TriggerBackendKnative TriggerBackend = "Knative"
+
+	// TriggerBackendKubernetes serves webhook triggers using a Deployment and
+	// a Service. If the trigger allows scaling to zero, requests are routed
+	// through the activator run by the operator.
+	TriggerBackendKubernetes TriggerBackend = "Kubernetes"
+)
+
+type TenantDeletionPolicy string
+
+const (
+	// TenantDeletionPolicyOrphan leaves the namespace managed by the tenant
+	// and all of its contents, including the resources of its webhook
+	// triggers, in place while the tenant is deleted.
+	TenantDeletionPolicyOrphan TenantDeletionPolicy = "Orphan"
+
+	// TenantDeletionPolicyDrain stops accepting new trigger events and waits
+	// for in-flight workflow runs in the managed namespace or that reference
+	// the tenant to complete, up to the drain timeout, before removing the
+	// managed namespace.
+	TenantDeletionPolicyDrain TenantDeletionPolicy = "Drain"
+
+	// TenantDeletionPolicyDelete immediately removes the managed namespace
+	// when the tenant is deleted.
+	TenantDeletionPolicyDelete TenantDeletionPolicy = "Delete"
+)
+
 type NamespaceTemplate struct {
 	// Metadata is the metadata to associate with the namespace to create, such
 	// as a name and list of labels. If not specified, values are automatically
@@ -57,6 +140,27 @@ type ToolInjection struct {
 	//
 	// +optional
 	VolumeClaimTemplate *corev1.PersistentVolumeClaim `json:"volumeClaimTemplate,omitempty"`
+
+	// Image is the image containing the tools to copy into the volume. If not
+	// specified, the image configured for the operator is used.
+	//
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Digest pins the image to a specific content digest, e.g.
+	// "sha256:...". Changing the image or the digest causes a new version of
+	// the volume to be populated.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]+:[a-f0-9]+$`
+	Digest string `json:"digest,omitempty"`
+
+	// MountPath is the path at which the tools are mounted in tenant
+	// containers. If not specified, the tools are mounted at
+	// /var/lib/puppet/relay/.
+	//
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
 }
 
 // TriggerEventSink represents the destination for trigger events. At most one
@@ -67,6 +171,83 @@ type TriggerEventSink struct {
 	//
 	// +optional
 	API *APITriggerEventSink `json:"api,omitempty"`
+
+	// Direct is an event sink that creates a workflow run in the tenant
+	// namespace for each event.
+	//
+	// +optional
+	Direct *DirectTriggerEventSink `json:"direct,omitempty"`
+
+	// Sinks are additional destinations for events. Each event is delivered
+	// to every sink in this list whose condition it matches as well as to the
+	// sink specified by the other fields, if any.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Sinks []NamedTriggerEventSink `json:"sinks,omitempty"`
+}
+
+// NamedTriggerEventSink is an event sink that only receives the events
+// matching its condition. Exactly one of the API and Direct fields must be
+// specified.
+type NamedTriggerEventSink struct {
+	// Name identifies this sink in the delivery statistics of triggers.
+	Name string `json:"name"`
+
+	// When is a condition evaluated against the data of each event using the
+	// same expressions as the when clause of a step. The event data is
+	// available using the Data type. If not specified, every event is
+	// delivered to this sink.
+	//
+	// +optional
+	When Unstructured `json:"when,omitempty"`
+
+	// API is an event sink for the proprietary Relay API.
+	//
+	// +optional
+	API *APITriggerEventSink `json:"api,omitempty"`
+
+	// Direct is an event sink that creates a workflow run in the tenant
+	// namespace for each event.
+	//
+	// +optional
+	Direct *DirectTriggerEventSink `json:"direct,omitempty"`
+}
+
+// TenantSecrets represents the backend for secrets and connections. At most
+// one of the fields may be specified at any one given time. If more than one is
+// specified, the behavior is undefined.
+type TenantSecrets struct {
+	// Vault is a secrets backend backed by a Vault KV version 2 secrets engine.
+	//
+	// +optional
+	Vault *VaultTenantSecrets `json:"vault,omitempty"`
+}
+
+type VaultTenantSecrets struct {
+	// EngineMount is the path to the KV version 2 secrets engine.
+	EngineMount string `json:"engineMount"`
+
+	// SecretPath is the path, relative to the engine mount, under which
+	// secrets are stored.
+	//
+	// +optional
+	SecretPath string `json:"secretPath,omitempty"`
+
+	// ConnectionPath is the path, relative to the engine mount, under which
+	// connections are stored.
+	//
+	// +optional
+	ConnectionPath string `json:"connectionPath,omitempty"`
+
+	// AuthRole is the name of the role to use when authenticating to Vault.
+	// If not specified, the default role configured for the metadata API is
+	// used. The role must match one of the roles the metadata API is
+	// configured to allow.
+	//
+	// +optional
+	AuthRole string `json:"authRole,omitempty"`
 }
 
 type APITriggerEventSink struct {
@@ -90,6 +271,34 @@ type APITokenSource struct {
 	SecretKeyRef *SecretKeySelector `json:"secretKeyRef,omitempty"`
 }
 
+type DirectTriggerEventSink struct {
+	// WorkflowFrom selects the workflow definition to run.
+	WorkflowFrom WorkflowSource `json:"workflowFrom"`
+
+	// Parameters maps the data of an event to the parameters of the workflow
+	// run. Values are evaluated using the same expressions as step specs, and
+	// the event data is available using the Data type. If not specified, the
+	// defaults from the workflow definition are used.
+	//
+	// +optional
+	Parameters UnstructuredObject `json:"parameters,omitempty"`
+}
+
+type WorkflowSource struct {
+	// ConfigMapKeyRef selects a Relay workflow definition, in YAML format, by
+	// looking up the value in a config map.
+	//
+	// +optional
+	ConfigMapKeyRef *ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+type ConfigMapKeySelector struct {
+	corev1.LocalObjectReference `json:",inline"`
+
+	// Key is the key from the config map to use.
+	Key string `json:"key"`
+}
+
 type SecretKeySelector struct {
 	corev1.LocalObjectReference `json:",inline"`
 
@@ -116,6 +325,48 @@ type TenantStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []TenantCondition `json:"conditions,omitempty"`
+
+	// Drain is the progress of draining this tenant. It is only set when the
+	// tenant is being deleted using the Drain deletion policy.
+	//
+	// +optional
+	Drain *TenantDrainStatus `json:"drain,omitempty"`
+
+	// ToolInjection is the version of the tool injection volume currently
+	// attached to new tenant containers.
+	//
+	// +optional
+	ToolInjection *TenantToolInjectionStatus `json:"toolInjection,omitempty"`
+}
+
+type TenantToolInjectionStatus struct {
+	// Version identifies the contents of the tool injection volume. It
+	// changes whenever the image or digest changes.
+	Version string `json:"version"`
+
+	// Image is the full reference of the image used to populate the volume.
+	Image string `json:"image"`
+
+	// VolumeClaimName is the name of the read-only volume claim attached to
+	// tenant containers.
+	VolumeClaimName string `json:"volumeClaimName"`
+
+	// MountPath is the path at which the tools are mounted in tenant
+	// containers.
+	MountPath string `json:"mountPath"`
+}
+
+type TenantDrainStatus struct {
+	// StartTime is the time at which the tenant started draining.
+	StartTime metav1.Time `json:"startTime"`
+
+	// Deadline is the time after which the tenant will be torn down
+	// regardless of whether any workflow runs are still in progress.
+	Deadline metav1.Time `json:"deadline"`
+
+	// ActiveWorkflowRuns is the number of workflow runs belonging to this
+	// tenant that have not yet completed.
+	ActiveWorkflowRuns int32 `json:"activeWorkflowRuns"`
 }
 
 type TenantConditionType string
@@ -129,6 +380,10 @@ const (
 	// example, any secret references must be resolvable.
 	TenantEventSinkReady TenantConditionType = "EventSinkReady"
 
+	// TenantSecretsReady indicates whether the secrets backend configuration
+	// is valid.
+	TenantSecretsReady TenantConditionType = "SecretsReady"
+
 	// TenantToolInjectionReady indicates whether the tool injection
 	// suite is ready to use.
 	TenantToolInjectionReady TenantConditionType = "ToolInjectionReady"
@@ -142,7 +397,7 @@ type TenantCondition struct {
 
 	// Type is the identifier for this condition.
 	//
-	// +kubebuilder:validation:Enum=NamespaceReady;EventSinkReady;ToolInjectionReady;Ready
+	// +kubebuilder:validation:Enum=NamespaceReady;EventSinkReady;SecretsReady;ToolInjectionReady;Ready
 	Type TenantConditionType `json:"type"`
 }
 
@@ -9,6 +9,13 @@ import (
 //
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.url`
+// +kubebuilder:printcolumn:name="Last Request",type=date,JSONPath=`.status.deliveries.lastRequestTime`
+// +kubebuilder:printcolumn:name="Last Event",type=date,JSONPath=`.status.deliveries.lastEventTime`
+// +kubebuilder:printcolumn:name="Received",type=integer,JSONPath=`.status.deliveries.requestsReceived`
+// +kubebuilder:printcolumn:name="Emitted",type=integer,JSONPath=`.status.deliveries.eventsEmitted`
+// +kubebuilder:printcolumn:name="Failed",type=integer,JSONPath=`.status.deliveries.failed`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 // +kubebuilder:storageversion
 type WebhookTrigger struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -56,6 +63,205 @@ type WebhookTriggerSpec struct {
 	//
 	// +optional
 	Env UnstructuredObject `json:"env,omitempty"`
+
+	// Verification configures checking the signature of incoming requests.
+	// Requests that fail verification are rejected before they reach the
+	// container.
+	//
+	// +optional
+	Verification *WebhookTriggerVerification `json:"verification,omitempty"`
+
+	// Scaling configures how many instances of the container may run and how
+	// many requests each instance handles. The webhook verifier in front of
+	// the container follows the same instance limits, but if the trigger's
+	// rate is limited it runs no more instances than requests per second.
+	//
+	// +optional
+	Scaling *WebhookTriggerScaling `json:"scaling,omitempty"`
+
+	// Route requests a specific address for this webhook trigger. If not
+	// specified, the address is assigned by the operator.
+	//
+	// +optional
+	Route *WebhookTriggerRoute `json:"route,omitempty"`
+
+	// Capture records the most recent requests received by this webhook
+	// trigger for debugging. Captured requests can be replayed by setting the
+	// relay.sh/replay-webhook-request annotation to the ID of a request.
+	//
+	// +optional
+	Capture *WebhookTriggerCapture `json:"capture,omitempty"`
+
+	// Limits restricts the requests accepted by this webhook trigger and the
+	// events it may emit.
+	//
+	// +optional
+	Limits *WebhookTriggerLimits `json:"limits,omitempty"`
+}
+
+type WebhookTriggerLimits struct {
+	// RequestsPerSecond is the sustained rate of requests accepted by the
+	// webhook trigger. The same rate applies to the events emitted by the
+	// trigger. Requests over the limit receive a 429 Too Many Requests
+	// response. If not specified or 0, the rate is not limited.
+	//
+	// The rate is enforced by the webhook verifier, each instance of which
+	// accepts an equal share of it. The verifier scales as configured, but
+	// it runs at most 10 instances if no maximum scale is specified, and
+	// never more instances than requests per second.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RequestsPerSecond int32 `json:"requestsPerSecond,omitempty"`
+
+	// Burst is the number of requests accepted at once before the rate limit
+	// applies. If not specified or 0, it is the same as RequestsPerSecond.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Burst int32 `json:"burst,omitempty"`
+
+	// MaxBodyBytes is the largest request body accepted by the webhook
+	// trigger. Larger requests receive a 413 Request Entity Too Large
+	// response. If not specified or 0, the default of the webhook verifier
+	// (10 MiB) applies.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxBodyBytes int64 `json:"maxBodyBytes,omitempty"`
+}
+
+type WebhookTriggerCapture struct {
+	// Requests is the number of requests to keep. Older requests are
+	// discarded. If not specified, 10 requests are kept.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=25
+	Requests int32 `json:"requests,omitempty"`
+}
+
+type WebhookTriggerRoute struct {
+	// Host is the host name to serve this webhook trigger at. It must not be
+	// used by any other webhook trigger with the same path. If not specified,
+	// a host name is assigned by the operator.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	Host string `json:"host,omitempty"`
+
+	// Path is the path prefix to serve this webhook trigger at. If not
+	// specified, all paths are served.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^/`
+	Path string `json:"path,omitempty"`
+
+	// TLS configures the certificate to serve this webhook trigger with.
+	//
+	// +optional
+	TLS *WebhookTriggerRouteTLS `json:"tls,omitempty"`
+}
+
+type WebhookTriggerRouteTLS struct {
+	// SecretName is the name of a secret of type kubernetes.io/tls in the
+	// namespace of the webhook trigger containing the certificate and private
+	// key for the host.
+	SecretName string `json:"secretName"`
+}
+
+type WebhookTriggerScaling struct {
+	// MinScale is the minimum number of instances to keep running. Set it to
+	// at least 1 to avoid cold starts for latency-sensitive webhooks.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MinScale *int32 `json:"minScale,omitempty"`
+
+	// MaxScale is the maximum number of instances to run. If not specified or
+	// 0, the number of instances is not limited.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxScale *int32 `json:"maxScale,omitempty"`
+
+	// ContainerConcurrency is the maximum number of requests handled by each
+	// instance at the same time. If not specified or 0, the number of
+	// requests is not limited.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ContainerConcurrency *int64 `json:"containerConcurrency,omitempty"`
+
+	// TimeoutSeconds is the maximum amount of time the container may take to
+	// respond to a request.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty"`
+}
+
+type WebhookTriggerVerificationPreset string
+
+const (
+	// WebhookTriggerVerificationPresetGitHub verifies the
+	// X-Hub-Signature-256 header sent by GitHub.
+	WebhookTriggerVerificationPresetGitHub WebhookTriggerVerificationPreset = "GitHub"
+
+	// WebhookTriggerVerificationPresetGitLab verifies the X-Gitlab-Token
+	// header sent by GitLab.
+	WebhookTriggerVerificationPresetGitLab WebhookTriggerVerificationPreset = "GitLab"
+
+	// WebhookTriggerVerificationPresetSlack verifies the X-Slack-Signature
+	// and X-Slack-Request-Timestamp headers sent by Slack.
+	WebhookTriggerVerificationPresetSlack WebhookTriggerVerificationPreset = "Slack"
+)
+
+type WebhookTriggerVerificationAlgorithm string
+
+const (
+	// WebhookTriggerVerificationAlgorithmHMACSHA1 expects a hex-encoded
+	// HMAC-SHA1 digest of the request body.
+	WebhookTriggerVerificationAlgorithmHMACSHA1 WebhookTriggerVerificationAlgorithm = "HMAC-SHA1"
+
+	// WebhookTriggerVerificationAlgorithmHMACSHA256 expects a hex-encoded
+	// HMAC-SHA256 digest of the request body.
+	WebhookTriggerVerificationAlgorithmHMACSHA256 WebhookTriggerVerificationAlgorithm = "HMAC-SHA256"
+
+	// WebhookTriggerVerificationAlgorithmToken expects the secret itself.
+	WebhookTriggerVerificationAlgorithmToken WebhookTriggerVerificationAlgorithm = "Token"
+)
+
+type WebhookTriggerVerification struct {
+	// Preset sets the algorithm, header and prefix to those used by a
+	// well-known webhook provider. Any other fields specified override the
+	// preset.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=GitHub;GitLab;Slack
+	Preset WebhookTriggerVerificationPreset `json:"preset,omitempty"`
+
+	// Algorithm is the method used to compute the signature. It is required
+	// if no preset is specified.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=HMAC-SHA1;HMAC-SHA256;Token
+	Algorithm WebhookTriggerVerificationAlgorithm `json:"algorithm,omitempty"`
+
+	// Header is the name of the request header containing the signature. It
+	// is required if no preset is specified.
+	//
+	// +optional
+	Header string `json:"header,omitempty"`
+
+	// Prefix is stripped from the header value before it is compared to the
+	// computed signature, e.g. "sha256=".
+	//
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// SecretKeyRef selects the shared secret used to sign requests.
+	SecretKeyRef SecretKeySelector `json:"secretKeyRef"`
 }
 
 type WebhookTriggerStatus struct {
@@ -82,13 +288,118 @@ type WebhookTriggerStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []WebhookTriggerCondition `json:"conditions,omitempty"`
+
+	// Scaling is the scaling configuration in effect for the service
+	// handling requests, including any defaults applied.
+	//
+	// +optional
+	Scaling *WebhookTriggerScalingStatus `json:"scaling,omitempty"`
+
+	// Deliveries summarizes the requests received by this webhook trigger and
+	// the events it emitted.
+	//
+	// +optional
+	Deliveries *WebhookTriggerDeliveryStatus `json:"deliveries,omitempty"`
+}
+
+type WebhookTriggerDeliveryStatus struct {
+	// LastRequestTime is the time the most recent request was received.
+	// Requests are only observed if the webhook verifier is in front of the
+	// trigger, i.e., if verification or capture is configured.
+	//
+	// +optional
+	LastRequestTime *metav1.Time `json:"lastRequestTime,omitempty"`
+
+	// LastEventTime is the time the trigger most recently emitted an event.
+	//
+	// +optional
+	LastEventTime *metav1.Time `json:"lastEventTime,omitempty"`
+
+	// RequestsReceived is the number of requests received.
+	RequestsReceived int64 `json:"requestsReceived"`
+
+	// EventsEmitted is the number of events emitted.
+	EventsEmitted int64 `json:"eventsEmitted"`
+
+	// Failed is the number of requests the trigger responded to with an error
+	// and events that could not be emitted.
+	Failed int64 `json:"failed"`
+
+	// LastError is the message of the most recent failure.
+	//
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// LastErrorTime is the time of the most recent failure.
+	//
+	// +optional
+	LastErrorTime *metav1.Time `json:"lastErrorTime,omitempty"`
+
+	// Sinks summarizes the delivery of events to each named event sink of the
+	// tenant.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Sinks []WebhookTriggerSinkDeliveryStatus `json:"sinks,omitempty"`
+}
+
+type WebhookTriggerSinkDeliveryStatus struct {
+	// Name is the name of the event sink.
+	Name string `json:"name"`
+
+	// LastEventTime is the time an event was most recently delivered to the
+	// sink.
+	//
+	// +optional
+	LastEventTime *metav1.Time `json:"lastEventTime,omitempty"`
+
+	// EventsDelivered is the number of events delivered to the sink.
+	EventsDelivered int64 `json:"eventsDelivered"`
+
+	// Failed is the number of events that matched the condition of the sink
+	// but could not be delivered.
+	Failed int64 `json:"failed"`
+
+	// LastError is the message of the most recent failure.
+	//
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// LastErrorTime is the time of the most recent failure.
+	//
+	// +optional
+	LastErrorTime *metav1.Time `json:"lastErrorTime,omitempty"`
+}
+
+type WebhookTriggerScalingStatus struct {
+	// MinScale is the minimum number of instances kept running.
+	MinScale int32 `json:"minScale"`
+
+	// MaxScale is the maximum number of instances, or 0 if unlimited.
+	MaxScale int32 `json:"maxScale"`
+
+	// ContainerConcurrency is the maximum number of concurrent requests per
+	// instance, or 0 if unlimited.
+	ContainerConcurrency int64 `json:"containerConcurrency"`
+
+	// TimeoutSeconds is the maximum duration of a request.
+	//
+	// +optional
+	TimeoutSeconds int64 `json:"timeoutSeconds,omitempty"`
 }
 
 type WebhookTriggerConditionType string
 
 const (
 	WebhookTriggerServiceReady WebhookTriggerConditionType = "ServiceReady"
-	WebhookTriggerReady        WebhookTriggerConditionType = "Ready"
+
+	// WebhookTriggerRouteReady indicates whether the route to the webhook
+	// trigger accepts requests. It is false if the requested address is
+	// already used by another webhook trigger.
+	WebhookTriggerRouteReady WebhookTriggerConditionType = "RouteReady"
+
+	WebhookTriggerReady WebhookTriggerConditionType = "Ready"
 )
 
 type WebhookTriggerCondition struct {
@@ -96,7 +407,7 @@ type WebhookTriggerCondition struct {
 
 	// Type is the identifier for this condition.
 	//
-	// +kubebuilder:validation:Enum=ServiceReady;Ready
+	// +kubebuilder:validation:Enum=ServiceReady;RouteReady;Ready
 	Type WebhookTriggerConditionType `json:"type"`
 }
 
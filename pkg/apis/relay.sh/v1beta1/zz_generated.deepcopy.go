@@ -5,7 +5,8 @@
 package v1beta1
 
 import (
-	"k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -65,6 +66,71 @@ func (in *Condition) DeepCopy() *Condition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapKeySelector) DeepCopyInto(out *ConfigMapKeySelector) {
+	*out = *in
+	out.LocalObjectReference = in.LocalObjectReference
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapKeySelector.
+func (in *ConfigMapKeySelector) DeepCopy() *ConfigMapKeySelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapKeySelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DirectTriggerEventSink) DeepCopyInto(out *DirectTriggerEventSink) {
+	*out = *in
+	in.WorkflowFrom.DeepCopyInto(&out.WorkflowFrom)
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(UnstructuredObject, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DirectTriggerEventSink.
+func (in *DirectTriggerEventSink) DeepCopy() *DirectTriggerEventSink {
+	if in == nil {
+		return nil
+	}
+	out := new(DirectTriggerEventSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamedTriggerEventSink) DeepCopyInto(out *NamedTriggerEventSink) {
+	*out = *in
+	in.When.DeepCopyInto(&out.When)
+	if in.API != nil {
+		in, out := &in.API, &out.API
+		*out = new(APITriggerEventSink)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Direct != nil {
+		in, out := &in.Direct, &out.Direct
+		*out = new(DirectTriggerEventSink)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamedTriggerEventSink.
+func (in *NamedTriggerEventSink) DeepCopy() *NamedTriggerEventSink {
+	if in == nil {
+		return nil
+	}
+	out := new(NamedTriggerEventSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NamespaceTemplate) DeepCopyInto(out *NamespaceTemplate) {
 	*out = *in
@@ -140,6 +206,52 @@ func (in *TenantCondition) DeepCopy() *TenantCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantDefaults) DeepCopyInto(out *TenantDefaults) {
+	*out = *in
+	if in.Spec != nil {
+		in, out := &in.Spec, &out.Spec
+		*out = make(UnstructuredObject, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make(UnstructuredObject, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantDefaults.
+func (in *TenantDefaults) DeepCopy() *TenantDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantDrainStatus) DeepCopyInto(out *TenantDrainStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.Deadline.DeepCopyInto(&out.Deadline)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantDrainStatus.
+func (in *TenantDrainStatus) DeepCopy() *TenantDrainStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantDrainStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TenantList) DeepCopyInto(out *TenantList) {
 	*out = *in
@@ -172,12 +284,39 @@ func (in *TenantList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantSecrets) DeepCopyInto(out *TenantSecrets) {
+	*out = *in
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultTenantSecrets)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantSecrets.
+func (in *TenantSecrets) DeepCopy() *TenantSecrets {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantSecrets)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TenantSpec) DeepCopyInto(out *TenantSpec) {
 	*out = *in
 	in.NamespaceTemplate.DeepCopyInto(&out.NamespaceTemplate)
 	in.ToolInjection.DeepCopyInto(&out.ToolInjection)
 	in.TriggerEventSink.DeepCopyInto(&out.TriggerEventSink)
+	in.Secrets.DeepCopyInto(&out.Secrets)
+	if in.DrainTimeout != nil {
+		in, out := &in.DrainTimeout, &out.DrainTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	in.Defaults.DeepCopyInto(&out.Defaults)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantSpec.
@@ -198,6 +337,16 @@ func (in *TenantStatus) DeepCopyInto(out *TenantStatus) {
 		*out = make([]TenantCondition, len(*in))
 		copy(*out, *in)
 	}
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(TenantDrainStatus)
+		**out = **in
+	}
+	if in.ToolInjection != nil {
+		in, out := &in.ToolInjection, &out.ToolInjection
+		*out = new(TenantToolInjectionStatus)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantStatus.
@@ -210,12 +359,27 @@ func (in *TenantStatus) DeepCopy() *TenantStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantToolInjectionStatus) DeepCopyInto(out *TenantToolInjectionStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantToolInjectionStatus.
+func (in *TenantToolInjectionStatus) DeepCopy() *TenantToolInjectionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantToolInjectionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ToolInjection) DeepCopyInto(out *ToolInjection) {
 	*out = *in
 	if in.VolumeClaimTemplate != nil {
 		in, out := &in.VolumeClaimTemplate, &out.VolumeClaimTemplate
-		*out = new(v1.PersistentVolumeClaim)
+		*out = new(corev1.PersistentVolumeClaim)
 		(*in).DeepCopyInto(*out)
 	}
 }
@@ -238,6 +402,18 @@ func (in *TriggerEventSink) DeepCopyInto(out *TriggerEventSink) {
 		*out = new(APITriggerEventSink)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Direct != nil {
+		in, out := &in.Direct, &out.Direct
+		*out = new(DirectTriggerEventSink)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sinks != nil {
+		in, out := &in.Sinks, &out.Sinks
+		*out = make([]NamedTriggerEventSink, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TriggerEventSink.
@@ -277,6 +453,21 @@ func (in UnstructuredObject) DeepCopy() UnstructuredObject {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultTenantSecrets) DeepCopyInto(out *VaultTenantSecrets) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultTenantSecrets.
+func (in *VaultTenantSecrets) DeepCopy() *VaultTenantSecrets {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultTenantSecrets)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WebhookTrigger) DeepCopyInto(out *WebhookTrigger) {
 	*out = *in
@@ -304,6 +495,21 @@ func (in *WebhookTrigger) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookTriggerCapture) DeepCopyInto(out *WebhookTriggerCapture) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTriggerCapture.
+func (in *WebhookTriggerCapture) DeepCopy() *WebhookTriggerCapture {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookTriggerCapture)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WebhookTriggerCondition) DeepCopyInto(out *WebhookTriggerCondition) {
 	*out = *in
@@ -320,6 +526,55 @@ func (in *WebhookTriggerCondition) DeepCopy() *WebhookTriggerCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookTriggerDeliveryStatus) DeepCopyInto(out *WebhookTriggerDeliveryStatus) {
+	*out = *in
+	if in.LastRequestTime != nil {
+		in, out := &in.LastRequestTime, &out.LastRequestTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastEventTime != nil {
+		in, out := &in.LastEventTime, &out.LastEventTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastErrorTime != nil {
+		in, out := &in.LastErrorTime, &out.LastErrorTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Sinks != nil {
+		in, out := &in.Sinks, &out.Sinks
+		*out = make([]WebhookTriggerSinkDeliveryStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTriggerDeliveryStatus.
+func (in *WebhookTriggerDeliveryStatus) DeepCopy() *WebhookTriggerDeliveryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookTriggerDeliveryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookTriggerLimits) DeepCopyInto(out *WebhookTriggerLimits) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTriggerLimits.
+func (in *WebhookTriggerLimits) DeepCopy() *WebhookTriggerLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookTriggerLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WebhookTriggerList) DeepCopyInto(out *WebhookTriggerList) {
 	*out = *in
@@ -352,6 +607,114 @@ func (in *WebhookTriggerList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookTriggerRoute) DeepCopyInto(out *WebhookTriggerRoute) {
+	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(WebhookTriggerRouteTLS)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTriggerRoute.
+func (in *WebhookTriggerRoute) DeepCopy() *WebhookTriggerRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookTriggerRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookTriggerRouteTLS) DeepCopyInto(out *WebhookTriggerRouteTLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTriggerRouteTLS.
+func (in *WebhookTriggerRouteTLS) DeepCopy() *WebhookTriggerRouteTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookTriggerRouteTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookTriggerScaling) DeepCopyInto(out *WebhookTriggerScaling) {
+	*out = *in
+	if in.MinScale != nil {
+		in, out := &in.MinScale, &out.MinScale
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxScale != nil {
+		in, out := &in.MaxScale, &out.MaxScale
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ContainerConcurrency != nil {
+		in, out := &in.ContainerConcurrency, &out.ContainerConcurrency
+		*out = new(int64)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTriggerScaling.
+func (in *WebhookTriggerScaling) DeepCopy() *WebhookTriggerScaling {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookTriggerScaling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookTriggerScalingStatus) DeepCopyInto(out *WebhookTriggerScalingStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTriggerScalingStatus.
+func (in *WebhookTriggerScalingStatus) DeepCopy() *WebhookTriggerScalingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookTriggerScalingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookTriggerSinkDeliveryStatus) DeepCopyInto(out *WebhookTriggerSinkDeliveryStatus) {
+	*out = *in
+	if in.LastEventTime != nil {
+		in, out := &in.LastEventTime, &out.LastEventTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastErrorTime != nil {
+		in, out := &in.LastErrorTime, &out.LastErrorTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTriggerSinkDeliveryStatus.
+func (in *WebhookTriggerSinkDeliveryStatus) DeepCopy() *WebhookTriggerSinkDeliveryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookTriggerSinkDeliveryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WebhookTriggerSpec) DeepCopyInto(out *WebhookTriggerSpec) {
 	*out = *in
@@ -380,6 +743,31 @@ func (in *WebhookTriggerSpec) DeepCopyInto(out *WebhookTriggerSpec) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(WebhookTriggerVerification)
+		**out = **in
+	}
+	if in.Scaling != nil {
+		in, out := &in.Scaling, &out.Scaling
+		*out = new(WebhookTriggerScaling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Route != nil {
+		in, out := &in.Route, &out.Route
+		*out = new(WebhookTriggerRoute)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Capture != nil {
+		in, out := &in.Capture, &out.Capture
+		*out = new(WebhookTriggerCapture)
+		**out = **in
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(WebhookTriggerLimits)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTriggerSpec.
@@ -400,6 +788,16 @@ func (in *WebhookTriggerStatus) DeepCopyInto(out *WebhookTriggerStatus) {
 		*out = make([]WebhookTriggerCondition, len(*in))
 		copy(*out, *in)
 	}
+	if in.Scaling != nil {
+		in, out := &in.Scaling, &out.Scaling
+		*out = new(WebhookTriggerScalingStatus)
+		**out = **in
+	}
+	if in.Deliveries != nil {
+		in, out := &in.Deliveries, &out.Deliveries
+		*out = new(WebhookTriggerDeliveryStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTriggerStatus.
@@ -411,3 +809,39 @@ func (in *WebhookTriggerStatus) DeepCopy() *WebhookTriggerStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookTriggerVerification) DeepCopyInto(out *WebhookTriggerVerification) {
+	*out = *in
+	out.SecretKeyRef = in.SecretKeyRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTriggerVerification.
+func (in *WebhookTriggerVerification) DeepCopy() *WebhookTriggerVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookTriggerVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowSource) DeepCopyInto(out *WorkflowSource) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(ConfigMapKeySelector)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowSource.
+func (in *WorkflowSource) DeepCopy() *WorkflowSource {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowSource)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -30,12 +30,63 @@ type Claims struct {
 	RelayKubernetesImmutableConfigMapName string `json:"relay.sh/k8s/immutable-config-map-name,omitempty"`
 	RelayKubernetesMutableConfigMapName   string `json:"relay.sh/k8s/mutable-config-map-name,omitempty"`
 
+	// RelayKubernetesWebhookDeliveriesConfigMapName is the name of the config
+	// map that counts the requests and events of a webhook trigger.
+	RelayKubernetesWebhookDeliveriesConfigMapName string `json:"relay.sh/k8s/webhook-deliveries-config-map-name,omitempty"`
+
+	// RelayKubernetesWebhookRequestsConfigMapName is the name of the config
+	// map that stores captured requests for a webhook trigger, if capture is
+	// enabled.
+	RelayKubernetesWebhookRequestsConfigMapName string `json:"relay.sh/k8s/webhook-requests-config-map-name,omitempty"`
+	RelayWebhookRequestsLimit                   int    `json:"relay.sh/webhook-requests/limit,omitempty"`
+
+	// RelayWebhookVerifier indicates that the token was issued to the webhook
+	// verifier in front of a trigger rather than to the trigger itself. Only
+	// the verifier may report the requests the trigger received.
+	RelayWebhookVerifier bool `json:"relay.sh/webhook/verifier,omitempty"`
+
+	// RelayWebhookDirect indicates that requests are routed to the trigger
+	// without passing through a webhook verifier, so the trigger reports the
+	// requests it receives itself.
+	RelayWebhookDirect bool `json:"relay.sh/webhook/direct,omitempty"`
+
+	// RelayLimitsRequestsPerSecond, RelayLimitsBurst and RelayLimitsMaxBodyBytes
+	// restrict the requests a webhook trigger may make to rate-limited
+	// endpoints of the metadata API.
+	RelayLimitsRequestsPerSecond int32 `json:"relay.sh/limits/requests-per-second,omitempty"`
+	RelayLimitsBurst             int32 `json:"relay.sh/limits/burst,omitempty"`
+	RelayLimitsMaxBodyBytes      int64 `json:"relay.sh/limits/max-body-bytes,omitempty"`
+
 	RelayVaultEnginePath     string `json:"relay.sh/vault/engine-path,omitempty"`
 	RelayVaultSecretPath     string `json:"relay.sh/vault/secret-path,omitempty"`
 	RelayVaultConnectionPath string `json:"relay.sh/vault/connection-path,omitempty"`
+	RelayVaultAuthRole       string `json:"relay.sh/vault/auth-role,omitempty"`
 
 	RelayEventAPIURL   *jsonutil.URL `json:"relay.sh/event/api/url,omitempty"`
 	RelayEventAPIToken string        `json:"relay.sh/event/api/token,omitempty"`
+
+	// RelayEventDirect indicates that events should create workflow runs in
+	// the Kubernetes namespace from the template stored in the immutable
+	// config map instead of being sent to the API.
+	RelayEventDirect bool `json:"relay.sh/event/direct,omitempty"`
+
+	// RelayEventSinks are additional event sinks that receive the events
+	// matching their conditions.
+	RelayEventSinks []*EventSinkClaims `json:"relay.sh/event/sinks,omitempty"`
+}
+
+// EventSinkClaims configures a named event sink. Either the API URL or the
+// direct flag is set.
+type EventSinkClaims struct {
+	Name string      `json:"name"`
+	When interface{} `json:"when,omitempty"`
+
+	APIURL   *jsonutil.URL `json:"api/url,omitempty"`
+	APIToken string        `json:"api/token,omitempty"`
+
+	// Direct indicates that events should create workflow runs from the
+	// template stored for this sink in the immutable config map.
+	Direct bool `json:"direct,omitempty"`
 }
 
 func (c *Claims) Action() model.Action {
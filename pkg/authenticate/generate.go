@@ -0,0 +1,3 @@
+//go:generate go run sigs.k8s.io/controller-tools/cmd/controller-gen rbac:roleName=relay-metadata-api paths=./... output:rbac:artifacts:config=../../manifests/rbac/metadata-api
+
+package authenticate
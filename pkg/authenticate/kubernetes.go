@@ -15,6 +15,10 @@ import (
 	"k8s.io/client-go/rest"
 )
 
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
+// +kubebuilder:rbac:groups=tekton.dev,resources=conditions,verbs=get
+
 const (
 	KubernetesTokenAnnotation   = "relay.sh/token"
 	KubernetesSubjectAnnotation = "relay.sh/subject"
@@ -87,9 +91,30 @@ func (ki *KubernetesIntermediary) next(ctx context.Context, state *Authenticatio
 		return nil, nil, &NotFoundError{Reason: fmt.Sprintf("kubernetes: multiple pods found with IP %s (bug?)", ki.ip)}
 	}
 
-	pod := pods.Items[0]
+	return kubernetesPodToken(ctx, ki.client, state, &pods.Items[0])
+}
+
+func (ki *KubernetesIntermediary) Chain(fn KubernetesChainIntermediaryFunc) Intermediary {
+	return kubernetesChain(ki.next, fn)
+}
+
+func (ki *KubernetesIntermediary) Next(ctx context.Context, state *Authentication) (Raw, error) {
+	raw, _, err := ki.next(ctx, state)
+	return raw, err
+}
+
+func NewKubernetesIntermediary(client *KubernetesInterface, ip net.IP) *KubernetesIntermediary {
+	return &KubernetesIntermediary{
+		client: client,
+		ip:     ip,
+	}
+}
 
-	ns, err := ki.client.CoreV1().Namespaces().Get(pod.GetNamespace(), metav1.GetOptions{})
+// kubernetesPodToken reads the token and subject annotations of a pod that has
+// been authenticated by some other means and adds validators that check the
+// resulting claims against the pod.
+func kubernetesPodToken(ctx context.Context, client *KubernetesInterface, state *Authentication, pod *corev1.Pod) (Raw, *KubernetesIntermediaryMetadata, error) {
+	ns, err := client.CoreV1().Namespaces().Get(pod.GetNamespace(), metav1.GetOptions{})
 	if errors.IsNotFound(err) {
 		return nil, nil, &NotFoundError{Reason: "kubernetes: namespace of requesting pod no longer exists"}
 	} else if err != nil {
@@ -109,7 +134,7 @@ func (ki *KubernetesIntermediary) next(ctx context.Context, state *Authenticatio
 		// is true for us, but not for Tekton generally.
 		name := pod.GetLabels()["tekton.dev/pipelineTask"]
 
-		tr, err := ki.client.TektonV1alpha1().Conditions(ns.GetName()).Get(name, metav1.GetOptions{})
+		tr, err := client.TektonV1alpha1().Conditions(ns.GetName()).Get(name, metav1.GetOptions{})
 		if errors.IsNotFound(err) {
 			return nil, nil, &NotFoundError{Reason: "kubernetes: Tekton condition of requesting pod does not exist"}
 		} else if err != nil {
@@ -161,9 +186,9 @@ func (ki *KubernetesIntermediary) next(ctx context.Context, state *Authenticatio
 	return Raw(tok), md, nil
 }
 
-func (ki *KubernetesIntermediary) Chain(fn KubernetesChainIntermediaryFunc) Intermediary {
+func kubernetesChain(lookup func(ctx context.Context, state *Authentication) (Raw, *KubernetesIntermediaryMetadata, error), fn KubernetesChainIntermediaryFunc) Intermediary {
 	return IntermediaryFunc(func(ctx context.Context, state *Authentication) (Raw, error) {
-		raw, md, err := ki.next(ctx, state)
+		raw, md, err := lookup(ctx, state)
 		if err != nil {
 			return nil, err
 		}
@@ -176,15 +201,3 @@ func (ki *KubernetesIntermediary) Chain(fn KubernetesChainIntermediaryFunc) Inte
 		return next.Next(ctx, state)
 	})
 }
-
-func (ki *KubernetesIntermediary) Next(ctx context.Context, state *Authentication) (Raw, error) {
-	raw, _, err := ki.next(ctx, state)
-	return raw, err
-}
-
-func NewKubernetesIntermediary(client *KubernetesInterface, ip net.IP) *KubernetesIntermediary {
-	return &KubernetesIntermediary{
-		client: client,
-		ip:     ip,
-	}
-}
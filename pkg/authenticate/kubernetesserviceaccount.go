@@ -0,0 +1,302 @@
+package authenticate
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+
+const (
+	// DefaultKubernetesTokenReviewCacheTTL is how long the result of reviewing
+	// a service account token is reused for, unless the token expires sooner.
+	DefaultKubernetesTokenReviewCacheTTL = 1 * time.Minute
+
+	kubernetesServiceAccountUsernamePrefix = "system:serviceaccount:"
+	kubernetesServiceAccountPodNameExtra   = "authentication.kubernetes.io/pod-name"
+	kubernetesServiceAccountPodUIDExtra    = "authentication.kubernetes.io/pod-uid"
+)
+
+// KubernetesServiceAccountTokenIntermediary validates a projected service
+// account token presented by a pod using the TokenReview API and reads the
+// value of an annotation of the pod the token is bound to as the
+// authentication credential.
+type KubernetesServiceAccountTokenIntermediary struct {
+	client   *KubernetesInterface
+	token    string
+	audience string
+	cache    *KubernetesTokenReviewCache
+	fallback *KubernetesIntermediary
+}
+
+var _ Intermediary = &KubernetesServiceAccountTokenIntermediary{}
+
+func (ksi *KubernetesServiceAccountTokenIntermediary) next(ctx context.Context, state *Authentication) (Raw, *KubernetesIntermediaryMetadata, error) {
+	// Only requests without a token are looked up by IP. A token that does
+	// not pass review is rejected rather than ignored, so that a pod cannot
+	// present a bad token and still be authenticated by its address.
+	if ksi.token == "" && ksi.fallback != nil {
+		return ksi.fallback.next(ctx, state)
+	}
+
+	return ksi.review(ctx, state)
+}
+
+func (ksi *KubernetesServiceAccountTokenIntermediary) review(ctx context.Context, state *Authentication) (Raw, *KubernetesIntermediaryMetadata, error) {
+	if ksi.token == "" {
+		return nil, nil, &NotFoundError{Reason: "kubernetes: no service account token to review"}
+	}
+
+	ref, found := ksi.cache.get(ksi.token, time.Now())
+	if !found {
+		var err error
+		ref, err = ksi.reviewToken()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ksi.cache.put(ksi.token, ref, time.Now())
+	}
+
+	pod, err := ksi.client.CoreV1().Pods(ref.namespace).Get(ref.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil, &NotFoundError{Reason: "kubernetes: pod bound to service account token no longer exists"}
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	if pod.GetUID() != ref.uid {
+		// The pod was deleted and recreated with the same name.
+		return nil, nil, &NotFoundError{Reason: "kubernetes: pod bound to service account token no longer exists"}
+	} else if pod.Status.Phase != corev1.PodRunning {
+		return nil, nil, &NotFoundError{Reason: fmt.Sprintf("kubernetes: pod bound to service account token is not running (phase %s)", pod.Status.Phase)}
+	}
+
+	return kubernetesPodToken(ctx, ksi.client, state, pod)
+}
+
+// reviewToken asks the API server which pod the token is bound to.
+func (ksi *KubernetesServiceAccountTokenIntermediary) reviewToken() (*kubernetesTokenReviewPodRef, error) {
+	review, err := ksi.client.AuthenticationV1().TokenReviews().Create(&authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     ksi.token,
+			Audiences: []string{ksi.audience},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !review.Status.Authenticated {
+		return nil, &NotFoundError{Reason: fmt.Sprintf("kubernetes: service account token not authenticated: %s", review.Status.Error)}
+	}
+
+	if !containsString(review.Status.Audiences, ksi.audience) {
+		return nil, &NotFoundError{Reason: fmt.Sprintf("kubernetes: service account token not valid for audience %q", ksi.audience)}
+	}
+
+	username := review.Status.User.Username
+	if !strings.HasPrefix(username, kubernetesServiceAccountUsernamePrefix) {
+		return nil, &NotFoundError{Reason: fmt.Sprintf("kubernetes: token user %q is not a service account", username)}
+	}
+
+	parts := strings.Split(strings.TrimPrefix(username, kubernetesServiceAccountUsernamePrefix), ":")
+	if len(parts) != 2 {
+		return nil, &NotFoundError{Reason: fmt.Sprintf("kubernetes: token user %q is not a service account", username)}
+	}
+
+	podNames := review.Status.User.Extra[kubernetesServiceAccountPodNameExtra]
+	podUIDs := review.Status.User.Extra[kubernetesServiceAccountPodUIDExtra]
+	if len(podNames) != 1 || len(podUIDs) != 1 {
+		return nil, &NotFoundError{Reason: "kubernetes: service account token is not bound to a pod"}
+	}
+
+	return &kubernetesTokenReviewPodRef{
+		namespace: parts[0],
+		name:      podNames[0],
+		uid:       types.UID(podUIDs[0]),
+	}, nil
+}
+
+func (ksi *KubernetesServiceAccountTokenIntermediary) Chain(fn KubernetesChainIntermediaryFunc) Intermediary {
+	return kubernetesChain(ksi.next, fn)
+}
+
+func (ksi *KubernetesServiceAccountTokenIntermediary) Next(ctx context.Context, state *Authentication) (Raw, error) {
+	raw, _, err := ksi.next(ctx, state)
+	return raw, err
+}
+
+type KubernetesServiceAccountTokenIntermediaryOption func(ksi *KubernetesServiceAccountTokenIntermediary)
+
+// KubernetesServiceAccountTokenIntermediaryWithFallback uses the given
+// intermediary, which looks up the pod by IP, if no token is presented.
+func KubernetesServiceAccountTokenIntermediaryWithFallback(fallback *KubernetesIntermediary) KubernetesServiceAccountTokenIntermediaryOption {
+	return func(ksi *KubernetesServiceAccountTokenIntermediary) {
+		ksi.fallback = fallback
+	}
+}
+
+// KubernetesServiceAccountTokenIntermediaryWithCache reuses the results of
+// reviewing tokens kept in the given cache, which should be shared by the
+// intermediaries created for every request.
+func KubernetesServiceAccountTokenIntermediaryWithCache(cache *KubernetesTokenReviewCache) KubernetesServiceAccountTokenIntermediaryOption {
+	return func(ksi *KubernetesServiceAccountTokenIntermediary) {
+		ksi.cache = cache
+	}
+}
+
+// NewKubernetesServiceAccountTokenIntermediary creates an intermediary that
+// reviews the given service account token, which must be valid for the given
+// audience.
+func NewKubernetesServiceAccountTokenIntermediary(client *KubernetesInterface, token, audience string, opts ...KubernetesServiceAccountTokenIntermediaryOption) *KubernetesServiceAccountTokenIntermediary {
+	ksi := &KubernetesServiceAccountTokenIntermediary{
+		client:   client,
+		token:    token,
+		audience: audience,
+	}
+
+	for _, opt := range opts {
+		opt(ksi)
+	}
+
+	return ksi
+}
+
+// kubernetesTokenReviewPodRef identifies the pod a service account token is
+// bound to.
+type kubernetesTokenReviewPodRef struct {
+	namespace string
+	name      string
+	uid       types.UID
+}
+
+type kubernetesTokenReviewCacheEntry struct {
+	ref     *kubernetesTokenReviewPodRef
+	expires time.Time
+}
+
+// KubernetesTokenReviewCache keeps the pods that service account tokens were
+// found to be bound to, so that a pod making many requests with the same token
+// does not need a TokenReview for each of them. Only tokens that pass review
+// are kept, and never past their expiry. The pod itself is still checked on
+// every request.
+type KubernetesTokenReviewCache struct {
+	mut        sync.Mutex
+	entries    map[[sha256.Size]byte]*kubernetesTokenReviewCacheEntry
+	ttl        time.Duration
+	lastPruned time.Time
+}
+
+func (c *KubernetesTokenReviewCache) get(token string, now time.Time) (*kubernetesTokenReviewPodRef, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	e, found := c.entries[sha256.Sum256([]byte(token))]
+	if !found || !now.Before(e.expires) {
+		return nil, false
+	}
+
+	return e.ref, true
+}
+
+func (c *KubernetesTokenReviewCache) put(token string, ref *kubernetesTokenReviewPodRef, now time.Time) {
+	if c == nil {
+		return
+	}
+
+	expires := now.Add(c.ttl)
+
+	// The API server has already validated the token, so we only read its
+	// expiry here.
+	if tok, err := jwt.ParseSigned(token); err == nil {
+		var claims jwt.Claims
+		if err := tok.UnsafeClaimsWithoutVerification(&claims); err == nil && claims.Expiry != nil {
+			if exp := claims.Expiry.Time(); exp.Before(expires) {
+				expires = exp
+			}
+		}
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.prune(now)
+
+	c.entries[sha256.Sum256([]byte(token))] = &kubernetesTokenReviewCacheEntry{
+		ref:     ref,
+		expires: expires,
+	}
+}
+
+// prune discards expired entries. It scans the entries at most once per TTL.
+func (c *KubernetesTokenReviewCache) prune(now time.Time) {
+	if now.Sub(c.lastPruned) < c.ttl {
+		return
+	}
+
+	c.lastPruned = now
+
+	for key, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Len returns the number of tokens in the cache.
+func (c *KubernetesTokenReviewCache) Len() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return len(c.entries)
+}
+
+type KubernetesTokenReviewCacheOption func(c *KubernetesTokenReviewCache)
+
+// KubernetesTokenReviewCacheWithTTL sets how long the result of reviewing a
+// token is reused for.
+func KubernetesTokenReviewCacheWithTTL(ttl time.Duration) KubernetesTokenReviewCacheOption {
+	return func(c *KubernetesTokenReviewCache) {
+		c.ttl = ttl
+	}
+}
+
+func NewKubernetesTokenReviewCache(opts ...KubernetesTokenReviewCacheOption) *KubernetesTokenReviewCache {
+	c := &KubernetesTokenReviewCache{
+		entries: make(map[[sha256.Size]byte]*kubernetesTokenReviewCacheEntry),
+		ttl:     DefaultKubernetesTokenReviewCacheTTL,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.lastPruned = time.Now()
+	return c
+}
+
+func containsString(l []string, s string) bool {
+	for _, candidate := range l {
+		if candidate == s {
+			return true
+		}
+	}
+
+	return false
+}
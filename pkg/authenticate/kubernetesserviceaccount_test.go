@@ -0,0 +1,247 @@
+package authenticate_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/puppetlabs/relay-core/pkg/authenticate"
+	"github.com/puppetlabs/relay-core/pkg/util/testutil"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestKubernetesServiceAccountTokenIntermediary(t *testing.T) {
+	ctx := context.Background()
+
+	podA := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "my-test-namespace",
+			Name:      "pod-a",
+			Annotations: map[string]string{
+				authenticate.KubernetesTokenAnnotation:   "my-auth-token",
+				authenticate.KubernetesSubjectAnnotation: "my-test-subject",
+			},
+		},
+		Status: corev1.PodStatus{
+			PodIP: "10.20.30.40",
+			Phase: corev1.PodRunning,
+		},
+	}
+	podB := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "my-test-namespace",
+			Name:      "pod-b",
+			Annotations: map[string]string{
+				authenticate.KubernetesTokenAnnotation:   "my-previous-auth-token",
+				authenticate.KubernetesSubjectAnnotation: "my-previous-test-subject",
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodSucceeded,
+		},
+	}
+
+	kc := testutil.NewMockKubernetesClient(
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "my-test-namespace",
+			},
+		},
+		podA,
+		podB,
+	)
+
+	// Service account tokens are mapped to the users the API server would
+	// authenticate them as.
+	users := map[string]authenticationv1.UserInfo{
+		"pod-a-token": {
+			Username: "system:serviceaccount:my-test-namespace:default",
+			Extra: map[string]authenticationv1.ExtraValue{
+				"authentication.kubernetes.io/pod-name": {"pod-a"},
+				"authentication.kubernetes.io/pod-uid":  {string(podA.GetUID())},
+			},
+		},
+		"pod-b-token": {
+			Username: "system:serviceaccount:my-test-namespace:default",
+			Extra: map[string]authenticationv1.ExtraValue{
+				"authentication.kubernetes.io/pod-name": {"pod-b"},
+				"authentication.kubernetes.io/pod-uid":  {string(podB.GetUID())},
+			},
+		},
+		"stale-pod-a-token": {
+			Username: "system:serviceaccount:my-test-namespace:default",
+			Extra: map[string]authenticationv1.ExtraValue{
+				"authentication.kubernetes.io/pod-name": {"pod-a"},
+				"authentication.kubernetes.io/pod-uid":  {"stale-pod-a-uid"},
+			},
+		},
+		"unbound-token": {
+			Username: "system:serviceaccount:my-test-namespace:default",
+		},
+		"user-token": {
+			Username: "jane",
+		},
+	}
+
+	kc.(*fake.Clientset).PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview).DeepCopy()
+
+		user, found := users[review.Spec.Token]
+		if !found {
+			review.Status.Error = "invalid token"
+			return true, review, nil
+		}
+
+		review.Status.Authenticated = true
+		review.Status.User = user
+		review.Status.Audiences = review.Spec.Audiences
+		return true, review, nil
+	})
+
+	client := &authenticate.KubernetesInterface{
+		Interface:       kc,
+		TektonInterface: testutil.NewMockTektonKubernetesClient(),
+	}
+
+	tests := []struct {
+		Token         string
+		ExpectedRaw   authenticate.Raw
+		ExpectedError error
+	}{
+		{
+			Token:       "pod-a-token",
+			ExpectedRaw: authenticate.Raw("my-auth-token"),
+		},
+		{
+			Token:         "pod-b-token",
+			ExpectedError: &authenticate.NotFoundError{Reason: "kubernetes: pod bound to service account token is not running (phase Succeeded)"},
+		},
+		{
+			Token:         "stale-pod-a-token",
+			ExpectedError: &authenticate.NotFoundError{Reason: "kubernetes: pod bound to service account token no longer exists"},
+		},
+		{
+			Token:         "unbound-token",
+			ExpectedError: &authenticate.NotFoundError{Reason: "kubernetes: service account token is not bound to a pod"},
+		},
+		{
+			Token:         "user-token",
+			ExpectedError: &authenticate.NotFoundError{Reason: `kubernetes: token user "jane" is not a service account`},
+		},
+		{
+			Token:         "bad-token",
+			ExpectedError: &authenticate.NotFoundError{Reason: "kubernetes: service account token not authenticated: invalid token"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Token, func(t *testing.T) {
+			im := authenticate.NewKubernetesServiceAccountTokenIntermediary(client, test.Token, authenticate.MetadataAPIAudienceV1)
+			raw, err := im.Next(ctx, authenticate.NewAuthentication())
+			require.Equal(t, test.ExpectedError, err)
+			require.Equal(t, test.ExpectedRaw, raw)
+		})
+	}
+
+	// Without a service account token, the pod is looked up by its IP
+	// address instead. A token that does not pass review is rejected.
+	fallback := authenticate.KubernetesServiceAccountTokenIntermediaryWithFallback(authenticate.NewKubernetesIntermediary(client, net.ParseIP("10.20.30.40")))
+
+	raw, err := authenticate.NewKubernetesServiceAccountTokenIntermediary(client, "", authenticate.MetadataAPIAudienceV1, fallback).Next(ctx, authenticate.NewAuthentication())
+	require.NoError(t, err)
+	require.Equal(t, authenticate.Raw("my-auth-token"), raw)
+
+	for _, token := range []string{"bad-token", "user-token", "pod-b-token"} {
+		raw, err := authenticate.NewKubernetesServiceAccountTokenIntermediary(client, token, authenticate.MetadataAPIAudienceV1, fallback).Next(ctx, authenticate.NewAuthentication())
+		require.IsType(t, &authenticate.NotFoundError{}, err, "token %q", token)
+		require.Nil(t, raw, "token %q", token)
+	}
+}
+
+func TestKubernetesServiceAccountTokenIntermediaryCache(t *testing.T) {
+	ctx := context.Background()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "my-test-namespace",
+			Name:      "pod-a",
+			Annotations: map[string]string{
+				authenticate.KubernetesTokenAnnotation:   "my-auth-token",
+				authenticate.KubernetesSubjectAnnotation: "my-test-subject",
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+		},
+	}
+
+	kc := testutil.NewMockKubernetesClient(
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "my-test-namespace",
+			},
+		},
+		pod,
+	)
+
+	var reviews int
+	kc.(*fake.Clientset).PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		reviews++
+
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview).DeepCopy()
+		if review.Spec.Token != "pod-a-token" {
+			review.Status.Error = "invalid token"
+			return true, review, nil
+		}
+
+		review.Status.Authenticated = true
+		review.Status.User = authenticationv1.UserInfo{
+			Username: "system:serviceaccount:my-test-namespace:default",
+			Extra: map[string]authenticationv1.ExtraValue{
+				"authentication.kubernetes.io/pod-name": {"pod-a"},
+				"authentication.kubernetes.io/pod-uid":  {string(pod.GetUID())},
+			},
+		}
+		review.Status.Audiences = review.Spec.Audiences
+		return true, review, nil
+	})
+
+	client := &authenticate.KubernetesInterface{
+		Interface:       kc,
+		TektonInterface: testutil.NewMockTektonKubernetesClient(),
+	}
+
+	cache := authenticate.NewKubernetesTokenReviewCache()
+
+	next := func(token string) (authenticate.Raw, error) {
+		im := authenticate.NewKubernetesServiceAccountTokenIntermediary(
+			client,
+			token,
+			authenticate.MetadataAPIAudienceV1,
+			authenticate.KubernetesServiceAccountTokenIntermediaryWithCache(cache),
+		)
+		return im.Next(ctx, authenticate.NewAuthentication())
+	}
+
+	// The token is only reviewed once.
+	for i := 0; i < 3; i++ {
+		raw, err := next("pod-a-token")
+		require.NoError(t, err)
+		require.Equal(t, authenticate.Raw("my-auth-token"), raw)
+	}
+	require.Equal(t, 1, reviews)
+	require.Equal(t, 1, cache.Len())
+
+	// Rejected tokens are reviewed every time.
+	for i := 0; i < 2; i++ {
+		_, err := next("bad-token")
+		require.IsType(t, &authenticate.NotFoundError{}, err)
+	}
+	require.Equal(t, 3, reviews)
+	require.Equal(t, 1, cache.Len())
+}
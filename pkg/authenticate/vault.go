@@ -162,10 +162,11 @@ func (vf VaultResolverInjectorFunc) Inject(ctx context.Context, claims *Claims,
 }
 
 type VaultResolver struct {
-	cfg       *vaultapi.Config
-	path      string
-	role      string
-	injectors []VaultResolverInjector
+	cfg          *vaultapi.Config
+	path         string
+	role         string
+	allowedRoles []string
+	injectors    []VaultResolverInjector
 }
 
 var _ Resolver = &VaultResolver{}
@@ -180,11 +181,28 @@ func (vr *VaultResolver) Resolve(ctx context.Context, state *Authentication, raw
 	// when a client is initialized, so unset it here for good measure.
 	client.ClearToken()
 
+	role := vr.role
+
+	// The token may request a specific role to log in with. Vault still
+	// validates the token against the bound claims of that role, so we don't
+	// need to verify the token ourselves here. However, the role comes from
+	// the tenant, so it must be one the operator allows tenants to use.
+	if tok, err := jwt.ParseSigned(string(raw)); err == nil {
+		rc := &Claims{}
+		if err := tok.UnsafeClaimsWithoutVerification(rc); err == nil && rc.RelayVaultAuthRole != "" {
+			if !vr.roleAllowed(rc.RelayVaultAuthRole) {
+				return nil, &NotFoundError{Reason: fmt.Sprintf("vault: resolver: role %q is not allowed", rc.RelayVaultAuthRole)}
+			}
+
+			role = rc.RelayVaultAuthRole
+		}
+	}
+
 	data := map[string]interface{}{
 		"jwt": string(raw),
 	}
-	if vr.role != "" {
-		data["role"] = vr.role
+	if role != "" {
+		data["role"] = role
 	}
 
 	secret, err := client.Logical().Write(path.Join(vr.path, "login"), data)
@@ -225,6 +243,16 @@ func (vr *VaultResolver) Resolve(ctx context.Context, state *Authentication, raw
 	return claims, nil
 }
 
+func (vr *VaultResolver) roleAllowed(role string) bool {
+	for _, pattern := range vr.allowedRoles {
+		if ok, _ := path.Match(pattern, role); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
 type VaultResolverOption func(vr *VaultResolver)
 
 func VaultResolverWithRole(role string) VaultResolverOption {
@@ -233,6 +261,16 @@ func VaultResolverWithRole(role string) VaultResolverOption {
 	}
 }
 
+// VaultResolverWithAllowedRoles sets the roles a token may request to log in
+// with instead of the default role. Each entry is a pattern as understood by
+// path.Match, so a prefix can be allowed using a trailing "*". Tokens that
+// request any other role are rejected. By default, no roles are allowed.
+func VaultResolverWithAllowedRoles(patterns []string) VaultResolverOption {
+	return func(vr *VaultResolver) {
+		vr.allowedRoles = append(vr.allowedRoles, patterns...)
+	}
+}
+
 func VaultResolverWithInjector(injector VaultResolverInjector) VaultResolverOption {
 	return func(vr *VaultResolver) {
 		vr.injectors = append(vr.injectors, injector)
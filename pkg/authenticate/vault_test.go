@@ -153,6 +153,77 @@ func TestVaultResolver(t *testing.T) {
 	})
 }
 
+func TestVaultResolverWithClaimedRole(t *testing.T) {
+	ctx := context.Background()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS512, Key: key}, &jose.SignerOptions{})
+	require.NoError(t, err)
+
+	claims := &authenticate.Claims{
+		Claims: &jwt.Claims{
+			Subject:   "foo",
+			Audience:  []string{"test-aud"},
+			NotBefore: jwt.NewNumericDate(time.Now()),
+		},
+		RelayVaultAuthRole: "tenant",
+	}
+	tok, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	require.NoError(t, err)
+
+	testutil.WithVaultClient(t, func(vc *vaultapi.Client) {
+		require.NoError(t, vc.Sys().EnableAuthWithOptions("jwt-test", &vaultapi.EnableAuthOptions{
+			Type: "jwt",
+		}))
+
+		_, err := vc.Logical().Write("auth/jwt-test/role/tenant", map[string]interface{}{
+			"name":            "tenant",
+			"role_type":       "jwt",
+			"bound_audiences": []string{"test-aud"},
+			"user_claim":      "sub",
+			"token_type":      "batch",
+		})
+		require.NoError(t, err)
+
+		_, err = vc.Logical().Write("auth/jwt-test/config", map[string]interface{}{
+			"jwt_validation_pubkeys": []string{string(pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PUBLIC KEY",
+				Bytes: pub,
+			}))},
+			"jwt_supported_algs": []string{"RS256", "RS512"},
+		})
+		require.NoError(t, err)
+
+		// The default role does not exist, so this will only succeed if the
+		// role from the claims is used.
+		resolver := authenticate.NewStubConfigVaultResolver(
+			vc.Address(),
+			"auth/jwt-test",
+			authenticate.VaultResolverWithRole("default"),
+			authenticate.VaultResolverWithAllowedRoles([]string{"ten*"}),
+		)
+		claims, err := resolver.Resolve(ctx, authenticate.NewAuthentication(), authenticate.Raw(tok))
+		require.NoError(t, err)
+		require.Equal(t, "foo", claims.Subject)
+		require.Equal(t, "tenant", claims.RelayVaultAuthRole)
+
+		// Roles the operator has not allowed are rejected.
+		resolver = authenticate.NewStubConfigVaultResolver(
+			vc.Address(),
+			"auth/jwt-test",
+			authenticate.VaultResolverWithRole("default"),
+			authenticate.VaultResolverWithAllowedRoles([]string{"other"}),
+		)
+		_, err = resolver.Resolve(ctx, authenticate.NewAuthentication(), authenticate.Raw(tok))
+		require.Equal(t, &authenticate.NotFoundError{Reason: `vault: resolver: role "tenant" is not allowed`}, err)
+	})
+}
+
 func TestVaultResolverEphemeralPorts(t *testing.T) {
 	// This test checks whether the Vault resolver uses all available ports when
 	// receiving tens of thousands of requests quickly on the same TCP
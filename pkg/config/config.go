@@ -5,6 +5,7 @@ import (
 
 	"github.com/puppetlabs/horsehead/v2/instrumentation/alerts"
 	"github.com/puppetlabs/horsehead/v2/instrumentation/alerts/trackers"
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
 	"k8s.io/client-go/tools/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -24,7 +25,37 @@ type WorkflowControllerConfig struct {
 	WebhookServerKeyDir     string
 	DynamicRBACBinding      bool
 	ToolInjectionImage      string
+	WebhookVerifierImage    string
 	AlertsDelegate          alerts.DelegateFunc
+
+	// WebhookTriggerBackend is the backend used to serve webhook triggers for
+	// tenants that do not select one.
+	WebhookTriggerBackend relayv1beta1.TriggerBackend
+
+	// WebhookTriggerActivatorService is the optionally namespaced name of the
+	// service in front of the activator. If set, webhook triggers served by
+	// the Kubernetes backend may scale to zero.
+	WebhookTriggerActivatorService string
+
+	// WebhookRouter is the kind of route used to expose webhook triggers:
+	// Ambassador, Ingress or Gateway.
+	WebhookRouter string
+
+	// WebhookDomain is the DNS suffix of the host names assigned to webhook
+	// triggers.
+	WebhookDomain string
+
+	// WebhookAmbassadorID selects the Ambassador instance that serves webhook
+	// triggers when using the Ambassador router.
+	WebhookAmbassadorID string
+
+	// WebhookIngressClassName is the class of ingresses to create when using
+	// the Ingress router.
+	WebhookIngressClassName string
+
+	// WebhookGateway is the optionally namespaced name of the Gateway API
+	// gateway to attach routes to when using the Gateway router.
+	WebhookGateway string
 }
 
 func (c *WorkflowControllerConfig) Capturer() trackers.Capturer {
@@ -58,6 +89,46 @@ func (c *WorkflowControllerConfig) ImagePullSecretKey() client.ObjectKey {
 	}
 }
 
+// WebhookTriggerActivatorServiceKey returns the key of the service in front
+// of the activator, or false if the activator is not configured.
+func (c *WorkflowControllerConfig) WebhookTriggerActivatorServiceKey() (client.ObjectKey, bool) {
+	if c.WebhookTriggerActivatorService == "" {
+		return client.ObjectKey{}, false
+	}
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(c.WebhookTriggerActivatorService)
+	if err != nil {
+		name = c.WebhookTriggerActivatorService
+	}
+
+	if namespace == "" {
+		namespace = c.Namespace
+	}
+
+	return client.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}, true
+}
+
+// WebhookGatewayKey returns the key of the Gateway API gateway to attach
+// webhook trigger routes to.
+func (c *WorkflowControllerConfig) WebhookGatewayKey() client.ObjectKey {
+	namespace, name, err := cache.SplitMetaNamespaceKey(c.WebhookGateway)
+	if err != nil {
+		name = c.WebhookGateway
+	}
+
+	if namespace == "" {
+		namespace = c.Namespace
+	}
+
+	return client.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}
+}
+
 // K8sClusterProvisionerConfig is the configuration object to used
 // to configure the Kubernetes provisioner task.
 type K8sClusterProvisionerConfig struct {
@@ -7,8 +7,12 @@ import (
 	"github.com/puppetlabs/relay-core/pkg/dependency"
 	"github.com/puppetlabs/relay-core/pkg/errmark"
 	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/puppetlabs/relay-core/pkg/obj"
 	"github.com/puppetlabs/relay-core/pkg/reconciler/filter"
 	"github.com/puppetlabs/relay-core/pkg/reconciler/trigger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -18,7 +22,7 @@ import (
 )
 
 func add(mgr manager.Manager, r reconcile.Reconciler, cfg *config.WorkflowControllerConfig) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: cfg.MaxConcurrentReconciles,
 		}).
@@ -27,17 +31,29 @@ func add(mgr manager.Manager, r reconcile.Reconciler, cfg *config.WorkflowContro
 			Label:      model.RelayControllerTenantNameLabel,
 			TargetType: &relayv1beta1.WebhookTrigger{},
 		}).
-		Watches(&source.Kind{Type: &servingv1.Service{}}, &handler.EnqueueRequestForAnnotatedDependent{OwnerType: &relayv1beta1.WebhookTrigger{}}).
-		Complete(filter.ChainRight(r,
-			filter.ErrorCaptureReconcilerLink(
-				&relayv1beta1.WebhookTrigger{},
-				cfg.Capturer(),
-				filter.ErrorCaptureReconcilerWithAdditionalTransientRule(
-					errmark.TransientPredicate(errmark.TransientIfForbidden, func() bool { return cfg.DynamicRBACBinding }),
-				),
+		Watches(&source.Kind{Type: &appsv1.Deployment{}}, &handler.EnqueueRequestForAnnotatedDependent{OwnerType: &relayv1beta1.WebhookTrigger{}}).
+		// The metadata API records delivery statistics in a config map that
+		// we copy to the status of the webhook trigger.
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestForAnnotatedDependent{OwnerType: &relayv1beta1.WebhookTrigger{}})
+
+	// Knative Serving is only required for the Knative backend, so we only
+	// watch its services if it is installed.
+	if _, err := mgr.GetRESTMapper().RESTMapping(obj.KnativeServiceKind.GroupKind(), obj.KnativeServiceKind.Version); err == nil {
+		b = b.Watches(&source.Kind{Type: &servingv1.Service{}}, &handler.EnqueueRequestForAnnotatedDependent{OwnerType: &relayv1beta1.WebhookTrigger{}})
+	} else if !meta.IsNoMatchError(err) {
+		return err
+	}
+
+	return b.Complete(filter.ChainRight(r,
+		filter.ErrorCaptureReconcilerLink(
+			&relayv1beta1.WebhookTrigger{},
+			cfg.Capturer(),
+			filter.ErrorCaptureReconcilerWithAdditionalTransientRule(
+				errmark.TransientPredicate(errmark.TransientIfForbidden, func() bool { return cfg.DynamicRBACBinding }),
 			),
-			filter.NamespaceFilterReconcilerLink(cfg.Namespace),
-		))
+		),
+		filter.NamespaceFilterReconcilerLink(cfg.Namespace),
+	))
 }
 
 func Add(dm *dependency.DependencyManager) error {
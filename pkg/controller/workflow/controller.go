@@ -5,15 +5,35 @@ import (
 	"github.com/puppetlabs/relay-core/pkg/config"
 	"github.com/puppetlabs/relay-core/pkg/dependency"
 	"github.com/puppetlabs/relay-core/pkg/errmark"
+	"github.com/puppetlabs/relay-core/pkg/model"
 	"github.com/puppetlabs/relay-core/pkg/reconciler/filter"
 	"github.com/puppetlabs/relay-core/pkg/reconciler/workflow"
 	tekv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+// enqueueRequestForWorkflowRunPod maps the pods of a workflow run's steps to
+// the run, so that their tokens are renewed as they start and stop.
+var enqueueRequestForWorkflowRunPod = &handler.EnqueueRequestsFromMapFunc{
+	ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+		name, found := o.Meta.GetLabels()[model.RelayControllerWorkflowRunIDLabel]
+		if !found {
+			return nil
+		}
+
+		return []reconcile.Request{
+			{NamespacedName: types.NamespacedName{Namespace: o.Meta.GetNamespace(), Name: name}},
+		}
+	}),
+}
+
 func add(mgr manager.Manager, r reconcile.Reconciler, cfg *config.WorkflowControllerConfig) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(controller.Options{
@@ -21,6 +41,7 @@ func add(mgr manager.Manager, r reconcile.Reconciler, cfg *config.WorkflowContro
 		}).
 		For(&nebulav1.WorkflowRun{}).
 		Owns(&tekv1beta1.PipelineRun{}).
+		Watches(&source.Kind{Type: &corev1.Pod{}}, enqueueRequestForWorkflowRunPod).
 		Complete(filter.ChainRight(r,
 			filter.ErrorCaptureReconcilerLink(
 				&nebulav1.WorkflowRun{},
@@ -22,8 +22,6 @@ func (e *UnexpectedResponseError) Error() string {
 	return fmt.Sprintf("received HTTP %d from API: %+v", e.StatusCode, e.Cause)
 }
 
-// TODO: Support event sources other than triggers?
-
 type EventManager struct {
 	me    model.Action
 	url   string
@@ -33,62 +31,76 @@ type EventManager struct {
 var _ model.EventManager = &EventManager{}
 
 func (m *EventManager) Emit(ctx context.Context, data map[string]interface{}, key string) (*model.Event, error) {
+	var source *eventSourceEnvelope
+
 	switch at := m.me.(type) {
 	case *model.Trigger:
-		encoded := make(map[string]transfer.JSONInterface, len(data))
-		for k, v := range data {
-			encoded[k] = transfer.JSONInterface{Data: v}
+		source = &eventSourceEnvelope{
+			Type: "trigger",
+			Trigger: &triggerIdentifierEnvelope{
+				Name: at.Name,
+			},
 		}
-
-		env := &postEventRequestEnvelope{
-			Source: &triggerEventSourceEnvelope{
-				Type: "trigger",
-				Trigger: &triggerIdentifierEnvelope{
-					Name: at.Name,
+	case *model.Step:
+		source = &eventSourceEnvelope{
+			Type: "step",
+			Step: &stepIdentifierEnvelope{
+				Run: &runIdentifierEnvelope{
+					ID: at.Run.ID,
 				},
+				Name: at.Name,
 			},
-			Data: encoded,
-			Key:  key,
 		}
+	default:
+		return nil, model.ErrRejected
+	}
 
-		b, err := json.Marshal(env)
-		if err != nil {
-			return nil, err
-		}
+	encoded := make(map[string]transfer.JSONInterface, len(data))
+	for k, v := range data {
+		encoded[k] = transfer.JSONInterface{Data: v}
+	}
 
-		req, err := http.NewRequest(http.MethodPost, m.url, bytes.NewBuffer(b))
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", m.token))
+	env := &postEventRequestEnvelope{
+		Source: source,
+		Data:   encoded,
+		Key:    key,
+	}
 
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return nil, err
-		}
-		defer resp.Body.Close()
+	b, err := json.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
 
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			err := &UnexpectedResponseError{
-				StatusCode: resp.StatusCode,
-			}
+	req, err := http.NewRequest(http.MethodPost, m.url, bytes.NewBuffer(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", m.token))
 
-			// Try to decode an error.
-			var env utilapi.ErrorEnvelope
-			if derr := json.NewDecoder(resp.Body).Decode(&env); derr == nil && env.Error != nil {
-				err.Cause = env.Error.AsError()
-			}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
-			return nil, err
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err := &UnexpectedResponseError{
+			StatusCode: resp.StatusCode,
 		}
 
-		return &model.Event{
-			Data: data,
-			Key:  key,
-		}, nil
-	default:
-		return nil, model.ErrRejected
+		// Try to decode an error.
+		var env utilapi.ErrorEnvelope
+		if derr := json.NewDecoder(resp.Body).Decode(&env); derr == nil && env.Error != nil {
+			err.Cause = env.Error.AsError()
+		}
+
+		return nil, err
 	}
+
+	return &model.Event{
+		Data: data,
+		Key:  key,
+	}, nil
 }
 
 func NewEventManager(action model.Action, url, token string) *EventManager {
@@ -103,13 +115,23 @@ type triggerIdentifierEnvelope struct {
 	Name string `json:"name"`
 }
 
-type triggerEventSourceEnvelope struct {
+type runIdentifierEnvelope struct {
+	ID string `json:"id"`
+}
+
+type stepIdentifierEnvelope struct {
+	Run  *runIdentifierEnvelope `json:"run"`
+	Name string                 `json:"name"`
+}
+
+type eventSourceEnvelope struct {
 	Type    string                     `json:"type"`
-	Trigger *triggerIdentifierEnvelope `json:"trigger"`
+	Trigger *triggerIdentifierEnvelope `json:"trigger,omitempty"`
+	Step    *stepIdentifierEnvelope    `json:"step,omitempty"`
 }
 
 type postEventRequestEnvelope struct {
-	Source *triggerEventSourceEnvelope       `json:"source"`
+	Source *eventSourceEnvelope              `json:"source"`
 	Data   map[string]transfer.JSONInterface `json:"data"`
 	Key    string                            `json:"key,omitempty"`
 }
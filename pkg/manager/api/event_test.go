@@ -60,3 +60,48 @@ func TestEventManager(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, data, ev.Data)
 }
+
+func TestEventManagerStep(t *testing.T) {
+	ctx := context.Background()
+
+	step := &model.Step{
+		Run:  model.Run{ID: "my-run"},
+		Name: "deploy",
+	}
+
+	data := map[string]interface{}{
+		"environment": "staging",
+	}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env struct {
+			Source struct {
+				Type    string                 `json:"type"`
+				Trigger map[string]interface{} `json:"trigger"`
+				Step    struct {
+					Run struct {
+						ID string `json:"id"`
+					} `json:"run"`
+					Name string `json:"name"`
+				} `json:"step"`
+			} `json:"source"`
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&env))
+
+		assert.Equal(t, "step", env.Source.Type)
+		assert.Nil(t, env.Source.Trigger)
+		assert.Equal(t, step.Run.ID, env.Source.Step.Run.ID)
+		assert.Equal(t, step.Name, env.Source.Step.Name)
+		assert.Equal(t, data, env.Data)
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer s.Close()
+
+	em := api.NewEventManager(step, fmt.Sprintf("%s/api/events", s.URL), "token")
+
+	ev, err := em.Emit(ctx, data, "")
+	require.NoError(t, err)
+	require.Equal(t, data, ev.Data)
+}
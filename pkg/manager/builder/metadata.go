@@ -6,6 +6,7 @@ import (
 )
 
 type metadataManagers struct {
+	changes     model.ChangeManager
 	connections model.ConnectionManager
 	conditions  model.ConditionGetterManager
 	events      model.EventManager
@@ -13,12 +14,19 @@ type metadataManagers struct {
 	parameters  model.ParameterGetterManager
 	secrets     model.SecretManager
 	spec        model.SpecGetterManager
-	state       model.StateGetterManager
+	state       model.StateManager
 	stepOutputs model.StepOutputManager
+
+	webhookDeliveries model.WebhookDeliveryRecorderManager
+	webhookRequests   model.WebhookRequestRecorderManager
 }
 
 var _ model.MetadataManagers = &metadataManagers{}
 
+func (mm *metadataManagers) Changes() model.ChangeManager {
+	return mm.changes
+}
+
 func (mm *metadataManagers) Connections() model.ConnectionManager {
 	return mm.connections
 }
@@ -47,7 +55,7 @@ func (mm *metadataManagers) Spec() model.SpecGetterManager {
 	return mm.spec
 }
 
-func (mm *metadataManagers) State() model.StateGetterManager {
+func (mm *metadataManagers) State() model.StateManager {
 	return mm.state
 }
 
@@ -55,7 +63,16 @@ func (mm *metadataManagers) StepOutputs() model.StepOutputManager {
 	return mm.stepOutputs
 }
 
+func (mm *metadataManagers) WebhookDeliveries() model.WebhookDeliveryRecorderManager {
+	return mm.webhookDeliveries
+}
+
+func (mm *metadataManagers) WebhookRequests() model.WebhookRequestRecorderManager {
+	return mm.webhookRequests
+}
+
 type MetadataBuilder struct {
+	changes     model.ChangeManager
 	connections model.ConnectionManager
 	conditions  model.ConditionGetterManager
 	events      model.EventManager
@@ -63,8 +80,16 @@ type MetadataBuilder struct {
 	parameters  model.ParameterGetterManager
 	secrets     model.SecretManager
 	spec        model.SpecGetterManager
-	state       model.StateGetterManager
+	state       model.StateManager
 	stepOutputs model.StepOutputManager
+
+	webhookDeliveries model.WebhookDeliveryRecorderManager
+	webhookRequests   model.WebhookRequestRecorderManager
+}
+
+func (mb *MetadataBuilder) SetChanges(m model.ChangeManager) *MetadataBuilder {
+	mb.changes = m
+	return mb
 }
 
 func (mb *MetadataBuilder) SetConnections(m model.ConnectionManager) *MetadataBuilder {
@@ -102,7 +127,7 @@ func (mb *MetadataBuilder) SetSpec(m model.SpecGetterManager) *MetadataBuilder {
 	return mb
 }
 
-func (mb *MetadataBuilder) SetState(m model.StateGetterManager) *MetadataBuilder {
+func (mb *MetadataBuilder) SetState(m model.StateManager) *MetadataBuilder {
 	mb.state = m
 	return mb
 }
@@ -112,8 +137,19 @@ func (mb *MetadataBuilder) SetStepOutputs(m model.StepOutputManager) *MetadataBu
 	return mb
 }
 
+func (mb *MetadataBuilder) SetWebhookDeliveries(m model.WebhookDeliveryRecorderManager) *MetadataBuilder {
+	mb.webhookDeliveries = m
+	return mb
+}
+
+func (mb *MetadataBuilder) SetWebhookRequests(m model.WebhookRequestRecorderManager) *MetadataBuilder {
+	mb.webhookRequests = m
+	return mb
+}
+
 func (mb *MetadataBuilder) Build() model.MetadataManagers {
 	return &metadataManagers{
+		changes:     mb.changes,
 		connections: mb.connections,
 		conditions:  mb.conditions,
 		events:      mb.events,
@@ -123,11 +159,15 @@ func (mb *MetadataBuilder) Build() model.MetadataManagers {
 		spec:        mb.spec,
 		state:       mb.state,
 		stepOutputs: mb.stepOutputs,
+
+		webhookDeliveries: mb.webhookDeliveries,
+		webhookRequests:   mb.webhookRequests,
 	}
 }
 
 func NewMetadataBuilder() *MetadataBuilder {
 	return &MetadataBuilder{
+		changes:     reject.ChangeManager,
 		connections: reject.ConnectionManager,
 		conditions:  reject.ConditionManager,
 		events:      reject.EventManager,
@@ -137,5 +177,8 @@ func NewMetadataBuilder() *MetadataBuilder {
 		spec:        reject.SpecManager,
 		state:       reject.StateManager,
 		stepOutputs: reject.StepOutputManager,
+
+		webhookDeliveries: reject.WebhookDeliveryManager,
+		webhookRequests:   reject.WebhookRequestManager,
 	}
 }
@@ -0,0 +1,72 @@
+package configmap
+
+import (
+	"context"
+
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+type ChangeManager struct {
+	cm WatchableConfigMap
+}
+
+var _ model.ChangeManager = &ChangeManager{}
+
+func (m *ChangeManager) Watch(ctx context.Context) (<-chan struct{}, error) {
+	w, err := m.cm.Watch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan struct{}, 1)
+	go m.run(ctx, w, ch)
+
+	return ch, nil
+}
+
+func (m *ChangeManager) run(ctx context.Context, w watch.Interface, ch chan<- struct{}) {
+	defer close(ch)
+	defer func() { w.Stop() }()
+
+	notify := func() {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				// The API server closes watches periodically, so we start a
+				// new one. Changes made in between would be lost, so we
+				// always notify afterward.
+				nw, err := m.cm.Watch(ctx)
+				if err != nil {
+					return
+				}
+
+				w = nw
+				notify()
+				continue
+			}
+
+			switch ev.Type {
+			case watch.Added, watch.Modified, watch.Deleted:
+				notify()
+			case watch.Error:
+				return
+			}
+		}
+	}
+}
+
+func NewChangeManager(cm WatchableConfigMap) *ChangeManager {
+	return &ChangeManager{
+		cm: cm,
+	}
+}
@@ -0,0 +1,59 @@
+package configmap_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestChangeManager(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "mutable",
+			UID:       "b3c4e6f4-3a0e-4d43-9c1b-5b9b1b7f3d4a",
+		},
+	})
+
+	step := &model.Step{
+		Run:  model.Run{ID: "foo"},
+		Name: "bar",
+	}
+
+	mutableMap := configmap.NewClientConfigMap(client, "test", "mutable")
+
+	watchCtx, watchCancel := context.WithCancel(ctx)
+	defer watchCancel()
+
+	changes, err := configmap.NewChangeManager(mutableMap).Watch(watchCtx)
+	require.NoError(t, err)
+
+	_, err = configmap.NewStepOutputManager(step, mutableMap).Set(ctx, "baz", "quux")
+	require.NoError(t, err)
+
+	select {
+	case _, ok := <-changes:
+		require.True(t, ok)
+	case <-ctx.Done():
+		require.Fail(t, "timed out waiting for change notification")
+	}
+
+	watchCancel()
+
+	select {
+	case _, ok := <-changes:
+		require.False(t, ok)
+	case <-ctx.Done():
+		require.Fail(t, "timed out waiting for watch to close")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -15,12 +17,19 @@ type ConfigMap interface {
 	CreateOrUpdate(ctx context.Context, cm *corev1.ConfigMap) (*corev1.ConfigMap, error)
 }
 
+// WatchableConfigMap is a config map that can notify callers of changes to
+// it.
+type WatchableConfigMap interface {
+	ConfigMap
+	Watch(ctx context.Context) (watch.Interface, error)
+}
+
 type ClientConfigMap struct {
 	client          kubernetes.Interface
 	namespace, name string
 }
 
-var _ ConfigMap = &ClientConfigMap{}
+var _ WatchableConfigMap = &ClientConfigMap{}
 
 func (ccm *ClientConfigMap) Get(ctx context.Context) (*corev1.ConfigMap, error) {
 	return ccm.client.CoreV1().ConfigMaps(ccm.namespace).Get(ccm.name, metav1.GetOptions{})
@@ -37,6 +46,12 @@ func (ccm *ClientConfigMap) CreateOrUpdate(ctx context.Context, cm *corev1.Confi
 	return ccm.client.CoreV1().ConfigMaps(ccm.namespace).Update(cm)
 }
 
+func (ccm *ClientConfigMap) Watch(ctx context.Context) (watch.Interface, error) {
+	return ccm.client.CoreV1().ConfigMaps(ccm.namespace).Watch(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", ccm.name).String(),
+	})
+}
+
 func NewClientConfigMap(client kubernetes.Interface, namespace, name string) *ClientConfigMap {
 	return &ClientConfigMap{
 		client:    client,
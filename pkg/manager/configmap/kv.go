@@ -3,6 +3,7 @@ package configmap
 import (
 	"context"
 	"encoding/json"
+	"strings"
 
 	"github.com/puppetlabs/horsehead/v2/encoding/transfer"
 	"github.com/puppetlabs/relay-core/pkg/model"
@@ -35,14 +36,66 @@ func (kcm *KVConfigMap) Get(ctx context.Context, key string) (interface{}, error
 	return value.Data, nil
 }
 
+// List returns the values of every key that starts with the given prefix.
+func (kcm *KVConfigMap) List(ctx context.Context, prefix string) (map[string]interface{}, error) {
+	cm, err := kcm.cm.Get(ctx)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	for key, encoded := range cm.Data {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		var value transfer.JSONInterface
+		if err := json.Unmarshal([]byte(encoded), &value); err != nil {
+			return nil, err
+		}
+
+		values[key] = value.Data
+	}
+
+	return values, nil
+}
+
 func (kcm *KVConfigMap) Set(ctx context.Context, key string, value interface{}) error {
-	encoded, err := json.Marshal(transfer.JSONInterface{Data: value})
-	if err != nil {
-		return err
+	return kcm.SetAll(ctx, map[string]interface{}{key: value})
+}
+
+// SetAll sets the given keys to their values in a single update.
+func (kcm *KVConfigMap) SetAll(ctx context.Context, values map[string]interface{}) error {
+	return kcm.Update(ctx, values, nil)
+}
+
+func (kcm *KVConfigMap) Delete(ctx context.Context, key string) error {
+	return kcm.Update(ctx, nil, []string{key})
+}
+
+// Update sets the given keys to their values and removes the given keys to
+// delete in a single update.
+func (kcm *KVConfigMap) Update(ctx context.Context, values map[string]interface{}, deletes []string) error {
+	encoded := make(map[string]string, len(values))
+	for key, value := range values {
+		b, err := json.Marshal(transfer.JSONInterface{Data: value})
+		if err != nil {
+			return err
+		}
+
+		encoded[key] = string(b)
 	}
 
 	if _, err := MutateConfigMap(ctx, kcm.cm, func(cm *corev1.ConfigMap) {
-		cm.Data[key] = string(encoded)
+		for _, key := range deletes {
+			delete(cm.Data, key)
+		}
+
+		for key, value := range encoded {
+			cm.Data[key] = value
+		}
 	}); err != nil {
 		return err
 	}
@@ -29,4 +29,16 @@ func TestKVConfigMap(t *testing.T) {
 
 	_, err = kcm.Get(ctx, "bar")
 	require.Equal(t, model.ErrNotFound, err)
+
+	require.NoError(t, kcm.SetAll(ctx, map[string]interface{}{
+		"prefix.a": 1,
+		"prefix.b": map[string]interface{}{"c": "d"},
+	}))
+
+	vals, err := kcm.List(ctx, "prefix.")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"prefix.a": float64(1),
+		"prefix.b": map[string]interface{}{"c": "d"},
+	}, vals)
 }
@@ -0,0 +1,89 @@
+package configmap
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+
+	"github.com/puppetlabs/relay-core/pkg/model"
+)
+
+// RedactionManager stores the values to redact from the logs of an action.
+// Because the values are usually secrets, they are always encrypted. If the
+// encrypter is nil, values cannot be recorded, and listing fails if any have
+// been recorded by another manager.
+type RedactionManager struct {
+	me        model.Action
+	kcm       *KVConfigMap
+	encrypter Encrypter
+}
+
+var _ model.RedactionManager = &RedactionManager{}
+
+func (m *RedactionManager) List(ctx context.Context) ([]string, error) {
+	values, err := m.kcm.List(ctx, redactionPrefix(m.me))
+	if err != nil {
+		return nil, err
+	}
+
+	var l []string
+	for key, value := range values {
+		ciphertext, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("configmap: invalid redaction %q of type %T", key, value)
+		} else if m.encrypter == nil {
+			return nil, fmt.Errorf("configmap: redactions are encrypted, but no encrypter is configured")
+		}
+
+		b, err := m.encrypter.Decrypt(ctx, ciphertext)
+		if err != nil {
+			return nil, err
+		}
+
+		var source []string
+		if err := json.Unmarshal(b, &source); err != nil {
+			return nil, err
+		}
+
+		l = append(l, source...)
+	}
+
+	return l, nil
+}
+
+func (m *RedactionManager) Record(ctx context.Context, source string, values []string) error {
+	if m.encrypter == nil {
+		return fmt.Errorf("configmap: redactions must be encrypted, but no encrypter is configured")
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := m.encrypter.Encrypt(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	return m.kcm.Set(ctx, redactionKey(m.me, source), ciphertext)
+}
+
+func NewRedactionManager(action model.Action, cm ConfigMap, enc Encrypter) *RedactionManager {
+	return &RedactionManager{
+		me:        action,
+		kcm:       NewKVConfigMap(cm),
+		encrypter: enc,
+	}
+}
+
+func redactionPrefix(action model.Action) string {
+	return fmt.Sprintf("%s.%s.redaction.", action.Type().Plural, action.Hash())
+}
+
+// redactionKey is the key of the values recorded for a source. Sources are
+// hashed because they may contain characters that are not valid in keys.
+func redactionKey(action model.Action, source string) string {
+	return fmt.Sprintf("%s%x", redactionPrefix(action), sha1.Sum([]byte(source)))
+}
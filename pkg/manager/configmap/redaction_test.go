@@ -0,0 +1,63 @@
+package configmap_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRedactionManager(t *testing.T) {
+	ctx := context.Background()
+
+	step1 := &model.Step{
+		Run:  model.Run{ID: "foo"},
+		Name: "bar",
+	}
+	step2 := &model.Step{
+		Run:  model.Run{ID: "foo"},
+		Name: "baz",
+	}
+
+	obj := &corev1.ConfigMap{}
+	rm1 := configmap.NewRedactionManager(step1, configmap.NewLocalConfigMap(obj), testEncrypter{})
+	rm2 := configmap.NewRedactionManager(step2, configmap.NewLocalConfigMap(obj), testEncrypter{})
+
+	require.NoError(t, rm1.Record(ctx, "secret/password", []string{"hunter2"}))
+	require.NoError(t, rm1.Record(ctx, "connection/aws/prod", []string{"AKIA", "wJalr"}))
+	require.NoError(t, rm2.Record(ctx, "secret/token", []string{"abc123"}))
+
+	for key, value := range obj.Data {
+		require.NotContains(t, value, "hunter2", "key %q contains a recorded value", key)
+	}
+
+	values, err := rm1.List(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"hunter2", "AKIA", "wJalr"}, values)
+
+	// Recording a source again replaces its values.
+	require.NoError(t, rm1.Record(ctx, "secret/password", []string{"changeme"}))
+
+	values, err = rm1.List(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"changeme", "AKIA", "wJalr"}, values)
+
+	values, err = rm2.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"abc123"}, values)
+
+	// Without an encrypter, nothing can be recorded and recorded values
+	// cannot be listed.
+	rm3 := configmap.NewRedactionManager(step1, configmap.NewLocalConfigMap(obj), nil)
+	require.Error(t, rm3.Record(ctx, "secret/password", []string{"hunter2"}))
+
+	_, err = rm3.List(ctx)
+	require.Error(t, err)
+
+	values, err = configmap.NewRedactionManager(step1, configmap.NewLocalConfigMap(&corev1.ConfigMap{}), nil).List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, values)
+}
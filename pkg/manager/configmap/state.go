@@ -37,6 +37,10 @@ func (m *StateManager) Set(ctx context.Context, name string, value interface{})
 	}, nil
 }
 
+func (m *StateManager) Delete(ctx context.Context, name string) error {
+	return m.kcm.Delete(ctx, stateKey(m.me, name))
+}
+
 func NewStateManager(action model.Action, cm ConfigMap) *StateManager {
 	return &StateManager{
 		me:  action,
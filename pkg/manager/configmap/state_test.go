@@ -49,4 +49,15 @@ func TestStateManager(t *testing.T) {
 
 	val, err = sm2.Get(ctx, "key-b")
 	require.Equal(t, model.ErrNotFound, err)
+
+	// Deleting state only affects the step that owns it.
+	require.NoError(t, sm1.Delete(ctx, "key-a"))
+	require.NoError(t, sm1.Delete(ctx, "key-a"))
+
+	_, err = sm1.Get(ctx, "key-a")
+	require.Equal(t, model.ErrNotFound, err)
+
+	val, err = sm2.Get(ctx, "key-a")
+	require.NoError(t, err)
+	require.Equal(t, "value-a-step-2", val.Value)
 }
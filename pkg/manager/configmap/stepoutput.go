@@ -2,14 +2,40 @@ package configmap
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/json"
 	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
 
+	"github.com/puppetlabs/horsehead/v2/encoding/transfer"
+	"github.com/puppetlabs/horsehead/v2/storage"
 	"github.com/puppetlabs/relay-core/pkg/model"
 )
 
+// DefaultStepOutputBlobThresholdBytes is the encoded size above which outputs
+// are stored in blob storage instead of the config map, if blob storage is
+// configured.
+const DefaultStepOutputBlobThresholdBytes = 64 * 1024
+
+// Encrypter protects the values of sensitive step outputs stored in a config
+// map.
+type Encrypter interface {
+	Encrypt(ctx context.Context, plaintext []byte) (string, error)
+	Decrypt(ctx context.Context, ciphertext string) ([]byte, error)
+}
+
 type StepOutputManager struct {
 	me  *model.Step
 	kcm *KVConfigMap
+
+	blobs         storage.BlobStore
+	blobThreshold int
+	blobPrefix    string
+
+	encrypter Encrypter
 }
 
 var _ model.StepOutputManager = &StepOutputManager{}
@@ -20,37 +46,400 @@ func (m *StepOutputManager) Get(ctx context.Context, stepName, name string) (*mo
 		Name: stepName,
 	}
 
-	value, err := m.kcm.Get(ctx, stepOutputKey(step, name))
+	prefix := stepOutputPrefix(step)
+
+	values, err := m.kcm.List(ctx, prefix)
 	if err != nil {
 		return nil, err
 	}
 
-	return &model.StepOutput{
-		Step:  step,
-		Name:  name,
-		Value: value,
-	}, nil
+	l, err := m.outputs(ctx, step, trimKeyPrefix(values, prefix), name)
+	if err != nil {
+		return nil, err
+	} else if len(l) == 0 {
+		return nil, model.ErrNotFound
+	}
+
+	return l[0], nil
+}
+
+func (m *StepOutputManager) List(ctx context.Context) ([]*model.StepOutput, error) {
+	prefix := fmt.Sprintf("%s.", model.ActionTypeStep.Plural)
+
+	values, err := m.kcm.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys only identify steps by their hashes, so we rely on the step names
+	// recorded when outputs are set.
+	steps := make(map[string]*model.Step)
+	byStep := make(map[string]map[string]interface{})
+	for key, value := range values {
+		parts := strings.SplitN(strings.TrimPrefix(key, prefix), ".", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		if parts[1] == "output-step-name" {
+			if name, ok := value.(string); ok {
+				steps[parts[0]] = &model.Step{
+					Run:  m.me.Run,
+					Name: name,
+				}
+			}
+
+			continue
+		}
+
+		if byStep[parts[0]] == nil {
+			byStep[parts[0]] = make(map[string]interface{})
+		}
+		byStep[parts[0]][parts[1]] = value
+	}
+
+	var l []*model.StepOutput
+	for h, step := range steps {
+		outputs, err := m.outputs(ctx, step, byStep[h], "")
+		if err != nil {
+			return nil, err
+		}
+
+		l = append(l, outputs...)
+	}
+
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Step.Name != l[j].Step.Name {
+			return l[i].Step.Name < l[j].Step.Name
+		}
+
+		return l[i].Name < l[j].Name
+	})
+
+	return l, nil
+}
+
+func (m *StepOutputManager) ListStep(ctx context.Context, stepName string) ([]*model.StepOutput, error) {
+	step := &model.Step{
+		Run:  m.me.Run,
+		Name: stepName,
+	}
+
+	prefix := stepOutputPrefix(step)
+
+	values, err := m.kcm.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := m.outputs(ctx, step, trimKeyPrefix(values, prefix), "")
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Name < l[j].Name
+	})
+
+	return l, nil
 }
 
 func (m *StepOutputManager) Set(ctx context.Context, name string, value interface{}) (*model.StepOutput, error) {
-	if err := m.kcm.Set(ctx, stepOutputKey(m.me, name), value); err != nil {
+	return m.set(ctx, name, value, false)
+}
+
+// SetSensitive sets an output and marks it as sensitive. If an encrypter is
+// configured, the value is stored encrypted. Sensitive values are never moved
+// to blob storage.
+func (m *StepOutputManager) SetSensitive(ctx context.Context, name string, value interface{}) (*model.StepOutput, error) {
+	return m.set(ctx, name, value, true)
+}
+
+func (m *StepOutputManager) set(ctx context.Context, name string, value interface{}, sensitive bool) (*model.StepOutput, error) {
+	values := map[string]interface{}{
+		stepOutputStepNameKey(m.me): m.me.Name,
+	}
+
+	kinds := map[string]interface{}{}
+
+	b, err := json.Marshal(transfer.JSONInterface{Data: value})
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case sensitive && m.encrypter != nil:
+		ciphertext, err := m.encrypter.Encrypt(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+
+		kinds[stepOutputKindEncrypted] = ciphertext
+	case !sensitive && m.blobs != nil && len(b) > m.blobThreshold:
+		ref := stepOutputBlobStorageKey(m.blobPrefix, m.me, name)
+
+		if err := m.blobs.Put(ctx, ref, func(w io.Writer) error {
+			_, err := w.Write(b)
+			return err
+		}, storage.PutOptions{
+			ContentType: "application/json",
+		}); err != nil {
+			return nil, err
+		}
+
+		kinds[stepOutputKindBlob] = ref
+	default:
+		kinds[stepOutputKindValue] = value
+	}
+
+	if sensitive {
+		kinds[stepOutputKindSensitive] = true
+	}
+
+	// Any other representation of the output left over from a previous value
+	// is removed.
+	var deletes []string
+	for _, kind := range stepOutputKinds {
+		key := stepOutputKindKey(m.me, kind, name)
+
+		if v, found := kinds[kind]; found {
+			values[key] = v
+		} else {
+			deletes = append(deletes, key)
+		}
+	}
+
+	if err := m.kcm.Update(ctx, values, deletes); err != nil {
 		return nil, err
 	}
 
 	return &model.StepOutput{
-		Step:  m.me,
-		Name:  name,
-		Value: value,
+		Step:      m.me,
+		Name:      name,
+		Value:     value,
+		Sensitive: sensitive,
 	}, nil
 }
 
-func NewStepOutputManager(step *model.Step, cm ConfigMap) *StepOutputManager {
-	return &StepOutputManager{
-		me:  step,
-		kcm: NewKVConfigMap(cm),
+// outputs converts the config map entries for the given step, with the step
+// prefix removed, to outputs. If name is not empty, only the output with that
+// name is converted.
+func (m *StepOutputManager) outputs(ctx context.Context, step *model.Step, values map[string]interface{}, name string) ([]*model.StepOutput, error) {
+	sensitive := make(map[string]bool)
+	for key := range values {
+		if n := strings.TrimPrefix(key, stepOutputKindSensitive+"."); n != key {
+			sensitive[n] = true
+		}
+	}
+
+	var l []*model.StepOutput
+	for key, value := range values {
+		parts := strings.SplitN(key, ".", 2)
+		if len(parts) != 2 || (name != "" && parts[1] != name) {
+			continue
+		}
+
+		var err error
+
+		switch parts[0] {
+		case stepOutputKindValue:
+		case stepOutputKindBlob:
+			value, err = m.readBlob(ctx, value)
+		case stepOutputKindEncrypted:
+			value, err = m.decrypt(ctx, value)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		l = append(l, &model.StepOutput{
+			Step:      step,
+			Name:      parts[1],
+			Value:     value,
+			Sensitive: sensitive[parts[1]],
+		})
+	}
+
+	return l, nil
+}
+
+func (m *StepOutputManager) readBlob(ctx context.Context, ref interface{}) (interface{}, error) {
+	key, ok := ref.(string)
+	if !ok {
+		return nil, fmt.Errorf("configmap: invalid blob reference of type %T", ref)
+	} else if m.blobs == nil {
+		return nil, fmt.Errorf("configmap: output is in blob storage, but blob storage is not configured")
+	}
+
+	var value transfer.JSONInterface
+	err := m.blobs.Get(ctx, key, func(meta *storage.Meta, r io.Reader) error {
+		return json.NewDecoder(r).Decode(&value)
+	}, storage.GetOptions{})
+	if storage.IsNotFoundError(err) {
+		return nil, model.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return value.Data, nil
+}
+
+func (m *StepOutputManager) decrypt(ctx context.Context, ciphertext interface{}) (interface{}, error) {
+	s, ok := ciphertext.(string)
+	if !ok {
+		return nil, fmt.Errorf("configmap: invalid encrypted output of type %T", ciphertext)
+	} else if m.encrypter == nil {
+		return nil, fmt.Errorf("configmap: output is encrypted, but no encrypter is configured")
+	}
+
+	b, err := m.encrypter.Decrypt(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	var value transfer.JSONInterface
+	if err := json.Unmarshal(b, &value); err != nil {
+		return nil, err
+	}
+
+	return value.Data, nil
+}
+
+type StepOutputManagerOption func(m *StepOutputManager)
+
+// StepOutputManagerWithBlobStore stores outputs larger than the given number of
+// bytes, when encoded, in the given blob store. Only a reference to the blob is
+// kept in the config map.
+func StepOutputManagerWithBlobStore(bs storage.BlobStore, thresholdBytes int) StepOutputManagerOption {
+	return func(m *StepOutputManager) {
+		m.blobs = bs
+		m.blobThreshold = thresholdBytes
+	}
+}
+
+// StepOutputManagerWithBlobKeyPrefix stores outputs in blob storage under the
+// given prefix, which must be unique to the run, such as the UID of the
+// namespace of the run, so that runs cannot read or overwrite each other's
+// outputs.
+func StepOutputManagerWithBlobKeyPrefix(prefix string) StepOutputManagerOption {
+	return func(m *StepOutputManager) {
+		m.blobPrefix = prefix
 	}
 }
 
-func stepOutputKey(step *model.Step, name string) string {
-	return fmt.Sprintf("%s.%s.output.%s", step.Type().Plural, step.Hash(), name)
+// StepOutputManagerWithEncrypter encrypts the values of sensitive outputs
+// using the given encrypter.
+func StepOutputManagerWithEncrypter(enc Encrypter) StepOutputManagerOption {
+	return func(m *StepOutputManager) {
+		m.encrypter = enc
+	}
+}
+
+func NewStepOutputManager(step *model.Step, cm ConfigMap, opts ...StepOutputManagerOption) *StepOutputManager {
+	m := &StepOutputManager{
+		me:            step,
+		kcm:           NewKVConfigMap(cm),
+		blobThreshold: DefaultStepOutputBlobThresholdBytes,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+const (
+	stepOutputKindValue     = "output"
+	stepOutputKindBlob      = "output-blob"
+	stepOutputKindEncrypted = "output-encrypted"
+	stepOutputKindSensitive = "output-sensitive"
+)
+
+var stepOutputKinds = []string{
+	stepOutputKindValue,
+	stepOutputKindBlob,
+	stepOutputKindEncrypted,
+	stepOutputKindSensitive,
+}
+
+func stepOutputPrefix(step *model.Step) string {
+	return fmt.Sprintf("%s.%s.", step.Type().Plural, step.Hash())
+}
+
+func stepOutputKindKey(step *model.Step, kind, name string) string {
+	return fmt.Sprintf("%s%s.%s", stepOutputPrefix(step), kind, name)
+}
+
+func stepOutputStepNameKey(step *model.Step) string {
+	return stepOutputPrefix(step) + "output-step-name"
+}
+
+func trimKeyPrefix(values map[string]interface{}, prefix string) map[string]interface{} {
+	trimmed := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		trimmed[strings.TrimPrefix(key, prefix)] = value
+	}
+
+	return trimmed
+}
+
+// stepOutputBlobStorageKey is the key of an output in blob storage. Output
+// names are hashed because they may contain characters that are not valid in
+// keys.
+func stepOutputBlobStorageKey(prefix string, step *model.Step, name string) string {
+	return path.Join(prefix, fmt.Sprintf("%s/%s/outputs/%x", step.Type().Plural, step.Hash(), sha1.Sum([]byte(name))))
+}
+
+// HasStepOutputBlobs returns true if the given config map refers to any
+// output stored in blob storage.
+func HasStepOutputBlobs(ctx context.Context, cm ConfigMap) (bool, error) {
+	refs, err := stepOutputBlobRefs(ctx, cm)
+	if err != nil {
+		return false, err
+	}
+
+	return len(refs) > 0, nil
+}
+
+// DeleteStepOutputBlobs removes every output stored in blob storage that the
+// given config map refers to. Blobs are not owned by the Kubernetes objects of
+// a run, so they must be removed explicitly when the run is deleted.
+func DeleteStepOutputBlobs(ctx context.Context, cm ConfigMap, bs storage.BlobStore) error {
+	refs, err := stepOutputBlobRefs(ctx, cm)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		if err := bs.Delete(ctx, ref, storage.DeleteOptions{}); err != nil && !storage.IsNotFoundError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func stepOutputBlobRefs(ctx context.Context, cm ConfigMap) ([]string, error) {
+	values, err := NewKVConfigMap(cm).List(ctx, fmt.Sprintf("%s.", model.ActionTypeStep.Plural))
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []string
+	for key, value := range values {
+		// <steps>.<hash>.<kind>.<name>
+		parts := strings.SplitN(key, ".", 4)
+		if len(parts) != 4 || parts[2] != stepOutputKindBlob {
+			continue
+		}
+
+		if ref, ok := value.(string); ok {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs, nil
 }
@@ -2,9 +2,16 @@ package configmap_test
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	filesystem "github.com/puppetlabs/horsehead/v2/storage/file"
 	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
 	"github.com/puppetlabs/relay-core/pkg/model"
 	"github.com/stretchr/testify/require"
@@ -52,6 +59,195 @@ func TestStepOutputManager(t *testing.T) {
 
 			_, err = om.Get(ctx, step2.Name, "key-b")
 			require.Equal(t, model.ErrNotFound, err)
+
+			outs, err := om.ListStep(ctx, step1.Name)
+			require.NoError(t, err)
+			require.Len(t, outs, 2)
+			require.Equal(t, "key-a", outs[0].Name)
+			require.Equal(t, "value-a-step-1", outs[0].Value)
+			require.Equal(t, "key-b", outs[1].Name)
+
+			outs, err = om.ListStep(ctx, "nonexistent")
+			require.NoError(t, err)
+			require.Empty(t, outs)
+
+			outs, err = om.List(ctx)
+			require.NoError(t, err)
+			require.Len(t, outs, 3)
+			require.Equal(t, step1.Name, outs[0].Step.Name)
+			require.Equal(t, "key-a", outs[0].Name)
+			require.Equal(t, step1.Name, outs[1].Step.Name)
+			require.Equal(t, "key-b", outs[1].Name)
+			require.Equal(t, step2.Name, outs[2].Step.Name)
+			require.Equal(t, "value-a-step-2", outs[2].Value)
 		})
 	}
 }
+
+func TestStepOutputManagerBlobStore(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "relay-stepoutput-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bs, err := filesystem.New(url.URL{Scheme: "file", Path: dir})
+	require.NoError(t, err)
+
+	step := &model.Step{
+		Run:  model.Run{ID: "foo"},
+		Name: "bar",
+	}
+
+	obj := &corev1.ConfigMap{}
+	som := configmap.NewStepOutputManager(step, configmap.NewLocalConfigMap(obj), configmap.StepOutputManagerWithBlobStore(bs, 16))
+
+	large := strings.Repeat("a", 1024)
+
+	_, err = som.Set(ctx, "small", "b")
+	require.NoError(t, err)
+
+	_, err = som.Set(ctx, "large/../report", large)
+	require.NoError(t, err)
+
+	// Only a reference to the large output is kept in the config map.
+	for key, value := range obj.Data {
+		require.NotContains(t, value, large, "key %q contains the large output", key)
+	}
+
+	out, err := som.Get(ctx, "bar", "large/../report")
+	require.NoError(t, err)
+	require.Equal(t, large, out.Value)
+
+	outs, err := som.ListStep(ctx, "bar")
+	require.NoError(t, err)
+	require.Len(t, outs, 2)
+	require.Equal(t, "large/../report", outs[0].Name)
+	require.Equal(t, large, outs[0].Value)
+	require.Equal(t, "small", outs[1].Name)
+	require.Equal(t, "b", outs[1].Value)
+
+	outs, err = som.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, outs, 2)
+	require.Equal(t, large, outs[0].Value)
+
+	// Replacing a large output with a small one removes the reference.
+	_, err = som.Set(ctx, "large/../report", "c")
+	require.NoError(t, err)
+
+	out, err = som.Get(ctx, "bar", "large/../report")
+	require.NoError(t, err)
+	require.Equal(t, "c", out.Value)
+
+	outs, err = som.ListStep(ctx, "bar")
+	require.NoError(t, err)
+	require.Len(t, outs, 2)
+
+	// Blobs are removed along with the run.
+	obj = &corev1.ConfigMap{}
+	som = configmap.NewStepOutputManager(
+		step,
+		configmap.NewLocalConfigMap(obj),
+		configmap.StepOutputManagerWithBlobStore(bs, 16),
+		configmap.StepOutputManagerWithBlobKeyPrefix("my-namespace-uid/foo"),
+	)
+
+	ok, err := configmap.HasStepOutputBlobs(ctx, configmap.NewLocalConfigMap(obj))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = som.Set(ctx, "large", large)
+	require.NoError(t, err)
+
+	ok, err = configmap.HasStepOutputBlobs(ctx, configmap.NewLocalConfigMap(obj))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, err = os.Stat(filepath.Join(dir, "blob", "my-namespace-uid", "foo"))
+	require.NoError(t, err)
+
+	require.NoError(t, configmap.DeleteStepOutputBlobs(ctx, configmap.NewLocalConfigMap(obj), bs))
+
+	_, err = som.Get(ctx, "bar", "large")
+	require.Equal(t, model.ErrNotFound, err)
+
+	// Without a blob store, every output stays in the config map.
+	obj = &corev1.ConfigMap{}
+	som = configmap.NewStepOutputManager(step, configmap.NewLocalConfigMap(obj))
+
+	_, err = som.Set(ctx, "large", large)
+	require.NoError(t, err)
+
+	out, err = som.Get(ctx, "bar", "large")
+	require.NoError(t, err)
+	require.Equal(t, large, out.Value)
+}
+
+type testEncrypter struct{}
+
+func (testEncrypter) Encrypt(ctx context.Context, plaintext []byte) (string, error) {
+	return "test:" + base64.StdEncoding.EncodeToString(plaintext), nil
+}
+
+func (testEncrypter) Decrypt(ctx context.Context, ciphertext string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, "test:"))
+}
+
+func TestStepOutputManagerSensitive(t *testing.T) {
+	ctx := context.Background()
+
+	step := &model.Step{
+		Run:  model.Run{ID: "foo"},
+		Name: "bar",
+	}
+
+	obj := &corev1.ConfigMap{}
+	som := configmap.NewStepOutputManager(step, configmap.NewLocalConfigMap(obj), configmap.StepOutputManagerWithEncrypter(testEncrypter{}))
+
+	_, err := som.SetSensitive(ctx, "password", "hunter2")
+	require.NoError(t, err)
+
+	_, err = som.Set(ctx, "username", "admin")
+	require.NoError(t, err)
+
+	// The sensitive value is not stored in the clear.
+	for key, value := range obj.Data {
+		require.NotContains(t, value, "hunter2", "key %q contains the sensitive output", key)
+	}
+
+	out, err := som.Get(ctx, "bar", "password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", out.Value)
+	require.True(t, out.Sensitive)
+
+	outs, err := som.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, outs, 2)
+	require.Equal(t, "password", outs[0].Name)
+	require.True(t, outs[0].Sensitive)
+	require.Equal(t, "username", outs[1].Name)
+	require.False(t, outs[1].Sensitive)
+
+	// Setting the output again without the flag clears it.
+	_, err = som.Set(ctx, "password", "changeme")
+	require.NoError(t, err)
+
+	out, err = som.Get(ctx, "bar", "password")
+	require.NoError(t, err)
+	require.Equal(t, "changeme", out.Value)
+	require.False(t, out.Sensitive)
+
+	// Without an encrypter, sensitive outputs are stored as-is but keep their
+	// flag.
+	obj = &corev1.ConfigMap{}
+	som = configmap.NewStepOutputManager(step, configmap.NewLocalConfigMap(obj))
+
+	_, err = som.SetSensitive(ctx, "password", map[string]interface{}{"token": "hunter2"})
+	require.NoError(t, err)
+
+	out, err = som.Get(ctx, "bar", "password")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"token": "hunter2"}, out.Value)
+	require.True(t, out.Sensitive)
+}
@@ -0,0 +1,187 @@
+package configmap
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+const webhookDeliveriesKey = "deliveries"
+
+type webhookDeliveries struct {
+	LastRequestTime  time.Time `json:"lastRequestTime,omitempty"`
+	LastEventTime    time.Time `json:"lastEventTime,omitempty"`
+	RequestsReceived int64     `json:"requestsReceived"`
+	EventsEmitted    int64     `json:"eventsEmitted"`
+	Failed           int64     `json:"failed"`
+	LastError        string    `json:"lastError,omitempty"`
+	LastErrorTime    time.Time `json:"lastErrorTime,omitempty"`
+
+	Sinks map[string]*webhookSinkDeliveries `json:"sinks,omitempty"`
+}
+
+func (wd *webhookDeliveries) fail(at time.Time, failure error) {
+	wd.Failed++
+
+	if at.Before(wd.LastErrorTime) {
+		return
+	}
+
+	wd.LastError = failure.Error()
+	wd.LastErrorTime = at
+}
+
+type webhookSinkDeliveries struct {
+	LastEventTime   time.Time `json:"lastEventTime,omitempty"`
+	EventsDelivered int64     `json:"eventsDelivered"`
+	Failed          int64     `json:"failed"`
+	LastError       string    `json:"lastError,omitempty"`
+	LastErrorTime   time.Time `json:"lastErrorTime,omitempty"`
+}
+
+func (wsd *webhookSinkDeliveries) fail(at time.Time, failure error) {
+	wsd.Failed++
+
+	if at.Before(wsd.LastErrorTime) {
+		return
+	}
+
+	wsd.LastError = failure.Error()
+	wsd.LastErrorTime = at
+}
+
+func (wsd *webhookSinkDeliveries) model(name string) *model.WebhookSinkDeliveries {
+	return &model.WebhookSinkDeliveries{
+		Name:            name,
+		LastEventTime:   wsd.LastEventTime,
+		EventsDelivered: wsd.EventsDelivered,
+		Failed:          wsd.Failed,
+		LastError:       wsd.LastError,
+		LastErrorTime:   wsd.LastErrorTime,
+	}
+}
+
+func (wd *webhookDeliveries) model() *model.WebhookDeliveries {
+	var sinks []*model.WebhookSinkDeliveries
+	for name, wsd := range wd.Sinks {
+		sinks = append(sinks, wsd.model(name))
+	}
+
+	sort.Slice(sinks, func(i, j int) bool {
+		return sinks[i].Name < sinks[j].Name
+	})
+
+	return &model.WebhookDeliveries{
+		LastRequestTime:  wd.LastRequestTime,
+		LastEventTime:    wd.LastEventTime,
+		RequestsReceived: wd.RequestsReceived,
+		EventsEmitted:    wd.EventsEmitted,
+		Failed:           wd.Failed,
+		LastError:        wd.LastError,
+		LastErrorTime:    wd.LastErrorTime,
+		Sinks:            sinks,
+	}
+}
+
+// WebhookDeliveryManager keeps counters for the requests received by a webhook
+// trigger and the events it emitted in a config map.
+type WebhookDeliveryManager struct {
+	cm ConfigMap
+}
+
+var _ model.WebhookDeliveryManager = &WebhookDeliveryManager{}
+
+func (m *WebhookDeliveryManager) Get(ctx context.Context) (*model.WebhookDeliveries, error) {
+	cm, err := m.cm.Get(ctx)
+	if errors.IsNotFound(err) {
+		return &model.WebhookDeliveries{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var wd webhookDeliveries
+	if err := decodeWebhookData(cm, webhookDeliveriesKey, &wd); err != nil {
+		return nil, err
+	}
+
+	return wd.model(), nil
+}
+
+func (m *WebhookDeliveryManager) RecordRequest(ctx context.Context, at time.Time, failure error) error {
+	return m.mutate(ctx, func(wd *webhookDeliveries) {
+		wd.RequestsReceived++
+		if at.After(wd.LastRequestTime) {
+			wd.LastRequestTime = at
+		}
+
+		if failure != nil {
+			wd.fail(at, failure)
+		}
+	})
+}
+
+func (m *WebhookDeliveryManager) RecordEvent(ctx context.Context, at time.Time, failure error) error {
+	return m.mutate(ctx, func(wd *webhookDeliveries) {
+		if failure != nil {
+			wd.fail(at, failure)
+			return
+		}
+
+		wd.EventsEmitted++
+		if at.After(wd.LastEventTime) {
+			wd.LastEventTime = at
+		}
+	})
+}
+
+func (m *WebhookDeliveryManager) RecordSinkEvent(ctx context.Context, sink string, at time.Time, failure error) error {
+	return m.mutate(ctx, func(wd *webhookDeliveries) {
+		wsd, found := wd.Sinks[sink]
+		if !found {
+			wsd = &webhookSinkDeliveries{}
+
+			if wd.Sinks == nil {
+				wd.Sinks = make(map[string]*webhookSinkDeliveries)
+			}
+			wd.Sinks[sink] = wsd
+		}
+
+		if failure != nil {
+			wsd.fail(at, failure)
+			return
+		}
+
+		wsd.EventsDelivered++
+		if at.After(wsd.LastEventTime) {
+			wsd.LastEventTime = at
+		}
+	})
+}
+
+func (m *WebhookDeliveryManager) mutate(ctx context.Context, fn func(wd *webhookDeliveries)) error {
+	var err error
+	if _, merr := MutateConfigMap(ctx, m.cm, func(cm *corev1.ConfigMap) {
+		var wd webhookDeliveries
+		if err = decodeWebhookData(cm, webhookDeliveriesKey, &wd); err != nil {
+			return
+		}
+
+		fn(&wd)
+
+		err = encodeWebhookData(cm, webhookDeliveriesKey, &wd)
+	}); merr != nil {
+		return merr
+	}
+
+	return err
+}
+
+func NewWebhookDeliveryManager(cm ConfigMap) *WebhookDeliveryManager {
+	return &WebhookDeliveryManager{
+		cm: cm,
+	}
+}
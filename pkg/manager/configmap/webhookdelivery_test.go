@@ -0,0 +1,77 @@
+package configmap_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestWebhookDeliveryManager(t *testing.T) {
+	ctx := context.Background()
+
+	obj := &corev1.ConfigMap{}
+	wdm := configmap.NewWebhookDeliveryManager(configmap.NewLocalConfigMap(obj))
+
+	wd, err := wdm.Get(ctx)
+	require.NoError(t, err)
+	assert.True(t, wd.LastRequestTime.IsZero())
+	assert.Equal(t, int64(0), wd.RequestsReceived)
+
+	now := time.Now()
+
+	require.NoError(t, wdm.RecordRequest(ctx, now, nil))
+	require.NoError(t, wdm.RecordEvent(ctx, now, nil))
+	require.NoError(t, wdm.RecordEvent(ctx, now.Add(time.Second), errors.New("sink unavailable")))
+
+	// A request reported late must not move the last request time back.
+	require.NoError(t, wdm.RecordRequest(ctx, now.Add(-time.Minute), errors.New("HTTP 500")))
+
+	wd, err = wdm.Get(ctx)
+	require.NoError(t, err)
+	assert.True(t, now.Equal(wd.LastRequestTime))
+	assert.True(t, now.Equal(wd.LastEventTime))
+	assert.Equal(t, int64(2), wd.RequestsReceived)
+	assert.Equal(t, int64(1), wd.EventsEmitted)
+	assert.Equal(t, int64(2), wd.Failed)
+	assert.Equal(t, "sink unavailable", wd.LastError)
+	assert.True(t, now.Add(time.Second).Equal(wd.LastErrorTime))
+}
+
+func TestWebhookDeliveryManagerSinks(t *testing.T) {
+	ctx := context.Background()
+
+	obj := &corev1.ConfigMap{}
+	wdm := configmap.NewWebhookDeliveryManager(configmap.NewLocalConfigMap(obj))
+
+	now := time.Now()
+
+	require.NoError(t, wdm.RecordSinkEvent(ctx, "relay", now, nil))
+	require.NoError(t, wdm.RecordSinkEvent(ctx, "audit", now, nil))
+	require.NoError(t, wdm.RecordSinkEvent(ctx, "audit", now.Add(time.Second), errors.New("collector unavailable")))
+
+	wd, err := wdm.Get(ctx)
+	require.NoError(t, err)
+	require.Len(t, wd.Sinks, 2)
+
+	// Per-sink deliveries do not affect the trigger totals.
+	assert.Equal(t, int64(0), wd.EventsEmitted)
+	assert.Equal(t, int64(0), wd.Failed)
+
+	audit := wd.Sinks[0]
+	assert.Equal(t, "audit", audit.Name)
+	assert.Equal(t, int64(1), audit.EventsDelivered)
+	assert.Equal(t, int64(1), audit.Failed)
+	assert.Equal(t, "collector unavailable", audit.LastError)
+	assert.True(t, now.Equal(audit.LastEventTime))
+
+	relay := wd.Sinks[1]
+	assert.Equal(t, "relay", relay.Name)
+	assert.Equal(t, int64(1), relay.EventsDelivered)
+	assert.Equal(t, int64(0), relay.Failed)
+}
@@ -0,0 +1,274 @@
+package configmap
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/puppetlabs/horsehead/v2/encoding/transfer"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// DefaultWebhookRequestLimit is the number of requests kept if no limit is
+	// given.
+	DefaultWebhookRequestLimit = 10
+
+	webhookRequestsKey = "requests"
+	webhookEventsKey   = "events"
+
+	// maxPendingWebhookEvents bounds the number of events waiting to be
+	// attached to a request. Events are normally attached within the
+	// lifetime of a single request, so this only matters when requests are
+	// not being captured at all.
+	maxPendingWebhookEvents = 50
+)
+
+type webhookEvent struct {
+	Time      time.Time                         `json:"time"`
+	RequestID string                            `json:"requestId,omitempty"`
+	Data      map[string]transfer.JSONInterface `json:"data"`
+	Key       string                            `json:"key,omitempty"`
+}
+
+// belongsTo returns true if the event was emitted while handling the given
+// request. Events that carry a request ID only belong to that request. Other
+// events, from triggers that do not send the request ID, are attributed by
+// time and may be attached to the wrong request if requests overlap.
+func (we *webhookEvent) belongsTo(wr *webhookRequest) bool {
+	if we.RequestID != "" {
+		return we.RequestID == wr.ID
+	}
+
+	return !we.Time.Before(wr.ReceivedAt) && !we.Time.After(wr.CompletedAt)
+}
+
+func (we *webhookEvent) model() *model.Event {
+	data := make(map[string]interface{}, len(we.Data))
+	for k, v := range we.Data {
+		data[k] = v.Data
+	}
+
+	return &model.Event{
+		Data: data,
+		Key:  we.Key,
+	}
+}
+
+func newWebhookEvent(ev *model.Event, requestID string, at time.Time) *webhookEvent {
+	data := make(map[string]transfer.JSONInterface, len(ev.Data))
+	for k, v := range ev.Data {
+		data[k] = transfer.JSONInterface{Data: v}
+	}
+
+	return &webhookEvent{
+		Time:      at,
+		RequestID: requestID,
+		Data:      data,
+		Key:       ev.Key,
+	}
+}
+
+type webhookRequest struct {
+	ID            string          `json:"id"`
+	ReceivedAt    time.Time       `json:"receivedAt"`
+	CompletedAt   time.Time       `json:"completedAt"`
+	Method        string          `json:"method"`
+	URL           string          `json:"url"`
+	Header        http.Header     `json:"header,omitempty"`
+	Body          []byte          `json:"body,omitempty"`
+	BodyTruncated bool            `json:"bodyTruncated,omitempty"`
+	StatusCode    int             `json:"statusCode"`
+	Error         string          `json:"error,omitempty"`
+	Events        []*webhookEvent `json:"events,omitempty"`
+	ReplayOf      string          `json:"replayOf,omitempty"`
+}
+
+func (wr *webhookRequest) model() *model.WebhookRequest {
+	events := make([]*model.Event, len(wr.Events))
+	for i, ev := range wr.Events {
+		events[i] = ev.model()
+	}
+
+	return &model.WebhookRequest{
+		ID:            wr.ID,
+		ReceivedAt:    wr.ReceivedAt,
+		CompletedAt:   wr.CompletedAt,
+		Method:        wr.Method,
+		URL:           wr.URL,
+		Header:        wr.Header,
+		Body:          wr.Body,
+		BodyTruncated: wr.BodyTruncated,
+		StatusCode:    wr.StatusCode,
+		Error:         wr.Error,
+		Events:        events,
+		ReplayOf:      wr.ReplayOf,
+	}
+}
+
+// WebhookRequestManager keeps the most recent requests received by a webhook
+// trigger in a dedicated config map.
+type WebhookRequestManager struct {
+	cm    ConfigMap
+	limit int
+}
+
+var _ model.WebhookRequestManager = &WebhookRequestManager{}
+
+func (m *WebhookRequestManager) List(ctx context.Context) ([]*model.WebhookRequest, error) {
+	cm, err := m.cm.Get(ctx)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var wrs []*webhookRequest
+	if err := decodeWebhookData(cm, webhookRequestsKey, &wrs); err != nil {
+		return nil, err
+	}
+
+	reqs := make([]*model.WebhookRequest, len(wrs))
+	for i, wr := range wrs {
+		reqs[i] = wr.model()
+	}
+
+	return reqs, nil
+}
+
+func (m *WebhookRequestManager) Get(ctx context.Context, id string) (*model.WebhookRequest, error) {
+	reqs, err := m.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, req := range reqs {
+		if req.ID == id {
+			return req, nil
+		}
+	}
+
+	return nil, model.ErrNotFound
+}
+
+func (m *WebhookRequestManager) Record(ctx context.Context, req *model.WebhookRequest) (*model.WebhookRequest, error) {
+	wr := &webhookRequest{
+		ID:            req.ID,
+		ReceivedAt:    req.ReceivedAt,
+		CompletedAt:   req.CompletedAt,
+		Method:        req.Method,
+		URL:           req.URL,
+		Header:        req.Header,
+		Body:          req.Body,
+		BodyTruncated: req.BodyTruncated,
+		StatusCode:    req.StatusCode,
+		Error:         req.Error,
+		ReplayOf:      req.ReplayOf,
+	}
+	if wr.ID == "" {
+		wr.ID = uuid.New().String()
+	}
+
+	var err error
+	if _, merr := MutateConfigMap(ctx, m.cm, func(cm *corev1.ConfigMap) {
+		var wrs []*webhookRequest
+		var wes []*webhookEvent
+
+		if err = decodeWebhookData(cm, webhookRequestsKey, &wrs); err != nil {
+			return
+		} else if err = decodeWebhookData(cm, webhookEventsKey, &wes); err != nil {
+			return
+		}
+
+		// Claim any events emitted while the request was being handled.
+		wr.Events = nil
+
+		var pending []*webhookEvent
+		for _, we := range wes {
+			if we.belongsTo(wr) {
+				wr.Events = append(wr.Events, we)
+			} else {
+				pending = append(pending, we)
+			}
+		}
+
+		wrs = append(wrs, wr)
+		if len(wrs) > m.limit {
+			wrs = wrs[len(wrs)-m.limit:]
+		}
+
+		if err = encodeWebhookData(cm, webhookRequestsKey, wrs); err != nil {
+			return
+		}
+
+		err = encodeWebhookData(cm, webhookEventsKey, pending)
+	}); merr != nil {
+		return nil, merr
+	} else if err != nil {
+		return nil, err
+	}
+
+	return wr.model(), nil
+}
+
+func (m *WebhookRequestManager) RecordEvent(ctx context.Context, ev *model.Event, requestID string, at time.Time) error {
+	var err error
+	if _, merr := MutateConfigMap(ctx, m.cm, func(cm *corev1.ConfigMap) {
+		var wes []*webhookEvent
+		if err = decodeWebhookData(cm, webhookEventsKey, &wes); err != nil {
+			return
+		}
+
+		wes = append(wes, newWebhookEvent(ev, requestID, at))
+		if len(wes) > maxPendingWebhookEvents {
+			wes = wes[len(wes)-maxPendingWebhookEvents:]
+		}
+
+		err = encodeWebhookData(cm, webhookEventsKey, wes)
+	}); merr != nil {
+		return merr
+	}
+
+	return err
+}
+
+// NewWebhookRequestManager creates a manager that keeps at most the given
+// number of requests in the config map. If the limit is not positive,
+// DefaultWebhookRequestLimit is used.
+func NewWebhookRequestManager(cm ConfigMap, limit int) *WebhookRequestManager {
+	if limit <= 0 {
+		limit = DefaultWebhookRequestLimit
+	}
+
+	return &WebhookRequestManager{
+		cm:    cm,
+		limit: limit,
+	}
+}
+
+func decodeWebhookData(cm *corev1.ConfigMap, key string, into interface{}) error {
+	encoded, found := cm.Data[key]
+	if !found || encoded == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(encoded), into)
+}
+
+func encodeWebhookData(cm *corev1.ConfigMap, key string, value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+
+	cm.Data[key] = string(b)
+	return nil
+}
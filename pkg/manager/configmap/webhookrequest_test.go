@@ -0,0 +1,107 @@
+package configmap_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestWebhookRequestManager(t *testing.T) {
+	ctx := context.Background()
+
+	obj := &corev1.ConfigMap{}
+	wrm := configmap.NewWebhookRequestManager(configmap.NewLocalConfigMap(obj), 2)
+
+	now := time.Now()
+
+	// An event emitted before the request was received is not attached to it.
+	require.NoError(t, wrm.RecordEvent(ctx, &model.Event{Data: map[string]interface{}{"n": "early"}}, "", now.Add(-time.Minute)))
+	require.NoError(t, wrm.RecordEvent(ctx, &model.Event{Data: map[string]interface{}{"n": "during"}, Key: "k"}, "", now.Add(time.Second)))
+
+	// An event for a different request is not attached even though it was
+	// emitted during this one.
+	require.NoError(t, wrm.RecordEvent(ctx, &model.Event{Data: map[string]interface{}{"n": "other"}}, "other", now.Add(time.Second)))
+
+	req, err := wrm.Record(ctx, &model.WebhookRequest{
+		ReceivedAt:  now,
+		CompletedAt: now.Add(2 * time.Second),
+		Method:      http.MethodPost,
+		URL:         "/hook?x=y",
+		Header:      http.Header{"Content-Type": []string{"application/json"}},
+		Body:        []byte(`{"hello":"world"}`),
+		StatusCode:  http.StatusAccepted,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, req.ID)
+	require.Len(t, req.Events, 1)
+	assert.Equal(t, &model.Event{Data: map[string]interface{}{"n": "during"}, Key: "k"}, req.Events[0])
+
+	got, err := wrm.Get(ctx, req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "/hook?x=y", got.URL)
+	assert.Equal(t, []byte(`{"hello":"world"}`), got.Body)
+	assert.Equal(t, http.StatusAccepted, got.StatusCode)
+	assert.True(t, now.Equal(got.ReceivedAt))
+
+	// Only the most recent requests are kept.
+	for i := 0; i < 2; i++ {
+		_, err := wrm.Record(ctx, &model.WebhookRequest{
+			ReceivedAt:  now.Add(time.Hour),
+			CompletedAt: now.Add(time.Hour),
+			Method:      http.MethodPost,
+			URL:         "/",
+		})
+		require.NoError(t, err)
+	}
+
+	reqs, err := wrm.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, reqs, 2)
+
+	_, err = wrm.Get(ctx, req.ID)
+	assert.Equal(t, model.ErrNotFound, err)
+}
+
+func TestWebhookRequestManagerCorrelatesByRequestID(t *testing.T) {
+	ctx := context.Background()
+
+	obj := &corev1.ConfigMap{}
+	wrm := configmap.NewWebhookRequestManager(configmap.NewLocalConfigMap(obj), 10)
+
+	now := time.Now()
+
+	// Both requests overlap, so only the request ID can tell the events
+	// apart.
+	require.NoError(t, wrm.RecordEvent(ctx, &model.Event{Data: map[string]interface{}{"n": "b"}}, "b", now.Add(time.Second)))
+	require.NoError(t, wrm.RecordEvent(ctx, &model.Event{Data: map[string]interface{}{"n": "a"}}, "a", now.Add(time.Second)))
+
+	a, err := wrm.Record(ctx, &model.WebhookRequest{
+		ID:          "a",
+		ReceivedAt:  now,
+		CompletedAt: now.Add(2 * time.Second),
+		Method:      http.MethodPost,
+		URL:         "/",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "a", a.ID)
+	require.Len(t, a.Events, 1)
+	assert.Equal(t, map[string]interface{}{"n": "a"}, a.Events[0].Data)
+
+	b, err := wrm.Record(ctx, &model.WebhookRequest{
+		ID:          "b",
+		ReceivedAt:  now,
+		CompletedAt: now.Add(2 * time.Second),
+		Method:      http.MethodPost,
+		URL:         "/",
+	})
+	require.NoError(t, err)
+	require.Len(t, b.Events, 1)
+	assert.Equal(t, map[string]interface{}{"n": "b"}, b.Events[0].Data)
+}
@@ -0,0 +1,68 @@
+package configmap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/puppetlabs/relay-core/pkg/expr/parse"
+	"github.com/puppetlabs/relay-core/pkg/model"
+)
+
+type WorkflowRunTemplateManager struct {
+	key string
+	kcm *KVConfigMap
+}
+
+var _ model.WorkflowRunTemplateManager = &WorkflowRunTemplateManager{}
+
+func (m *WorkflowRunTemplateManager) Get(ctx context.Context) (*model.WorkflowRunTemplate, error) {
+	value, err := m.kcm.Get(ctx, m.key)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+
+	tmpl := &model.WorkflowRunTemplate{
+		Parameters: parse.Tree(obj["parameters"]),
+	}
+	tmpl.WorkflowRun, _ = obj["workflowRun"].(map[string]interface{})
+
+	return tmpl, nil
+}
+
+func (m *WorkflowRunTemplateManager) Set(ctx context.Context, tmpl *model.WorkflowRunTemplate) (*model.WorkflowRunTemplate, error) {
+	value := map[string]interface{}{
+		"workflowRun": tmpl.WorkflowRun,
+		"parameters":  tmpl.Parameters,
+	}
+
+	if err := m.kcm.Set(ctx, m.key, value); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+func NewWorkflowRunTemplateManager(action model.Action, cm ConfigMap) *WorkflowRunTemplateManager {
+	return &WorkflowRunTemplateManager{
+		key: workflowRunTemplateKey(action),
+		kcm: NewKVConfigMap(cm),
+	}
+}
+
+// NewWorkflowRunTemplateManagerForSink creates a manager for the template used
+// by the named event sink of the tenant of the given action.
+func NewWorkflowRunTemplateManagerForSink(action model.Action, sink string, cm ConfigMap) *WorkflowRunTemplateManager {
+	return &WorkflowRunTemplateManager{
+		key: fmt.Sprintf("%s.%s", workflowRunTemplateKey(action), sink),
+		kcm: NewKVConfigMap(cm),
+	}
+}
+
+func workflowRunTemplateKey(action model.Action) string {
+	return fmt.Sprintf("%s.%s.workflow-run-template", action.Type().Plural, action.Hash())
+}
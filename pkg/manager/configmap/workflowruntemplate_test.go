@@ -0,0 +1,73 @@
+package configmap_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestWorkflowRunTemplateManager(t *testing.T) {
+	ctx := context.Background()
+	trigger := &model.Trigger{Name: "foo"}
+
+	wrtm := configmap.NewWorkflowRunTemplateManager(trigger, configmap.NewLocalConfigMap(&corev1.ConfigMap{}))
+
+	_, err := wrtm.Get(ctx)
+	require.Equal(t, model.ErrNotFound, err)
+
+	_, err = wrtm.Set(ctx, &model.WorkflowRunTemplate{
+		WorkflowRun: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"workflow": map[string]interface{}{"name": "bar"},
+			},
+		},
+		Parameters: map[string]interface{}{
+			"repo": map[string]interface{}{"$type": "Data", "query": "repository.name"},
+		},
+	})
+	require.NoError(t, err)
+
+	tmpl, err := wrtm.Get(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"workflow": map[string]interface{}{"name": "bar"},
+		},
+	}, tmpl.WorkflowRun)
+	require.Equal(t, map[string]interface{}{
+		"repo": map[string]interface{}{"$type": "Data", "query": "repository.name"},
+	}, tmpl.Parameters)
+}
+
+func TestWorkflowRunTemplateManagerForSink(t *testing.T) {
+	ctx := context.Background()
+	trigger := &model.Trigger{Name: "foo"}
+	cm := configmap.NewLocalConfigMap(&corev1.ConfigMap{})
+
+	_, err := configmap.NewWorkflowRunTemplateManager(trigger, cm).Set(ctx, &model.WorkflowRunTemplate{
+		WorkflowRun: map[string]interface{}{"metadata": map[string]interface{}{"name": "default"}},
+	})
+	require.NoError(t, err)
+
+	wrtm := configmap.NewWorkflowRunTemplateManagerForSink(trigger, "audit", cm)
+
+	_, err = wrtm.Get(ctx)
+	require.Equal(t, model.ErrNotFound, err)
+
+	_, err = wrtm.Set(ctx, &model.WorkflowRunTemplate{
+		WorkflowRun: map[string]interface{}{"metadata": map[string]interface{}{"name": "audit"}},
+	})
+	require.NoError(t, err)
+
+	tmpl, err := wrtm.Get(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"metadata": map[string]interface{}{"name": "audit"}}, tmpl.WorkflowRun)
+
+	tmpl, err = configmap.NewWorkflowRunTemplateManager(trigger, cm).Get(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"metadata": map[string]interface{}{"name": "default"}}, tmpl.WorkflowRun)
+}
@@ -0,0 +1,153 @@
+package direct
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	nebulav1 "github.com/puppetlabs/relay-core/pkg/apis/nebula.puppet.com/v1"
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/expr/evaluate"
+	"github.com/puppetlabs/relay-core/pkg/expr/resolve"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+)
+
+var workflowRunKind = nebulav1.SchemeGroupVersion.WithKind("WorkflowRun")
+
+// EventManager creates a workflow run in the tenant namespace for each event
+// emitted by a trigger or step.
+type EventManager struct {
+	me        model.Action
+	templates model.WorkflowRunTemplateGetterManager
+	client    rest.Interface
+	namespace string
+	sink      string
+}
+
+var _ model.EventManager = &EventManager{}
+
+func (m *EventManager) Emit(ctx context.Context, data map[string]interface{}, key string) (*model.Event, error) {
+	switch m.me.(type) {
+	case *model.Trigger, *model.Step:
+	default:
+		return nil, model.ErrRejected
+	}
+
+	tmpl, err := m.templates.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wr, err := workflowRunFromTemplate(tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	if tmpl.Parameters != nil {
+		ev := evaluate.NewEvaluator(
+			evaluate.WithDataTypeResolver(resolve.NewMemoryDataTypeResolver(data)),
+		)
+
+		r, err := ev.EvaluateAll(ctx, tmpl.Parameters)
+		if err != nil {
+			return nil, err
+		} else if !r.Complete() {
+			return nil, r.Unresolvable.AsError()
+		}
+
+		params, ok := r.Value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("workflow run parameters must be an object, got %T", r.Value)
+		}
+
+		wr.Spec.Parameters = relayv1beta1.NewUnstructuredObject(params)
+	}
+
+	name := workflowRunName(m.me, m.sink, key)
+
+	wr.SetGroupVersionKind(workflowRunKind)
+	wr.SetName(name)
+	wr.SetNamespace(m.namespace)
+	wr.Spec.Name = name
+
+	b, err := json.Marshal(wr)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.client.Post().
+		Context(ctx).
+		AbsPath("/apis", nebulav1.SchemeGroupVersion.Group, nebulav1.SchemeGroupVersion.Version, "namespaces", m.namespace, "workflowruns").
+		SetHeader("Content-Type", "application/json").
+		Body(b).
+		Do().
+		Error()
+	if errors.IsAlreadyExists(err) && key != "" {
+		// The event is a duplicate of one we've already handled.
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &model.Event{
+		Data: data,
+		Key:  key,
+	}, nil
+}
+
+type EventManagerOption func(em *EventManager)
+
+// EventManagerWithSinkName distinguishes the workflow runs created by this
+// manager from those created for the same event by other named sinks.
+func EventManagerWithSinkName(name string) EventManagerOption {
+	return func(em *EventManager) {
+		em.sink = name
+	}
+}
+
+func NewEventManager(action model.Action, templates model.WorkflowRunTemplateGetterManager, client rest.Interface, namespace string, opts ...EventManagerOption) *EventManager {
+	em := &EventManager{
+		me:        action,
+		templates: templates,
+		client:    client,
+		namespace: namespace,
+	}
+
+	for _, opt := range opts {
+		opt(em)
+	}
+
+	return em
+}
+
+func workflowRunFromTemplate(tmpl *model.WorkflowRunTemplate) (*nebulav1.WorkflowRun, error) {
+	b, err := json.Marshal(tmpl.WorkflowRun)
+	if err != nil {
+		return nil, err
+	}
+
+	wr := &nebulav1.WorkflowRun{}
+	if err := json.Unmarshal(b, wr); err != nil {
+		return nil, err
+	}
+
+	return wr, nil
+}
+
+// workflowRunName returns a name for the workflow run created for an event.
+// Events with the same key always map to the same workflow run so that
+// retried deliveries do not start the workflow again.
+func workflowRunName(action model.Action, sink, key string) string {
+	if key == "" {
+		return fmt.Sprintf("run-%s", uuid.New())
+	}
+
+	name := fmt.Sprintf("relay.sh/%s/%s/events/%s", action.Type().Plural, action.Hash(), key)
+	if sink != "" {
+		name = fmt.Sprintf("relay.sh/%s/%s/sinks/%s/events/%s", action.Type().Plural, action.Hash(), sink, key)
+	}
+
+	return fmt.Sprintf("run-%s", uuid.NewSHA1(uuid.NameSpaceURL, []byte(name)))
+}
@@ -0,0 +1,113 @@
+package direct_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	nebulav1 "github.com/puppetlabs/relay-core/pkg/apis/nebula.puppet.com/v1"
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/puppetlabs/relay-core/pkg/manager/direct"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestEventManager(t *testing.T) {
+	ctx := context.Background()
+	trigger := &model.Trigger{Name: "my-trigger"}
+
+	created := make(map[string]*nebulav1.WorkflowRun)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/apis/nebula.puppet.com/v1/namespaces/my-tenant/workflowruns", r.URL.Path)
+
+		wr := &nebulav1.WorkflowRun{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(wr))
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if _, found := created[wr.GetName()]; found {
+			w.WriteHeader(http.StatusConflict)
+			require.NoError(t, json.NewEncoder(w).Encode(&metav1.Status{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"},
+				Status:   metav1.StatusFailure,
+				Reason:   metav1.StatusReasonAlreadyExists,
+				Code:     http.StatusConflict,
+			}))
+			return
+		}
+
+		created[wr.GetName()] = wr
+
+		w.WriteHeader(http.StatusCreated)
+		require.NoError(t, json.NewEncoder(w).Encode(wr))
+	}))
+	defer srv.Close()
+
+	kc, err := kubernetes.NewForConfig(&rest.Config{Host: srv.URL})
+	require.NoError(t, err)
+
+	wrtm := configmap.NewWorkflowRunTemplateManager(trigger, configmap.NewLocalConfigMap(&corev1.ConfigMap{}))
+	_, err = wrtm.Set(ctx, &model.WorkflowRunTemplate{
+		WorkflowRun: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{
+					model.RelayControllerWebhookTriggerIDLabel: "my-trigger",
+				},
+			},
+			"spec": map[string]interface{}{
+				"workflow": map[string]interface{}{
+					"name": "my-workflow",
+					"steps": []interface{}{
+						map[string]interface{}{"name": "my-step", "image": "alpine:latest"},
+					},
+				},
+			},
+		},
+		Parameters: map[string]interface{}{
+			"repo": map[string]interface{}{"$type": "Data", "query": "repository.name"},
+		},
+	})
+	require.NoError(t, err)
+
+	em := direct.NewEventManager(trigger, wrtm, kc.Discovery().RESTClient(), "my-tenant")
+
+	data := map[string]interface{}{
+		"repository": map[string]interface{}{"name": "relay-core"},
+	}
+
+	ev, err := em.Emit(ctx, data, "my-key")
+	require.NoError(t, err)
+	require.Equal(t, "my-key", ev.Key)
+	require.Len(t, created, 1)
+
+	for name, wr := range created {
+		require.Equal(t, name, wr.Spec.Name)
+		require.Equal(t, "my-tenant", wr.GetNamespace())
+		require.Equal(t, "my-trigger", wr.GetLabels()[model.RelayControllerWebhookTriggerIDLabel])
+		require.Equal(t, "my-workflow", wr.Spec.Workflow.Name)
+		require.Equal(t, map[string]interface{}{"repo": "relay-core"}, wr.Spec.Parameters.Value())
+	}
+
+	// Delivering the same event again does not create another run.
+	_, err = em.Emit(ctx, data, "my-key")
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+
+	// Events without a key always create a run.
+	_, err = em.Emit(ctx, data, "")
+	require.NoError(t, err)
+	require.Len(t, created, 2)
+
+	// Parameters that can't be mapped from the event fail the event.
+	_, err = em.Emit(ctx, map[string]interface{}{}, "")
+	require.Error(t, err)
+	require.Len(t, created, 2)
+}
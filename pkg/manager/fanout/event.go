@@ -0,0 +1,182 @@
+package fanout
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/expr/evaluate"
+	"github.com/puppetlabs/relay-core/pkg/expr/parse"
+	"github.com/puppetlabs/relay-core/pkg/expr/resolve"
+	"github.com/puppetlabs/relay-core/pkg/model"
+)
+
+// EventSink is a destination for events that only receives the events
+// matching its condition.
+type EventSink struct {
+	// Name identifies the sink in delivery statistics. Deliveries to a sink
+	// without a name are not recorded individually.
+	Name string
+
+	// When is a condition evaluated against the event data. If nil, the sink
+	// receives every event.
+	When parse.Tree
+
+	Manager model.EventManager
+}
+
+func (es *EventSink) matches(ctx context.Context, data map[string]interface{}) (bool, error) {
+	if es.When == nil {
+		return true, nil
+	}
+
+	ev := evaluate.NewEvaluator(
+		evaluate.WithDataTypeResolver(resolve.NewMemoryDataTypeResolver(data)),
+	)
+
+	r, err := ev.EvaluateAll(ctx, es.When)
+	if err != nil {
+		return false, err
+	}
+
+	// Conditions that refer to data the event does not have cannot match.
+	if !r.Complete() {
+		return false, nil
+	}
+
+	switch vt := r.Value.(type) {
+	case bool:
+		return vt, nil
+	case []interface{}:
+		for _, cond := range vt {
+			result, ok := cond.(bool)
+			if !ok {
+				return false, fmt.Errorf("condition must be a boolean, got %T", cond)
+			}
+
+			if !result {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	default:
+		return false, fmt.Errorf("condition must be a boolean or a list of booleans, got %T", vt)
+	}
+}
+
+// EventError describes the failures of the sinks an event could not be
+// delivered to.
+type EventError struct {
+	Causes map[string]error
+
+	// Partial is true if the event was delivered to at least one other sink.
+	Partial bool
+}
+
+// Sinks returns the names of the sinks the event could not be delivered to,
+// sorted by name. An unnamed sink is represented by the empty string.
+func (e *EventError) Sinks() []string {
+	names := make([]string, 0, len(e.Causes))
+	for name := range e.Causes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (e *EventError) Error() string {
+	names := e.Sinks()
+
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		if name == "" {
+			msgs[i] = e.Causes[name].Error()
+		} else {
+			msgs[i] = fmt.Sprintf("sink %q: %s", name, e.Causes[name].Error())
+		}
+	}
+
+	if e.Partial {
+		return fmt.Sprintf("event could not be delivered to every sink: %s", strings.Join(msgs, "; "))
+	}
+
+	return fmt.Sprintf("event could not be delivered to any sink: %s", strings.Join(msgs, "; "))
+}
+
+// EventManager delivers each event to all of the sinks that match it.
+type EventManager struct {
+	sinks    []*EventSink
+	recorder model.WebhookDeliveryRecorderManager
+}
+
+var _ model.EventManager = &EventManager{}
+
+// Emit delivers the event to every matching sink. An event that matches no
+// sink is discarded.
+//
+// If any matching sink fails, Emit returns an *EventError. When only some of
+// the sinks failed, the event is returned along with the error so that the
+// caller can tell that it was partially delivered.
+func (m *EventManager) Emit(ctx context.Context, data map[string]interface{}, key string) (*model.Event, error) {
+	var delivered bool
+	causes := make(map[string]error)
+
+	for _, sink := range m.sinks {
+		ok, err := sink.matches(ctx, data)
+		if err == nil && !ok {
+			continue
+		} else if err == nil {
+			_, err = sink.Manager.Emit(ctx, data, key)
+		}
+
+		m.record(ctx, sink, err)
+
+		if err != nil {
+			causes[sink.Name] = err
+		} else {
+			delivered = true
+		}
+	}
+
+	if !delivered && len(causes) == 1 {
+		for _, cause := range causes {
+			return nil, cause
+		}
+	}
+
+	if !delivered && len(causes) > 0 {
+		return nil, &EventError{Causes: causes}
+	}
+
+	ev := &model.Event{
+		Data: data,
+		Key:  key,
+	}
+
+	if len(causes) > 0 {
+		return ev, &EventError{Causes: causes, Partial: true}
+	}
+
+	return ev, nil
+}
+
+func (m *EventManager) record(ctx context.Context, sink *EventSink, failure error) {
+	if sink.Name == "" {
+		return
+	}
+
+	if err := m.recorder.RecordSinkEvent(ctx, sink.Name, time.Now(), failure); err != nil && err != model.ErrRejected {
+		log(ctx).Warn("failed to record event delivery for sink", "sink", sink.Name, "error", err)
+	}
+}
+
+func NewEventManager(sinks []*EventSink, recorder model.WebhookDeliveryRecorderManager) *EventManager {
+	return &EventManager{
+		sinks:    sinks,
+		recorder: recorder,
+	}
+}
@@ -0,0 +1,127 @@
+package fanout_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/puppetlabs/relay-core/pkg/manager/fanout"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type recordingEventManager struct {
+	events []*model.Event
+	err    error
+}
+
+func (m *recordingEventManager) Emit(ctx context.Context, data map[string]interface{}, key string) (*model.Event, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	ev := &model.Event{Data: data, Key: key}
+	m.events = append(m.events, ev)
+	return ev, nil
+}
+
+func TestEventManager(t *testing.T) {
+	ctx := context.Background()
+
+	deliveries := configmap.NewWebhookDeliveryManager(configmap.NewLocalConfigMap(&corev1.ConfigMap{}))
+
+	relay := &recordingEventManager{}
+	audit := &recordingEventManager{}
+	pushes := &recordingEventManager{}
+
+	em := fanout.NewEventManager([]*fanout.EventSink{
+		{Manager: relay},
+		{Name: "audit", Manager: audit},
+		{
+			Name: "pushes",
+			When: map[string]interface{}{
+				"$fn.equals": []interface{}{
+					map[string]interface{}{"$type": "Data", "query": "kind"},
+					"push",
+				},
+			},
+			Manager: pushes,
+		},
+	}, deliveries)
+
+	_, err := em.Emit(ctx, map[string]interface{}{"kind": "push"}, "a")
+	require.NoError(t, err)
+
+	_, err = em.Emit(ctx, map[string]interface{}{"kind": "pull_request"}, "b")
+	require.NoError(t, err)
+
+	// Events that do not have the data the condition refers to do not match.
+	_, err = em.Emit(ctx, map[string]interface{}{}, "c")
+	require.NoError(t, err)
+
+	assert.Len(t, relay.events, 3)
+	assert.Len(t, audit.events, 3)
+	require.Len(t, pushes.events, 1)
+	assert.Equal(t, "a", pushes.events[0].Key)
+
+	wd, err := deliveries.Get(ctx)
+	require.NoError(t, err)
+	require.Len(t, wd.Sinks, 2)
+	assert.Equal(t, "audit", wd.Sinks[0].Name)
+	assert.Equal(t, int64(3), wd.Sinks[0].EventsDelivered)
+	assert.Equal(t, "pushes", wd.Sinks[1].Name)
+	assert.Equal(t, int64(1), wd.Sinks[1].EventsDelivered)
+}
+
+func TestEventManagerFailures(t *testing.T) {
+	ctx := context.Background()
+
+	deliveries := configmap.NewWebhookDeliveryManager(configmap.NewLocalConfigMap(&corev1.ConfigMap{}))
+
+	relay := &recordingEventManager{}
+	audit := &recordingEventManager{err: errors.New("collector unavailable")}
+
+	em := fanout.NewEventManager([]*fanout.EventSink{
+		{Name: "relay", Manager: relay},
+		{Name: "audit", Manager: audit},
+	}, deliveries)
+
+	// If only some sinks accept the event, it is returned along with an
+	// error describing the sinks that failed.
+	ev, err := em.Emit(ctx, map[string]interface{}{}, "")
+	require.NotNil(t, ev)
+	require.IsType(t, &fanout.EventError{}, err)
+	assert.True(t, err.(*fanout.EventError).Partial)
+	assert.Equal(t, []string{"audit"}, err.(*fanout.EventError).Sinks())
+	assert.Equal(t, `event could not be delivered to every sink: sink "audit": collector unavailable`, err.Error())
+
+	wd, err := deliveries.Get(ctx)
+	require.NoError(t, err)
+	require.Len(t, wd.Sinks, 2)
+	assert.Equal(t, int64(1), wd.Sinks[0].Failed)
+	assert.Equal(t, "collector unavailable", wd.Sinks[0].LastError)
+	assert.Equal(t, int64(1), wd.Sinks[1].EventsDelivered)
+
+	relay.err = errors.New("API unavailable")
+
+	ev, err = em.Emit(ctx, map[string]interface{}{}, "")
+	require.Nil(t, ev)
+	require.IsType(t, &fanout.EventError{}, err)
+	assert.False(t, err.(*fanout.EventError).Partial)
+	assert.Contains(t, err.Error(), `sink "audit": collector unavailable`)
+	assert.Contains(t, err.Error(), `sink "relay": API unavailable`)
+}
+
+func TestEventManagerInvalidCondition(t *testing.T) {
+	ctx := context.Background()
+
+	em := fanout.NewEventManager([]*fanout.EventSink{
+		{Name: "audit", When: "yes", Manager: &recordingEventManager{}},
+	}, configmap.NewWebhookDeliveryManager(configmap.NewLocalConfigMap(&corev1.ConfigMap{})))
+
+	_, err := em.Emit(ctx, map[string]interface{}{}, "")
+	require.Error(t, err)
+}
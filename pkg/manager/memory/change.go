@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/puppetlabs/relay-core/pkg/model"
+)
+
+// ChangeManager notifies watchers when the in-memory managers configured to
+// use it are modified.
+type ChangeManager struct {
+	mut      sync.Mutex
+	watchers map[chan struct{}]struct{}
+}
+
+var _ model.ChangeManager = &ChangeManager{}
+
+func (m *ChangeManager) Watch(ctx context.Context) (<-chan struct{}, error) {
+	ch := make(chan struct{}, 1)
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	m.watchers[ch] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+
+		m.mut.Lock()
+		defer m.mut.Unlock()
+
+		delete(m.watchers, ch)
+		close(ch)
+	}()
+
+	return ch, nil
+}
+
+// Notify sends a change notification to every current watcher.
+func (m *ChangeManager) Notify() {
+	if m == nil {
+		return
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	for ch := range m.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func NewChangeManager() *ChangeManager {
+	return &ChangeManager{
+		watchers: make(map[chan struct{}]struct{}),
+	}
+}
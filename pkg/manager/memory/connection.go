@@ -2,6 +2,7 @@ package memory
 
 import (
 	"context"
+	"sort"
 
 	"github.com/puppetlabs/relay-core/pkg/model"
 )
@@ -29,6 +30,18 @@ func (cm *ConnectionManager) Get(ctx context.Context, typ, name string) (*model.
 	}, nil
 }
 
+func (cm *ConnectionManager) List(ctx context.Context, typ string) ([]string, error) {
+	var names []string
+	for key := range cm.connections {
+		if key.Type == typ {
+			names = append(names, key.Name)
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
 func NewConnectionManager(m map[ConnectionKey]map[string]interface{}) *ConnectionManager {
 	return &ConnectionManager{
 		connections: m,
@@ -2,6 +2,7 @@ package memory
 
 import (
 	"context"
+	"sort"
 
 	"github.com/puppetlabs/relay-core/pkg/model"
 )
@@ -24,6 +25,16 @@ func (m *SecretManager) Get(ctx context.Context, name string) (*model.Secret, er
 	}, nil
 }
 
+func (m *SecretManager) List(ctx context.Context) ([]string, error) {
+	names := make([]string, 0, len(m.secrets))
+	for name := range m.secrets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
 func NewSecretManager(secrets map[string]string) *SecretManager {
 	m := make(map[string]string, len(secrets))
 	for k, v := range secrets {
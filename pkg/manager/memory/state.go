@@ -8,8 +8,9 @@ import (
 )
 
 type StateManager struct {
-	mut   sync.RWMutex
-	state map[string]interface{}
+	mut     sync.RWMutex
+	state   map[string]interface{}
+	changes *ChangeManager
 }
 
 var _ model.StateManager = &StateManager{}
@@ -34,6 +35,7 @@ func (m *StateManager) Set(ctx context.Context, name string, value interface{})
 	defer m.mut.Unlock()
 
 	m.state[name] = value
+	m.changes.Notify()
 
 	return &model.State{
 		Name:  name,
@@ -41,6 +43,16 @@ func (m *StateManager) Set(ctx context.Context, name string, value interface{})
 	}, nil
 }
 
+func (m *StateManager) Delete(ctx context.Context, name string) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	delete(m.state, name)
+	m.changes.Notify()
+
+	return nil
+}
+
 type StateManagerOption func(sm *StateManager)
 
 func StateManagerWithInitialState(state map[string]interface{}) StateManagerOption {
@@ -51,6 +63,14 @@ func StateManagerWithInitialState(state map[string]interface{}) StateManagerOpti
 	}
 }
 
+// StateManagerWithChangeManager notifies the given change manager whenever the
+// state is modified.
+func StateManagerWithChangeManager(cm *ChangeManager) StateManagerOption {
+	return func(sm *StateManager) {
+		sm.changes = cm
+	}
+}
+
 func NewStateManager(opts ...StateManagerOption) *StateManager {
 	sm := &StateManager{
 		state: make(map[string]interface{}),
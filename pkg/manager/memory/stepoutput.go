@@ -2,6 +2,7 @@ package memory
 
 import (
 	"context"
+	"sort"
 	"sync"
 
 	"github.com/puppetlabs/relay-core/pkg/model"
@@ -9,10 +10,12 @@ import (
 
 type StepOutputMap struct {
 	mut     sync.RWMutex
-	outputs map[model.Hash]map[string]interface{}
+	steps   map[model.Hash]*model.Step
+	outputs map[model.Hash]map[string]*model.StepOutput
+	changes *ChangeManager
 }
 
-func (m *StepOutputMap) Get(step *model.Step, name string) (interface{}, bool) {
+func (m *StepOutputMap) Get(step *model.Step, name string) (*model.StepOutput, bool) {
 	m.mut.RLock()
 	defer m.mut.RUnlock()
 
@@ -21,11 +24,60 @@ func (m *StepOutputMap) Get(step *model.Step, name string) (interface{}, bool) {
 		return nil, false
 	}
 
-	value, found := outputs[name]
-	return value, found
+	output, found := outputs[name]
+	if !found {
+		return nil, false
+	}
+
+	return &model.StepOutput{
+		Step:      step,
+		Name:      name,
+		Value:     output.Value,
+		Sensitive: output.Sensitive,
+	}, true
+}
+
+// List returns the outputs of every step in the given run.
+func (m *StepOutputMap) List(run model.Run) []*model.StepOutput {
+	m.mut.RLock()
+	defer m.mut.RUnlock()
+
+	var l []*model.StepOutput
+	for h, step := range m.steps {
+		if step.Run != run {
+			continue
+		}
+
+		for name, output := range m.outputs[h] {
+			l = append(l, &model.StepOutput{
+				Step:      step,
+				Name:      name,
+				Value:     output.Value,
+				Sensitive: output.Sensitive,
+			})
+		}
+	}
+
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Step.Name != l[j].Step.Name {
+			return l[i].Step.Name < l[j].Step.Name
+		}
+
+		return l[i].Name < l[j].Name
+	})
+
+	return l
 }
 
 func (m *StepOutputMap) Set(step *model.Step, name string, value interface{}) {
+	m.set(step, name, value, false)
+}
+
+func (m *StepOutputMap) SetSensitive(step *model.Step, name string, value interface{}) {
+	m.set(step, name, value, true)
+}
+
+func (m *StepOutputMap) set(step *model.Step, name string, value interface{}, sensitive bool) {
 	m.mut.Lock()
 	defer m.mut.Unlock()
 
@@ -33,19 +85,43 @@ func (m *StepOutputMap) Set(step *model.Step, name string, value interface{}) {
 
 	outputs, found := m.outputs[h]
 	if !found {
-		outputs = make(map[string]interface{})
+		outputs = make(map[string]*model.StepOutput)
 		m.outputs[h] = outputs
+		m.steps[h] = step
 	}
 
-	outputs[name] = value
+	outputs[name] = &model.StepOutput{
+		Step:      step,
+		Name:      name,
+		Value:     value,
+		Sensitive: sensitive,
+	}
+	m.changes.Notify()
 }
 
-func NewStepOutputMap() *StepOutputMap {
-	return &StepOutputMap{
-		outputs: make(map[model.Hash]map[string]interface{}),
+type StepOutputMapOption func(m *StepOutputMap)
+
+// StepOutputMapWithChangeManager notifies the given change manager whenever an
+// output is set.
+func StepOutputMapWithChangeManager(cm *ChangeManager) StepOutputMapOption {
+	return func(m *StepOutputMap) {
+		m.changes = cm
 	}
 }
 
+func NewStepOutputMap(opts ...StepOutputMapOption) *StepOutputMap {
+	m := &StepOutputMap{
+		steps:   make(map[model.Hash]*model.Step),
+		outputs: make(map[model.Hash]map[string]*model.StepOutput),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
 type StepOutputManager struct {
 	me *model.Step
 	m  *StepOutputMap
@@ -59,16 +135,27 @@ func (m *StepOutputManager) Get(ctx context.Context, stepName, name string) (*mo
 		Name: stepName,
 	}
 
-	value, found := m.m.Get(step, name)
+	output, found := m.m.Get(step, name)
 	if !found {
 		return nil, model.ErrNotFound
 	}
 
-	return &model.StepOutput{
-		Step:  step,
-		Name:  name,
-		Value: value,
-	}, nil
+	return output, nil
+}
+
+func (m *StepOutputManager) List(ctx context.Context) ([]*model.StepOutput, error) {
+	return m.m.List(m.me.Run), nil
+}
+
+func (m *StepOutputManager) ListStep(ctx context.Context, stepName string) ([]*model.StepOutput, error) {
+	var l []*model.StepOutput
+	for _, output := range m.m.List(m.me.Run) {
+		if output.Step.Name == stepName {
+			l = append(l, output)
+		}
+	}
+
+	return l, nil
 }
 
 func (m *StepOutputManager) Set(ctx context.Context, name string, value interface{}) (*model.StepOutput, error) {
@@ -81,6 +168,17 @@ func (m *StepOutputManager) Set(ctx context.Context, name string, value interfac
 	}, nil
 }
 
+func (m *StepOutputManager) SetSensitive(ctx context.Context, name string, value interface{}) (*model.StepOutput, error) {
+	m.m.SetSensitive(m.me, name, value)
+
+	return &model.StepOutput{
+		Step:      m.me,
+		Name:      name,
+		Value:     value,
+		Sensitive: true,
+	}, nil
+}
+
 func NewStepOutputManager(step *model.Step, backend *StepOutputMap) *StepOutputManager {
 	return &StepOutputManager{
 		me: step,
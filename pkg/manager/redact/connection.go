@@ -0,0 +1,46 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/puppetlabs/relay-core/pkg/util/maskutil"
+)
+
+// ConnectionManager records every string in the attributes of each connection
+// retrieved from its delegate.
+type ConnectionManager struct {
+	delegate model.ConnectionManager
+	recorder model.RedactionRecorderManager
+}
+
+var _ model.ConnectionManager = &ConnectionManager{}
+
+func (m *ConnectionManager) Get(ctx context.Context, typ, name string) (*model.Connection, error) {
+	conn, err := m.delegate.Get(ctx, typ, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Numbers and booleans, like ports, are rarely confidential and would make
+	// logs hard to read if masked, so only strings are recorded.
+	values := maskutil.Strings(conn.Attributes)
+
+	if err := m.recorder.Record(ctx, fmt.Sprintf("connections/%s/%s", typ, name), values); err != nil {
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (m *ConnectionManager) List(ctx context.Context, typ string) ([]string, error) {
+	return m.delegate.List(ctx, typ)
+}
+
+func NewConnectionManager(delegate model.ConnectionManager, recorder model.RedactionRecorderManager) *ConnectionManager {
+	return &ConnectionManager{
+		delegate: delegate,
+		recorder: recorder,
+	}
+}
@@ -0,0 +1,39 @@
+// Package redact provides managers that record the confidential values an
+// action retrieves, so that they can be removed from its logs.
+package redact
+
+import (
+	"github.com/puppetlabs/relay-core/pkg/model"
+)
+
+type metadataManagers struct {
+	model.MetadataManagers
+
+	connections model.ConnectionManager
+	secrets     model.SecretManager
+	stepOutputs model.StepOutputManager
+}
+
+func (mm *metadataManagers) Connections() model.ConnectionManager {
+	return mm.connections
+}
+
+func (mm *metadataManagers) Secrets() model.SecretManager {
+	return mm.secrets
+}
+
+func (mm *metadataManagers) StepOutputs() model.StepOutputManager {
+	return mm.stepOutputs
+}
+
+// NewMetadataManagers records the values of the connections, secrets, and
+// sensitive outputs retrieved through the given managers.
+func NewMetadataManagers(delegate model.MetadataManagers, recorder model.RedactionRecorderManager) model.MetadataManagers {
+	return &metadataManagers{
+		MetadataManagers: delegate,
+
+		connections: NewConnectionManager(delegate.Connections(), recorder),
+		secrets:     NewSecretManager(delegate.Secrets(), recorder),
+		stepOutputs: NewStepOutputManager(delegate.StepOutputs(), recorder),
+	}
+}
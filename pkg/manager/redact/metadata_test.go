@@ -0,0 +1,103 @@
+package redact_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/puppetlabs/relay-core/pkg/manager/builder"
+	"github.com/puppetlabs/relay-core/pkg/manager/memory"
+	"github.com/puppetlabs/relay-core/pkg/manager/redact"
+	"github.com/puppetlabs/relay-core/pkg/manager/reject"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+type testRecorder map[string][]string
+
+func (tr testRecorder) Record(ctx context.Context, source string, values []string) error {
+	tr[source] = values
+	return nil
+}
+
+func TestMetadataManagers(t *testing.T) {
+	ctx := context.Background()
+
+	step := &model.Step{
+		Run:  model.Run{ID: "foo"},
+		Name: "bar",
+	}
+
+	outputs := memory.NewStepOutputMap()
+	outputs.Set(step, "public", "hello")
+	outputs.SetSensitive(step, "private", map[string]interface{}{"token": "abc123"})
+
+	rec := testRecorder{}
+	mgrs := redact.NewMetadataManagers(
+		builder.NewMetadataBuilder().
+			SetSecrets(memory.NewSecretManager(map[string]string{"password": "hunter2"})).
+			SetConnections(memory.NewConnectionManager(map[memory.ConnectionKey]map[string]interface{}{
+				{Type: "aws", Name: "prod"}: {"accessKeyID": "AKIA", "region": 1.0},
+			})).
+			SetStepOutputs(memory.NewStepOutputManager(step, outputs)).
+			Build(),
+		rec,
+	)
+
+	// Listing names does not record anything.
+	_, err := mgrs.Secrets().List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, rec)
+
+	_, err = mgrs.Secrets().Get(ctx, "password")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hunter2"}, rec["secrets/password"])
+
+	_, err = mgrs.Connections().Get(ctx, "aws", "prod")
+	require.NoError(t, err)
+	require.Equal(t, []string{"AKIA"}, rec["connections/aws/prod"])
+
+	_, err = mgrs.StepOutputs().List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{`{"token":"abc123"}`, "abc123"}, rec["outputs/bar/private"])
+	require.NotContains(t, rec, "outputs/bar/public")
+
+	// Setting outputs is passed through.
+	_, err = mgrs.StepOutputs().SetSensitive(ctx, "other", "xyz")
+	require.NoError(t, err)
+
+	_, err = mgrs.StepOutputs().Get(ctx, "bar", "other")
+	require.NoError(t, err)
+	require.Equal(t, []string{"xyz"}, rec["outputs/bar/other"])
+}
+
+func TestMetadataManagersWithoutRecorder(t *testing.T) {
+	ctx := context.Background()
+
+	step := &model.Step{
+		Run:  model.Run{ID: "foo"},
+		Name: "bar",
+	}
+
+	outputs := memory.NewStepOutputMap()
+	outputs.Set(step, "public", "hello")
+	outputs.SetSensitive(step, "private", "abc123")
+
+	mgrs := redact.NewMetadataManagers(
+		builder.NewMetadataBuilder().
+			SetSecrets(memory.NewSecretManager(map[string]string{"password": "hunter2"})).
+			SetStepOutputs(memory.NewStepOutputManager(step, outputs)).
+			Build(),
+		reject.RedactionManager,
+	)
+
+	// Confidential values are not handed out if they cannot be recorded.
+	_, err := mgrs.Secrets().Get(ctx, "password")
+	require.Equal(t, model.ErrRejected, err)
+
+	_, err = mgrs.StepOutputs().Get(ctx, "bar", "private")
+	require.Equal(t, model.ErrRejected, err)
+
+	output, err := mgrs.StepOutputs().Get(ctx, "bar", "public")
+	require.NoError(t, err)
+	require.Equal(t, "hello", output.Value)
+}
@@ -0,0 +1,40 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/puppetlabs/relay-core/pkg/model"
+)
+
+// SecretManager records the value of every secret retrieved from its delegate.
+type SecretManager struct {
+	delegate model.SecretManager
+	recorder model.RedactionRecorderManager
+}
+
+var _ model.SecretManager = &SecretManager{}
+
+func (m *SecretManager) Get(ctx context.Context, name string) (*model.Secret, error) {
+	sec, err := m.delegate.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.recorder.Record(ctx, fmt.Sprintf("secrets/%s", name), []string{sec.Value}); err != nil {
+		return nil, err
+	}
+
+	return sec, nil
+}
+
+func (m *SecretManager) List(ctx context.Context) ([]string, error) {
+	return m.delegate.List(ctx)
+}
+
+func NewSecretManager(delegate model.SecretManager, recorder model.RedactionRecorderManager) *SecretManager {
+	return &SecretManager{
+		delegate: delegate,
+		recorder: recorder,
+	}
+}
@@ -0,0 +1,83 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/puppetlabs/relay-core/pkg/util/maskutil"
+)
+
+// StepOutputManager records the value of every sensitive output retrieved
+// from its delegate.
+type StepOutputManager struct {
+	model.StepOutputSetterManager
+
+	delegate model.StepOutputManager
+	recorder model.RedactionRecorderManager
+}
+
+var _ model.StepOutputManager = &StepOutputManager{}
+
+func (m *StepOutputManager) Get(ctx context.Context, stepName, name string) (*model.StepOutput, error) {
+	output, err := m.delegate.Get(ctx, stepName, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.record(ctx, []*model.StepOutput{output}); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+func (m *StepOutputManager) List(ctx context.Context) ([]*model.StepOutput, error) {
+	outputs, err := m.delegate.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.record(ctx, outputs); err != nil {
+		return nil, err
+	}
+
+	return outputs, nil
+}
+
+func (m *StepOutputManager) ListStep(ctx context.Context, stepName string) ([]*model.StepOutput, error) {
+	outputs, err := m.delegate.ListStep(ctx, stepName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.record(ctx, outputs); err != nil {
+		return nil, err
+	}
+
+	return outputs, nil
+}
+
+func (m *StepOutputManager) record(ctx context.Context, outputs []*model.StepOutput) error {
+	for _, output := range outputs {
+		if !output.Sensitive {
+			continue
+		}
+
+		source := fmt.Sprintf("outputs/%s/%s", output.Step.Name, output.Name)
+		if err := m.recorder.Record(ctx, source, maskutil.Values(output.Value)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func NewStepOutputManager(delegate model.StepOutputManager, recorder model.RedactionRecorderManager) *StepOutputManager {
+	return &StepOutputManager{
+		StepOutputSetterManager: delegate,
+
+		delegate: delegate,
+		recorder: recorder,
+	}
+}
@@ -0,0 +1,15 @@
+package reject
+
+import (
+	"context"
+
+	"github.com/puppetlabs/relay-core/pkg/model"
+)
+
+type changeManager struct{}
+
+func (*changeManager) Watch(ctx context.Context) (<-chan struct{}, error) {
+	return nil, model.ErrRejected
+}
+
+var ChangeManager model.ChangeManager = &changeManager{}
@@ -12,4 +12,8 @@ func (*connectionManager) Get(ctx context.Context, typ, name string) (*model.Con
 	return nil, model.ErrRejected
 }
 
+func (*connectionManager) List(ctx context.Context, typ string) ([]string, error) {
+	return nil, model.ErrRejected
+}
+
 var ConnectionManager model.ConnectionManager = &connectionManager{}
@@ -12,4 +12,8 @@ func (*secretManager) Get(ctx context.Context, name string) (*model.Secret, erro
 	return nil, model.ErrRejected
 }
 
+func (*secretManager) List(ctx context.Context) ([]string, error) {
+	return nil, model.ErrRejected
+}
+
 var SecretManager model.SecretManager = &secretManager{}
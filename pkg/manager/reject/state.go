@@ -16,4 +16,8 @@ func (*stateManager) Set(ctx context.Context, name string, value interface{}) (*
 	return nil, model.ErrRejected
 }
 
+func (*stateManager) Delete(ctx context.Context, name string) error {
+	return model.ErrRejected
+}
+
 var StateManager model.StateManager = &stateManager{}
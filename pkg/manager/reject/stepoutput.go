@@ -12,8 +12,20 @@ func (*stepOutputManager) Get(ctx context.Context, stepName, name string) (*mode
 	return nil, model.ErrRejected
 }
 
+func (*stepOutputManager) List(ctx context.Context) ([]*model.StepOutput, error) {
+	return nil, model.ErrRejected
+}
+
+func (*stepOutputManager) ListStep(ctx context.Context, stepName string) ([]*model.StepOutput, error) {
+	return nil, model.ErrRejected
+}
+
 func (*stepOutputManager) Set(ctx context.Context, name string, value interface{}) (*model.StepOutput, error) {
 	return nil, model.ErrRejected
 }
 
+func (*stepOutputManager) SetSensitive(ctx context.Context, name string, value interface{}) (*model.StepOutput, error) {
+	return nil, model.ErrRejected
+}
+
 var StepOutputManager model.StepOutputManager = &stepOutputManager{}
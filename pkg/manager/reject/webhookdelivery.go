@@ -0,0 +1,44 @@
+package reject
+
+import (
+	"context"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/model"
+)
+
+type webhookDeliveryManager struct{}
+
+func (*webhookDeliveryManager) Get(ctx context.Context) (*model.WebhookDeliveries, error) {
+	return nil, model.ErrRejected
+}
+
+func (*webhookDeliveryManager) RecordRequest(ctx context.Context, at time.Time, failure error) error {
+	return model.ErrRejected
+}
+
+func (*webhookDeliveryManager) RecordEvent(ctx context.Context, at time.Time, failure error) error {
+	return model.ErrRejected
+}
+
+func (*webhookDeliveryManager) RecordSinkEvent(ctx context.Context, sink string, at time.Time, failure error) error {
+	return model.ErrRejected
+}
+
+var WebhookDeliveryManager model.WebhookDeliveryManager = &webhookDeliveryManager{}
+
+type webhookDeliveryEventManager struct {
+	model.WebhookDeliveryRecorderManager
+}
+
+func (*webhookDeliveryEventManager) RecordRequest(ctx context.Context, at time.Time, failure error) error {
+	return model.ErrRejected
+}
+
+// NewWebhookDeliveryEventManager wraps the given manager so that events can be
+// recorded but requests cannot.
+func NewWebhookDeliveryEventManager(delegate model.WebhookDeliveryRecorderManager) model.WebhookDeliveryRecorderManager {
+	return &webhookDeliveryEventManager{
+		WebhookDeliveryRecorderManager: delegate,
+	}
+}
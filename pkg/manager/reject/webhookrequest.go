@@ -0,0 +1,44 @@
+package reject
+
+import (
+	"context"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/model"
+)
+
+type webhookRequestManager struct{}
+
+func (*webhookRequestManager) List(ctx context.Context) ([]*model.WebhookRequest, error) {
+	return nil, model.ErrRejected
+}
+
+func (*webhookRequestManager) Get(ctx context.Context, id string) (*model.WebhookRequest, error) {
+	return nil, model.ErrRejected
+}
+
+func (*webhookRequestManager) Record(ctx context.Context, req *model.WebhookRequest) (*model.WebhookRequest, error) {
+	return nil, model.ErrRejected
+}
+
+func (*webhookRequestManager) RecordEvent(ctx context.Context, ev *model.Event, requestID string, at time.Time) error {
+	return model.ErrRejected
+}
+
+var WebhookRequestManager model.WebhookRequestManager = &webhookRequestManager{}
+
+type webhookRequestEventManager struct {
+	model.WebhookRequestRecorderManager
+}
+
+func (*webhookRequestEventManager) Record(ctx context.Context, req *model.WebhookRequest) (*model.WebhookRequest, error) {
+	return nil, model.ErrRejected
+}
+
+// NewWebhookRequestEventManager wraps the given manager so that events can be
+// attached to captured requests but no new requests can be captured.
+func NewWebhookRequestEventManager(delegate model.WebhookRequestRecorderManager) model.WebhookRequestRecorderManager {
+	return &webhookRequestEventManager{
+		WebhookRequestRecorderManager: delegate,
+	}
+}
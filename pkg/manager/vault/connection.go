@@ -43,6 +43,10 @@ func (m *ConnectionManager) Get(ctx context.Context, typ, name string) (*model.C
 	}, nil
 }
 
+func (m *ConnectionManager) List(ctx context.Context, typ string) ([]string, error) {
+	return m.client.In(typ).ListValues(ctx)
+}
+
 func NewConnectionManager(client *KVV2Client) *ConnectionManager {
 	return &ConnectionManager{
 		client: client,
@@ -50,5 +50,13 @@ func TestConnectionManager(t *testing.T) {
 
 		_, err = cm.Get(ctx, "some-other-type", "test")
 		require.Equal(t, model.ErrNotFound, err)
+
+		names, err := cm.List(ctx, "some-type")
+		require.NoError(t, err)
+		require.Equal(t, []string{"test"}, names)
+
+		names, err = cm.List(ctx, "some-other-type")
+		require.NoError(t, err)
+		require.Empty(t, names)
 	})
 }
@@ -3,6 +3,8 @@ package vault
 import (
 	"context"
 	"path"
+	"sort"
+	"strings"
 
 	vaultapi "github.com/hashicorp/vault/api"
 	"github.com/puppetlabs/horsehead/v2/encoding/transfer"
@@ -90,6 +92,30 @@ func (c *KVV2Client) List(ctx context.Context) ([]string, error) {
 	return keys, nil
 }
 
+// ListValues returns the keys under this path that contain values, omitting
+// subdirectories. Unlike List, a path with no keys is empty rather than not
+// found.
+func (c *KVV2Client) ListValues(ctx context.Context) ([]string, error) {
+	keys, err := c.List(ctx)
+	if err == model.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for _, key := range keys {
+		if strings.HasSuffix(key, "/") {
+			continue
+		}
+
+		values = append(values, key)
+	}
+
+	sort.Strings(values)
+	return values, nil
+}
+
 func (c *KVV2Client) dataPath() string {
 	return path.Join(c.enginePath, "data", c.path)
 }
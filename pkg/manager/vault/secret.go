@@ -24,6 +24,10 @@ func (m *SecretManager) Get(ctx context.Context, name string) (*model.Secret, er
 	}, nil
 }
 
+func (m *SecretManager) List(ctx context.Context) ([]string, error) {
+	return m.client.ListValues(ctx)
+}
+
 func NewSecretManager(client *KVV2Client) *SecretManager {
 	return &SecretManager{
 		client: client,
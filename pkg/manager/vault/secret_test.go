@@ -30,5 +30,20 @@ func TestSecretManager(t *testing.T) {
 
 		_, err = sm.Get(ctx, "nonexistent")
 		require.Equal(t, model.ErrNotFound, err)
+
+		_, err = vcfg.Client.Logical().Write(path.Join(vcfg.SecretsPath, "data/foo/nested/qux"), map[string]interface{}{
+			"data": map[string]interface{}{
+				"value": "quux",
+			},
+		})
+		require.NoError(t, err)
+
+		names, err := sm.List(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"bar"}, names)
+
+		names, err = vault.NewSecretManager(vault.NewKVV2Client(vcfg.Client, vcfg.SecretsPath).In("empty")).List(ctx)
+		require.NoError(t, err)
+		require.Empty(t, names)
 	})
 }
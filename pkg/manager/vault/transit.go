@@ -0,0 +1,91 @@
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// TransitClient encrypts and decrypts data using a key in a transit engine
+// mount.
+type TransitClient struct {
+	client  *vaultapi.Client
+	path    string
+	key     string
+	context string
+}
+
+func (c *TransitClient) Encrypt(ctx context.Context, plaintext []byte) (string, error) {
+	data := map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+	}
+	c.addContext(data)
+
+	sec, err := c.client.Logical().Write(path.Join(c.path, "encrypt", c.key), data)
+	if err != nil {
+		return "", err
+	} else if sec == nil {
+		return "", fmt.Errorf("vault: transit: no secret data in response (bug?)")
+	}
+
+	ciphertext, ok := sec.Data["ciphertext"].(string)
+	if !ok {
+		return "", fmt.Errorf("vault: transit: ciphertext missing from secret data (bug?)")
+	}
+
+	return ciphertext, nil
+}
+
+func (c *TransitClient) Decrypt(ctx context.Context, ciphertext string) ([]byte, error) {
+	data := map[string]interface{}{
+		"ciphertext": ciphertext,
+	}
+	c.addContext(data)
+
+	sec, err := c.client.Logical().Write(path.Join(c.path, "decrypt", c.key), data)
+	if err != nil {
+		return nil, err
+	} else if sec == nil {
+		return nil, fmt.Errorf("vault: transit: no secret data in response (bug?)")
+	}
+
+	encoded, ok := sec.Data["plaintext"].(string)
+	if !ok {
+		return nil, fmt.Errorf("vault: transit: plaintext missing from secret data (bug?)")
+	}
+
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+func (c *TransitClient) addContext(data map[string]interface{}) {
+	if c.context != "" {
+		data["context"] = base64.StdEncoding.EncodeToString([]byte(c.context))
+	}
+}
+
+type TransitClientOption func(c *TransitClient)
+
+// TransitClientWithContext sets the key derivation context for the client.
+// It is required if the key was created with derivation enabled.
+func TransitClientWithContext(context string) TransitClientOption {
+	return func(c *TransitClient) {
+		c.context = context
+	}
+}
+
+func NewTransitClient(client *vaultapi.Client, path, key string, opts ...TransitClientOption) *TransitClient {
+	c := &TransitClient{
+		client: client,
+		path:   path,
+		key:    key,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
@@ -0,0 +1,30 @@
+package vault_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/puppetlabs/relay-core/pkg/manager/vault"
+	"github.com/puppetlabs/relay-core/pkg/util/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitClient(t *testing.T) {
+	ctx := context.Background()
+
+	testutil.WithVault(t, func(vcfg *testutil.Vault) {
+		tc := vault.NewTransitClient(vcfg.Client, vcfg.TransitPath, vcfg.TransitKey, vault.TransitClientWithContext("foo"))
+
+		ciphertext, err := tc.Encrypt(ctx, []byte("hello"))
+		require.NoError(t, err)
+		require.NotContains(t, ciphertext, "hello")
+
+		plaintext, err := tc.Decrypt(ctx, ciphertext)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(plaintext))
+
+		// A different context derives a different key.
+		_, err = vault.NewTransitClient(vcfg.Client, vcfg.TransitPath, vcfg.TransitKey, vault.TransitClientWithContext("bar")).Decrypt(ctx, ciphertext)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,74 @@
+// Package audit describes the requests made to the metadata API and delivers
+// these descriptions to a sink.
+package audit
+
+import (
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/authenticate"
+)
+
+// Identity describes the authenticated client that made a request.
+type Identity struct {
+	Subject  string `json:"subject,omitempty"`
+	DomainID string `json:"domain_id,omitempty"`
+	TenantID string `json:"tenant_id,omitempty"`
+	RunID    string `json:"run_id,omitempty"`
+
+	// ActionType is the type of the action, like "step" or "trigger", and
+	// ActionName its name.
+	ActionType string `json:"action_type,omitempty"`
+	ActionName string `json:"action_name,omitempty"`
+}
+
+func IdentityFromClaims(claims *authenticate.Claims) *Identity {
+	id := &Identity{
+		Subject:    claims.Subject,
+		DomainID:   claims.RelayDomainID,
+		TenantID:   claims.RelayTenantID,
+		RunID:      claims.RelayRunID,
+		ActionName: claims.RelayName,
+	}
+
+	if action := claims.Action(); action != nil {
+		id.ActionType = action.Type().Singular
+	}
+
+	return id
+}
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// OutcomeForStatusCode returns the outcome of a request that completed with
+// the given HTTP status code.
+func OutcomeForStatusCode(code int) Outcome {
+	if code >= 200 && code < 400 {
+		return OutcomeSuccess
+	}
+
+	return OutcomeFailure
+}
+
+// Record describes a single request. It identifies the resources a client
+// accessed but never includes their values.
+type Record struct {
+	Time     time.Time `json:"time"`
+	Identity *Identity `json:"identity"`
+
+	// Method is the HTTP method of the request and Route the template of the
+	// path it matched, like "/secrets/{name}".
+	Method string `json:"method"`
+	Route  string `json:"route"`
+
+	// Resource contains the variables of the route, like the name of the
+	// secret.
+	Resource map[string]string `json:"resource,omitempty"`
+
+	StatusCode int     `json:"status_code"`
+	Outcome    Outcome `json:"outcome"`
+}
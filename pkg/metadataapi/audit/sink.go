@@ -0,0 +1,311 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/puppetlabs/horsehead/v2/instrumentation/metrics"
+	"github.com/puppetlabs/horsehead/v2/instrumentation/metrics/collectors"
+)
+
+const (
+	// DefaultHTTPSinkTimeout bounds each request an HTTP sink makes, including
+	// reading the response.
+	DefaultHTTPSinkTimeout = 10 * time.Second
+
+	// DefaultAsyncSinkQueueSize is the number of records an asynchronous sink
+	// holds before it starts dropping them.
+	DefaultAsyncSinkQueueSize = 1024
+
+	// AsyncSinkDrainTimeout bounds the time an asynchronous sink spends
+	// writing queued records once it is asked to stop.
+	AsyncSinkDrainTimeout = 5 * time.Second
+)
+
+const (
+	metricAuditRecordsDropped = "audit_records_dropped"
+	metricAuditRecordsFailed  = "audit_records_failed"
+)
+
+// ErrAsyncSinkQueueFull is returned by an asynchronous sink when it drops a
+// record because its queue is full.
+var ErrAsyncSinkQueueFull = errors.New("audit: sink queue is full")
+
+// Sink is a destination for audit records.
+type Sink interface {
+	Write(ctx context.Context, rec *Record) error
+}
+
+type logSink struct{}
+
+func (*logSink) Write(ctx context.Context, rec *Record) error {
+	log(ctx).Info(
+		"metadata API request",
+		"identity", rec.Identity,
+		"method", rec.Method,
+		"route", rec.Route,
+		"resource", rec.Resource,
+		"status_code", rec.StatusCode,
+		"outcome", rec.Outcome,
+	)
+	return nil
+}
+
+// LogSink writes audit records to the application log.
+var LogSink Sink = &logSink{}
+
+// WriterSink writes audit records to a writer as JSON, one per line.
+type WriterSink struct {
+	mut sync.Mutex
+	w   io.Writer
+}
+
+var _ Sink = &WriterSink{}
+
+func (ws *WriterSink) Write(ctx context.Context, rec *Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	ws.mut.Lock()
+	defer ws.mut.Unlock()
+
+	_, err = ws.w.Write(append(b, '\n'))
+	return err
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{
+		w: w,
+	}
+}
+
+// NewFileSink appends audit records to the file at the given path, creating
+// it if necessary.
+func NewFileSink(path string) (*WriterSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWriterSink(f), nil
+}
+
+// HTTPSink sends each audit record to an HTTP endpoint as a JSON document in
+// the body of a POST request.
+type HTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+var _ Sink = &HTTPSink{}
+
+func (hs *HTTPSink) Write(ctx context.Context, rec *Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, hs.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := hs.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("audit: HTTP sink responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+type HTTPSinkOption func(hs *HTTPSink)
+
+func HTTPSinkWithClient(client *http.Client) HTTPSinkOption {
+	return func(hs *HTTPSink) {
+		hs.client = client
+	}
+}
+
+func NewHTTPSink(url string, opts ...HTTPSinkOption) *HTTPSink {
+	hs := &HTTPSink{
+		url: url,
+		client: &http.Client{
+			Timeout: DefaultHTTPSinkTimeout,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(hs)
+	}
+
+	return hs
+}
+
+// AsyncSink writes audit records to another sink in the background so that a
+// slow or unavailable sink does not delay responses. Records are queued until
+// they are written; once the queue is full, new records are dropped.
+type AsyncSink struct {
+	delegate Sink
+	size     int
+	mets     *metrics.Metrics
+
+	queue   chan *Record
+	dropped uint64
+	failed  uint64
+}
+
+var _ Sink = &AsyncSink{}
+
+// Write queues the record to be written by Run. It returns
+// ErrAsyncSinkQueueFull if the record was dropped.
+func (as *AsyncSink) Write(ctx context.Context, rec *Record) error {
+	select {
+	case as.queue <- rec:
+		return nil
+	default:
+		atomic.AddUint64(&as.dropped, 1)
+		as.count(metricAuditRecordsDropped)
+
+		return ErrAsyncSinkQueueFull
+	}
+}
+
+// Dropped returns the number of records dropped because the queue was full or
+// could not be drained in time.
+func (as *AsyncSink) Dropped() uint64 {
+	return atomic.LoadUint64(&as.dropped)
+}
+
+// Failed returns the number of records the delegate sink failed to write.
+func (as *AsyncSink) Failed() uint64 {
+	return atomic.LoadUint64(&as.failed)
+}
+
+// Run writes queued records to the delegate sink until the context is done.
+// It then tries to write the records remaining in the queue for up to
+// AsyncSinkDrainTimeout.
+func (as *AsyncSink) Run(ctx context.Context) error {
+	for {
+		select {
+		case rec := <-as.queue:
+			as.write(ctx, rec)
+		case <-ctx.Done():
+			as.drain()
+			return nil
+		}
+	}
+}
+
+func (as *AsyncSink) drain() {
+	ctx, cancel := context.WithTimeout(context.Background(), AsyncSinkDrainTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case rec := <-as.queue:
+			if ctx.Err() != nil {
+				atomic.AddUint64(&as.dropped, 1)
+				as.count(metricAuditRecordsDropped)
+				continue
+			}
+
+			as.write(ctx, rec)
+		default:
+			return
+		}
+	}
+}
+
+func (as *AsyncSink) write(ctx context.Context, rec *Record) {
+	if err := as.delegate.Write(ctx, rec); err != nil {
+		atomic.AddUint64(&as.failed, 1)
+		as.count(metricAuditRecordsFailed)
+
+		log(ctx).Error("failed to write audit record", "error", err)
+	}
+}
+
+func (as *AsyncSink) count(metric string) {
+	if as.mets == nil {
+		return
+	}
+
+	as.mets.MustCounter(metric).Inc()
+}
+
+type AsyncSinkOption func(as *AsyncSink)
+
+// AsyncSinkWithQueueSize sets the number of records the sink holds before it
+// starts dropping them.
+func AsyncSinkWithQueueSize(size int) AsyncSinkOption {
+	return func(as *AsyncSink) {
+		as.size = size
+	}
+}
+
+// AsyncSinkWithMetrics counts dropped and failed records in the given metrics
+// namespace.
+func AsyncSinkWithMetrics(mets *metrics.Metrics) AsyncSinkOption {
+	return func(as *AsyncSink) {
+		as.mets = mets
+	}
+}
+
+func NewAsyncSink(delegate Sink, opts ...AsyncSinkOption) *AsyncSink {
+	as := &AsyncSink{
+		delegate: delegate,
+		size:     DefaultAsyncSinkQueueSize,
+	}
+
+	for _, opt := range opts {
+		opt(as)
+	}
+
+	as.queue = make(chan *Record, as.size)
+
+	if as.mets != nil {
+		as.mets.MustRegisterCounter(metricAuditRecordsDropped, collectors.CounterOptions{
+			Description: "number of audit records dropped because the sink could not keep up",
+		})
+		as.mets.MustRegisterCounter(metricAuditRecordsFailed, collectors.CounterOptions{
+			Description: "number of audit records the sink failed to write",
+		})
+	}
+
+	return as
+}
+
+// NewSinkFromURL creates a sink described by a URL. The "log:" URL selects
+// LogSink; "file:///path/to/file" appends to a file; and HTTP(S) URLs post
+// records to the given endpoint.
+func NewSinkFromURL(u *url.URL) (Sink, error) {
+	switch u.Scheme {
+	case "log":
+		return LogSink, nil
+	case "file":
+		return NewFileSink(u.Path)
+	case "http", "https":
+		return NewHTTPSink(u.String()), nil
+	default:
+		return nil, fmt.Errorf("audit: unsupported sink URL scheme %q", u.Scheme)
+	}
+}
@@ -0,0 +1,173 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/audit"
+	"github.com/stretchr/testify/require"
+)
+
+func testRecord() *audit.Record {
+	return &audit.Record{
+		Time: time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC),
+		Identity: &audit.Identity{
+			TenantID:   "tenant",
+			RunID:      "run",
+			ActionType: "step",
+			ActionName: "deploy",
+		},
+		Method:     http.MethodGet,
+		Route:      "/secrets/{name}",
+		Resource:   map[string]string{"name": "password"},
+		StatusCode: http.StatusOK,
+		Outcome:    audit.OutcomeSuccess,
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	sink := audit.NewWriterSink(&buf)
+
+	require.NoError(t, sink.Write(ctx, testRecord()))
+	require.NoError(t, sink.Write(ctx, testRecord()))
+
+	dec := json.NewDecoder(&buf)
+	for i := 0; i < 2; i++ {
+		var rec audit.Record
+		require.NoError(t, dec.Decode(&rec))
+		require.Equal(t, testRecord(), &rec)
+	}
+	require.False(t, dec.More())
+}
+
+func TestFileSink(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "relay-audit-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+
+	sink, err := audit.NewSinkFromURL(&url.URL{Scheme: "file", Path: path})
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(ctx, testRecord()))
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	var rec audit.Record
+	require.NoError(t, json.Unmarshal(b, &rec))
+	require.Equal(t, testRecord(), &rec)
+}
+
+func TestHTTPSink(t *testing.T) {
+	ctx := context.Background()
+
+	var received []*audit.Record
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("content-type"))
+
+		var rec audit.Record
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&rec))
+		received = append(received, &rec)
+
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer s.Close()
+
+	u, err := url.Parse(s.URL)
+	require.NoError(t, err)
+
+	sink, err := audit.NewSinkFromURL(u)
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(ctx, testRecord()))
+	require.Equal(t, []*audit.Record{testRecord()}, received)
+
+	require.Error(t, audit.NewHTTPSink(s.URL+"/fail").Write(ctx, testRecord()))
+}
+
+func TestHTTPSinkTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	unblock := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer s.Close()
+	defer close(unblock)
+
+	sink := audit.NewHTTPSink(s.URL, audit.HTTPSinkWithClient(&http.Client{Timeout: 50 * time.Millisecond}))
+	require.Error(t, sink.Write(ctx, testRecord()))
+}
+
+type blockingSink struct {
+	unblock  chan struct{}
+	received chan *audit.Record
+}
+
+func (bs *blockingSink) Write(ctx context.Context, rec *audit.Record) error {
+	<-bs.unblock
+	bs.received <- rec
+	return nil
+}
+
+func TestAsyncSink(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	delegate := &blockingSink{
+		unblock:  make(chan struct{}),
+		received: make(chan *audit.Record, 10),
+	}
+
+	sink := audit.NewAsyncSink(delegate, audit.AsyncSinkWithQueueSize(1))
+
+	// Without a running writer, the queue fills up and further records are
+	// dropped instead of blocking.
+	require.NoError(t, sink.Write(ctx, testRecord()))
+	require.Equal(t, audit.ErrAsyncSinkQueueFull, sink.Write(ctx, testRecord()))
+	require.Equal(t, uint64(1), sink.Dropped())
+
+	done := make(chan error)
+	go func() {
+		done <- sink.Run(ctx)
+	}()
+
+	close(delegate.unblock)
+
+	select {
+	case rec := <-delegate.received:
+		require.Equal(t, testRecord(), rec)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for record")
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+	require.Equal(t, uint64(0), sink.Failed())
+}
+
+func TestNewSinkFromURL(t *testing.T) {
+	sink, err := audit.NewSinkFromURL(&url.URL{Scheme: "log"})
+	require.NoError(t, err)
+	require.Equal(t, audit.LogSink, sink)
+
+	_, err = audit.NewSinkFromURL(&url.URL{Scheme: "ftp", Host: "example.com"})
+	require.Error(t, err)
+}
@@ -0,0 +1,406 @@
+// Package client provides a typed client for the metadata API.
+//
+// The routes and envelopes used by this client are described in the OpenAPI
+// document at pkg/metadataapi/openapi.yaml.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/puppetlabs/horsehead/v2/encoding/transfer"
+	utilapi "github.com/puppetlabs/horsehead/v2/httputil/api"
+	"github.com/puppetlabs/relay-core/pkg/expr/evaluate"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/api"
+)
+
+// evaluationResult is the JSON encoding of an evaluate.Result.
+type evaluationResult struct {
+	Value        transfer.JSONInterface
+	Unresolvable struct {
+		Data        []evaluate.UnresolvableData
+		Secrets     []evaluate.UnresolvableSecret
+		Connections []evaluate.UnresolvableConnection
+		Outputs     []evaluate.UnresolvableOutput
+		Parameters  []evaluate.UnresolvableParameter
+		Answers     []evaluate.UnresolvableAnswer
+		Invocations []struct {
+			// The cause of the failure cannot be decoded, so we only keep the
+			// name of the function.
+			Name string
+		}
+	}
+}
+
+func (er *evaluationResult) result() *evaluate.Result {
+	ur := evaluate.Unresolvable{
+		Data:        er.Unresolvable.Data,
+		Secrets:     er.Unresolvable.Secrets,
+		Connections: er.Unresolvable.Connections,
+		Outputs:     er.Unresolvable.Outputs,
+		Parameters:  er.Unresolvable.Parameters,
+		Answers:     er.Unresolvable.Answers,
+	}
+	for _, call := range er.Unresolvable.Invocations {
+		ur.Invocations = append(ur.Invocations, evaluate.UnresolvableInvocation{Name: call.Name})
+	}
+
+	return &evaluate.Result{
+		Value:        er.Value.Data,
+		Unresolvable: ur,
+	}
+}
+
+type UnexpectedResponseError struct {
+	StatusCode int
+}
+
+func (e *UnexpectedResponseError) Error() string {
+	return fmt.Sprintf("received HTTP %d from metadata API", e.StatusCode)
+}
+
+// Client makes requests to the metadata API on behalf of a step or trigger.
+//
+// If the metadata API responds with an error, methods return it as an
+// errawr.Error, so it can be checked using the functions in the
+// pkg/metadataapi/errors package.
+type Client struct {
+	url       *url.URL
+	token     string
+	tokenFile string
+	client    *http.Client
+}
+
+func (c *Client) GetConditions(ctx context.Context, wait time.Duration) (*api.GetConditionsResponseEnvelope, error) {
+	q := url.Values{}
+	if wait > 0 {
+		q.Set("wait", wait.String())
+	}
+
+	env := &api.GetConditionsResponseEnvelope{}
+	if err := c.do(ctx, http.MethodGet, c.path("conditions").withQuery(q), nil, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func (c *Client) ListConnections(ctx context.Context, typ string) (*api.ListConnectionsResponseEnvelope, error) {
+	env := &api.ListConnectionsResponseEnvelope{}
+	if err := c.do(ctx, http.MethodGet, c.path("connections", typ), nil, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func (c *Client) GetEnvironment(ctx context.Context) (*evaluate.JSONResultEnvelope, error) {
+	env := &evaluate.JSONResultEnvelope{}
+	if err := c.do(ctx, http.MethodGet, c.path("environment"), nil, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// GetEnvironmentVariable evaluates a single environment variable of the step.
+//
+// The metadata API responds to this request with the evaluation result
+// itself rather than a JSONResultEnvelope; it is converted to one here so the
+// result can be handled like any other evaluated response.
+func (c *Client) GetEnvironmentVariable(ctx context.Context, name string) (*evaluate.JSONResultEnvelope, error) {
+	rv := &evaluationResult{}
+	if err := c.do(ctx, http.MethodGet, c.path("environment", name), nil, rv); err != nil {
+		return nil, err
+	}
+
+	return evaluate.NewJSONResultEnvelope(rv.result()), nil
+}
+
+func (c *Client) PostEvent(ctx context.Context, data map[string]interface{}, key string) error {
+	encoded := make(map[string]transfer.JSONInterface, len(data))
+	for k, v := range data {
+		encoded[k] = transfer.JSONInterface{Data: v}
+	}
+
+	body, err := newJSONBody(&api.PostEventRequestEnvelope{
+		Data: encoded,
+		Key:  key,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, http.MethodPost, c.path("events"), body, nil)
+}
+
+func (c *Client) ListOutputs(ctx context.Context) (*api.ListOutputsResponseEnvelope, error) {
+	env := &api.ListOutputsResponseEnvelope{}
+	if err := c.do(ctx, http.MethodGet, c.path("outputs"), nil, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func (c *Client) ListStepOutputs(ctx context.Context, stepName string) (*api.ListStepOutputsResponseEnvelope, error) {
+	env := &api.ListStepOutputsResponseEnvelope{}
+	if err := c.do(ctx, http.MethodGet, c.path("outputs", stepName), nil, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func (c *Client) GetOutput(ctx context.Context, stepName, name string) (*api.GetOutputResponseEnvelope, error) {
+	env := &api.GetOutputResponseEnvelope{}
+	if err := c.do(ctx, http.MethodGet, c.path("outputs", stepName, name), nil, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// PutOutput sets an output of the step. Strings are stored exactly as given;
+// any other value must be serializable to JSON.
+func (c *Client) PutOutput(ctx context.Context, name string, value interface{}) error {
+	body, err := newValueBody(value)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, http.MethodPut, c.path("outputs", name), body, nil)
+}
+
+// PutSensitiveOutput sets an output of the step like PutOutput, but marks it as
+// sensitive.
+func (c *Client) PutSensitiveOutput(ctx context.Context, name string, value interface{}) error {
+	body, err := newValueBody(value)
+	if err != nil {
+		return err
+	}
+
+	q := url.Values{}
+	q.Set("sensitive", "true")
+
+	return c.do(ctx, http.MethodPut, c.path("outputs", name).withQuery(q), body, nil)
+}
+
+func (c *Client) ListSecrets(ctx context.Context) (*api.ListSecretsResponseEnvelope, error) {
+	env := &api.ListSecretsResponseEnvelope{}
+	if err := c.do(ctx, http.MethodGet, c.path("secrets"), nil, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func (c *Client) GetSecret(ctx context.Context, name string) (*api.GetSecretResponseEnvelope, error) {
+	env := &api.GetSecretResponseEnvelope{}
+	if err := c.do(ctx, http.MethodGet, c.path("secrets", name), nil, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func (c *Client) GetSpec(ctx context.Context) (*evaluate.JSONResultEnvelope, error) {
+	return c.QuerySpec(ctx, "", "")
+}
+
+// QuerySpec evaluates the given query against the spec using the given query
+// language, which may be "path" (the default), "jsonpath", or
+// "jsonpath-template".
+func (c *Client) QuerySpec(ctx context.Context, lang, query string) (*evaluate.JSONResultEnvelope, error) {
+	q := url.Values{}
+	if lang != "" {
+		q.Set("lang", lang)
+	}
+	if query != "" {
+		q.Set("q", query)
+	}
+
+	env := &evaluate.JSONResultEnvelope{}
+	if err := c.do(ctx, http.MethodGet, c.path("spec").withQuery(q), nil, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func (c *Client) GetState(ctx context.Context, name string) (*api.GetStateResponseEnvelope, error) {
+	env := &api.GetStateResponseEnvelope{}
+	if err := c.do(ctx, http.MethodGet, c.path("state", name), nil, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// PutState sets a state variable. Values are handled the same way as they are
+// for PutOutput.
+func (c *Client) PutState(ctx context.Context, name string, value interface{}) error {
+	body, err := newValueBody(value)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, http.MethodPut, c.path("state", name), body, nil)
+}
+
+func (c *Client) DeleteState(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, c.path("state", name), nil, nil)
+}
+
+type requestURL struct {
+	*url.URL
+}
+
+func (u requestURL) withQuery(q url.Values) requestURL {
+	u.RawQuery = q.Encode()
+	return u
+}
+
+func (c *Client) path(segments ...string) requestURL {
+	// The server unescapes path variables as if they were query string
+	// components, so "+" must be escaped too.
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = strings.Replace(url.QueryEscape(segment), "+", "%20", -1)
+	}
+
+	u := *c.url
+	u.Path = strings.TrimSuffix(c.url.Path, "/") + "/" + strings.Join(segments, "/")
+	u.RawPath = strings.TrimSuffix(c.url.EscapedPath(), "/") + "/" + strings.Join(escaped, "/")
+
+	return requestURL{URL: &u}
+}
+
+type requestBody struct {
+	contentType string
+	r           io.Reader
+}
+
+func newJSONBody(v interface{}) (*requestBody, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &requestBody{
+		contentType: "application/json",
+		r:           bytes.NewReader(b),
+	}, nil
+}
+
+func newValueBody(value interface{}) (*requestBody, error) {
+	if s, ok := value.(string); ok {
+		return &requestBody{
+			contentType: "text/plain",
+			r:           strings.NewReader(s),
+		}, nil
+	}
+
+	return newJSONBody(value)
+}
+
+func (c *Client) do(ctx context.Context, method string, u requestURL, body *requestBody, into interface{}) error {
+	var r io.Reader
+	if body != nil {
+		r = body.r
+	}
+
+	req, err := http.NewRequest(method, u.String(), r)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	if body != nil {
+		req.Header.Set("Content-Type", body.contentType)
+	}
+
+	token := c.token
+	if c.tokenFile != "" {
+		// Projected tokens are replaced by the kubelet before they expire, so
+		// we read the file for every request.
+		b, err := ioutil.ReadFile(c.tokenFile)
+		if err != nil {
+			return err
+		}
+
+		token = strings.TrimSpace(string(b))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var env utilapi.ErrorEnvelope
+		if derr := json.NewDecoder(resp.Body).Decode(&env); derr == nil && env.Error != nil {
+			return env.Error.AsError()
+		}
+
+		return &UnexpectedResponseError{
+			StatusCode: resp.StatusCode,
+		}
+	}
+
+	if into == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(into)
+}
+
+type ClientOption func(c *Client)
+
+// ClientWithToken authenticates requests using the given bearer token. Without
+// a token, the metadata API identifies the caller by its source IP address.
+func ClientWithToken(token string) ClientOption {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// ClientWithTokenFile authenticates requests using the bearer token in the
+// given file, such as the service account token mounted at the path in the
+// METADATA_API_TOKEN_FILE environment variable of steps and triggers.
+func ClientWithTokenFile(path string) ClientOption {
+	return func(c *Client) {
+		c.tokenFile = path
+	}
+}
+
+func ClientWithHTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+func NewClient(u *url.URL, opts ...ClientOption) *Client {
+	c := &Client{
+		url:    u,
+		client: http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
@@ -0,0 +1,160 @@
+package client_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/expr/parse"
+	"github.com/puppetlabs/relay-core/pkg/expr/serialize"
+	exprtestutil "github.com/puppetlabs/relay-core/pkg/expr/testutil"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/client"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/errors"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/opt"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/sample"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+
+	tokenGenerator, err := sample.NewHS256TokenGenerator(nil)
+	require.NoError(t, err)
+
+	sc := &opt.SampleConfig{
+		Secrets: map[string]string{
+			"foo": "bar",
+		},
+		Runs: map[string]*opt.SampleConfigRun{
+			"test": &opt.SampleConfigRun{
+				Steps: map[string]*opt.SampleConfigStep{
+					"previous-task": &opt.SampleConfigStep{},
+					"current-task": &opt.SampleConfigStep{
+						Conditions: serialize.YAMLTree{
+							Tree: []interface{}{
+								exprtestutil.JSONInvocation("equals", []interface{}{
+									exprtestutil.JSONOutput("previous-task", "output a+b"),
+									"foobar",
+								}),
+							},
+						},
+						Spec: opt.SampleConfigSpec{
+							"secret": serialize.YAMLTree{Tree: parse.Tree(exprtestutil.JSONSecret("foo"))},
+						},
+						Env: opt.SampleConfigEnvironment{
+							"SECRET":  serialize.YAMLTree{Tree: parse.Tree(exprtestutil.JSONSecret("foo"))},
+							"MISSING": serialize.YAMLTree{Tree: parse.Tree(exprtestutil.JSONSecret("missing"))},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tokenMap := tokenGenerator.GenerateAll(ctx, sc)
+
+	previousTaskToken, found := tokenMap.ForStep("test", "previous-task")
+	require.True(t, found)
+
+	currentTaskToken, found := tokenMap.ForStep("test", "current-task")
+	require.True(t, found)
+
+	s := httptest.NewServer(api.NewHandler(sample.NewAuthenticator(sc, tokenGenerator.Key())))
+	defer s.Close()
+
+	u, err := url.Parse(s.URL)
+	require.NoError(t, err)
+
+	previous := client.NewClient(u, client.ClientWithToken(previousTaskToken))
+
+	// The token may also be read from a file, like a projected service
+	// account token.
+	tokenFile, err := ioutil.TempFile("", "relay-metadata-api-token-")
+	require.NoError(t, err)
+	defer os.Remove(tokenFile.Name())
+
+	_, err = tokenFile.WriteString(currentTaskToken + "\n")
+	require.NoError(t, err)
+	require.NoError(t, tokenFile.Close())
+
+	current := client.NewClient(u, client.ClientWithTokenFile(tokenFile.Name()))
+
+	// Outputs with names that need escaping.
+	require.NoError(t, previous.PutOutput(ctx, "output a+b", "foobar"))
+	require.NoError(t, previous.PutOutput(ctx, "typed/output", map[string]interface{}{"a": []interface{}{"b"}}))
+
+	output, err := current.GetOutput(ctx, "previous-task", "output a+b")
+	require.NoError(t, err)
+	require.Equal(t, "foobar", output.Value.Data)
+
+	outputs, err := current.ListStepOutputs(ctx, "previous-task")
+	require.NoError(t, err)
+	require.Len(t, outputs.Outputs, 2)
+	require.Equal(t, "typed/output", outputs.Outputs[1].Key)
+	require.Equal(t, map[string]interface{}{"a": []interface{}{"b"}}, outputs.Outputs[1].Value.Data)
+
+	require.NoError(t, previous.PutSensitiveOutput(ctx, "password", "hunter2"))
+
+	output, err = current.GetOutput(ctx, "previous-task", "password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", output.Value.Data)
+	require.True(t, output.Sensitive)
+
+	// Conditions.
+	conds, err := current.GetConditions(ctx, time.Second)
+	require.NoError(t, err)
+	require.True(t, conds.Success)
+
+	// Spec.
+	spec, err := current.GetSpec(ctx)
+	require.NoError(t, err)
+	require.True(t, spec.Complete)
+	require.Equal(t, map[string]interface{}{"secret": "bar"}, spec.Value.Data)
+
+	spec, err = current.QuerySpec(ctx, "jsonpath-template", "{.secret}")
+	require.NoError(t, err)
+	require.Equal(t, "bar", spec.Value.Data)
+
+	// Environment.
+	ev, err := current.GetEnvironmentVariable(ctx, "SECRET")
+	require.NoError(t, err)
+	require.True(t, ev.Complete)
+	require.Equal(t, "bar", ev.Value.Data)
+
+	ev, err = current.GetEnvironmentVariable(ctx, "MISSING")
+	require.NoError(t, err)
+	require.False(t, ev.Complete)
+	require.Len(t, ev.Unresolvable.Secrets, 1)
+	require.Equal(t, "missing", ev.Unresolvable.Secrets[0].Name)
+
+	// Secrets.
+	secrets, err := current.ListSecrets(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"foo"}, secrets.Keys)
+
+	secret, err := current.GetSecret(ctx, "foo")
+	require.NoError(t, err)
+	require.Equal(t, "bar", secret.Value.Data)
+
+	// State.
+	require.NoError(t, current.PutState(ctx, "count", 2))
+
+	state, err := current.GetState(ctx, "count")
+	require.NoError(t, err)
+	require.Equal(t, float64(2), state.Value.Data)
+
+	require.NoError(t, current.DeleteState(ctx, "count"))
+
+	// Errors are returned as metadata API errors.
+	_, err = current.GetState(ctx, "count")
+	require.Error(t, err)
+
+	rerr, ok := err.(errors.Error)
+	require.True(t, ok, "error %T is not a metadata API error", err)
+	require.True(t, errors.IsModelNotFoundError(rerr))
+}
@@ -134,6 +134,210 @@ func NewAPIMalformedRequestError() Error {
 	return NewAPIMalformedRequestErrorBuilder().Build()
 }
 
+// APIMalformedSensitiveErrorCode is the code for an instance of "malformed_sensitive_error".
+const APIMalformedSensitiveErrorCode = "rma_api_malformed_sensitive_error"
+
+// IsAPIMalformedSensitiveError tests whether a given error is an instance of "malformed_sensitive_error".
+func IsAPIMalformedSensitiveError(err errawr.Error) bool {
+	return err != nil && err.Is(APIMalformedSensitiveErrorCode)
+}
+
+// IsAPIMalformedSensitiveError tests whether a given error is an instance of "malformed_sensitive_error".
+func (External) IsAPIMalformedSensitiveError(err errawr.Error) bool {
+	return IsAPIMalformedSensitiveError(err)
+}
+
+// APIMalformedSensitiveErrorBuilder is a builder for "malformed_sensitive_error" errors.
+type APIMalformedSensitiveErrorBuilder struct {
+	arguments impl.ErrorArguments
+}
+
+// Build creates the error for the code "malformed_sensitive_error" from this builder.
+func (b *APIMalformedSensitiveErrorBuilder) Build() Error {
+	description := &impl.ErrorDescription{
+		Friendly:  "The sensitive flag {{quote sensitive}} is not a valid boolean value.",
+		Technical: "The sensitive flag {{quote sensitive}} is not a valid boolean value.",
+	}
+
+	return &impl.Error{
+		ErrorArguments:   b.arguments,
+		ErrorCode:        "malformed_sensitive_error",
+		ErrorDescription: description,
+		ErrorDomain:      Domain,
+		ErrorMetadata: &impl.ErrorMetadata{HTTPErrorMetadata: &impl.HTTPErrorMetadata{
+			ErrorHeaders: impl.HTTPErrorMetadataHeaders{},
+			ErrorStatus:  422,
+		}},
+		ErrorSection:     APISection,
+		ErrorSensitivity: errawr.ErrorSensitivityNone,
+		ErrorTitle:       "Malformed sensitive flag",
+		Version:          1,
+	}
+}
+
+// NewAPIMalformedSensitiveErrorBuilder creates a new error builder for the code "malformed_sensitive_error".
+func NewAPIMalformedSensitiveErrorBuilder(sensitive string) *APIMalformedSensitiveErrorBuilder {
+	return &APIMalformedSensitiveErrorBuilder{arguments: impl.ErrorArguments{"sensitive": impl.NewErrorArgument(sensitive, "the requested value of the sensitive flag")}}
+}
+
+// NewAPIMalformedSensitiveError creates a new error with the code "malformed_sensitive_error".
+func NewAPIMalformedSensitiveError(sensitive string) Error {
+	return NewAPIMalformedSensitiveErrorBuilder(sensitive).Build()
+}
+
+// APIMalformedWaitErrorCode is the code for an instance of "malformed_wait_error".
+const APIMalformedWaitErrorCode = "rma_api_malformed_wait_error"
+
+// IsAPIMalformedWaitError tests whether a given error is an instance of "malformed_wait_error".
+func IsAPIMalformedWaitError(err errawr.Error) bool {
+	return err != nil && err.Is(APIMalformedWaitErrorCode)
+}
+
+// IsAPIMalformedWaitError tests whether a given error is an instance of "malformed_wait_error".
+func (External) IsAPIMalformedWaitError(err errawr.Error) bool {
+	return IsAPIMalformedWaitError(err)
+}
+
+// APIMalformedWaitErrorBuilder is a builder for "malformed_wait_error" errors.
+type APIMalformedWaitErrorBuilder struct {
+	arguments impl.ErrorArguments
+}
+
+// Build creates the error for the code "malformed_wait_error" from this builder.
+func (b *APIMalformedWaitErrorBuilder) Build() Error {
+	description := &impl.ErrorDescription{
+		Friendly:  "The wait duration {{quote wait}} is not a valid, non-negative duration.",
+		Technical: "The wait duration {{quote wait}} is not a valid, non-negative duration.",
+	}
+
+	return &impl.Error{
+		ErrorArguments:   b.arguments,
+		ErrorCode:        "malformed_wait_error",
+		ErrorDescription: description,
+		ErrorDomain:      Domain,
+		ErrorMetadata: &impl.ErrorMetadata{HTTPErrorMetadata: &impl.HTTPErrorMetadata{
+			ErrorHeaders: impl.HTTPErrorMetadataHeaders{},
+			ErrorStatus:  422,
+		}},
+		ErrorSection:     APISection,
+		ErrorSensitivity: errawr.ErrorSensitivityNone,
+		ErrorTitle:       "Malformed wait duration",
+		Version:          1,
+	}
+}
+
+// NewAPIMalformedWaitErrorBuilder creates a new error builder for the code "malformed_wait_error".
+func NewAPIMalformedWaitErrorBuilder(wait string) *APIMalformedWaitErrorBuilder {
+	return &APIMalformedWaitErrorBuilder{arguments: impl.ErrorArguments{"wait": impl.NewErrorArgument(wait, "the requested wait duration")}}
+}
+
+// NewAPIMalformedWaitError creates a new error with the code "malformed_wait_error".
+func NewAPIMalformedWaitError(wait string) Error {
+	return NewAPIMalformedWaitErrorBuilder(wait).Build()
+}
+
+// APIRateLimitedErrorCode is the code for an instance of "rate_limited_error".
+const APIRateLimitedErrorCode = "rma_api_rate_limited_error"
+
+// IsAPIRateLimitedError tests whether a given error is an instance of "rate_limited_error".
+func IsAPIRateLimitedError(err errawr.Error) bool {
+	return err != nil && err.Is(APIRateLimitedErrorCode)
+}
+
+// IsAPIRateLimitedError tests whether a given error is an instance of "rate_limited_error".
+func (External) IsAPIRateLimitedError(err errawr.Error) bool {
+	return IsAPIRateLimitedError(err)
+}
+
+// APIRateLimitedErrorBuilder is a builder for "rate_limited_error" errors.
+type APIRateLimitedErrorBuilder struct {
+	arguments impl.ErrorArguments
+}
+
+// Build creates the error for the code "rate_limited_error" from this builder.
+func (b *APIRateLimitedErrorBuilder) Build() Error {
+	description := &impl.ErrorDescription{
+		Friendly:  "You have made too many requests. Try again in {{retryAfter}} seconds.",
+		Technical: "You have made too many requests. Try again in {{retryAfter}} seconds.",
+	}
+
+	return &impl.Error{
+		ErrorArguments:   b.arguments,
+		ErrorCode:        "rate_limited_error",
+		ErrorDescription: description,
+		ErrorDomain:      Domain,
+		ErrorMetadata: &impl.ErrorMetadata{HTTPErrorMetadata: &impl.HTTPErrorMetadata{
+			ErrorHeaders: impl.HTTPErrorMetadataHeaders{},
+			ErrorStatus:  429,
+		}},
+		ErrorSection:     APISection,
+		ErrorSensitivity: errawr.ErrorSensitivityNone,
+		ErrorTitle:       "Too many requests",
+		Version:          1,
+	}
+}
+
+// NewAPIRateLimitedErrorBuilder creates a new error builder for the code "rate_limited_error".
+func NewAPIRateLimitedErrorBuilder(retryAfter int64) *APIRateLimitedErrorBuilder {
+	return &APIRateLimitedErrorBuilder{arguments: impl.ErrorArguments{"retryAfter": impl.NewErrorArgument(retryAfter, "the number of seconds to wait before trying again")}}
+}
+
+// NewAPIRateLimitedError creates a new error with the code "rate_limited_error".
+func NewAPIRateLimitedError(retryAfter int64) Error {
+	return NewAPIRateLimitedErrorBuilder(retryAfter).Build()
+}
+
+// APIRequestTooLargeErrorCode is the code for an instance of "request_too_large_error".
+const APIRequestTooLargeErrorCode = "rma_api_request_too_large_error"
+
+// IsAPIRequestTooLargeError tests whether a given error is an instance of "request_too_large_error".
+func IsAPIRequestTooLargeError(err errawr.Error) bool {
+	return err != nil && err.Is(APIRequestTooLargeErrorCode)
+}
+
+// IsAPIRequestTooLargeError tests whether a given error is an instance of "request_too_large_error".
+func (External) IsAPIRequestTooLargeError(err errawr.Error) bool {
+	return IsAPIRequestTooLargeError(err)
+}
+
+// APIRequestTooLargeErrorBuilder is a builder for "request_too_large_error" errors.
+type APIRequestTooLargeErrorBuilder struct {
+	arguments impl.ErrorArguments
+}
+
+// Build creates the error for the code "request_too_large_error" from this builder.
+func (b *APIRequestTooLargeErrorBuilder) Build() Error {
+	description := &impl.ErrorDescription{
+		Friendly:  "The request body exceeds the maximum size of {{maxBytes}} bytes.",
+		Technical: "The request body exceeds the maximum size of {{maxBytes}} bytes.",
+	}
+
+	return &impl.Error{
+		ErrorArguments:   b.arguments,
+		ErrorCode:        "request_too_large_error",
+		ErrorDescription: description,
+		ErrorDomain:      Domain,
+		ErrorMetadata: &impl.ErrorMetadata{HTTPErrorMetadata: &impl.HTTPErrorMetadata{
+			ErrorHeaders: impl.HTTPErrorMetadataHeaders{},
+			ErrorStatus:  413,
+		}},
+		ErrorSection:     APISection,
+		ErrorSensitivity: errawr.ErrorSensitivityNone,
+		ErrorTitle:       "Request too large",
+		Version:          1,
+	}
+}
+
+// NewAPIRequestTooLargeErrorBuilder creates a new error builder for the code "request_too_large_error".
+func NewAPIRequestTooLargeErrorBuilder(maxBytes int64) *APIRequestTooLargeErrorBuilder {
+	return &APIRequestTooLargeErrorBuilder{arguments: impl.ErrorArguments{"maxBytes": impl.NewErrorArgument(maxBytes, "the maximum number of bytes allowed in the request body")}}
+}
+
+// NewAPIRequestTooLargeError creates a new error with the code "request_too_large_error".
+func NewAPIRequestTooLargeError(maxBytes int64) Error {
+	return NewAPIRequestTooLargeErrorBuilder(maxBytes).Build()
+}
+
 // APIUnknownRequestMediaTypeErrorCode is the code for an instance of "unknown_request_media_type_error".
 const APIUnknownRequestMediaTypeErrorCode = "rma_api_unknown_request_media_type_error"
 
@@ -458,6 +662,57 @@ func NewModelAuthorizationError() Error {
 	return NewModelAuthorizationErrorBuilder().Build()
 }
 
+// ModelEventPartiallyDeliveredErrorCode is the code for an instance of "event_partially_delivered_error".
+const ModelEventPartiallyDeliveredErrorCode = "rma_model_event_partially_delivered_error"
+
+// IsModelEventPartiallyDeliveredError tests whether a given error is an instance of "event_partially_delivered_error".
+func IsModelEventPartiallyDeliveredError(err errawr.Error) bool {
+	return err != nil && err.Is(ModelEventPartiallyDeliveredErrorCode)
+}
+
+// IsModelEventPartiallyDeliveredError tests whether a given error is an instance of "event_partially_delivered_error".
+func (External) IsModelEventPartiallyDeliveredError(err errawr.Error) bool {
+	return IsModelEventPartiallyDeliveredError(err)
+}
+
+// ModelEventPartiallyDeliveredErrorBuilder is a builder for "event_partially_delivered_error" errors.
+type ModelEventPartiallyDeliveredErrorBuilder struct {
+	arguments impl.ErrorArguments
+}
+
+// Build creates the error for the code "event_partially_delivered_error" from this builder.
+func (b *ModelEventPartiallyDeliveredErrorBuilder) Build() Error {
+	description := &impl.ErrorDescription{
+		Friendly:  "The event was delivered to some of its sinks, but could not be delivered to {{#enum sinks}}{{quote this}}{{/enum}}.",
+		Technical: "The event was delivered to some of its sinks, but could not be delivered to {{#enum sinks}}{{quote this}}{{/enum}}.",
+	}
+
+	return &impl.Error{
+		ErrorArguments:   b.arguments,
+		ErrorCode:        "event_partially_delivered_error",
+		ErrorDescription: description,
+		ErrorDomain:      Domain,
+		ErrorMetadata: &impl.ErrorMetadata{HTTPErrorMetadata: &impl.HTTPErrorMetadata{
+			ErrorHeaders: impl.HTTPErrorMetadataHeaders{},
+			ErrorStatus:  502,
+		}},
+		ErrorSection:     ModelSection,
+		ErrorSensitivity: errawr.ErrorSensitivityNone,
+		ErrorTitle:       "Event partially delivered",
+		Version:          1,
+	}
+}
+
+// NewModelEventPartiallyDeliveredErrorBuilder creates a new error builder for the code "event_partially_delivered_error".
+func NewModelEventPartiallyDeliveredErrorBuilder(sinks []string) *ModelEventPartiallyDeliveredErrorBuilder {
+	return &ModelEventPartiallyDeliveredErrorBuilder{arguments: impl.ErrorArguments{"sinks": impl.NewErrorArgument(sinks, "the names of the sinks the event could not be delivered to")}}
+}
+
+// NewModelEventPartiallyDeliveredError creates a new error with the code "event_partially_delivered_error".
+func NewModelEventPartiallyDeliveredError(sinks []string) Error {
+	return NewModelEventPartiallyDeliveredErrorBuilder(sinks).Build()
+}
+
 // ModelNotFoundErrorCode is the code for an instance of "not_found_error".
 const ModelNotFoundErrorCode = "rma_model_not_found_error"
 
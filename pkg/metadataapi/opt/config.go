@@ -8,7 +8,10 @@ import (
 	"os"
 
 	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/puppetlabs/horsehead/v2/storage"
 	"github.com/puppetlabs/relay-core/pkg/authenticate"
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/audit"
 	"github.com/spf13/viper"
 	yaml "gopkg.in/yaml.v3"
 	"k8s.io/client-go/kubernetes"
@@ -68,6 +71,11 @@ type Config struct {
 	// VaultAuthRole is the role to use when logging in as a tenant.
 	VaultAuthRole string
 
+	// VaultAuthAllowedRoles are patterns matching the roles tenants may
+	// request to log in with instead of VaultAuthRole. A trailing "*" allows
+	// every role with the given prefix.
+	VaultAuthAllowedRoles []string
+
 	// KubernetesURL is the the HTTP(S) URL to the Kubernetes cluster master.
 	KubernetesURL string
 
@@ -79,6 +87,30 @@ type Config struct {
 	// reading pod data.
 	KubernetesServiceAccountToken string
 
+	// StorageURL is the URL of the blob store to use for step outputs that
+	// are too large to keep in a run's config map. If empty, every output is
+	// stored in the config map.
+	StorageURL string
+
+	// StepOutputBlobThresholdBytes is the encoded size above which step
+	// outputs are stored in the blob store.
+	StepOutputBlobThresholdBytes int
+
+	// AuditSinkURL describes where to write a record of every authenticated
+	// request: "log:" for the application log, a file:// URL for a file, or
+	// an HTTP(S) URL for an endpoint that accepts the records as POST
+	// requests. If empty, requests are not recorded.
+	AuditSinkURL string
+
+	// AuditSinkQueueSize is the number of audit records held in memory while
+	// they wait to be written to the sink. Records are dropped once the queue
+	// is full.
+	AuditSinkQueueSize int
+
+	// MetricsServerBindAddr is the host:port to serve metrics on. If empty,
+	// metrics are not served.
+	MetricsServerBindAddr string
+
 	// SampleConfigFiles is a list of configuration files that configure this
 	// instance of the metadata API to serve sample data for demo or testing
 	// purposes.
@@ -184,6 +216,32 @@ func (c *Config) VaultTransitClient() (*vaultapi.Client, error) {
 	return client, nil
 }
 
+func (c *Config) BlobStore() (storage.BlobStore, error) {
+	if c.StorageURL == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(c.StorageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.NewBlobStore(*u)
+}
+
+func (c *Config) AuditSink() (audit.Sink, error) {
+	if c.AuditSinkURL == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(c.AuditSinkURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return audit.NewSinkFromURL(u)
+}
+
 func (c *Config) SampleConfig() (*SampleConfig, error) {
 	if len(c.SampleConfigFiles) == 0 {
 		return nil, nil
@@ -233,6 +291,11 @@ func NewConfig() *Config {
 	viper.SetDefault("vault_auth_url", viper.GetString("vault_addr"))
 	viper.SetDefault("vault_auth_path", "auth/jwt")
 
+	viper.SetDefault("step_output_blob_threshold_bytes", configmap.DefaultStepOutputBlobThresholdBytes)
+
+	viper.SetDefault("audit_sink_url", "log:")
+	viper.SetDefault("audit_sink_queue_size", audit.DefaultAsyncSinkQueueSize)
+
 	return &Config{
 		Debug:       viper.GetBool("debug"),
 		Environment: viper.GetString("environment"),
@@ -250,10 +313,20 @@ func NewConfig() *Config {
 		VaultAuthPath: viper.GetString("vault_auth_path"),
 		VaultAuthRole: viper.GetString("vault_auth_role"),
 
+		VaultAuthAllowedRoles: viper.GetStringSlice("vault_auth_allowed_roles"),
+
 		KubernetesURL:                 viper.GetString("kubernetes_url"),
 		KubernetesCAData:              viper.GetString("kubernetes_ca_data"),
 		KubernetesServiceAccountToken: viper.GetString("kubernetes_service_account_token"),
 
+		StorageURL:                   viper.GetString("storage_url"),
+		StepOutputBlobThresholdBytes: viper.GetInt("step_output_blob_threshold_bytes"),
+
+		AuditSinkURL:       viper.GetString("audit_sink_url"),
+		AuditSinkQueueSize: viper.GetInt("audit_sink_queue_size"),
+
+		MetricsServerBindAddr: viper.GetString("metrics_server_bind_addr"),
+
 		SampleConfigFiles:     viper.GetStringSlice("sample_config_files"),
 		SampleHS256SigningKey: viper.GetString("sample_hs256_signing_key"),
 
@@ -67,7 +67,15 @@ type SampleConfigRun struct {
 	Steps      map[string]*SampleConfigStep `yaml:"steps"`
 }
 
-type SampleConfigTrigger struct{}
+type SampleConfigTriggerLimits struct {
+	RequestsPerSecond int32 `yaml:"requestsPerSecond"`
+	Burst             int32 `yaml:"burst"`
+	MaxBodyBytes      int64 `yaml:"maxBodyBytes"`
+}
+
+type SampleConfigTrigger struct {
+	Limits *SampleConfigTriggerLimits `yaml:"limits"`
+}
 
 type SampleConfig struct {
 	Connections SampleConfigConnections         `yaml:"connections"`
@@ -6,18 +6,22 @@ import (
 
 	"github.com/puppetlabs/relay-core/pkg/authenticate"
 	"github.com/puppetlabs/relay-core/pkg/manager/builder"
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
 	mlog "github.com/puppetlabs/relay-core/pkg/manager/log"
 	"github.com/puppetlabs/relay-core/pkg/manager/memory"
+	"github.com/puppetlabs/relay-core/pkg/manager/reject"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/audit"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/opt"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/middleware"
 	"github.com/puppetlabs/relay-core/pkg/model"
 	"gopkg.in/square/go-jose.v2/jwt"
+	corev1 "k8s.io/api/core/v1"
 )
 
 type Authenticator struct {
 	sc   *opt.SampleConfig
 	key  interface{}
-	mgrs map[model.Hash]func(mgrs *builder.MetadataBuilder)
+	mgrs map[model.Hash]func(mgrs *builder.MetadataBuilder, verifier bool)
 }
 
 var _ middleware.Authenticator = &Authenticator{}
@@ -26,6 +30,9 @@ func (a *Authenticator) Authenticate(r *http.Request) (*middleware.Credential, e
 	mgrs := builder.NewMetadataBuilder()
 	mgrs.SetEvents(mlog.EventManager)
 
+	var limits *middleware.Limits
+	var identity *audit.Identity
+
 	auth := authenticate.NewAuthenticator(
 		authenticate.NewHTTPAuthorizationHeaderIntermediary(r),
 		authenticate.NewKeyResolver(
@@ -38,8 +45,6 @@ func (a *Authenticator) Authenticate(r *http.Request) (*middleware.Credential, e
 			mgrs.SetConnections(memory.NewConnectionManager(a.sc.Connections))
 			mgrs.SetSecrets(memory.NewSecretManager(a.sc.Secrets))
 
-			// TODO: Add support for triggers!
-
 			model.IfStep(claims.Action(), func(step *model.Step) {
 				cfg, found := a.mgrs[step.Hash()]
 				if !found {
@@ -47,9 +52,18 @@ func (a *Authenticator) Authenticate(r *http.Request) (*middleware.Credential, e
 					return
 				}
 
-				cfg(mgrs)
+				cfg(mgrs, false)
 			})
 
+			model.IfTrigger(claims.Action(), func(trigger *model.Trigger) {
+				if cfg, found := a.mgrs[trigger.Hash()]; found {
+					cfg(mgrs, claims.RelayWebhookVerifier)
+				}
+			})
+
+			limits = middleware.LimitsFromClaims(claims)
+			identity = audit.IdentityFromClaims(claims)
+
 			return nil
 		})),
 	)
@@ -62,6 +76,8 @@ func (a *Authenticator) Authenticate(r *http.Request) (*middleware.Credential, e
 
 	return &middleware.Credential{
 		Managers: mgrs.Build(),
+		Limits:   limits,
+		Identity: identity,
 	}, nil
 }
 
@@ -69,13 +85,14 @@ func NewAuthenticator(sc *opt.SampleConfig, key interface{}) *Authenticator {
 	a := &Authenticator{
 		sc:   sc,
 		key:  key,
-		mgrs: make(map[model.Hash]func(mgrs *builder.MetadataBuilder)),
+		mgrs: make(map[model.Hash]func(mgrs *builder.MetadataBuilder, verifier bool)),
 	}
 
 	// Pre-build managers so that changes persist across HTTP requests.
 	for id, sc := range sc.Runs {
 		run := model.Run{ID: id}
-		som := memory.NewStepOutputMap()
+		changes := memory.NewChangeManager()
+		som := memory.NewStepOutputMap(memory.StepOutputMapWithChangeManager(changes))
 
 		parameterManager := memory.NewParameterManager(memory.ParameterManagerWithInitialParameters(sc.Parameters))
 
@@ -106,7 +123,9 @@ func NewAuthenticator(sc *opt.SampleConfig, key interface{}) *Authenticator {
 
 			specManager := memory.NewSpecManager(specOpts...)
 
-			var stateOpts []memory.StateManagerOption
+			stateOpts := []memory.StateManagerOption{
+				memory.StateManagerWithChangeManager(changes),
+			}
 			if sc.State != nil {
 				stateOpts = append(stateOpts, memory.StateManagerWithInitialState(sc.State))
 			}
@@ -119,7 +138,8 @@ func NewAuthenticator(sc *opt.SampleConfig, key interface{}) *Authenticator {
 
 			stepOutputManager := memory.NewStepOutputManager(step, som)
 
-			a.mgrs[step.Hash()] = func(mgrs *builder.MetadataBuilder) {
+			a.mgrs[step.Hash()] = func(mgrs *builder.MetadataBuilder, verifier bool) {
+				mgrs.SetChanges(changes)
 				mgrs.SetConditions(conditionManager)
 				mgrs.SetEnvironment(environmentManager)
 				mgrs.SetParameters(parameterManager)
@@ -130,5 +150,25 @@ func NewAuthenticator(sc *opt.SampleConfig, key interface{}) *Authenticator {
 		}
 	}
 
+	for name := range sc.Triggers {
+		trigger := &model.Trigger{Name: name}
+
+		webhookDeliveryManager := configmap.NewWebhookDeliveryManager(configmap.NewLocalConfigMap(&corev1.ConfigMap{}))
+		webhookRequestManager := configmap.NewWebhookRequestManager(configmap.NewLocalConfigMap(&corev1.ConfigMap{}), 0)
+
+		a.mgrs[trigger.Hash()] = func(mgrs *builder.MetadataBuilder, verifier bool) {
+			// Like the Kubernetes authenticator, only the webhook verifier may
+			// report requests.
+			if verifier {
+				mgrs.SetWebhookDeliveries(webhookDeliveryManager)
+				mgrs.SetWebhookRequests(webhookRequestManager)
+				return
+			}
+
+			mgrs.SetWebhookDeliveries(reject.NewWebhookDeliveryEventManager(webhookDeliveryManager))
+			mgrs.SetWebhookRequests(reject.NewWebhookRequestEventManager(webhookRequestManager))
+		}
+	}
+
 	return a
 }
@@ -18,8 +18,9 @@ type stepTokenEntry struct {
 }
 
 type TokenMap struct {
-	steps    map[stepTokenEntry]string
-	triggers map[string]string
+	steps            map[stepTokenEntry]string
+	triggers         map[string]string
+	webhookVerifiers map[string]string
 }
 
 func (tm *TokenMap) ForStep(runID, stepName string) (tok string, found bool) {
@@ -32,6 +33,11 @@ func (tm *TokenMap) ForTrigger(triggerName string) (tok string, found bool) {
 	return
 }
 
+func (tm *TokenMap) ForWebhookVerifier(triggerName string) (tok string, found bool) {
+	tok, found = tm.webhookVerifiers[triggerName]
+	return
+}
+
 type TokenGenerator struct {
 	key    interface{}
 	issuer authenticate.Issuer
@@ -43,8 +49,9 @@ func (tg *TokenGenerator) Key() interface{} {
 
 func (tg *TokenGenerator) GenerateAll(ctx context.Context, sc *opt.SampleConfig) *TokenMap {
 	m := &TokenMap{
-		steps:    make(map[stepTokenEntry]string),
-		triggers: make(map[string]string),
+		steps:            make(map[stepTokenEntry]string),
+		triggers:         make(map[string]string),
+		webhookVerifiers: make(map[string]string),
 	}
 
 	for id, run := range sc.Runs {
@@ -72,7 +79,7 @@ func (tg *TokenGenerator) GenerateAll(ctx context.Context, sc *opt.SampleConfig)
 		}
 	}
 
-	for name := range sc.Triggers {
+	for name, trigger := range sc.Triggers {
 		tm := &model.Trigger{Name: name}
 
 		claims := &authenticate.Claims{
@@ -83,6 +90,12 @@ func (tg *TokenGenerator) GenerateAll(ctx context.Context, sc *opt.SampleConfig)
 			RelayName: tm.Name,
 		}
 
+		if trigger != nil && trigger.Limits != nil {
+			claims.RelayLimitsRequestsPerSecond = trigger.Limits.RequestsPerSecond
+			claims.RelayLimitsBurst = trigger.Limits.Burst
+			claims.RelayLimitsMaxBodyBytes = trigger.Limits.MaxBodyBytes
+		}
+
 		tok, err := tg.issuer.Issue(ctx, claims)
 		if err != nil {
 			log().Error("failed to generate token for trigger", "trigger-name", tm.Name, "error", err)
@@ -90,6 +103,23 @@ func (tg *TokenGenerator) GenerateAll(ctx context.Context, sc *opt.SampleConfig)
 
 		m.triggers[tm.Name] = string(tok)
 		log().Info("generated JWT for trigger", "trigger-name", tm.Name, "token", string(tok))
+
+		vclaims := &authenticate.Claims{
+			Claims: &jwt.Claims{
+				Audience: jwt.Audience{authenticate.MetadataAPIAudienceV1},
+				Subject:  claims.Subject,
+			},
+			RelayName:            tm.Name,
+			RelayWebhookVerifier: true,
+		}
+
+		vtok, err := tg.issuer.Issue(ctx, vclaims)
+		if err != nil {
+			log().Error("failed to generate token for webhook verifier", "trigger-name", tm.Name, "error", err)
+		}
+
+		m.webhookVerifiers[tm.Name] = string(vtok)
+		log().Info("generated JWT for webhook verifier", "trigger-name", tm.Name, "token", string(vtok))
 	}
 
 	return m
@@ -0,0 +1,85 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/audit"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/opt"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/sample"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/api"
+	"github.com/stretchr/testify/require"
+)
+
+type testAuditSink struct {
+	records []*audit.Record
+}
+
+func (tas *testAuditSink) Write(ctx context.Context, rec *audit.Record) error {
+	tas.records = append(tas.records, rec)
+	return nil
+}
+
+func TestAudit(t *testing.T) {
+	ctx := context.Background()
+
+	tokenGenerator, err := sample.NewHS256TokenGenerator(nil)
+	require.NoError(t, err)
+
+	sc := &opt.SampleConfig{
+		Secrets: map[string]string{
+			"my secret": "hunter2",
+		},
+		Runs: map[string]*opt.SampleConfigRun{
+			"test": &opt.SampleConfigRun{
+				Steps: map[string]*opt.SampleConfigStep{
+					"test-task": &opt.SampleConfigStep{},
+				},
+			},
+		},
+	}
+
+	tokenMap := tokenGenerator.GenerateAll(ctx, sc)
+
+	testTaskToken, found := tokenMap.ForStep("test", "test-task")
+	require.True(t, found)
+
+	sink := &testAuditSink{}
+	h := api.NewHandler(sample.NewAuthenticator(sc, tokenGenerator.Key()), api.ServerWithAuditSink(sink))
+
+	request := func(path, token string) {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	request("/secrets/my%20secret", testTaskToken)
+	request("/secrets/nonexistent", testTaskToken)
+
+	// Unauthenticated requests are not recorded.
+	request("/secrets/my%20secret", "")
+
+	require.Len(t, sink.records, 2)
+
+	rec := sink.records[0]
+	require.Equal(t, "test", rec.Identity.RunID)
+	require.Equal(t, "step", rec.Identity.ActionType)
+	require.Equal(t, "test-task", rec.Identity.ActionName)
+	require.Equal(t, http.MethodGet, rec.Method)
+	require.Equal(t, "/secrets/{name}", rec.Route)
+	require.Equal(t, map[string]string{"name": "my secret"}, rec.Resource)
+	require.Equal(t, http.StatusOK, rec.StatusCode)
+	require.Equal(t, audit.OutcomeSuccess, rec.Outcome)
+	require.False(t, rec.Time.IsZero())
+
+	rec = sink.records[1]
+	require.Equal(t, map[string]string{"name": "nonexistent"}, rec.Resource)
+	require.Equal(t, http.StatusNotFound, rec.StatusCode)
+	require.Equal(t, audit.OutcomeFailure, rec.Outcome)
+}
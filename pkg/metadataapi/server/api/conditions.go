@@ -1,16 +1,27 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	utilapi "github.com/puppetlabs/horsehead/v2/httputil/api"
 	"github.com/puppetlabs/relay-core/pkg/expr/evaluate"
 	"github.com/puppetlabs/relay-core/pkg/manager/resolve"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/errors"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/middleware"
+	"github.com/puppetlabs/relay-core/pkg/model"
 )
 
+// MaxConditionsWait is the longest a request for conditions may block waiting
+// for them to become resolvable.
+const MaxConditionsWait = 5 * time.Minute
+
+// ConditionsPollInterval is how often a request waiting for conditions checks
+// them again when changes to the data backing them can't be watched.
+var ConditionsPollInterval = 5 * time.Second
+
 type GetConditionsResponseEnvelope struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
@@ -20,14 +31,90 @@ func (s *Server) GetConditions(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	managers := middleware.Managers(r)
-	cm := managers.Conditions()
 
-	cond, err := cm.Get(ctx)
-	if err != nil {
-		utilapi.WriteError(ctx, w, ModelReadError(err))
+	var wait time.Duration
+	if ws := r.URL.Query().Get("wait"); ws != "" {
+		d, err := time.ParseDuration(ws)
+		if err != nil || d < 0 {
+			utilapi.WriteError(ctx, w, errors.NewAPIMalformedWaitError(ws))
+			return
+		} else if d > MaxConditionsWait {
+			d = MaxConditionsWait
+		}
+
+		wait = d
+	}
+
+	if wait == 0 {
+		resp, err := evaluateConditions(ctx, managers)
+		if err != nil {
+			utilapi.WriteError(ctx, w, err)
+			return
+		}
+
+		utilapi.WriteObjectOK(ctx, w, resp)
 		return
 	}
 
+	wctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	// Start watching before the first evaluation so that we don't miss any
+	// changes made while evaluating. If the watch can't be established, or it
+	// ends early, we poll instead so that waiting never depends on it.
+	var poll <-chan time.Time
+	startPolling := func() {
+		t := time.NewTicker(ConditionsPollInterval)
+		poll = t.C
+
+		go func() {
+			<-wctx.Done()
+			t.Stop()
+		}()
+	}
+
+	changes, werr := managers.Changes().Watch(wctx)
+	if werr != nil {
+		log(ctx).Warn("failed to watch for changes, polling instead", "error", werr)
+		startPolling()
+	}
+
+	for {
+		resp, err := evaluateConditions(ctx, managers)
+		if err == nil || !errors.IsExpressionUnresolvableError(err) {
+			if err != nil {
+				utilapi.WriteError(ctx, w, err)
+			} else {
+				utilapi.WriteObjectOK(ctx, w, resp)
+			}
+			return
+		}
+
+		// The conditions can't be resolved yet, so we wait for the data
+		// backing them to change and try again.
+		select {
+		case _, ok := <-changes:
+			if !ok {
+				changes = nil
+				startPolling()
+			}
+			continue
+		case <-poll:
+			continue
+		case <-wctx.Done():
+		}
+
+		utilapi.WriteError(ctx, w, err)
+		return
+	}
+}
+
+func evaluateConditions(ctx context.Context, managers model.MetadataManagers) (*GetConditionsResponseEnvelope, errors.Error) {
+	cond, err := managers.Conditions().Get(ctx)
+	if err != nil {
+		return nil, ModelReadError(err)
+	}
+
 	ev := evaluate.NewEvaluator(
 		evaluate.WithParameterTypeResolver(resolve.NewParameterTypeResolver(managers.Parameters())),
 		evaluate.WithSecretTypeResolver(resolve.NewSecretTypeResolver(managers.Secrets())),
@@ -37,8 +124,7 @@ func (s *Server) GetConditions(w http.ResponseWriter, r *http.Request) {
 
 	rv, rerr := ev.EvaluateAll(ctx, cond.Tree)
 	if rerr != nil {
-		utilapi.WriteError(ctx, w, errors.NewExpressionEvaluationError(rerr.Error()).Bug())
-		return
+		return nil, errors.NewExpressionEvaluationError(rerr.Error()).Bug()
 	}
 
 	var failed bool
@@ -52,8 +138,7 @@ check:
 			result, ok := cond.(bool)
 			if !ok {
 				if rv.Complete() {
-					utilapi.WriteError(ctx, w, errors.NewConditionTypeError(fmt.Sprintf("%T", cond)))
-					return
+					return nil, errors.NewConditionTypeError(fmt.Sprintf("%T", cond))
 				}
 				continue
 			}
@@ -64,17 +149,15 @@ check:
 			}
 		}
 	default:
-		utilapi.WriteError(ctx, w, errors.NewConditionTypeError(fmt.Sprintf("%T", vt)))
-		return
+		return nil, errors.NewConditionTypeError(fmt.Sprintf("%T", vt))
 	}
 
-	var resp GetConditionsResponseEnvelope
+	resp := &GetConditionsResponseEnvelope{}
 
 	if failed {
 		resp.Success = false
 		resp.Message = "one or more conditions failed"
-		utilapi.WriteObjectOK(ctx, w, resp)
-		return
+		return resp, nil
 	}
 
 	// Not being complete means there are unresolved "expressions" for this tree. These can include
@@ -83,7 +166,7 @@ check:
 		uerr, ok := rv.Unresolvable.AsError().(*evaluate.UnresolvableError)
 		if !ok {
 			// This should never happen.
-			utilapi.WriteError(ctx, w, errors.NewModelReadError().WithCause(uerr).Bug())
+			return nil, errors.NewModelReadError().WithCause(uerr).Bug()
 		}
 
 		causes := make([]string, len(uerr.Causes))
@@ -91,12 +174,11 @@ check:
 			causes[i] = cause.Error()
 		}
 
-		utilapi.WriteError(ctx, w, errors.NewExpressionUnresolvableError(causes))
-		return
+		return nil, errors.NewExpressionUnresolvableError(causes)
 	}
 
 	resp.Success = true
 	resp.Message = "all checks passed"
 
-	utilapi.WriteObjectOK(ctx, w, resp)
+	return resp, nil
 }
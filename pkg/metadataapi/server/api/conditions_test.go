@@ -7,15 +7,19 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/puppetlabs/errawr-go/v2/pkg/errawr"
 	"github.com/puppetlabs/relay-core/pkg/expr/parse"
 	"github.com/puppetlabs/relay-core/pkg/expr/serialize"
 	exprtestutil "github.com/puppetlabs/relay-core/pkg/expr/testutil"
+	"github.com/puppetlabs/relay-core/pkg/manager/reject"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/errors"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/opt"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/sample"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/api"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/middleware"
+	"github.com/puppetlabs/relay-core/pkg/model"
 	"github.com/puppetlabs/relay-core/pkg/util/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -166,3 +170,182 @@ func TestGetConditions(t *testing.T) {
 		})
 	}
 }
+
+func TestGetConditionsWait(t *testing.T) {
+	ctx := context.Background()
+
+	tokenGenerator, err := sample.NewHS256TokenGenerator(nil)
+	require.NoError(t, err)
+
+	sc := &opt.SampleConfig{
+		Runs: map[string]*opt.SampleConfigRun{
+			"test": &opt.SampleConfigRun{
+				Steps: map[string]*opt.SampleConfigStep{
+					"previous-task": &opt.SampleConfigStep{},
+					"current-task": &opt.SampleConfigStep{
+						Conditions: serialize.YAMLTree{
+							Tree: []interface{}{
+								exprtestutil.JSONInvocation("equals", []interface{}{
+									exprtestutil.JSONOutput("previous-task", "output1"),
+									"foobar",
+								}),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tokenMap := tokenGenerator.GenerateAll(ctx, sc)
+
+	previousTaskToken, found := tokenMap.ForStep("test", "previous-task")
+	require.True(t, found)
+
+	currentTaskToken, found := tokenMap.ForStep("test", "current-task")
+	require.True(t, found)
+
+	h := api.NewHandler(sample.NewAuthenticator(sc, tokenGenerator.Key()))
+
+	getConditions := func(wait string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, "/conditions?wait="+wait, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+currentTaskToken)
+
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		return resp.Result()
+	}
+
+	// An invalid duration is rejected.
+	testutil.RequireErrorResponse(t, errors.NewAPIMalformedWaitError("soon"), getConditions("soon"))
+
+	// The deadline passes before the output is set.
+	testutil.RequireErrorResponse(t, errors.NewExpressionUnresolvableError([]string{
+		`resolve: output "output1" of step "previous-task" could not be found`,
+	}), getConditions("50ms"))
+
+	// The output is set while the request is waiting.
+	result := make(chan *http.Response, 1)
+	go func() {
+		result <- getConditions("30s")
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	req, err := http.NewRequest(http.MethodPut, "/outputs/output1", strings.NewReader("foobar"))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+previousTaskToken)
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusCreated, resp.Result().StatusCode)
+
+	select {
+	case r := <-result:
+		require.Equal(t, http.StatusOK, r.StatusCode)
+
+		var env api.GetConditionsResponseEnvelope
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&env))
+		require.True(t, env.Success)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "timed out waiting for conditions")
+	}
+}
+
+type unwatchableManagers struct {
+	model.MetadataManagers
+}
+
+func (unwatchableManagers) Changes() model.ChangeManager {
+	return reject.ChangeManager
+}
+
+type unwatchableAuthenticator struct {
+	delegate middleware.Authenticator
+}
+
+func (ua *unwatchableAuthenticator) Authenticate(r *http.Request) (*middleware.Credential, error) {
+	cred, err := ua.delegate.Authenticate(r)
+	if err != nil || cred == nil {
+		return cred, err
+	}
+
+	cred.Managers = unwatchableManagers{cred.Managers}
+	return cred, nil
+}
+
+func TestGetConditionsWaitWithoutWatch(t *testing.T) {
+	ctx := context.Background()
+
+	prev := api.ConditionsPollInterval
+	api.ConditionsPollInterval = 10 * time.Millisecond
+	defer func() { api.ConditionsPollInterval = prev }()
+
+	tokenGenerator, err := sample.NewHS256TokenGenerator(nil)
+	require.NoError(t, err)
+
+	sc := &opt.SampleConfig{
+		Runs: map[string]*opt.SampleConfigRun{
+			"test": &opt.SampleConfigRun{
+				Steps: map[string]*opt.SampleConfigStep{
+					"previous-task": &opt.SampleConfigStep{},
+					"current-task": &opt.SampleConfigStep{
+						Conditions: serialize.YAMLTree{
+							Tree: []interface{}{
+								exprtestutil.JSONInvocation("equals", []interface{}{
+									exprtestutil.JSONOutput("previous-task", "output1"),
+									"foobar",
+								}),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tokenMap := tokenGenerator.GenerateAll(ctx, sc)
+
+	previousTaskToken, found := tokenMap.ForStep("test", "previous-task")
+	require.True(t, found)
+
+	currentTaskToken, found := tokenMap.ForStep("test", "current-task")
+	require.True(t, found)
+
+	// Changes can't be watched, as when the metadata API is not allowed to,
+	// so the request falls back to polling.
+	h := api.NewHandler(&unwatchableAuthenticator{delegate: sample.NewAuthenticator(sc, tokenGenerator.Key())})
+
+	result := make(chan *http.Response, 1)
+	go func() {
+		req, err := http.NewRequest(http.MethodGet, "/conditions?wait=30s", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+currentTaskToken)
+
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		result <- resp.Result()
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	req, err := http.NewRequest(http.MethodPut, "/outputs/output1", strings.NewReader("foobar"))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+previousTaskToken)
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusCreated, resp.Result().StatusCode)
+
+	select {
+	case r := <-result:
+		require.Equal(t, http.StatusOK, r.StatusCode)
+
+		var env api.GetConditionsResponseEnvelope
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&env))
+		require.True(t, env.Success)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "timed out waiting for conditions")
+	}
+}
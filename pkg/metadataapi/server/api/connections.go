@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	utilapi "github.com/puppetlabs/horsehead/v2/httputil/api"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/middleware"
+)
+
+type ListConnectionsResponseEnvelope struct {
+	Type  string   `json:"type"`
+	Names []string `json:"names"`
+}
+
+func (s *Server) ListConnections(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	managers := middleware.Managers(r)
+	cm := managers.Connections()
+
+	typ, _ := middleware.Var(r, "type")
+
+	names, err := cm.List(ctx, typ)
+	if err != nil {
+		utilapi.WriteError(ctx, w, ModelReadError(err))
+		return
+	}
+
+	env := &ListConnectionsResponseEnvelope{
+		Type:  typ,
+		Names: append([]string{}, names...),
+	}
+
+	utilapi.WriteObjectOK(ctx, w, env)
+}
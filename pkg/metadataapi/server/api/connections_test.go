@@ -0,0 +1,84 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/puppetlabs/relay-core/pkg/manager/memory"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/opt"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/sample"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListConnections(t *testing.T) {
+	ctx := context.Background()
+
+	tokenGenerator, err := sample.NewHS256TokenGenerator(nil)
+	require.NoError(t, err)
+
+	sc := &opt.SampleConfig{
+		Connections: opt.SampleConfigConnections{
+			memory.ConnectionKey{Type: "aws", Name: "prod"}: map[string]interface{}{
+				"accessKeyID": "AKIA123456",
+			},
+			memory.ConnectionKey{Type: "aws", Name: "dev"}: map[string]interface{}{
+				"accessKeyID": "AKIA654321",
+			},
+			memory.ConnectionKey{Type: "gcp", Name: "prod"}: map[string]interface{}{
+				"serviceAccountInfo": "{}",
+			},
+		},
+		Runs: map[string]*opt.SampleConfigRun{
+			"test": &opt.SampleConfigRun{
+				Steps: map[string]*opt.SampleConfigStep{
+					"test-task": &opt.SampleConfigStep{},
+				},
+			},
+		},
+	}
+
+	tokenMap := tokenGenerator.GenerateAll(ctx, sc)
+
+	testTaskToken, found := tokenMap.ForStep("test", "test-task")
+	require.True(t, found)
+
+	h := api.NewHandler(sample.NewAuthenticator(sc, tokenGenerator.Key()))
+
+	tests := []struct {
+		Type          string
+		ExpectedNames []string
+	}{
+		{
+			Type:          "aws",
+			ExpectedNames: []string{"dev", "prod"},
+		},
+		{
+			Type:          "gcp",
+			ExpectedNames: []string{"prod"},
+		},
+		{
+			Type:          "azure",
+			ExpectedNames: []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Type, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/connections/"+test.Type, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+testTaskToken)
+
+			resp := httptest.NewRecorder()
+			h.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Result().StatusCode)
+
+			var env api.ListConnectionsResponseEnvelope
+			require.NoError(t, json.NewDecoder(resp.Result().Body).Decode(&env))
+			require.Equal(t, test.Type, env.Type)
+			require.Equal(t, test.ExpectedNames, env.Names)
+		})
+	}
+}
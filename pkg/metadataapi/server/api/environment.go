@@ -41,6 +41,9 @@ func (s *Server) GetEnvironmentVariable(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Steps already rely on this response being the evaluation result as is,
+	// with the field names of evaluate.Result, so unlike GetEnvironment we do
+	// not wrap it in a JSONResultEnvelope.
 	utilapi.WriteObjectOK(ctx, w, rv)
 }
 
@@ -103,8 +103,13 @@ func TestGetEnvironment(t *testing.T) {
 	h.ServeHTTP(resp, req)
 	require.Equal(t, http.StatusOK, resp.Result().StatusCode)
 
-	require.NoError(t, json.NewDecoder(resp.Result().Body).Decode(&r))
-	require.Equal(t, "test-secret-value", r.Value.Data)
+	// A single environment variable is returned as an evaluation result, not
+	// as a JSONResultEnvelope.
+	var raw map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Result().Body).Decode(&raw))
+	require.Equal(t, "test-secret-value", raw["Value"])
+	require.Contains(t, raw, "Unresolvable")
+	require.NotContains(t, raw, "complete")
 
 	req, err = http.NewRequest(http.MethodGet, "/environment/test-environment-variable-from-output", nil)
 	require.NoError(t, err)
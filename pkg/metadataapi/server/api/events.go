@@ -3,11 +3,14 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/puppetlabs/horsehead/v2/encoding/transfer"
 	utilapi "github.com/puppetlabs/horsehead/v2/httputil/api"
+	"github.com/puppetlabs/relay-core/pkg/manager/fanout"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/errors"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/middleware"
+	"github.com/puppetlabs/relay-core/pkg/model"
 )
 
 type PostEventRequestEnvelope struct {
@@ -32,7 +35,28 @@ func (s *Server) PostEvent(w http.ResponseWriter, r *http.Request) {
 		data[k] = v.Data
 	}
 
-	if _, err := em.Emit(ctx, data, env.Key); err != nil {
+	ev, err := em.Emit(ctx, data, env.Key)
+
+	// Update the delivery statistics of the trigger regardless of whether the
+	// event was emitted.
+	if derr := managers.WebhookDeliveries().RecordEvent(ctx, time.Now(), err); derr != nil && derr != model.ErrRejected {
+		log(ctx).Warn("failed to record event delivery", "error", derr)
+	}
+
+	// If requests to the trigger are being captured, note the event so it can
+	// be attached to the request that caused it. The event has already been
+	// emitted, at least to some sinks, so failing to note it must not fail the
+	// request.
+	if ev != nil {
+		if err := managers.WebhookRequests().RecordEvent(ctx, ev, r.Header.Get(model.WebhookRequestIDHeader), time.Now()); err != nil && err != model.ErrRejected {
+			log(ctx).Warn("failed to record event for captured webhook request", "error", err)
+		}
+	}
+
+	if eerr, ok := err.(*fanout.EventError); ok && eerr.Partial {
+		utilapi.WriteError(ctx, w, errors.NewModelEventPartiallyDeliveredError(eerr.Sinks()).WithCause(err))
+		return
+	} else if err != nil {
 		utilapi.WriteError(ctx, w, ModelWriteError(err))
 		return
 	}
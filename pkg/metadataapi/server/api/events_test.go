@@ -10,6 +10,7 @@ import (
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/opt"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/sample"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/api"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -40,3 +41,79 @@ func TestPostEvent(t *testing.T) {
 	h.ServeHTTP(resp, req)
 	require.Equal(t, http.StatusAccepted, resp.Result().StatusCode)
 }
+
+func TestPostEventFromStep(t *testing.T) {
+	ctx := context.Background()
+
+	tokenGenerator, err := sample.NewHS256TokenGenerator(nil)
+	require.NoError(t, err)
+
+	sc := &opt.SampleConfig{
+		Runs: map[string]*opt.SampleConfigRun{
+			"test": &opt.SampleConfigRun{
+				Steps: map[string]*opt.SampleConfigStep{
+					"deploy": &opt.SampleConfigStep{},
+				},
+			},
+		},
+	}
+
+	tokenMap := tokenGenerator.GenerateAll(ctx, sc)
+
+	stepToken, found := tokenMap.ForStep("test", "deploy")
+	require.True(t, found)
+
+	h := api.NewHandler(sample.NewAuthenticator(sc, tokenGenerator.Key()))
+
+	req, err := http.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"data":{"environment":"staging"}}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+stepToken)
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusAccepted, resp.Result().StatusCode)
+}
+
+func TestPostEventLimits(t *testing.T) {
+	ctx := context.Background()
+
+	tokenGenerator, err := sample.NewHS256TokenGenerator(nil)
+	require.NoError(t, err)
+
+	sc := &opt.SampleConfig{
+		Triggers: map[string]*opt.SampleConfigTrigger{
+			"test": &opt.SampleConfigTrigger{
+				Limits: &opt.SampleConfigTriggerLimits{
+					RequestsPerSecond: 1,
+					Burst:             2,
+					MaxBodyBytes:      32,
+				},
+			},
+		},
+	}
+
+	tokenMap := tokenGenerator.GenerateAll(ctx, sc)
+
+	triggerToken, found := tokenMap.ForTrigger("test")
+	require.True(t, found)
+
+	h := api.NewHandler(sample.NewAuthenticator(sc, tokenGenerator.Key()))
+
+	post := func(body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+triggerToken)
+
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		return resp.Result()
+	}
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(`{"data":{"foo":"`+strings.Repeat("a", 32)+`"}}`).StatusCode)
+	assert.Equal(t, http.StatusAccepted, post(`{"data":{"foo":"bar"}}`).StatusCode)
+
+	// The burst is used up by now.
+	resp := post(`{"data":{"foo":"bar"}}`)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+}
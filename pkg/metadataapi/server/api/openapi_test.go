@@ -0,0 +1,97 @@
+package api_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+type openAPIDocument struct {
+	Paths      map[string]map[string]interface{} `yaml:"paths"`
+	Components struct {
+		Schemas struct {
+			Error struct {
+				Properties struct {
+					Code struct {
+						Enum []string `yaml:"enum"`
+					} `yaml:"code"`
+				} `yaml:"properties"`
+			} `yaml:"Error"`
+		} `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+type errorsDocument struct {
+	Domain struct {
+		Key string `yaml:"key"`
+	} `yaml:"domain"`
+	Sections map[string]struct {
+		Errors map[string]interface{} `yaml:"errors"`
+	} `yaml:"sections"`
+}
+
+func TestOpenAPIDocumentIsComplete(t *testing.T) {
+	b, err := ioutil.ReadFile("../../openapi.yaml")
+	require.NoError(t, err)
+
+	var doc openAPIDocument
+	require.NoError(t, yaml.Unmarshal(b, &doc))
+
+	// Every route must be documented.
+	r := mux.NewRouter()
+	api.NewServer(nil).Route(r)
+
+	documented := make(map[string]bool)
+	require.NoError(t, r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		require.NoError(t, err)
+
+		methods, err := route.GetMethods()
+		require.NoError(t, err)
+
+		for _, method := range methods {
+			key := fmt.Sprintf("%s %s", method, tpl)
+			documented[key] = true
+
+			_, found := doc.Paths[tpl][strings.ToLower(method)]
+			assert.True(t, found, "route %s is not documented", key)
+		}
+
+		return nil
+	}))
+
+	// Every documented operation must exist.
+	for path, item := range doc.Paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+
+			key := fmt.Sprintf("%s %s", strings.ToUpper(method), path)
+			assert.True(t, documented[key], "documented operation %s does not exist", key)
+		}
+	}
+
+	// Every error code must be documented.
+	b, err = ioutil.ReadFile("../../errors/errors.yaml")
+	require.NoError(t, err)
+
+	var errs errorsDocument
+	require.NoError(t, yaml.Unmarshal(b, &errs))
+
+	var codes []string
+	for sk, section := range errs.Sections {
+		for ek := range section.Errors {
+			codes = append(codes, fmt.Sprintf("%s_%s_%s", errs.Domain.Key, sk, ek))
+		}
+	}
+
+	assert.ElementsMatch(t, codes, doc.Components.Schemas.Error.Properties.Code.Enum)
+}
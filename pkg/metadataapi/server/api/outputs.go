@@ -3,7 +3,10 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 
 	"github.com/puppetlabs/horsehead/v2/encoding/transfer"
 	utilapi "github.com/puppetlabs/horsehead/v2/httputil/api"
@@ -12,9 +15,10 @@ import (
 )
 
 type GetOutputResponseEnvelope struct {
-	TaskName string                 `json:"task_name"`
-	Key      string                 `json:"key"`
-	Value    transfer.JSONInterface `json:"value"`
+	TaskName  string                 `json:"task_name"`
+	Key       string                 `json:"key"`
+	Value     transfer.JSONInterface `json:"value"`
+	Sensitive bool                   `json:"sensitive,omitempty"`
 }
 
 func (s *Server) GetOutput(w http.ResponseWriter, r *http.Request) {
@@ -33,47 +37,162 @@ func (s *Server) GetOutput(w http.ResponseWriter, r *http.Request) {
 	}
 
 	env := &GetOutputResponseEnvelope{
-		TaskName: output.Step.Name,
-		Key:      output.Name,
-		Value:    transfer.JSONInterface{Data: output.Value},
+		TaskName:  output.Step.Name,
+		Key:       output.Name,
+		Value:     transfer.JSONInterface{Data: output.Value},
+		Sensitive: output.Sensitive,
 	}
 
 	utilapi.WriteObjectOK(ctx, w, env)
 }
 
-func (s *Server) PutOutput(w http.ResponseWriter, r *http.Request) {
+type OutputEnvelope struct {
+	Key       string                 `json:"key"`
+	Value     transfer.JSONInterface `json:"value"`
+	Sensitive bool                   `json:"sensitive,omitempty"`
+}
+
+type ListStepOutputsResponseEnvelope struct {
+	TaskName string            `json:"task_name"`
+	Outputs  []*OutputEnvelope `json:"outputs"`
+}
+
+type ListOutputsResponseEnvelope struct {
+	Steps []*ListStepOutputsResponseEnvelope `json:"steps"`
+}
+
+func (s *Server) ListOutputs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	managers := middleware.Managers(r)
 	om := managers.StepOutputs()
 
-	name, _ := middleware.Var(r, "name")
+	outputs, err := om.List(ctx)
+	if err != nil {
+		utilapi.WriteError(ctx, w, ModelReadError(err))
+		return
+	}
 
-	var value transfer.JSONInterface
+	env := &ListOutputsResponseEnvelope{
+		Steps: []*ListStepOutputsResponseEnvelope{},
+	}
 
-	switch r.Header.Get("content-type") {
-	case "application/json":
-		if err := json.NewDecoder(r.Body).Decode(&value.Data); err != nil {
-			utilapi.WriteError(ctx, w, errors.NewAPIMalformedRequestError().WithCause(err))
-			return
+	// Outputs are ordered by step, so each step's outputs are adjacent.
+	var step *ListStepOutputsResponseEnvelope
+	for _, output := range outputs {
+		if step == nil || step.TaskName != output.Step.Name {
+			step = &ListStepOutputsResponseEnvelope{
+				TaskName: output.Step.Name,
+				Outputs:  []*OutputEnvelope{},
+			}
+			env.Steps = append(env.Steps, step)
 		}
-	case "text/plain", "application/octet-stream", "":
-		buf := &bytes.Buffer{}
-		if _, err := buf.ReadFrom(r.Body); err != nil {
-			utilapi.WriteError(ctx, w, errors.NewAPIMalformedRequestError().WithCause(err))
+
+		step.Outputs = append(step.Outputs, &OutputEnvelope{
+			Key:       output.Name,
+			Value:     transfer.JSONInterface{Data: output.Value},
+			Sensitive: output.Sensitive,
+		})
+	}
+
+	utilapi.WriteObjectOK(ctx, w, env)
+}
+
+func (s *Server) ListStepOutputs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	managers := middleware.Managers(r)
+	om := managers.StepOutputs()
+
+	stepName, _ := middleware.Var(r, "stepName")
+
+	outputs, err := om.ListStep(ctx, stepName)
+	if err != nil {
+		utilapi.WriteError(ctx, w, ModelReadError(err))
+		return
+	}
+
+	env := &ListStepOutputsResponseEnvelope{
+		TaskName: stepName,
+		Outputs:  make([]*OutputEnvelope, len(outputs)),
+	}
+	for i, output := range outputs {
+		env.Outputs[i] = &OutputEnvelope{
+			Key:       output.Name,
+			Value:     transfer.JSONInterface{Data: output.Value},
+			Sensitive: output.Sensitive,
+		}
+	}
+
+	utilapi.WriteObjectOK(ctx, w, env)
+}
+
+func (s *Server) PutOutput(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	managers := middleware.Managers(r)
+	om := managers.StepOutputs()
+
+	name, _ := middleware.Var(r, "name")
+
+	// Sensitive outputs are protected at rest and masked in logs.
+	var sensitive bool
+	if ss := r.URL.Query().Get("sensitive"); ss != "" {
+		b, err := strconv.ParseBool(ss)
+		if err != nil {
+			utilapi.WriteError(ctx, w, errors.NewAPIMalformedSensitiveError(ss))
 			return
 		}
 
-		value.Data = buf.String()
-	default:
-		utilapi.WriteError(ctx, w, errors.NewAPIUnknownRequestMediaTypeError(r.Header.Get("content-type")))
+		sensitive = b
+	}
+
+	value, verr := readValue(r, 0)
+	if verr != nil {
+		utilapi.WriteError(ctx, w, verr)
 		return
 	}
 
-	if _, err := om.Set(ctx, name, value.Data); err != nil {
+	set := om.Set
+	if sensitive {
+		set = om.SetSensitive
+	}
+
+	if _, err := set(ctx, name, value); err != nil {
 		utilapi.WriteError(ctx, w, ModelWriteError(err))
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 }
+
+// readValue decodes a value from the request body according to its media
+// type. JSON documents are decoded as-is and other bodies are read as
+// strings. If maxBytes is greater than 0, larger bodies are rejected.
+func readValue(r *http.Request, maxBytes int64) (interface{}, errors.Error) {
+	body := io.Reader(r.Body)
+	if maxBytes > 0 {
+		body = io.LimitReader(body, maxBytes+1)
+	}
+
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, errors.NewAPIMalformedRequestError().WithCause(err)
+	} else if maxBytes > 0 && int64(len(b)) > maxBytes {
+		return nil, errors.NewAPIRequestTooLargeError(maxBytes)
+	}
+
+	switch r.Header.Get("content-type") {
+	case "application/json":
+		var value interface{}
+		if err := json.NewDecoder(bytes.NewReader(b)).Decode(&value); err != nil {
+			return nil, errors.NewAPIMalformedRequestError().WithCause(err)
+		}
+
+		return value, nil
+	case "text/plain", "application/octet-stream", "":
+		return string(b), nil
+	default:
+		return nil, errors.NewAPIUnknownRequestMediaTypeError(r.Header.Get("content-type"))
+	}
+}
@@ -8,9 +8,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/errors"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/opt"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/sample"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/api"
+	"github.com/puppetlabs/relay-core/pkg/util/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -65,3 +67,135 @@ func TestPutGetOutput(t *testing.T) {
 	require.Equal(t, "test-task", out.TaskName)
 	require.Equal(t, "bar\x90", out.Value.Data)
 }
+
+func TestPutSensitiveOutput(t *testing.T) {
+	ctx := context.Background()
+
+	tokenGenerator, err := sample.NewHS256TokenGenerator(nil)
+	require.NoError(t, err)
+
+	sc := &opt.SampleConfig{
+		Runs: map[string]*opt.SampleConfigRun{
+			"test": &opt.SampleConfigRun{
+				Steps: map[string]*opt.SampleConfigStep{
+					"test-task": &opt.SampleConfigStep{},
+				},
+			},
+		},
+	}
+
+	tokenMap := tokenGenerator.GenerateAll(ctx, sc)
+
+	testTaskToken, found := tokenMap.ForStep("test", "test-task")
+	require.True(t, found)
+
+	h := api.NewHandler(sample.NewAuthenticator(sc, tokenGenerator.Key()))
+
+	// Set a sensitive output.
+	req, err := http.NewRequest(http.MethodPut, "/outputs/password?sensitive=true", strings.NewReader("hunter2"))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testTaskToken)
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusCreated, resp.Result().StatusCode)
+
+	// Later steps can still read the value, but it is flagged.
+	req, err = http.NewRequest(http.MethodGet, "/outputs/test-task/password", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testTaskToken)
+
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Result().StatusCode)
+
+	var out api.GetOutputResponseEnvelope
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	require.Equal(t, "hunter2", out.Value.Data)
+	require.True(t, out.Sensitive)
+
+	// The flag must be a boolean.
+	req, err = http.NewRequest(http.MethodPut, "/outputs/password?sensitive=maybe", strings.NewReader("hunter2"))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testTaskToken)
+
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	testutil.RequireErrorResponse(t, errors.NewAPIMalformedSensitiveError("maybe"), resp.Result())
+}
+
+func TestListOutputs(t *testing.T) {
+	ctx := context.Background()
+
+	tokenGenerator, err := sample.NewHS256TokenGenerator(nil)
+	require.NoError(t, err)
+
+	sc := &opt.SampleConfig{
+		Runs: map[string]*opt.SampleConfigRun{
+			"test": &opt.SampleConfigRun{
+				Steps: map[string]*opt.SampleConfigStep{
+					"previous-task": &opt.SampleConfigStep{
+						Outputs: map[string]interface{}{
+							"foo": "bar",
+							"baz": []interface{}{"quux"},
+						},
+					},
+					"test-task": &opt.SampleConfigStep{},
+				},
+			},
+		},
+	}
+
+	tokenMap := tokenGenerator.GenerateAll(ctx, sc)
+
+	testTaskToken, found := tokenMap.ForStep("test", "test-task")
+	require.True(t, found)
+
+	h := api.NewHandler(sample.NewAuthenticator(sc, tokenGenerator.Key()))
+
+	// List all outputs in the run.
+	req, err := http.NewRequest(http.MethodGet, "/outputs", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testTaskToken)
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Result().StatusCode)
+
+	var env api.ListOutputsResponseEnvelope
+	require.NoError(t, json.NewDecoder(resp.Result().Body).Decode(&env))
+	require.Len(t, env.Steps, 1)
+	require.Equal(t, "previous-task", env.Steps[0].TaskName)
+	require.Len(t, env.Steps[0].Outputs, 2)
+	require.Equal(t, "baz", env.Steps[0].Outputs[0].Key)
+	require.Equal(t, []interface{}{"quux"}, env.Steps[0].Outputs[0].Value.Data)
+	require.Equal(t, "foo", env.Steps[0].Outputs[1].Key)
+	require.Equal(t, "bar", env.Steps[0].Outputs[1].Value.Data)
+
+	// List the outputs of a single step.
+	req, err = http.NewRequest(http.MethodGet, "/outputs/previous-task", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testTaskToken)
+
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Result().StatusCode)
+
+	var stepEnv api.ListStepOutputsResponseEnvelope
+	require.NoError(t, json.NewDecoder(resp.Result().Body).Decode(&stepEnv))
+	require.Equal(t, "previous-task", stepEnv.TaskName)
+	require.Len(t, stepEnv.Outputs, 2)
+
+	// A step without outputs has an empty list.
+	req, err = http.NewRequest(http.MethodGet, "/outputs/test-task", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testTaskToken)
+
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Result().StatusCode)
+
+	stepEnv = api.ListStepOutputsResponseEnvelope{}
+	require.NoError(t, json.NewDecoder(resp.Result().Body).Decode(&stepEnv))
+	require.Empty(t, stepEnv.Outputs)
+}
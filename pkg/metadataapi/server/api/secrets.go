@@ -14,6 +14,29 @@ type GetSecretResponseEnvelope struct {
 	Value transfer.JSONOrStr `json:"value"`
 }
 
+type ListSecretsResponseEnvelope struct {
+	Keys []string `json:"keys"`
+}
+
+func (s *Server) ListSecrets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	managers := middleware.Managers(r)
+	sm := managers.Secrets()
+
+	names, err := sm.List(ctx)
+	if err != nil {
+		utilapi.WriteError(ctx, w, ModelReadError(err))
+		return
+	}
+
+	env := &ListSecretsResponseEnvelope{
+		Keys: append([]string{}, names...),
+	}
+
+	utilapi.WriteObjectOK(ctx, w, env)
+}
+
 func (s *Server) GetSecret(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -85,3 +85,43 @@ func TestGetSecret(t *testing.T) {
 		})
 	}
 }
+
+func TestListSecrets(t *testing.T) {
+	ctx := context.Background()
+
+	tokenGenerator, err := sample.NewHS256TokenGenerator(nil)
+	require.NoError(t, err)
+
+	sc := &opt.SampleConfig{
+		Secrets: map[string]string{
+			"foo": "bar",
+			"baz": "",
+		},
+		Runs: map[string]*opt.SampleConfigRun{
+			"test": &opt.SampleConfigRun{
+				Steps: map[string]*opt.SampleConfigStep{
+					"test-task": &opt.SampleConfigStep{},
+				},
+			},
+		},
+	}
+
+	tokenMap := tokenGenerator.GenerateAll(ctx, sc)
+
+	testTaskToken, found := tokenMap.ForStep("test", "test-task")
+	require.True(t, found)
+
+	h := api.NewHandler(sample.NewAuthenticator(sc, tokenGenerator.Key()))
+
+	req, err := http.NewRequest(http.MethodGet, "/secrets", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testTaskToken)
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Result().StatusCode)
+
+	var env api.ListSecretsResponseEnvelope
+	require.NoError(t, json.NewDecoder(resp.Result().Body).Decode(&env))
+	require.Equal(t, []string{"baz", "foo"}, env.Keys)
+}
@@ -4,32 +4,44 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/audit"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/middleware"
 )
 
 type Server struct {
-	auth middleware.Authenticator
+	auth     middleware.Authenticator
+	limiters *middleware.RateLimiters
+	audit    audit.Sink
 }
 
 func (s *Server) Route(r *mux.Router) {
 	r.UseEncodedPath()
 	r.Use(middleware.WithAuthentication(s.auth))
+	if s.audit != nil {
+		r.Use(middleware.WithAudit(s.audit))
+	}
 
 	// Conditions
 	r.HandleFunc("/conditions", s.GetConditions).Methods(http.MethodGet)
 
 	// Events
-	r.HandleFunc("/events", s.PostEvent).Methods(http.MethodPost)
+	r.Handle("/events", middleware.WithRequestLimits(s.limiters)(http.HandlerFunc(s.PostEvent))).Methods(http.MethodPost)
+
+	// Connections
+	r.HandleFunc("/connections/{type}", s.ListConnections).Methods(http.MethodGet)
 
 	// Environment
 	r.HandleFunc("/environment", s.GetEnvironment).Methods(http.MethodGet)
 	r.HandleFunc("/environment/{name}", s.GetEnvironmentVariable).Methods(http.MethodGet)
 
 	// Outputs
+	r.HandleFunc("/outputs", s.ListOutputs).Methods(http.MethodGet)
 	r.HandleFunc("/outputs/{name}", s.PutOutput).Methods(http.MethodPut)
+	r.HandleFunc("/outputs/{stepName}", s.ListStepOutputs).Methods(http.MethodGet)
 	r.HandleFunc("/outputs/{stepName}/{name}", s.GetOutput).Methods(http.MethodGet)
 
 	// Secrets
+	r.HandleFunc("/secrets", s.ListSecrets).Methods(http.MethodGet)
 	r.HandleFunc("/secrets/{name}", s.GetSecret).Methods(http.MethodGet)
 
 	// Spec
@@ -37,16 +49,38 @@ func (s *Server) Route(r *mux.Router) {
 
 	// State
 	r.HandleFunc("/state/{name}", s.GetState).Methods(http.MethodGet)
+	r.HandleFunc("/state/{name}", s.PutState).Methods(http.MethodPut)
+	r.HandleFunc("/state/{name}", s.DeleteState).Methods(http.MethodDelete)
+
+	// Webhook requests
+	r.HandleFunc("/webhook-requests", s.PostWebhookRequest).Methods(http.MethodPost)
+}
+
+type ServerOption func(s *Server)
+
+// ServerWithAuditSink writes a record of every authenticated request to the
+// given sink.
+func ServerWithAuditSink(sink audit.Sink) ServerOption {
+	return func(s *Server) {
+		s.audit = sink
+	}
 }
 
-func NewServer(auth middleware.Authenticator) *Server {
-	return &Server{
-		auth: auth,
+func NewServer(auth middleware.Authenticator, opts ...ServerOption) *Server {
+	s := &Server{
+		auth:     auth,
+		limiters: middleware.NewRateLimiters(),
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
-func NewHandler(auth middleware.Authenticator) http.Handler {
+func NewHandler(auth middleware.Authenticator, opts ...ServerOption) http.Handler {
 	r := mux.NewRouter()
-	NewServer(auth).Route(r)
+	NewServer(auth, opts...).Route(r)
 	return r
 }
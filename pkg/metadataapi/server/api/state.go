@@ -8,6 +8,10 @@ import (
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/middleware"
 )
 
+// MaxStateValueBytes is the largest value a step may store in a single state
+// key. State shares a config map with outputs, so it must stay small.
+const MaxStateValueBytes = 64 * 1024
+
 type GetStateResponseEnvelope struct {
 	Key   string                 `json:"key"`
 	Value transfer.JSONInterface `json:"value"`
@@ -34,3 +38,41 @@ func (s *Server) GetState(w http.ResponseWriter, r *http.Request) {
 
 	utilapi.WriteObjectOK(ctx, w, env)
 }
+
+func (s *Server) PutState(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	managers := middleware.Managers(r)
+	sm := managers.State()
+
+	name, _ := middleware.Var(r, "name")
+
+	value, verr := readValue(r, MaxStateValueBytes)
+	if verr != nil {
+		utilapi.WriteError(ctx, w, verr)
+		return
+	}
+
+	if _, err := sm.Set(ctx, name, value); err != nil {
+		utilapi.WriteError(ctx, w, ModelWriteError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) DeleteState(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	managers := middleware.Managers(r)
+	sm := managers.State()
+
+	name, _ := middleware.Var(r, "name")
+
+	if err := sm.Delete(ctx, name); err != nil {
+		utilapi.WriteError(ctx, w, ModelWriteError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/puppetlabs/errawr-go/v2/pkg/errawr"
@@ -89,3 +90,80 @@ func TestGetState(t *testing.T) {
 		})
 	}
 }
+
+func TestPutDeleteState(t *testing.T) {
+	ctx := context.Background()
+
+	tokenGenerator, err := sample.NewHS256TokenGenerator(nil)
+	require.NoError(t, err)
+
+	sc := &opt.SampleConfig{
+		Runs: map[string]*opt.SampleConfigRun{
+			"test": &opt.SampleConfigRun{
+				Steps: map[string]*opt.SampleConfigStep{
+					"test-task": &opt.SampleConfigStep{},
+				},
+			},
+		},
+	}
+
+	tokenMap := tokenGenerator.GenerateAll(ctx, sc)
+
+	testTaskToken, found := tokenMap.ForStep("test", "test-task")
+	require.True(t, found)
+
+	h := api.NewHandler(sample.NewAuthenticator(sc, tokenGenerator.Key()))
+
+	do := func(method, path, contentType, body string) *http.Response {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+testTaskToken)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		return resp.Result()
+	}
+
+	// Store a structured checkpoint.
+	resp := do(http.MethodPut, "/state/checkpoint", "application/json", `{"page": 3, "done": false}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = do(http.MethodGet, "/state/checkpoint", "", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var env api.GetStateResponseEnvelope
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&env))
+	require.Equal(t, "checkpoint", env.Key)
+	require.Equal(t, map[string]interface{}{"page": float64(3), "done": false}, env.Value.Data)
+
+	// Plain text bodies are stored as strings.
+	resp = do(http.MethodPut, "/state/cursor", "text/plain", "abc\x90")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = do(http.MethodGet, "/state/cursor", "", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	env = api.GetStateResponseEnvelope{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&env))
+	require.Equal(t, "abc\x90", env.Value.Data)
+
+	// Values over the size limit are rejected.
+	resp = do(http.MethodPut, "/state/cursor", "text/plain", strings.Repeat("x", api.MaxStateValueBytes+1))
+	testutil.RequireErrorResponse(t, errors.NewAPIRequestTooLargeError(api.MaxStateValueBytes), resp)
+
+	resp = do(http.MethodPut, "/state/cursor", "application/xml", "<cursor/>")
+	testutil.RequireErrorResponse(t, errors.NewAPIUnknownRequestMediaTypeError("application/xml"), resp)
+
+	// Delete the checkpoint. Deleting it again succeeds.
+	resp = do(http.MethodDelete, "/state/checkpoint", "", "")
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = do(http.MethodDelete, "/state/checkpoint", "", "")
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = do(http.MethodGet, "/state/checkpoint", "", "")
+	testutil.RequireErrorResponse(t, errors.NewModelNotFoundError(), resp)
+}
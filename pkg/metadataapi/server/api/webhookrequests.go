@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	utilapi "github.com/puppetlabs/horsehead/v2/httputil/api"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/errors"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/middleware"
+	"github.com/puppetlabs/relay-core/pkg/model"
+)
+
+type PostWebhookRequestRequestEnvelope struct {
+	// ID is the ID the verifier sent to the trigger in the
+	// X-Relay-Webhook-Request-Id header. If empty, an ID is generated.
+	ID string `json:"id,omitempty"`
+
+	ReceivedAt    time.Time   `json:"received_at"`
+	CompletedAt   time.Time   `json:"completed_at"`
+	Method        string      `json:"method"`
+	URL           string      `json:"url"`
+	Header        http.Header `json:"header"`
+	Body          []byte      `json:"body"`
+	BodyTruncated bool        `json:"body_truncated"`
+	StatusCode    int         `json:"status_code"`
+
+	// Error describes why the request failed, if the proxy knows more than the
+	// status code.
+	Error string `json:"error,omitempty"`
+}
+
+type PostWebhookRequestResponseEnvelope struct {
+	// ID is the ID of the captured request. It is empty if the trigger does
+	// not capture requests.
+	ID          string `json:"id,omitempty"`
+	EventsCount int    `json:"events_count"`
+}
+
+// PostWebhookRequest records a request received by a webhook trigger. Only the
+// proxy in front of a trigger, or a trigger without a proxy, may call it. The
+// request always counts towards the delivery statistics of the trigger, and it
+// is captured if capture is enabled.
+func (s *Server) PostWebhookRequest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	managers := middleware.Managers(r)
+	wdm := managers.WebhookDeliveries()
+	wrm := managers.WebhookRequests()
+
+	var env PostWebhookRequestRequestEnvelope
+	if err := json.NewDecoder(r.Body).Decode(&env); err != nil {
+		utilapi.WriteError(ctx, w, errors.NewAPIMalformedRequestError().WithCause(err))
+		return
+	}
+
+	var failure error
+	if env.Error != "" {
+		failure = goerrors.New(env.Error)
+	} else if env.StatusCode >= 400 {
+		failure = fmt.Errorf("trigger responded with HTTP %d", env.StatusCode)
+	}
+
+	if err := wdm.RecordRequest(ctx, env.ReceivedAt, failure); err != nil {
+		utilapi.WriteError(ctx, w, ModelWriteError(err))
+		return
+	}
+
+	req, err := wrm.Record(ctx, &model.WebhookRequest{
+		ID:            env.ID,
+		ReceivedAt:    env.ReceivedAt,
+		CompletedAt:   env.CompletedAt,
+		Method:        env.Method,
+		URL:           env.URL,
+		Header:        env.Header,
+		Body:          env.Body,
+		BodyTruncated: env.BodyTruncated,
+		StatusCode:    env.StatusCode,
+		Error:         env.Error,
+	})
+	if err == model.ErrRejected {
+		utilapi.WriteObjectCreated(ctx, w, &PostWebhookRequestResponseEnvelope{})
+		return
+	} else if err != nil {
+		utilapi.WriteError(ctx, w, ModelWriteError(err))
+		return
+	}
+
+	utilapi.WriteObjectCreated(ctx, w, &PostWebhookRequestResponseEnvelope{
+		ID:          req.ID,
+		EventsCount: len(req.Events),
+	})
+}
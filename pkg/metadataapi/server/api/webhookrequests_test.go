@@ -0,0 +1,82 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/opt"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/sample"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostWebhookRequest(t *testing.T) {
+	ctx := context.Background()
+
+	tokenGenerator, err := sample.NewHS256TokenGenerator(nil)
+	require.NoError(t, err)
+
+	sc := &opt.SampleConfig{
+		Triggers: map[string]*opt.SampleConfigTrigger{
+			"test": &opt.SampleConfigTrigger{},
+		},
+	}
+
+	tokenMap := tokenGenerator.GenerateAll(ctx, sc)
+
+	triggerToken, found := tokenMap.ForTrigger("test")
+	require.True(t, found)
+
+	verifierToken, found := tokenMap.ForWebhookVerifier("test")
+	require.True(t, found)
+
+	h := api.NewHandler(sample.NewAuthenticator(sc, tokenGenerator.Key()))
+
+	receivedAt := time.Now()
+
+	req, err := http.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"data":{"foo":"bar"}}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+triggerToken)
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusAccepted, resp.Result().StatusCode)
+
+	b, err := json.Marshal(&api.PostWebhookRequestRequestEnvelope{
+		ReceivedAt:  receivedAt,
+		CompletedAt: time.Now(),
+		Method:      http.MethodPost,
+		URL:         "/",
+		Body:        []byte(`{"hello":"world"}`),
+		StatusCode:  http.StatusOK,
+	})
+	require.NoError(t, err)
+
+	// The trigger cannot report requests itself.
+	req, err = http.NewRequest(http.MethodPost, "/webhook-requests", strings.NewReader(string(b)))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+triggerToken)
+
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusForbidden, resp.Result().StatusCode)
+
+	req, err = http.NewRequest(http.MethodPost, "/webhook-requests", strings.NewReader(string(b)))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+verifierToken)
+
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusCreated, resp.Result().StatusCode)
+
+	var env api.PostWebhookRequestResponseEnvelope
+	require.NoError(t, json.NewDecoder(resp.Result().Body).Decode(&env))
+	assert.NotEmpty(t, env.ID)
+	assert.Equal(t, 1, env.EventsCount)
+}
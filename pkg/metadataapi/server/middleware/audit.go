@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/audit"
+)
+
+type auditContextKey int
+
+const (
+	auditContextKeyIdentity auditContextKey = iota
+)
+
+func withIdentity(r *http.Request, id *audit.Identity) *http.Request {
+	if id == nil {
+		return r
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), auditContextKeyIdentity, id))
+}
+
+// Identity returns the identity of the authenticated client making the
+// request, or nil if it is not known.
+func Identity(r *http.Request) *audit.Identity {
+	id, _ := r.Context().Value(auditContextKeyIdentity).(*audit.Identity)
+	return id
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	if sr.status == 0 {
+		sr.status = status
+	}
+
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+
+	return sr.ResponseWriter.Write(b)
+}
+
+// WithAudit writes a record of each request to the given sink once it
+// completes. It must be used after authentication, as unauthenticated requests
+// are not recorded. Failures to write records are logged but do not affect the
+// response.
+func WithAudit(sink audit.Sink) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := Identity(r)
+			if id == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &audit.Record{
+				Time:     time.Now(),
+				Identity: id,
+				Method:   r.Method,
+			}
+
+			if route := mux.CurrentRoute(r); route != nil {
+				rec.Route, _ = route.GetPathTemplate()
+			}
+
+			if vars := mux.Vars(r); len(vars) > 0 {
+				rec.Resource = make(map[string]string, len(vars))
+				for name, encoded := range vars {
+					value, err := url.QueryUnescape(encoded)
+					if err != nil {
+						value = encoded
+					}
+
+					rec.Resource[name] = value
+				}
+			}
+
+			sr := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(sr, r)
+
+			if sr.status == 0 {
+				sr.status = http.StatusOK
+			}
+
+			rec.StatusCode = sr.status
+			rec.Outcome = audit.OutcomeForStatusCode(sr.status)
+
+			if err := sink.Write(r.Context(), rec); err != nil {
+				log(r.Context()).Error("failed to write audit record", "error", err)
+			}
+		})
+	}
+}
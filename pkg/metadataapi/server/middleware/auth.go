@@ -4,15 +4,23 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"path"
 
 	"github.com/gorilla/mux"
 	vaultapi "github.com/hashicorp/vault/api"
 	"github.com/puppetlabs/horsehead/v2/instrumentation/alerts/trackers"
+	"github.com/puppetlabs/horsehead/v2/storage"
 	"github.com/puppetlabs/relay-core/pkg/authenticate"
+	"github.com/puppetlabs/relay-core/pkg/expr/parse"
 	"github.com/puppetlabs/relay-core/pkg/manager/api"
 	"github.com/puppetlabs/relay-core/pkg/manager/builder"
 	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/puppetlabs/relay-core/pkg/manager/direct"
+	"github.com/puppetlabs/relay-core/pkg/manager/fanout"
+	"github.com/puppetlabs/relay-core/pkg/manager/redact"
+	"github.com/puppetlabs/relay-core/pkg/manager/reject"
 	"github.com/puppetlabs/relay-core/pkg/manager/vault"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/audit"
 	"github.com/puppetlabs/relay-core/pkg/model"
 	"k8s.io/client-go/kubernetes"
 )
@@ -21,6 +29,12 @@ import (
 type Credential struct {
 	Managers model.MetadataManagers
 	Tags     []trackers.Tag
+
+	// Limits restrict the requests the client may make, if set.
+	Limits *Limits
+
+	// Identity describes the client in audit records.
+	Identity *audit.Identity
 }
 
 // Authenticator maps an HTTP request to a credential, if possible.
@@ -37,9 +51,14 @@ type KubernetesAuthenticator struct {
 	factory KubernetesAuthenticatorClientFactoryFunc
 
 	// Client for using a Kubernetes pod-lookup intermediary instead of Bearer
-	// request headers.
+	// request headers. Pods are looked up by the projected service account
+	// token in the Bearer request header if present, or by IP otherwise.
 	kubernetesClient *authenticate.KubernetesInterface
 
+	// Keeps the pods that service account tokens are bound to across
+	// requests.
+	tokenReviews *authenticate.KubernetesTokenReviewCache
+
 	// Uses Vault for token decryption (Kubernetes intermediary).
 	vaultClient      *vaultapi.Client
 	vaultTransitPath string
@@ -50,12 +69,31 @@ type KubernetesAuthenticator struct {
 	vaultJWTAuthPath string
 	vaultJWTAuthRole string
 
+	vaultJWTAuthAllowedRoles []string
+
 	// Static keys to use for JWT verification.
 	keys []interface{}
+
+	// Options for the step output manager, like blob storage for large
+	// outputs.
+	stepOutputOpts []configmap.StepOutputManagerOption
+
+	// Uses Vault to encrypt sensitive step outputs and the values to redact
+	// from step logs.
+	encryptionVaultClient      *vaultapi.Client
+	encryptionVaultTransitPath string
+	encryptionVaultTransitKey  string
 }
 
 var _ Authenticator = &KubernetesAuthenticator{}
 
+// kubernetesPodIntermediary is an intermediary that reads the token of a pod
+// from its annotations.
+type kubernetesPodIntermediary interface {
+	authenticate.Intermediary
+	Chain(fn authenticate.KubernetesChainIntermediaryFunc) authenticate.Intermediary
+}
+
 func (ka *KubernetesAuthenticator) intermediary(r *http.Request) authenticate.Intermediary {
 	if ka.kubernetesClient == nil {
 		// In this case we expect the JWT to be specified in the Authorization
@@ -63,8 +101,7 @@ func (ka *KubernetesAuthenticator) intermediary(r *http.Request) authenticate.In
 		return authenticate.NewHTTPAuthorizationHeaderIntermediary(r)
 	}
 
-	// Extract IP from request to hand to Kubernetes for pod
-	// authentication.
+	// Extract IP from request to hand to Kubernetes for pod authentication.
 	host, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
 		host = r.RemoteAddr
@@ -74,6 +111,24 @@ func (ka *KubernetesAuthenticator) intermediary(r *http.Request) authenticate.In
 	// fails the intermediary will bail on an empty IP anyway.
 	ki := authenticate.NewKubernetesIntermediary(ka.kubernetesClient, net.ParseIP(host))
 
+	// The pod may present a projected service account token bound to it,
+	// which we review to find the pod. If there is no token, we look up the
+	// pod by IP.
+	var token string
+	if raw, err := authenticate.NewHTTPAuthorizationHeaderIntermediary(r).Next(r.Context(), authenticate.NewAuthentication()); err == nil {
+		token = string(raw)
+	}
+
+	return ka.chainToVault(authenticate.NewKubernetesServiceAccountTokenIntermediary(
+		ka.kubernetesClient,
+		token,
+		authenticate.MetadataAPIAudienceV1,
+		authenticate.KubernetesServiceAccountTokenIntermediaryWithCache(ka.tokenReviews),
+		authenticate.KubernetesServiceAccountTokenIntermediaryWithFallback(ki),
+	))
+}
+
+func (ka *KubernetesAuthenticator) chainToVault(ki kubernetesPodIntermediary) authenticate.Intermediary {
 	if ka.vaultClient == nil {
 		// Done. We assume the token will be set directly on the pod
 		// annotation.
@@ -100,6 +155,7 @@ func (ka *KubernetesAuthenticator) resolver(mgrs *builder.MetadataBuilder) authe
 			ka.vaultJWTAuthAddr,
 			ka.vaultJWTAuthPath,
 			authenticate.VaultResolverWithRole(ka.vaultJWTAuthRole),
+			authenticate.VaultResolverWithAllowedRoles(ka.vaultJWTAuthAllowedRoles),
 			authenticate.VaultResolverWithInjector(authenticate.VaultResolverInjectorFunc(func(ctx context.Context, claims *authenticate.Claims, md *authenticate.VaultResolverMetadata) error {
 				if claims.RelayVaultEnginePath == "" {
 					return nil
@@ -127,7 +183,7 @@ func (ka *KubernetesAuthenticator) resolver(mgrs *builder.MetadataBuilder) authe
 	return authenticate.NewAnyResolver(delegates)
 }
 
-func (ka *KubernetesAuthenticator) injector(mgrs *builder.MetadataBuilder, tags *[]trackers.Tag) authenticate.Injector {
+func (ka *KubernetesAuthenticator) injector(mgrs *builder.MetadataBuilder, redactions *model.RedactionRecorderManager, tags *[]trackers.Tag, limits **Limits, identity **audit.Identity) authenticate.Injector {
 	return authenticate.InjectorFunc(func(ctx context.Context, claims *authenticate.Claims) error {
 		client, err := ka.factory(claims.KubernetesServiceAccountToken)
 		if err != nil {
@@ -143,17 +199,110 @@ func (ka *KubernetesAuthenticator) injector(mgrs *builder.MetadataBuilder, tags
 			// Only a step can work with parameters and outputs. Other actions
 			// will get the default rejection manager.
 			mgrs.SetParameters(configmap.NewParameterManager(immutableMap))
-			mgrs.SetStepOutputs(configmap.NewStepOutputManager(step, mutableMap))
+			stepOutputOpts := append([]configmap.StepOutputManagerOption{}, ka.stepOutputOpts...)
+			stepOutputOpts = append(stepOutputOpts, configmap.StepOutputManagerWithBlobKeyPrefix(path.Join(claims.KubernetesNamespaceUID, step.Run.ID)))
+			if ka.encryptionVaultClient != nil {
+				enc := vault.NewTransitClient(
+					ka.encryptionVaultClient,
+					ka.encryptionVaultTransitPath,
+					ka.encryptionVaultTransitKey,
+					vault.TransitClientWithContext(authenticate.VaultTransitNamespaceContext(claims.KubernetesNamespaceUID)),
+				)
+
+				stepOutputOpts = append(stepOutputOpts, configmap.StepOutputManagerWithEncrypter(enc))
+				*redactions = configmap.NewRedactionManager(step, mutableMap, enc)
+			} else {
+				// We cannot record the confidential values the step retrieves,
+				// so the operator would not be able to remove them from its
+				// logs. Refuse to hand them out at all.
+				*redactions = reject.RedactionManager
+			}
+
+			mgrs.SetStepOutputs(configmap.NewStepOutputManager(step, mutableMap, stepOutputOpts...))
+		})
+
+		var deliveries model.WebhookDeliveryRecorderManager = reject.WebhookDeliveryManager
+		var requests model.WebhookRequestRecorderManager = reject.WebhookRequestManager
+
+		model.IfTrigger(action, func(trigger *model.Trigger) {
+			if claims.RelayKubernetesWebhookDeliveriesConfigMapName != "" {
+				deliveriesMap := configmap.NewClientConfigMap(client, claims.KubernetesNamespaceName, claims.RelayKubernetesWebhookDeliveriesConfigMapName)
+				deliveries = configmap.NewWebhookDeliveryManager(deliveriesMap)
+			}
+
+			if claims.RelayKubernetesWebhookRequestsConfigMapName != "" {
+				requestsMap := configmap.NewClientConfigMap(client, claims.KubernetesNamespaceName, claims.RelayKubernetesWebhookRequestsConfigMapName)
+				requests = configmap.NewWebhookRequestManager(requestsMap, claims.RelayWebhookRequestsLimit)
+			}
 		})
 
-		if claims.RelayEventAPIURL != nil {
-			mgrs.SetEvents(api.NewEventManager(action, claims.RelayEventAPIURL.URL.String(), claims.RelayEventAPIToken))
+		if claims.RelayWebhookVerifier {
+			// The webhook verifier only reports the requests received by the
+			// trigger, so it gets no other managers.
+			mgrs.SetWebhookDeliveries(deliveries)
+			mgrs.SetWebhookRequests(requests)
+
+			*tags = append(*tags,
+				trackers.Tag{Key: "relay.action.type", Value: action.Type().Singular},
+				trackers.Tag{Key: "relay.action.name", Value: claims.RelayName},
+			)
+			*identity = audit.IdentityFromClaims(claims)
+
+			return nil
+		}
+
+		// A trigger behind the webhook verifier may only record the events it
+		// emits. Requests are reported by the verifier.
+		if !claims.RelayWebhookDirect {
+			deliveries = reject.NewWebhookDeliveryEventManager(deliveries)
+			requests = reject.NewWebhookRequestEventManager(requests)
+		}
+
+		mgrs.SetWebhookDeliveries(deliveries)
+		mgrs.SetWebhookRequests(requests)
+
+		var sinks []*fanout.EventSink
+
+		if claims.RelayEventDirect {
+			templates := configmap.NewWorkflowRunTemplateManager(action, immutableMap)
+			sinks = append(sinks, &fanout.EventSink{
+				Manager: direct.NewEventManager(action, templates, client.Discovery().RESTClient(), claims.KubernetesNamespaceName),
+			})
+		} else if claims.RelayEventAPIURL != nil {
+			sinks = append(sinks, &fanout.EventSink{
+				Manager: api.NewEventManager(action, claims.RelayEventAPIURL.URL.String(), claims.RelayEventAPIToken),
+			})
+		}
+
+		for _, sc := range claims.RelayEventSinks {
+			sink := &fanout.EventSink{
+				Name: sc.Name,
+				When: parse.Tree(sc.When),
+			}
+
+			if sc.Direct {
+				templates := configmap.NewWorkflowRunTemplateManagerForSink(action, sc.Name, immutableMap)
+				sink.Manager = direct.NewEventManager(action, templates, client.Discovery().RESTClient(), claims.KubernetesNamespaceName, direct.EventManagerWithSinkName(sc.Name))
+			} else if sc.APIURL != nil {
+				sink.Manager = api.NewEventManager(action, sc.APIURL.URL.String(), sc.APIToken)
+			} else {
+				continue
+			}
+
+			sinks = append(sinks, sink)
+		}
+
+		if len(claims.RelayEventSinks) > 0 {
+			mgrs.SetEvents(fanout.NewEventManager(sinks, deliveries))
+		} else if len(sinks) > 0 {
+			mgrs.SetEvents(sinks[0].Manager)
 		}
 
 		mgrs.SetConditions(configmap.NewConditionManager(action, immutableMap))
 		mgrs.SetEnvironment(configmap.NewEnvironmentManager(action, immutableMap))
 		mgrs.SetSpec(configmap.NewSpecManager(action, immutableMap))
 		mgrs.SetState(configmap.NewStateManager(action, mutableMap))
+		mgrs.SetChanges(configmap.NewChangeManager(mutableMap))
 
 		ts := []trackers.Tag{
 			{Key: "relay.domain.id", Value: claims.RelayDomainID},
@@ -168,18 +317,24 @@ func (ka *KubernetesAuthenticator) injector(mgrs *builder.MetadataBuilder, tags
 			}
 		}
 
+		*limits = LimitsFromClaims(claims)
+		*identity = audit.IdentityFromClaims(claims)
+
 		return nil
 	})
 }
 
 func (ka *KubernetesAuthenticator) Authenticate(r *http.Request) (*Credential, error) {
 	mgrs := builder.NewMetadataBuilder()
+	var redactions model.RedactionRecorderManager
 	var tags []trackers.Tag
+	var limits *Limits
+	var identity *audit.Identity
 
 	auth := authenticate.NewAuthenticator(
 		ka.intermediary(r),
 		ka.resolver(mgrs),
-		authenticate.AuthenticatorWithInjector(ka.injector(mgrs, &tags)),
+		authenticate.AuthenticatorWithInjector(ka.injector(mgrs, &redactions, &tags, &limits, &identity)),
 	)
 
 	if ok, err := auth.Authenticate(r.Context()); err != nil {
@@ -188,9 +343,19 @@ func (ka *KubernetesAuthenticator) Authenticate(r *http.Request) (*Credential, e
 		return nil, nil
 	}
 
+	// Connections and secrets may be set by the resolver after our injector
+	// runs, so we can only track what the action retrieves once every manager
+	// is configured.
+	managers := mgrs.Build()
+	if redactions != nil {
+		managers = redact.NewMetadataManagers(managers, redactions)
+	}
+
 	return &Credential{
-		Managers: mgrs.Build(),
+		Managers: managers,
 		Tags:     tags,
+		Limits:   limits,
+		Identity: identity,
 	}, nil
 }
 
@@ -218,21 +383,57 @@ func KubernetesAuthenticatorWithVaultResolver(addr, path, role string) Kubernete
 	}
 }
 
+// KubernetesAuthenticatorWithVaultAllowedRoles sets the patterns of the roles
+// tenants may request to log in to Vault with.
+func KubernetesAuthenticatorWithVaultAllowedRoles(patterns []string) KubernetesAuthenticatorOption {
+	return func(ka *KubernetesAuthenticator) {
+		ka.vaultJWTAuthAllowedRoles = append(ka.vaultJWTAuthAllowedRoles, patterns...)
+	}
+}
+
 func KubernetesAuthenticatorWithKeyResolver(key interface{}) KubernetesAuthenticatorOption {
 	return func(ka *KubernetesAuthenticator) {
 		ka.keys = append(ka.keys, key)
 	}
 }
 
+// KubernetesAuthenticatorWithStepOutputBlobStore stores step outputs larger
+// than the given number of bytes in the given blob store.
+func KubernetesAuthenticatorWithStepOutputBlobStore(bs storage.BlobStore, thresholdBytes int) KubernetesAuthenticatorOption {
+	return func(ka *KubernetesAuthenticator) {
+		ka.stepOutputOpts = append(ka.stepOutputOpts, configmap.StepOutputManagerWithBlobStore(bs, thresholdBytes))
+	}
+}
+
+// KubernetesAuthenticatorWithVaultTransitEncryption encrypts sensitive step
+// outputs using the given Vault transit key. The key is derived for the
+// namespace of each step.
+//
+// It also enables recording the secrets, connections, and sensitive outputs a
+// step retrieves, encrypted with the same key, so that the operator can redact
+// them from the step's logs.
+func KubernetesAuthenticatorWithVaultTransitEncryption(client *vaultapi.Client, path, key string) KubernetesAuthenticatorOption {
+	return func(ka *KubernetesAuthenticator) {
+		ka.encryptionVaultClient = client
+		ka.encryptionVaultTransitPath = path
+		ka.encryptionVaultTransitKey = key
+	}
+}
+
 func NewKubernetesAuthenticator(factory KubernetesAuthenticatorClientFactoryFunc, opts ...KubernetesAuthenticatorOption) *KubernetesAuthenticator {
 	ka := &KubernetesAuthenticator{
-		factory: factory,
+		factory:      factory,
+		tokenReviews: authenticate.NewKubernetesTokenReviewCache(),
 	}
 
 	for _, opt := range opts {
 		opt(ka)
 	}
 
+	if ka.encryptionVaultClient == nil {
+		log(context.Background()).Warn("Vault transit encryption is not configured; steps will not be able to retrieve secrets, connections, or sensitive outputs because their values cannot be recorded for redaction from logs")
+	}
+
 	return ka
 }
 
@@ -251,7 +452,7 @@ func WithAuthentication(a Authenticator) mux.MiddlewareFunc {
 					r = r.WithContext(trackers.NewContextWithCapturer(r.Context(), capturer))
 				}
 
-				WithManagers(cred.Managers)(next).ServeHTTP(w, r)
+				WithManagers(cred.Managers)(next).ServeHTTP(w, withIdentity(withLimits(r, cred.Limits), cred.Identity))
 			}
 		})
 	}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	utilapi "github.com/puppetlabs/horsehead/v2/httputil/api"
+	"github.com/puppetlabs/relay-core/pkg/authenticate"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/errors"
+	"github.com/puppetlabs/relay-core/pkg/util/ratelimitutil"
+	"golang.org/x/time/rate"
+)
+
+// Limits restrict the requests an authenticated client may make to endpoints
+// that enforce them.
+type Limits struct {
+	// Key identifies the client. Clients with the same key share a rate
+	// limit.
+	Key string
+
+	// RequestsPerSecond is the sustained rate of requests allowed, or 0 if the
+	// rate is not limited.
+	RequestsPerSecond int32
+
+	// Burst is the number of requests allowed at once. If 0, it is the same as
+	// RequestsPerSecond.
+	Burst int32
+
+	// MaxBodyBytes is the largest request body allowed, or 0 if the size of
+	// the body is not limited.
+	MaxBodyBytes int64
+}
+
+// LimitsFromClaims returns the limits configured in the given claims, or nil
+// if there are none.
+func LimitsFromClaims(claims *authenticate.Claims) *Limits {
+	if claims.RelayLimitsRequestsPerSecond <= 0 && claims.RelayLimitsMaxBodyBytes <= 0 {
+		return nil
+	}
+
+	return &Limits{
+		Key:               claims.Subject,
+		RequestsPerSecond: claims.RelayLimitsRequestsPerSecond,
+		Burst:             claims.RelayLimitsBurst,
+		MaxBodyBytes:      claims.RelayLimitsMaxBodyBytes,
+	}
+}
+
+type limitsContextKey int
+
+const (
+	limitsContextKeyLimits limitsContextKey = iota
+)
+
+func withLimits(r *http.Request, l *Limits) *http.Request {
+	if l == nil {
+		return r
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), limitsContextKeyLimits, l))
+}
+
+// RequestLimits returns the limits of the client making the request, or nil
+// if the client is not limited.
+func RequestLimits(r *http.Request) *Limits {
+	l, _ := r.Context().Value(limitsContextKeyLimits).(*Limits)
+	return l
+}
+
+// DefaultRateLimiterIdleTimeout is the time after which the rate limiter of
+// a client that has stopped making requests is discarded.
+const DefaultRateLimiterIdleTimeout = 10 * time.Minute
+
+type rateLimiterEntry struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// idle returns true if the limiter has not been used for at least the given
+// timeout and has since refilled, so that discarding it does not change the
+// requests it allows.
+func (e *rateLimiterEntry) idle(now time.Time, timeout time.Duration) bool {
+	unused := now.Sub(e.lastUsed)
+	if unused < timeout {
+		return false
+	}
+
+	if e.limiter.Limit() == rate.Inf {
+		return true
+	}
+
+	return unused.Seconds() >= float64(e.limiter.Burst())/float64(e.limiter.Limit())
+}
+
+// RateLimiters keeps the rate limiter for each client. Limiters of clients
+// that stop making requests are discarded once they are idle.
+type RateLimiters struct {
+	mut         sync.Mutex
+	limiters    map[string]*rateLimiterEntry
+	idleTimeout time.Duration
+	lastPruned  time.Time
+}
+
+func (rl *RateLimiters) limiter(l *Limits) *rate.Limiter {
+	rl.mut.Lock()
+	defer rl.mut.Unlock()
+
+	now := time.Now()
+	rl.prune(now)
+
+	limiter := ratelimitutil.NewLimiter(l.RequestsPerSecond, l.Burst)
+
+	// Keep the existing state unless the limits have changed.
+	if existing, found := rl.limiters[l.Key]; found && existing.limiter.Limit() == limiter.Limit() && existing.limiter.Burst() == limiter.Burst() {
+		existing.lastUsed = now
+		return existing.limiter
+	}
+
+	rl.limiters[l.Key] = &rateLimiterEntry{
+		limiter:  limiter,
+		lastUsed: now,
+	}
+	return limiter
+}
+
+// prune discards idle limiters. It scans the limiters at most once per idle
+// timeout.
+func (rl *RateLimiters) prune(now time.Time) {
+	if now.Sub(rl.lastPruned) < rl.idleTimeout {
+		return
+	}
+
+	rl.lastPruned = now
+
+	for key, e := range rl.limiters {
+		if e.idle(now, rl.idleTimeout) {
+			delete(rl.limiters, key)
+		}
+	}
+}
+
+// Allow takes a token from the rate limiter of the client with the given
+// limits if one is available now. Otherwise, it returns false and the time to
+// wait before a token is available.
+func (rl *RateLimiters) Allow(l *Limits) (time.Duration, bool) {
+	return ratelimitutil.Allow(rl.limiter(l))
+}
+
+// Len returns the number of clients with a rate limiter.
+func (rl *RateLimiters) Len() int {
+	rl.mut.Lock()
+	defer rl.mut.Unlock()
+
+	return len(rl.limiters)
+}
+
+type RateLimitersOption func(rl *RateLimiters)
+
+// RateLimitersWithIdleTimeout sets the time after which the rate limiter of a
+// client that has stopped making requests is discarded.
+func RateLimitersWithIdleTimeout(timeout time.Duration) RateLimitersOption {
+	return func(rl *RateLimiters) {
+		rl.idleTimeout = timeout
+	}
+}
+
+func NewRateLimiters(opts ...RateLimitersOption) *RateLimiters {
+	rl := &RateLimiters{
+		limiters:    make(map[string]*rateLimiterEntry),
+		idleTimeout: DefaultRateLimiterIdleTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(rl)
+	}
+
+	rl.lastPruned = time.Now()
+	return rl
+}
+
+// WithRequestLimits enforces the limits of the client making the request. A
+// request over the rate limit receives a 429 Too Many Requests response with a
+// Retry-After header, and a request with a body that is too large receives a
+// 413 Request Entity Too Large response.
+func WithRequestLimits(rl *RateLimiters) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			l := RequestLimits(r)
+			if l == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if l.RequestsPerSecond > 0 {
+				if delay, ok := rl.Allow(l); !ok {
+					ratelimitutil.SetRetryAfter(w, delay)
+					utilapi.WriteError(ctx, w, errors.NewAPIRateLimitedError(ratelimitutil.RetryAfterSeconds(delay)))
+					return
+				}
+			}
+
+			if l.MaxBodyBytes > 0 {
+				body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, l.MaxBodyBytes))
+				if err != nil {
+					utilapi.WriteError(ctx, w, errors.NewAPIRequestTooLargeError(l.MaxBodyBytes))
+					return
+				}
+
+				r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package middleware_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitersPruneIdleClients(t *testing.T) {
+	rl := middleware.NewRateLimiters(middleware.RateLimitersWithIdleTimeout(10 * time.Millisecond))
+
+	_, ok := rl.Allow(&middleware.Limits{Key: "a", RequestsPerSecond: 1000, Burst: 1})
+	assert.True(t, ok)
+
+	_, ok = rl.Allow(&middleware.Limits{Key: "b", RequestsPerSecond: 1000, Burst: 1})
+	assert.True(t, ok)
+	assert.Equal(t, 2, rl.Len())
+
+	time.Sleep(20 * time.Millisecond)
+
+	// Using the limiter of one client discards those of the idle clients.
+	_, ok = rl.Allow(&middleware.Limits{Key: "b", RequestsPerSecond: 1000, Burst: 1})
+	assert.True(t, ok)
+	assert.Equal(t, 1, rl.Len())
+
+	// A client that has not refilled its limiter keeps it even after the idle
+	// timeout.
+	_, ok = rl.Allow(&middleware.Limits{Key: "c", RequestsPerSecond: 1, Burst: 1})
+	assert.True(t, ok)
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, ok = rl.Allow(&middleware.Limits{Key: "b", RequestsPerSecond: 1000, Burst: 1})
+	assert.True(t, ok)
+	assert.Equal(t, 2, rl.Len())
+
+	_, ok = rl.Allow(&middleware.Limits{Key: "c", RequestsPerSecond: 1, Burst: 1})
+	assert.False(t, ok)
+}
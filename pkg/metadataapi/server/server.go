@@ -3,10 +3,12 @@ package server
 import (
 	"net/http"
 
+	"github.com/NYTimes/gziphandler"
 	"github.com/gorilla/mux"
 	"github.com/puppetlabs/errawr-go/v2/pkg/errawr"
 	utilapi "github.com/puppetlabs/horsehead/v2/httputil/api"
 	"github.com/puppetlabs/horsehead/v2/instrumentation/alerts/trackers"
+	"github.com/puppetlabs/relay-core/pkg/metadataapi/audit"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/api"
 	"github.com/puppetlabs/relay-core/pkg/metadataapi/server/middleware"
 )
@@ -16,6 +18,7 @@ type Server struct {
 	errorSensitivity errawr.ErrorSensitivity
 	capturer         trackers.Capturer
 	trustedProxyHops int
+	auditSink        audit.Sink
 }
 
 func (s *Server) Route(r *mux.Router) {
@@ -24,7 +27,12 @@ func (s *Server) Route(r *mux.Router) {
 	r.HandleFunc("/healthz", s.GetHealthz).Methods("GET")
 
 	// This has a different set of middleware so bind it under a subrouter.
-	api.NewServer(s.auth).Route(r.NewRoute().Subrouter())
+	var apiOpts []api.ServerOption
+	if s.auditSink != nil {
+		apiOpts = append(apiOpts, api.ServerWithAuditSink(s.auditSink))
+	}
+
+	api.NewServer(s.auth, apiOpts...).Route(r.NewRoute().Subrouter())
 }
 
 type Option func(s *Server)
@@ -47,6 +55,14 @@ func WithTrustedProxyHops(n int) Option {
 	}
 }
 
+// WithAuditSink writes a record of every authenticated API request to the
+// given sink.
+func WithAuditSink(sink audit.Sink) Option {
+	return func(s *Server) {
+		s.auditSink = sink
+	}
+}
+
 func new(auth middleware.Authenticator, opts ...Option) *Server {
 	s := &Server{
 		auth:             auth,
@@ -68,6 +84,7 @@ func NewHandler(auth middleware.Authenticator, opts ...Option) http.Handler {
 
 	// These should run for every request, not just ones that Mux matches.
 	var h http.Handler = r
+	h = gziphandler.GzipHandler(h)
 	h = middleware.WebSecurity(h)
 	h = utilapi.LogMiddleware(h)
 	h = utilapi.RequestMiddleware(h)
@@ -0,0 +1,13 @@
+package model
+
+import (
+	"context"
+)
+
+type ChangeManager interface {
+	// Watch returns a channel that receives a value whenever data available
+	// to the authenticated action may have changed. Notifications are
+	// coalesced, so a single value may represent any number of changes. The
+	// channel is closed when the context is done or the watch ends.
+	Watch(ctx context.Context) (<-chan struct{}, error)
+}
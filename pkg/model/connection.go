@@ -10,4 +10,8 @@ type Connection struct {
 
 type ConnectionManager interface {
 	Get(ctx context.Context, typ, name string) (*Connection, error)
+
+	// List returns the names of the available connections of the given type
+	// in order.
+	List(ctx context.Context, typ string) ([]string, error)
 }
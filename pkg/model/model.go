@@ -1,16 +1,25 @@
 package model
 
 const (
-	DefaultImage              = "alpine:latest"
-	DefaultToolInjectionImage = "relaysh/relay-runtime-tools"
+	DefaultImage                = "alpine:latest"
+	DefaultToolInjectionImage   = "relaysh/relay-runtime-tools"
+	DefaultWebhookVerifierImage = "relaysh/relay-webhook-verifier"
 
-	// TODO All tool injection settings should be fully configurable
+	// TODO The path of the tools within the image should be configurable
 	ToolInjectionImagePath = "/relay/runtime/tools/."
 	ToolInjectionMountName = "relay-runtime-tools"
 	ToolInjectionMountPath = "/var/lib/puppet/relay/"
 
 	ToolInjectionVolumeClaimSuffixReadOnlyMany  = "-volume-rox"
 	ToolInjectionVolumeClaimSuffixReadWriteOnce = "-volume-rwo"
+
+	// MetadataAPITokenFileEnvName is the environment variable set to the path
+	// of the service account token that steps and triggers present to the
+	// metadata API to identify their pod.
+	MetadataAPITokenFileEnvName = "METADATA_API_TOKEN_FILE"
+	MetadataAPITokenMountName   = "relay-metadata-api-token"
+	MetadataAPITokenMountPath   = "/var/run/secrets/relay.sh/metadata-api/"
+	MetadataAPITokenPath        = "token"
 )
 
 const (
@@ -20,19 +29,29 @@ const (
 	RelayVaultSecretPathAnnotation     = "relay.sh/vault-secret-path"
 	RelayVaultConnectionPathAnnotation = "relay.sh/vault-connection-path"
 
+	// RelayReplayWebhookRequestAnnotation is set on a webhook trigger to the
+	// ID of a captured request to send it to the trigger again.
+	RelayReplayWebhookRequestAnnotation = "relay.sh/replay-webhook-request"
+
 	RelayControllerTokenHashAnnotation        = "controller.relay.sh/token-hash"
+	RelayControllerTokenExpiryAnnotation      = "controller.relay.sh/token-expiry"
 	RelayControllerDependencyOfAnnotation     = "controller.relay.sh/dependency-of"
 	RelayControllerToolsVolumeClaimAnnotation = "controller.relay.sh/tools-volume-claim"
+	RelayControllerToolsMountPathAnnotation   = "controller.relay.sh/tools-mount-path"
 
-	RelayControllerTenantNameLabel       = "controller.relay.sh/tenant-name"
-	RelayControllerTenantWorkloadLabel   = "controller.relay.sh/tenant-workload"
-	RelayControllerWorkflowRunIDLabel    = "controller.relay.sh/run-id"
-	RelayControllerWebhookTriggerIDLabel = "controller.relay.sh/webhook-trigger-id"
+	RelayControllerTenantNameLabel          = "controller.relay.sh/tenant-name"
+	RelayControllerTenantWorkloadLabel      = "controller.relay.sh/tenant-workload"
+	RelayControllerWorkflowRunIDLabel       = "controller.relay.sh/run-id"
+	RelayControllerParentWorkflowRunIDLabel = "controller.relay.sh/parent-run-id"
+	RelayControllerWebhookTriggerIDLabel    = "controller.relay.sh/webhook-trigger-id"
+	RelayControllerWebhookVerifierIDLabel   = "controller.relay.sh/webhook-verifier-id"
+	RelayControllerToolsVersionLabel        = "controller.relay.sh/tools-version"
 )
 
 // MetadataManagers are the managers used by actions accessing the metadata
 // service.
 type MetadataManagers interface {
+	Changes() ChangeManager
 	Conditions() ConditionGetterManager
 	Connections() ConnectionManager
 	Events() EventManager
@@ -40,8 +59,10 @@ type MetadataManagers interface {
 	Parameters() ParameterGetterManager
 	Secrets() SecretManager
 	Spec() SpecGetterManager
-	State() StateGetterManager
+	State() StateManager
 	StepOutputs() StepOutputManager
+	WebhookDeliveries() WebhookDeliveryRecorderManager
+	WebhookRequests() WebhookRequestRecorderManager
 }
 
 // RunReconcilerManagers are the managers used by the run reconciler when
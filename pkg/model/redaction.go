@@ -0,0 +1,22 @@
+package model
+
+import (
+	"context"
+)
+
+type RedactionGetterManager interface {
+	// List returns every value recorded for the authenticated action.
+	List(ctx context.Context) ([]string, error)
+}
+
+type RedactionRecorderManager interface {
+	// Record stores values that the authenticated action has retrieved from
+	// the given source, like a secret, and that must therefore be removed
+	// from its logs. Values previously recorded for the source are replaced.
+	Record(ctx context.Context, source string, values []string) error
+}
+
+type RedactionManager interface {
+	RedactionGetterManager
+	RedactionRecorderManager
+}
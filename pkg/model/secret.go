@@ -8,4 +8,8 @@ type Secret struct {
 
 type SecretManager interface {
 	Get(ctx context.Context, name string) (*Secret, error)
+
+	// List returns the names of the available secrets in order. It does not
+	// retrieve their values.
+	List(ctx context.Context) ([]string, error)
 }
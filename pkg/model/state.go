@@ -15,7 +15,14 @@ type StateSetterManager interface {
 	Set(ctx context.Context, name string, value interface{}) (*State, error)
 }
 
+type StateDeleterManager interface {
+	// Delete removes the named state. Deleting state that does not exist is
+	// not an error.
+	Delete(ctx context.Context, name string) error
+}
+
 type StateManager interface {
 	StateGetterManager
 	StateSetterManager
+	StateDeleterManager
 }
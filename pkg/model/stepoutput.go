@@ -8,14 +8,28 @@ type StepOutput struct {
 	Step  *Step
 	Name  string
 	Value interface{}
+
+	// Sensitive indicates that the value should be protected at rest and must
+	// not be displayed, for example in logs.
+	Sensitive bool
 }
 
 type StepOutputGetterManager interface {
 	Get(ctx context.Context, stepName, name string) (*StepOutput, error)
+
+	// List returns every output of the run, ordered by step name and then by
+	// output name.
+	List(ctx context.Context) ([]*StepOutput, error)
+
+	// ListStep returns the outputs of the given step, ordered by name.
+	ListStep(ctx context.Context, stepName string) ([]*StepOutput, error)
 }
 
 type StepOutputSetterManager interface {
 	Set(ctx context.Context, name string, value interface{}) (*StepOutput, error)
+
+	// SetSensitive sets an output like Set, but marks it as sensitive.
+	SetSensitive(ctx context.Context, name string, value interface{}) (*StepOutput, error)
 }
 
 type StepOutputManager interface {
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// WebhookDeliveries summarizes the requests received by a webhook trigger and
+// the events it emitted.
+type WebhookDeliveries struct {
+	// LastRequestTime is the time the most recent request was received, or
+	// the zero time if no request has been observed.
+	LastRequestTime time.Time
+
+	// LastEventTime is the time the most recent event was emitted, or the zero
+	// time if no event has been emitted.
+	LastEventTime time.Time
+
+	RequestsReceived int64
+	EventsEmitted    int64
+
+	// Failed counts both requests the trigger responded to with an error and
+	// events that could not be emitted.
+	Failed int64
+
+	LastError     string
+	LastErrorTime time.Time
+
+	// Sinks summarizes the delivery of events to each named event sink, sorted
+	// by name.
+	Sinks []*WebhookSinkDeliveries
+}
+
+// WebhookSinkDeliveries summarizes the events delivered to a single named
+// event sink.
+type WebhookSinkDeliveries struct {
+	Name string
+
+	// LastEventTime is the time the most recent event was delivered to the
+	// sink, or the zero time if no event has been delivered.
+	LastEventTime time.Time
+
+	EventsDelivered int64
+	Failed          int64
+
+	LastError     string
+	LastErrorTime time.Time
+}
+
+type WebhookDeliveryGetterManager interface {
+	Get(ctx context.Context) (*WebhookDeliveries, error)
+}
+
+type WebhookDeliveryRecorderManager interface {
+	// RecordRequest notes that the trigger completed handling a request at the
+	// given time. If the request failed, the error describes why.
+	RecordRequest(ctx context.Context, at time.Time, failure error) error
+
+	// RecordEvent notes that the trigger attempted to emit an event at the
+	// given time. If the event could not be emitted, the error describes why.
+	RecordEvent(ctx context.Context, at time.Time, failure error) error
+
+	// RecordSinkEvent notes that the trigger attempted to deliver an event to
+	// the named event sink at the given time. If the event could not be
+	// delivered, the error describes why.
+	RecordSinkEvent(ctx context.Context, sink string, at time.Time, failure error) error
+}
+
+type WebhookDeliveryManager interface {
+	WebhookDeliveryGetterManager
+	WebhookDeliveryRecorderManager
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// WebhookRequestIDHeader is set by the webhook verifier on each request it
+// forwards to a webhook trigger. A trigger that sends the same header with the
+// events it emits while handling the request lets them be attached to the
+// request exactly.
+const WebhookRequestIDHeader = "X-Relay-Webhook-Request-Id"
+
+// WebhookRequest is a request received by a webhook trigger, captured for
+// debugging.
+type WebhookRequest struct {
+	ID string
+
+	ReceivedAt  time.Time
+	CompletedAt time.Time
+
+	Method string
+	// URL is the path and query of the request.
+	URL string
+	// Header contains the request headers. Headers that may contain
+	// credentials are redacted.
+	Header http.Header
+	Body   []byte
+	// BodyTruncated is true if the request body was too large to be captured
+	// in full.
+	BodyTruncated bool
+
+	// StatusCode is the status of the response returned by the trigger.
+	StatusCode int
+	// Error describes why the request failed, if known.
+	Error string
+
+	// Events are the events emitted by the trigger while handling the request.
+	Events []*Event
+
+	// ReplayOf is the ID of the captured request this request replays, if
+	// any.
+	ReplayOf string
+}
+
+type WebhookRequestGetterManager interface {
+	List(ctx context.Context) ([]*WebhookRequest, error)
+	Get(ctx context.Context, id string) (*WebhookRequest, error)
+}
+
+type WebhookRequestRecorderManager interface {
+	// Record stores a request once the trigger has responded to it. Events
+	// recorded with the ID of the request are attached to it. Events recorded
+	// without a request ID are attached to it if they were recorded between
+	// the time the request was received and the time it completed.
+	Record(ctx context.Context, req *WebhookRequest) (*WebhookRequest, error)
+
+	// RecordEvent notes that the trigger emitted an event so it can be
+	// attached to the request being handled. The request ID is the value of
+	// WebhookRequestIDHeader sent by the trigger, if any.
+	RecordEvent(ctx context.Context, ev *Event, requestID string, at time.Time) error
+}
+
+type WebhookRequestManager interface {
+	WebhookRequestGetterManager
+	WebhookRequestRecorderManager
+}
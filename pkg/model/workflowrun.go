@@ -0,0 +1,34 @@
+package model
+
+import (
+	"context"
+
+	"github.com/puppetlabs/relay-core/pkg/expr/parse"
+)
+
+// WorkflowRunTemplate is the workflow run to create for each event emitted by
+// a trigger when its tenant uses a direct event sink.
+type WorkflowRunTemplate struct {
+	// WorkflowRun is the JSON representation of the workflow run to create,
+	// without a name or parameters.
+	WorkflowRun map[string]interface{}
+
+	// Parameters maps the data of an event to the parameters of the workflow
+	// run.
+	Parameters parse.Tree
+}
+
+type WorkflowRunTemplateGetterManager interface {
+	// Get retrieves the workflow run template for this action, if any.
+	Get(ctx context.Context) (*WorkflowRunTemplate, error)
+}
+
+type WorkflowRunTemplateSetterManager interface {
+	// Set stores the workflow run template for this action.
+	Set(ctx context.Context, tmpl *WorkflowRunTemplate) (*WorkflowRunTemplate, error)
+}
+
+type WorkflowRunTemplateManager interface {
+	WorkflowRunTemplateGetterManager
+	WorkflowRunTemplateSetterManager
+}
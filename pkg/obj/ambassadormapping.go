@@ -0,0 +1,188 @@
+package obj
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	AmbassadorMappingKind = schema.GroupVersionKind{Group: "getambassador.io", Version: "v2", Kind: "Mapping"}
+	AmbassadorHostKind    = schema.GroupVersionKind{Group: "getambassador.io", Version: "v2", Kind: "Host"}
+)
+
+type AmbassadorMapping struct {
+	*UnstructuredRoute
+
+	// TLS indicates that the host of the mapping is served with a
+	// certificate.
+	TLS bool
+}
+
+var _ WebhookTriggerRoute = &AmbassadorMapping{}
+
+func (am *AmbassadorMapping) Ready() bool {
+	// Mappings do not report any status.
+	return am.Object.GetUID() != ""
+}
+
+func (am *AmbassadorMapping) URL() string {
+	prefix, _, _ := unstructured.NestedString(am.Object.Object, "spec", "prefix")
+
+	// If the mapping matches a pattern instead of a single host, the best we
+	// can do is report the host requests are rewritten to.
+	host, _, _ := unstructured.NestedString(am.Object.Object, "spec", "host")
+	if regex, _, _ := unstructured.NestedBool(am.Object.Object, "spec", "host_regex"); regex {
+		host, _, _ = unstructured.NestedString(am.Object.Object, "spec", "host_rewrite")
+	}
+
+	scheme := "http"
+	if am.TLS {
+		scheme = "https"
+	}
+
+	return webhookTriggerRouteURL(scheme, host, prefix)
+}
+
+func NewAmbassadorMapping(key client.ObjectKey) *AmbassadorMapping {
+	return &AmbassadorMapping{
+		UnstructuredRoute: NewUnstructuredRoute(AmbassadorMappingKind, key),
+	}
+}
+
+// NewAmbassadorHost creates an Ambassador host, which configures the
+// certificate Ambassador serves a host name with.
+func NewAmbassadorHost(key client.ObjectKey) *UnstructuredRoute {
+	return NewUnstructuredRoute(AmbassadorHostKind, key)
+}
+
+// AmbassadorWebhookTriggerRouter exposes webhook triggers using Ambassador.
+// Knative services are annotated for Ambassador's Knative integration, and
+// other workloads, as well as webhook triggers that request a specific route,
+// are exposed using mappings.
+type AmbassadorWebhookTriggerRouter struct {
+	// Domain is the DNS suffix of the host names assigned to webhook
+	// triggers that are not run by Knative. If not specified, mappings match
+	// the trigger name and namespace under any domain.
+	Domain string
+
+	// AmbassadorID selects the Ambassador instance that serves mappings.
+	AmbassadorID string
+}
+
+var _ WebhookTriggerRouter = &AmbassadorWebhookTriggerRouter{}
+
+func (r *AmbassadorWebhookTriggerRouter) ApplyRoute(ctx context.Context, cl client.Client, wtd *WebhookTriggerDeps, target WebhookTriggerService) (WebhookTriggerRoute, error) {
+	key, targetHost, err := webhookTriggerRouteTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	host, path := WebhookTriggerRouteAddress(wtd.WebhookTrigger.Object, WebhookTriggerServiceKey(wtd).Namespace, r.Domain)
+
+	tlsSecretName, err := webhookTriggerRouteTLSSecretName(wtd, host)
+	if err != nil {
+		return nil, err
+	}
+
+	// Knative has its own integration with Ambassador, so we only need to
+	// select the Ambassador instance. Unless a specific route is requested,
+	// the Knative service then reports its own URL.
+	if ks, ok := target.(*KnativeService); ok {
+		if Annotate(&ks.Object.ObjectMeta, AmbassadorIDAnnotation, r.AmbassadorID) {
+			if err := ks.Persist(ctx, cl); err != nil {
+				return nil, err
+			}
+		}
+
+		if wtd.WebhookTrigger.Object.Spec.Route == nil {
+			if err := r.deleteMapping(ctx, cl, ks.Key); err != nil {
+				return nil, err
+			}
+
+			return ks, nil
+		}
+	}
+
+	if tlsSecretName == "" {
+		if err := NewAmbassadorHost(key).Delete(ctx, cl); err != nil {
+			return nil, err
+		}
+	} else if err := applyUnstructuredRoute(ctx, cl, wtd, NewAmbassadorHost(key), func(spec map[string]interface{}) error {
+		spec["ambassador_id"] = []interface{}{r.AmbassadorID}
+		spec["hostname"] = host
+		spec["tlsSecret"] = map[string]interface{}{
+			"name": tlsSecretName,
+		}
+
+		// The certificate is provided by the user.
+		spec["acmeProvider"] = map[string]interface{}{
+			"authority": "none",
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	am := NewAmbassadorMapping(key)
+	am.TLS = tlsSecretName != ""
+
+	if err := applyUnstructuredRoute(ctx, cl, wtd, am.UnstructuredRoute, func(spec map[string]interface{}) error {
+		spec["ambassador_id"] = []interface{}{r.AmbassadorID}
+		spec["prefix"] = path
+
+		// Pass the full request path through to the workload.
+		spec["rewrite"] = ""
+
+		if host != "" {
+			spec["host"] = host
+		} else {
+			// Use the same host name layout as Knative,
+			// <name>.<namespace>.<domain>.
+			spec["host"] = fmt.Sprintf(`^%s\.%s\..+$`, regexp.QuoteMeta(wtd.WebhookTrigger.Key.Name), regexp.QuoteMeta(key.Namespace))
+			spec["host_regex"] = true
+		}
+
+		// Both Knative and the activator route requests using the host name.
+		spec["host_rewrite"] = targetHost
+		spec["service"] = fmt.Sprintf("http://%s", targetHost)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return am, nil
+}
+
+func (r *AmbassadorWebhookTriggerRouter) DeleteRoute(ctx context.Context, cl client.Client, key client.ObjectKey) error {
+	if err := r.deleteMapping(ctx, cl, key); err != nil {
+		return err
+	}
+
+	ks := NewKnativeService(key)
+	if ok, err := ks.Load(ctx, cl); meta.IsNoMatchError(err) {
+		return nil
+	} else if err != nil || !ok {
+		return err
+	}
+
+	if _, found := ks.Object.GetAnnotations()[AmbassadorIDAnnotation]; !found {
+		return nil
+	}
+
+	delete(ks.Object.Annotations, AmbassadorIDAnnotation)
+	return ks.Persist(ctx, cl)
+}
+
+func (r *AmbassadorWebhookTriggerRouter) deleteMapping(ctx context.Context, cl client.Client, key client.ObjectKey) error {
+	if err := NewAmbassadorMapping(key).Delete(ctx, cl); err != nil {
+		return err
+	}
+
+	return NewAmbassadorHost(key).Delete(ctx, cl)
+}
@@ -17,11 +17,12 @@ JQ="${JQ:-jq}"
 
 CONDITIONS_URL="${CONDITIONS_URL:-conditions}"
 VALUE_NAME="${VALUE_NAME:-success}"
+POLLING_WAIT="${POLLING_WAIT:-60s}"
 POLLING_INTERVAL="${POLLING_INTERVAL:-5s}"
-POLLING_ITERATIONS="${POLLING_ITERATIONS:-1080}"
+POLLING_TIMEOUT_SECONDS="${POLLING_TIMEOUT_SECONDS:-5400}"
 
-for i in $(seq ${POLLING_ITERATIONS}); do
-	CONDITIONS=$(curl "$METADATA_API_URL/${CONDITIONS_URL}")
+while [ "${SECONDS}" -lt "${POLLING_TIMEOUT_SECONDS}" ]; do
+	CONDITIONS=$(curl "$METADATA_API_URL/${CONDITIONS_URL}?wait=${POLLING_WAIT}")
 	VALUE=$(echo $CONDITIONS | $JQ --arg value "$VALUE_NAME" -r '.[$value]')
 	if [ -n "${VALUE}" ]; then
 	if [ "$VALUE" = "true" ]; then
@@ -2,12 +2,16 @@ package obj
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
 	"github.com/puppetlabs/relay-core/pkg/errmark"
 	"github.com/puppetlabs/relay-core/pkg/expr/evaluate"
+	"github.com/puppetlabs/relay-core/pkg/expr/parse"
 	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
 	"github.com/puppetlabs/relay-core/pkg/model"
+	workflowv1 "github.com/puppetlabs/relay-core/pkg/workflow/types/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -59,46 +63,90 @@ func NewConfigMap(key client.ObjectKey) *ConfigMap {
 	}
 }
 
-func ConfigureImmutableConfigMapForWebhookTrigger(ctx context.Context, cm *ConfigMap, wt *WebhookTrigger) error {
+func ConfigureImmutableConfigMapForWebhookTrigger(ctx context.Context, cm *ConfigMap, wt *WebhookTrigger, t *Tenant) error {
 	tm := ModelWebhookTrigger(wt)
 
 	// This implementation manages the underlying object, so no need to retrieve
 	// it later.
 	lcm := configmap.NewLocalConfigMap(cm.Object)
 
-	ev := evaluate.NewEvaluator()
+	if err := configureSpecAndEnvironment(ctx, lcm, tm, wt.Object.Spec.Spec, wt.Object.Spec.Env, t); err != nil {
+		return err
+	}
 
-	if len(wt.Object.Spec.Spec) > 0 {
-		r, err := ev.EvaluateAll(ctx, wt.Object.Spec.Spec.Value())
-		if err != nil {
-			return errmark.MarkUser(err)
+	if len(wt.Object.Spec.Input) > 0 {
+		if _, err := configmap.MutateConfigMap(ctx, lcm, func(cm *corev1.ConfigMap) {
+			cm.Data[scriptConfigMapKey(tm)] = model.ScriptForInput(wt.Object.Spec.Input)
+		}); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
 
-		if _, err := configmap.NewSpecManager(tm, lcm).Set(ctx, r.Value.(map[string]interface{})); err != nil {
+// ConfigureImmutableConfigMapForDirectTriggerEventSink stores the workflow runs
+// that the metadata API creates for each event emitted by the webhook trigger.
+func ConfigureImmutableConfigMapForDirectTriggerEventSink(ctx context.Context, cm *ConfigMap, wt *WebhookTrigger, td *TenantDeps) error {
+	labels := map[string]string{
+		model.RelayControllerWebhookTriggerIDLabel: wt.Key.Name,
+	}
+
+	lcm := configmap.NewLocalConfigMap(cm.Object)
+	action := ModelWebhookTrigger(wt)
+
+	return forEachDirectTriggerEventSink(td.DirectTriggerEventSink, td.NamedTriggerEventSinks, func(name string, sink *DirectTriggerEventSink) error {
+		tmpl, err := newWorkflowRunTemplate(ctx, sink, td.Tenant, wt.Object.ObjectMeta, labels)
+		if err != nil || tmpl == nil {
 			return err
 		}
+
+		_, err = newWorkflowRunTemplateManager(action, name, lcm).Set(ctx, tmpl)
+		return err
+	})
+}
+
+// ConfigureImmutableConfigMapForWorkflowRunEvents stores the workflow runs that
+// the metadata API creates for each event emitted by a step of the workflow
+// run.
+func ConfigureImmutableConfigMapForWorkflowRunEvents(ctx context.Context, cm *ConfigMap, wr *WorkflowRun, t *Tenant, direct *DirectTriggerEventSink, named []*NamedTriggerEventSink) error {
+	labels := map[string]string{
+		model.RelayControllerParentWorkflowRunIDLabel: wr.Key.Name,
 	}
 
-	if env := wt.Object.Spec.Env.Value(); env != nil {
-		em := configmap.NewEnvironmentManager(ModelWebhookTrigger(wt), lcm)
+	lcm := configmap.NewLocalConfigMap(cm.Object)
 
-		vars := make(map[string]interface{})
-		for name, value := range env {
-			r, err := ev.EvaluateAll(ctx, value)
-			if err != nil {
-				return errmark.MarkUser(err)
-			}
+	return forEachDirectTriggerEventSink(direct, named, func(name string, sink *DirectTriggerEventSink) error {
+		tmpl, err := newWorkflowRunTemplate(ctx, sink, t, wr.Object.ObjectMeta, labels)
+		if err != nil || tmpl == nil {
+			return err
+		}
 
-			vars[name] = r.Value.(map[string]interface{})
+		for _, step := range wr.Object.Spec.Workflow.Steps {
+			if _, err := newWorkflowRunTemplateManager(ModelStep(wr, step), name, lcm).Set(ctx, tmpl); err != nil {
+				return err
+			}
 		}
 
-		em.Set(ctx, vars)
+		return nil
+	})
+}
+
+// forEachDirectTriggerEventSink calls fn for the unnamed direct sink, if any,
+// and each named direct sink.
+func forEachDirectTriggerEventSink(direct *DirectTriggerEventSink, named []*NamedTriggerEventSink, fn func(name string, sink *DirectTriggerEventSink) error) error {
+	if direct != nil {
+		if err := fn("", direct); err != nil {
+			return err
+		}
 	}
 
-	if len(wt.Object.Spec.Input) > 0 {
-		if _, err := configmap.MutateConfigMap(ctx, lcm, func(cm *corev1.ConfigMap) {
-			cm.Data[scriptConfigMapKey(tm)] = model.ScriptForInput(wt.Object.Spec.Input)
-		}); err != nil {
+	for _, sink := range named {
+		if sink.Direct == nil {
+			continue
+		}
+
+		if err := fn(sink.Name(), sink.Direct); err != nil {
 			return err
 		}
 	}
@@ -106,7 +154,85 @@ func ConfigureImmutableConfigMapForWebhookTrigger(ctx context.Context, cm *Confi
 	return nil
 }
 
-func ConfigureImmutableConfigMapForWorkflowRun(ctx context.Context, cm *ConfigMap, wr *WorkflowRun) error {
+func newWorkflowRunTemplateManager(action model.Action, sink string, cm configmap.ConfigMap) model.WorkflowRunTemplateManager {
+	if sink == "" {
+		return configmap.NewWorkflowRunTemplateManager(action, cm)
+	}
+
+	return configmap.NewWorkflowRunTemplateManagerForSink(action, sink, cm)
+}
+
+// newWorkflowRunTemplate creates the template for workflow runs created by a
+// direct event sink. It returns nil if the sink does not have a workflow
+// definition.
+func newWorkflowRunTemplate(ctx context.Context, sink *DirectTriggerEventSink, t *Tenant, from metav1.ObjectMeta, labels map[string]string) (*model.WorkflowRunTemplate, error) {
+	// The tenant reports a missing workflow definition in its status.
+	data, ok := sink.Workflow()
+	if !ok {
+		return nil, nil
+	}
+
+	wd, err := (&workflowv1.YAMLDecoder{}).Decode(ctx, []byte(data))
+	if err != nil {
+		return nil, errmark.MarkUser(err)
+	}
+
+	name := wd.Name
+	if name == "" {
+		name = sink.Sink.WorkflowFrom.ConfigMapKeyRef.Name
+	}
+
+	manifest, err := workflowv1.NewDefaultRunEngineMapper(workflowv1.WithWorkflowNameRunOption(name)).ToRuntimeObjectsManifest(wd)
+	if err != nil {
+		return nil, errmark.MarkUser(err)
+	}
+
+	wr := manifest.WorkflowRun
+	wr.ObjectMeta = metav1.ObjectMeta{
+		Labels: labels,
+	}
+	wr.Spec.Name = ""
+
+	// Runs share the domain, tenant and Vault configuration of the object
+	// that created them.
+	for _, annotation := range []string{
+		model.RelayDomainIDAnnotation,
+		model.RelayTenantIDAnnotation,
+		model.RelayVaultEngineMountAnnotation,
+		model.RelayVaultSecretPathAnnotation,
+		model.RelayVaultConnectionPathAnnotation,
+	} {
+		if value, found := from.GetAnnotations()[annotation]; found {
+			metav1.SetMetaDataAnnotation(&wr.ObjectMeta, annotation, value)
+		}
+	}
+
+	// Runs are created in the namespace of the object that emitted the event.
+	// For a managed tenant, that is the child namespace of the tenant, from
+	// which the tenant is still resolved by name (see LoadTenantForNamespace).
+	wr.Spec.TenantRef = &corev1.LocalObjectReference{Name: t.Key.Name}
+
+	b, err := json.Marshal(wr)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, err
+	}
+
+	tmpl := &model.WorkflowRunTemplate{
+		WorkflowRun: obj,
+	}
+	if len(sink.Sink.Parameters) > 0 {
+		tmpl.Parameters = parse.Tree(sink.Sink.Parameters.Value())
+	}
+
+	return tmpl, nil
+}
+
+func ConfigureImmutableConfigMapForWorkflowRun(ctx context.Context, cm *ConfigMap, wr *WorkflowRun, t *Tenant) error {
 	// This implementation manages the underlying object, so no need to retrieve
 	// it later.
 	lcm := configmap.NewLocalConfigMap(cm.Object)
@@ -125,42 +251,76 @@ func ConfigureImmutableConfigMapForWorkflowRun(ctx context.Context, cm *ConfigMa
 	ev := evaluate.NewEvaluator()
 
 	for _, step := range wr.Object.Spec.Workflow.Steps {
-		if len(step.Spec) > 0 {
-			r, err := ev.EvaluateAll(ctx, step.Spec.Value())
+		if err := configureSpecAndEnvironment(ctx, lcm, ModelStep(wr, step), step.Spec, step.Env, t); err != nil {
+			return err
+		}
+
+		if when := step.When.Value(); when != nil {
+			r, err := ev.EvaluateAll(ctx, when)
 			if err != nil {
 				return errmark.MarkUser(err)
 			}
 
-			if _, err := configmap.NewSpecManager(ModelStep(wr, step), lcm).Set(ctx, r.Value.(map[string]interface{})); err != nil {
+			if _, err := configmap.NewConditionManager(ModelStep(wr, step), lcm).Set(ctx, r.Value); err != nil {
 				return err
 			}
 		}
+	}
+
+	return nil
+}
+
+// configureSpecAndEnvironment stores the spec and environment variables of an
+// action. If the action belongs to a tenant, the defaults of the tenant are
+// merged in with the values of the action taking precedence.
+func configureSpecAndEnvironment(ctx context.Context, lcm configmap.ConfigMap, action model.Action, spec, env relayv1beta1.UnstructuredObject, t *Tenant) error {
+	var defaults relayv1beta1.TenantDefaults
+	if t != nil {
+		defaults = t.Object.Spec.Defaults
+	}
+
+	ev := evaluate.NewEvaluator()
+
+	var tree parse.Tree
+	switch {
+	case len(defaults.Spec) > 0 && len(spec) > 0:
+		// Merging is deferred to the metadata API if either side refers to
+		// values that can't be resolved yet.
+		tree = map[string]interface{}{
+			"$fn.merge": []interface{}{defaults.Spec.Value(), spec.Value()},
+		}
+	case len(defaults.Spec) > 0:
+		tree = defaults.Spec.Value()
+	case len(spec) > 0:
+		tree = spec.Value()
+	}
 
-		if env := step.Env.Value(); env != nil {
-			em := configmap.NewEnvironmentManager(ModelStep(wr, step), lcm)
+	if tree != nil {
+		r, err := ev.EvaluateAll(ctx, tree)
+		if err != nil {
+			return errmark.MarkUser(err)
+		}
+
+		if _, err := configmap.NewSpecManager(action, lcm).Set(ctx, r.Value.(map[string]interface{})); err != nil {
+			return err
+		}
+	}
 
-			vars := make(map[string]interface{})
-			for name, value := range env {
+	if len(defaults.Env) > 0 || len(env) > 0 {
+		vars := make(map[string]interface{})
+		for _, src := range []relayv1beta1.UnstructuredObject{defaults.Env, env} {
+			for name, value := range src.Value() {
 				r, err := ev.EvaluateAll(ctx, value)
 				if err != nil {
 					return errmark.MarkUser(err)
 				}
 
-				vars[name] = r.Value.(map[string]interface{})
+				vars[name] = r.Value
 			}
-
-			em.Set(ctx, vars)
 		}
 
-		if when := step.When.Value(); when != nil {
-			r, err := ev.EvaluateAll(ctx, when)
-			if err != nil {
-				return errmark.MarkUser(err)
-			}
-
-			if _, err := configmap.NewConditionManager(ModelStep(wr, step), lcm).Set(ctx, r.Value); err != nil {
-				return err
-			}
+		if _, err := configmap.NewEnvironmentManager(action, lcm).Set(ctx, vars); err != nil {
+			return err
 		}
 	}
 
@@ -0,0 +1,299 @@
+package obj_test
+
+import (
+	"context"
+	"testing"
+
+	nebulav1 "github.com/puppetlabs/relay-core/pkg/apis/nebula.puppet.com/v1"
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/puppetlabs/relay-core/pkg/obj"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestConfigureImmutableConfigMapForDirectTriggerEventSink(t *testing.T) {
+	ctx := context.Background()
+
+	tn := obj.NewTenant(types.NamespacedName{Namespace: "default", Name: "my-tenant"})
+	tn.Object.Spec.TriggerEventSink.Direct = &relayv1beta1.DirectTriggerEventSink{
+		WorkflowFrom: relayv1beta1.WorkflowSource{
+			ConfigMapKeyRef: &relayv1beta1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "my-workflow"},
+				Key:                  "workflow.yaml",
+			},
+		},
+		Parameters: relayv1beta1.NewUnstructuredObject(map[string]interface{}{
+			"repo": map[string]interface{}{"$type": "Data", "query": "repository.name"},
+		}),
+	}
+
+	td := obj.NewTenantDeps(tn)
+	td.DirectTriggerEventSink.WorkflowConfigMap.Object.Data = map[string]string{
+		"workflow.yaml": `
+apiVersion: v1
+parameters:
+  repo:
+    default: unknown
+steps:
+- name: hello
+  image: alpine:latest
+`,
+	}
+
+	wt := obj.NewWebhookTrigger(types.NamespacedName{Namespace: "default", Name: "my-trigger"})
+	wt.Object.Annotations = map[string]string{
+		model.RelayDomainIDAnnotation: "my-domain",
+	}
+
+	cm := obj.NewConfigMap(types.NamespacedName{Namespace: "default", Name: "my-trigger-immutable"})
+	require.NoError(t, obj.ConfigureImmutableConfigMapForDirectTriggerEventSink(ctx, cm, wt, td))
+
+	tmpl, err := configmap.NewWorkflowRunTemplateManager(obj.ModelWebhookTrigger(wt), configmap.NewLocalConfigMap(cm.Object)).Get(ctx)
+	require.NoError(t, err)
+
+	metadata := tmpl.WorkflowRun["metadata"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{model.RelayControllerWebhookTriggerIDLabel: "my-trigger"}, metadata["labels"])
+	assert.Equal(t, map[string]interface{}{model.RelayDomainIDAnnotation: "my-domain"}, metadata["annotations"])
+
+	spec := tmpl.WorkflowRun["spec"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"name": "my-tenant"}, spec["tenantRef"])
+
+	workflow := spec["workflow"].(map[string]interface{})
+	assert.Equal(t, "my-workflow", workflow["name"])
+	assert.Equal(t, map[string]interface{}{"repo": "unknown"}, workflow["parameters"])
+	assert.Len(t, workflow["steps"], 1)
+
+	assert.Equal(t, map[string]interface{}{
+		"repo": map[string]interface{}{"$type": "Data", "query": "repository.name"},
+	}, tmpl.Parameters)
+}
+
+func TestConfigureImmutableConfigMapForDirectTriggerEventSinkOfManagedTenant(t *testing.T) {
+	ctx := context.Background()
+
+	tn := obj.NewTenant(types.NamespacedName{Namespace: "default", Name: "my-tenant"})
+	tn.Object.Spec.NamespaceTemplate.Metadata.Name = "my-tenant-child"
+	tn.Object.Spec.TriggerEventSink.Direct = &relayv1beta1.DirectTriggerEventSink{
+		WorkflowFrom: relayv1beta1.WorkflowSource{
+			ConfigMapKeyRef: &relayv1beta1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "my-workflow"},
+				Key:                  "workflow.yaml",
+			},
+		},
+	}
+
+	td := obj.NewTenantDeps(tn)
+	td.DirectTriggerEventSink.WorkflowConfigMap.Object.Data = map[string]string{
+		"workflow.yaml": `
+apiVersion: v1
+steps:
+- name: hello
+  image: alpine:latest
+`,
+	}
+
+	wt := obj.NewWebhookTrigger(types.NamespacedName{Namespace: "my-tenant-child", Name: "my-trigger"})
+
+	cm := obj.NewConfigMap(types.NamespacedName{Namespace: "my-tenant-child", Name: "my-trigger-immutable"})
+	require.NoError(t, obj.ConfigureImmutableConfigMapForDirectTriggerEventSink(ctx, cm, wt, td))
+
+	tmpl, err := configmap.NewWorkflowRunTemplateManager(obj.ModelWebhookTrigger(wt), configmap.NewLocalConfigMap(cm.Object)).Get(ctx)
+	require.NoError(t, err)
+
+	// Runs created in the child namespace still refer to the managed tenant,
+	// so they inherit its configuration.
+	spec := tmpl.WorkflowRun["spec"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"name": "my-tenant"}, spec["tenantRef"])
+}
+
+func TestConfigureImmutableConfigMapForNamedDirectTriggerEventSink(t *testing.T) {
+	ctx := context.Background()
+
+	tn := obj.NewTenant(types.NamespacedName{Namespace: "default", Name: "my-tenant"})
+	tn.Object.Spec.TriggerEventSink.Sinks = []relayv1beta1.NamedTriggerEventSink{
+		{
+			Name: "audit",
+			Direct: &relayv1beta1.DirectTriggerEventSink{
+				WorkflowFrom: relayv1beta1.WorkflowSource{
+					ConfigMapKeyRef: &relayv1beta1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "my-audit-workflow"},
+						Key:                  "workflow.yaml",
+					},
+				},
+			},
+		},
+		{
+			Name: "relay",
+			API:  &relayv1beta1.APITriggerEventSink{URL: "http://example.com", Token: "my-token"},
+		},
+	}
+
+	td := obj.NewTenantDeps(tn)
+	require.Len(t, td.NamedTriggerEventSinks, 2)
+	td.NamedTriggerEventSinks[0].Direct.WorkflowConfigMap.Object.Data = map[string]string{
+		"workflow.yaml": `
+apiVersion: v1
+steps:
+- name: record
+  image: alpine:latest
+`,
+	}
+
+	wt := obj.NewWebhookTrigger(types.NamespacedName{Namespace: "default", Name: "my-trigger"})
+
+	cm := obj.NewConfigMap(types.NamespacedName{Namespace: "default", Name: "my-trigger-immutable"})
+	require.NoError(t, obj.ConfigureImmutableConfigMapForDirectTriggerEventSink(ctx, cm, wt, td))
+
+	lcm := configmap.NewLocalConfigMap(cm.Object)
+
+	// The tenant does not have an unnamed direct sink.
+	_, err := configmap.NewWorkflowRunTemplateManager(obj.ModelWebhookTrigger(wt), lcm).Get(ctx)
+	require.Equal(t, model.ErrNotFound, err)
+
+	tmpl, err := configmap.NewWorkflowRunTemplateManagerForSink(obj.ModelWebhookTrigger(wt), "audit", lcm).Get(ctx)
+	require.NoError(t, err)
+
+	workflow := tmpl.WorkflowRun["spec"].(map[string]interface{})["workflow"].(map[string]interface{})
+	assert.Equal(t, "my-audit-workflow", workflow["name"])
+
+	_, err = configmap.NewWorkflowRunTemplateManagerForSink(obj.ModelWebhookTrigger(wt), "relay", lcm).Get(ctx)
+	require.Equal(t, model.ErrNotFound, err)
+}
+
+func TestConfigureImmutableConfigMapForWorkflowRunEvents(t *testing.T) {
+	ctx := context.Background()
+
+	tn := obj.NewTenant(types.NamespacedName{Namespace: "default", Name: "my-tenant"})
+	tn.Object.Spec.TriggerEventSink.Direct = &relayv1beta1.DirectTriggerEventSink{
+		WorkflowFrom: relayv1beta1.WorkflowSource{
+			ConfigMapKeyRef: &relayv1beta1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "my-workflow"},
+				Key:                  "workflow.yaml",
+			},
+		},
+	}
+
+	td := obj.NewTenantDeps(tn)
+	td.DirectTriggerEventSink.WorkflowConfigMap.Object.Data = map[string]string{
+		"workflow.yaml": `
+apiVersion: v1
+steps:
+- name: hello
+  image: alpine:latest
+`,
+	}
+
+	wr := obj.NewWorkflowRun(types.NamespacedName{Namespace: "default", Name: "my-run"})
+	wr.Object.Spec = nebulav1.WorkflowRunSpec{
+		Name: "my-run",
+		Workflow: nebulav1.Workflow{
+			Name: "my-parent-workflow",
+			Steps: []*nebulav1.WorkflowStep{
+				{Name: "deploy"},
+				{Name: "notify"},
+			},
+		},
+	}
+
+	cm := obj.NewConfigMap(types.NamespacedName{Namespace: "default", Name: "my-run-immutable"})
+	require.NoError(t, obj.ConfigureImmutableConfigMapForWorkflowRunEvents(ctx, cm, wr, tn, td.DirectTriggerEventSink, nil))
+
+	for _, step := range wr.Object.Spec.Workflow.Steps {
+		tmpl, err := configmap.NewWorkflowRunTemplateManager(obj.ModelStep(wr, step), configmap.NewLocalConfigMap(cm.Object)).Get(ctx)
+		require.NoError(t, err)
+
+		metadata := tmpl.WorkflowRun["metadata"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{model.RelayControllerParentWorkflowRunIDLabel: "my-run"}, metadata["labels"])
+		assert.Nil(t, tmpl.Parameters)
+	}
+}
+
+func TestConfigureImmutableConfigMapForWorkflowRunTenantDefaults(t *testing.T) {
+	ctx := context.Background()
+
+	tn := obj.NewTenant(types.NamespacedName{Namespace: "default", Name: "my-tenant"})
+	tn.Object.Spec.Defaults = relayv1beta1.TenantDefaults{
+		Spec: relayv1beta1.NewUnstructuredObject(map[string]interface{}{
+			"cluster": map[string]interface{}{"name": "prod", "region": "us-west-2"},
+			"debug":   false,
+		}),
+		Env: relayv1beta1.NewUnstructuredObject(map[string]interface{}{
+			"REGION":    "us-west-2",
+			"LOG_LEVEL": "info",
+		}),
+	}
+
+	wr := obj.NewWorkflowRun(types.NamespacedName{Namespace: "default", Name: "my-run"})
+	wr.Object.Spec = nebulav1.WorkflowRunSpec{
+		Name: "my-run",
+		Workflow: nebulav1.Workflow{
+			Name: "my-workflow",
+			Steps: []*nebulav1.WorkflowStep{
+				{
+					Name: "deploy",
+					Spec: relayv1beta1.NewUnstructuredObject(map[string]interface{}{
+						"cluster": map[string]interface{}{"region": "eu-central-1"},
+					}),
+					Env: relayv1beta1.NewUnstructuredObject(map[string]interface{}{
+						"LOG_LEVEL": "debug",
+					}),
+				},
+				{Name: "notify"},
+			},
+		},
+	}
+
+	cm := obj.NewConfigMap(types.NamespacedName{Namespace: "default", Name: "my-run-immutable"})
+	require.NoError(t, obj.ConfigureImmutableConfigMapForWorkflowRun(ctx, cm, wr, tn))
+
+	lcm := configmap.NewLocalConfigMap(cm.Object)
+
+	spec, err := configmap.NewSpecManager(obj.ModelStep(wr, wr.Object.Spec.Workflow.Steps[0]), lcm).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"cluster": map[string]interface{}{"name": "prod", "region": "eu-central-1"},
+		"debug":   false,
+	}, spec.Tree)
+
+	env, err := configmap.NewEnvironmentManager(obj.ModelStep(wr, wr.Object.Spec.Workflow.Steps[0]), lcm).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "us-west-2", env.Value["REGION"])
+	assert.Equal(t, "debug", env.Value["LOG_LEVEL"])
+
+	// Steps without their own configuration receive the defaults as-is.
+	spec, err = configmap.NewSpecManager(obj.ModelStep(wr, wr.Object.Spec.Workflow.Steps[1]), lcm).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"cluster": map[string]interface{}{"name": "prod", "region": "us-west-2"},
+		"debug":   false,
+	}, spec.Tree)
+
+	env, err = configmap.NewEnvironmentManager(obj.ModelStep(wr, wr.Object.Spec.Workflow.Steps[1]), lcm).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "info", env.Value["LOG_LEVEL"])
+}
+
+func TestConfigureImmutableConfigMapForWebhookTriggerTenantDefaults(t *testing.T) {
+	ctx := context.Background()
+
+	tn := obj.NewTenant(types.NamespacedName{Namespace: "default", Name: "my-tenant"})
+	tn.Object.Spec.Defaults.Env = relayv1beta1.NewUnstructuredObject(map[string]interface{}{
+		"CLUSTER": "prod",
+	})
+
+	wt := obj.NewWebhookTrigger(types.NamespacedName{Namespace: "default", Name: "my-trigger"})
+
+	cm := obj.NewConfigMap(types.NamespacedName{Namespace: "default", Name: "my-trigger-immutable"})
+	require.NoError(t, obj.ConfigureImmutableConfigMapForWebhookTrigger(ctx, cm, wt, tn))
+
+	env, err := configmap.NewEnvironmentManager(obj.ModelWebhookTrigger(wt), configmap.NewLocalConfigMap(cm.Object)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "prod", env.Value["CLUSTER"])
+
+	_, err = configmap.NewSpecManager(obj.ModelWebhookTrigger(wt), configmap.NewLocalConfigMap(cm.Object)).Get(ctx)
+	require.Equal(t, model.ErrNotFound, err)
+}
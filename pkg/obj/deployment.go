@@ -0,0 +1,69 @@
+package obj
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	DeploymentKind = appsv1.SchemeGroupVersion.WithKind("Deployment")
+)
+
+type Deployment struct {
+	Key    client.ObjectKey
+	Object *appsv1.Deployment
+}
+
+var _ Persister = &Deployment{}
+var _ Loader = &Deployment{}
+var _ Ownable = &Deployment{}
+var _ LabelAnnotatableFrom = &Deployment{}
+
+func (d *Deployment) Persist(ctx context.Context, cl client.Client) error {
+	return CreateOrUpdate(ctx, cl, d.Key, d.Object)
+}
+
+func (d *Deployment) Load(ctx context.Context, cl client.Client) (bool, error) {
+	return GetIgnoreNotFound(ctx, cl, d.Key, d.Object)
+}
+
+func (d *Deployment) Owned(ctx context.Context, owner Owner) error {
+	return Own(d.Object, owner)
+}
+
+func (d *Deployment) Label(ctx context.Context, name, value string) {
+	Label(&d.Object.ObjectMeta, name, value)
+}
+
+func (d *Deployment) Annotate(ctx context.Context, name, value string) {
+	Annotate(&d.Object.ObjectMeta, name, value)
+}
+
+func (d *Deployment) LabelAnnotateFrom(ctx context.Context, from metav1.ObjectMeta) {
+	CopyLabelsAndAnnotations(&d.Object.ObjectMeta, from)
+}
+
+// Ready returns true if the deployment controller has observed the current
+// specification and, unless the deployment is scaled to zero, at least one
+// replica is available.
+func (d *Deployment) Ready() bool {
+	if d.Object.GetUID() == "" || d.Object.Status.ObservedGeneration < d.Object.GetGeneration() {
+		return false
+	}
+
+	if d.Object.Spec.Replicas != nil && *d.Object.Spec.Replicas == 0 {
+		return true
+	}
+
+	return d.Object.Status.AvailableReplicas > 0
+}
+
+func NewDeployment(key client.ObjectKey) *Deployment {
+	return &Deployment{
+		Key:    key,
+		Object: &appsv1.Deployment{},
+	}
+}
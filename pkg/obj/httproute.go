@@ -0,0 +1,146 @@
+package obj
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	HTTPRouteKind = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "HTTPRoute"}
+)
+
+type HTTPRoute struct {
+	*UnstructuredRoute
+}
+
+var _ WebhookTriggerRoute = &HTTPRoute{}
+
+// Ready returns true if any gateway has accepted the route.
+func (hr *HTTPRoute) Ready() bool {
+	parents, _, _ := unstructured.NestedSlice(hr.Object.Object, "status", "parents")
+	for _, parent := range parents {
+		p, ok := parent.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		conds, _, _ := unstructured.NestedSlice(p, "conditions")
+		for _, cond := range conds {
+			c, ok := cond.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			if c["type"] == "Accepted" && c["status"] == "True" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (hr *HTTPRoute) URL() string {
+	hostnames, _, _ := unstructured.NestedStringSlice(hr.Object.Object, "spec", "hostnames")
+	if len(hostnames) == 0 {
+		return ""
+	}
+
+	var path string
+	if rules, _, _ := unstructured.NestedSlice(hr.Object.Object, "spec", "rules"); len(rules) > 0 {
+		if rule, ok := rules[0].(map[string]interface{}); ok {
+			if matches, _, _ := unstructured.NestedSlice(rule, "matches"); len(matches) > 0 {
+				if m, ok := matches[0].(map[string]interface{}); ok {
+					path, _, _ = unstructured.NestedString(m, "path", "value")
+				}
+			}
+		}
+	}
+
+	return webhookTriggerRouteURL("http", hostnames[0], path)
+}
+
+func NewHTTPRoute(key client.ObjectKey) *HTTPRoute {
+	return &HTTPRoute{
+		UnstructuredRoute: NewUnstructuredRoute(HTTPRouteKind, key),
+	}
+}
+
+// GatewayWebhookTriggerRouter exposes webhook triggers using Gateway API HTTP
+// routes attached to a single gateway. The gateway must allow routes from
+// tenant namespaces.
+type GatewayWebhookTriggerRouter struct {
+	// Domain is the DNS suffix of the host names assigned to webhook
+	// triggers.
+	Domain string
+
+	// Gateway is the gateway to attach routes to.
+	Gateway client.ObjectKey
+}
+
+var _ WebhookTriggerRouter = &GatewayWebhookTriggerRouter{}
+
+func (r *GatewayWebhookTriggerRouter) ApplyRoute(ctx context.Context, cl client.Client, wtd *WebhookTriggerDeps, target WebhookTriggerService) (WebhookTriggerRoute, error) {
+	service, targetHost, err := webhookTriggerRouteTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if wtd.RouteTLS() != nil {
+		return nil, fmt.Errorf("the %s webhook trigger router does not support TLS for routes; certificates must be configured on the listeners of the gateway", WebhookTriggerRouterGateway)
+	}
+
+	host, path := WebhookTriggerRouteAddress(wtd.WebhookTrigger.Object, WebhookTriggerServiceKey(wtd).Namespace, r.Domain)
+
+	hr := NewHTTPRoute(service)
+	if err := applyUnstructuredRoute(ctx, cl, wtd, hr.UnstructuredRoute, func(spec map[string]interface{}) error {
+		spec["parentRefs"] = []interface{}{
+			map[string]interface{}{
+				"name":      r.Gateway.Name,
+				"namespace": r.Gateway.Namespace,
+			},
+		}
+		spec["hostnames"] = []interface{}{host}
+		spec["rules"] = []interface{}{
+			map[string]interface{}{
+				"matches": []interface{}{
+					map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":  "PathPrefix",
+							"value": path,
+						},
+					},
+				},
+				"filters": []interface{}{
+					// Both Knative and the activator route requests using
+					// the host name.
+					map[string]interface{}{
+						"type": "URLRewrite",
+						"urlRewrite": map[string]interface{}{
+							"hostname": targetHost,
+						},
+					},
+				},
+				"backendRefs": []interface{}{
+					map[string]interface{}{
+						"name": service.Name,
+						"port": int64(80),
+					},
+				},
+			},
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return hr, nil
+}
+
+func (r *GatewayWebhookTriggerRouter) DeleteRoute(ctx context.Context, cl client.Client, key client.ObjectKey) error {
+	return NewHTTPRoute(key).Delete(ctx, cl)
+}
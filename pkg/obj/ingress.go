@@ -0,0 +1,149 @@
+package obj
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	IngressKind = schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}
+)
+
+type Ingress struct {
+	*UnstructuredRoute
+}
+
+var _ WebhookTriggerRoute = &Ingress{}
+
+func (i *Ingress) Ready() bool {
+	// Not every ingress controller reports load balancer status, so we
+	// consider the ingress ready as soon as it exists.
+	return i.Object.GetUID() != ""
+}
+
+func (i *Ingress) URL() string {
+	rules, _, _ := unstructured.NestedSlice(i.Object.Object, "spec", "rules")
+	if len(rules) == 0 {
+		return ""
+	}
+
+	rule, ok := rules[0].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	host, _, _ := unstructured.NestedString(rule, "host")
+
+	var path string
+	if paths, _, _ := unstructured.NestedSlice(rule, "http", "paths"); len(paths) > 0 {
+		if p, ok := paths[0].(map[string]interface{}); ok {
+			path, _, _ = unstructured.NestedString(p, "path")
+		}
+	}
+
+	scheme := "http"
+
+	tls, _, _ := unstructured.NestedSlice(i.Object.Object, "spec", "tls")
+	for _, t := range tls {
+		t, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		hosts, _, _ := unstructured.NestedStringSlice(t, "hosts")
+		for _, h := range hosts {
+			if h == host {
+				scheme = "https"
+			}
+		}
+	}
+
+	return webhookTriggerRouteURL(scheme, host, path)
+}
+
+func NewIngress(key client.ObjectKey) *Ingress {
+	return &Ingress{
+		UnstructuredRoute: NewUnstructuredRoute(IngressKind, key),
+	}
+}
+
+// IngressWebhookTriggerRouter exposes webhook triggers using Kubernetes
+// ingresses.
+//
+// Ingresses cannot portably rewrite the host name of requests, so when used
+// with the Knative backend, the ingress controller must be configured to
+// forward requests with the host name of the Knative service.
+type IngressWebhookTriggerRouter struct {
+	// Domain is the DNS suffix of the host names assigned to webhook
+	// triggers.
+	Domain string
+
+	// IngressClassName is the class of ingresses to create, if any.
+	IngressClassName string
+}
+
+var _ WebhookTriggerRouter = &IngressWebhookTriggerRouter{}
+
+func (r *IngressWebhookTriggerRouter) ApplyRoute(ctx context.Context, cl client.Client, wtd *WebhookTriggerDeps, target WebhookTriggerService) (WebhookTriggerRoute, error) {
+	service, _, err := webhookTriggerRouteTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	host, path := WebhookTriggerRouteAddress(wtd.WebhookTrigger.Object, WebhookTriggerServiceKey(wtd).Namespace, r.Domain)
+
+	tlsSecretName, err := webhookTriggerRouteTLSSecretName(wtd, host)
+	if err != nil {
+		return nil, err
+	}
+
+	i := NewIngress(service)
+	if err := applyUnstructuredRoute(ctx, cl, wtd, i.UnstructuredRoute, func(spec map[string]interface{}) error {
+		if r.IngressClassName != "" {
+			spec["ingressClassName"] = r.IngressClassName
+		}
+
+		if tlsSecretName != "" {
+			spec["tls"] = []interface{}{
+				map[string]interface{}{
+					"hosts":      []interface{}{host},
+					"secretName": tlsSecretName,
+				},
+			}
+		}
+
+		spec["rules"] = []interface{}{
+			map[string]interface{}{
+				"host": host,
+				"http": map[string]interface{}{
+					"paths": []interface{}{
+						map[string]interface{}{
+							"path":     path,
+							"pathType": "Prefix",
+							"backend": map[string]interface{}{
+								"service": map[string]interface{}{
+									"name": service.Name,
+									"port": map[string]interface{}{
+										"number": int64(80),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+func (r *IngressWebhookTriggerRouter) DeleteRoute(ctx context.Context, cl client.Client, key client.ObjectKey) error {
+	return NewIngress(key).Delete(ctx, cl)
+}
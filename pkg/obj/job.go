@@ -54,6 +54,8 @@ func ApplyJob(ctx context.Context, cl client.Client, key client.ObjectKey, job *
 			return nil, err
 		}
 
+		CopyLabelsAndAnnotations(&j.Object.ObjectMeta, job.ObjectMeta)
+
 		if exists {
 			j.Object.Spec.Template.Spec = job.Spec.Template.Spec
 		} else {
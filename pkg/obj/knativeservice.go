@@ -2,12 +2,13 @@ package obj
 
 import (
 	"context"
+	"strconv"
 
 	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
-	"github.com/puppetlabs/relay-core/pkg/authenticate"
-	"github.com/puppetlabs/relay-core/pkg/model"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/serving/pkg/apis/autoscaling"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -37,6 +38,7 @@ var _ Persister = &KnativeService{}
 var _ Loader = &KnativeService{}
 var _ Ownable = &KnativeService{}
 var _ LabelAnnotatableFrom = &KnativeService{}
+var _ WebhookTriggerService = &KnativeService{}
 
 func (ks *KnativeService) Persist(ctx context.Context, cl client.Client) error {
 	if err := CreateOrUpdate(ctx, cl, ks.Key, ks.Object); err != nil {
@@ -66,6 +68,26 @@ func (ks *KnativeService) LabelAnnotateFrom(ctx context.Context, from metav1.Obj
 	CopyLabelsAndAnnotations(&ks.Object.ObjectMeta, from)
 }
 
+func (ks *KnativeService) Namespace() string {
+	return ks.Key.Namespace
+}
+
+func (ks *KnativeService) Ready() bool {
+	return ks.Object.Status.IsReady()
+}
+
+func (ks *KnativeService) URL() string {
+	if ks.Object.Status.URL == nil {
+		return ""
+	}
+
+	return ks.Object.Status.URL.String()
+}
+
+func (ks *KnativeService) ScalingStatus() *relayv1beta1.WebhookTriggerScalingStatus {
+	return KnativeServiceScalingStatus(ks)
+}
+
 func NewKnativeService(key client.ObjectKey) *KnativeService {
 	return &KnativeService{
 		Key:    key,
@@ -74,8 +96,6 @@ func NewKnativeService(key client.ObjectKey) *KnativeService {
 }
 
 func ConfigureKnativeService(ctx context.Context, s *KnativeService, wtd *WebhookTriggerDeps) error {
-	// FIXME This should be configurable
-	s.Annotate(ctx, AmbassadorIDAnnotation, AmbassadorID)
 	s.Label(ctx, KnativeServiceVisibilityLabel, KnativeServiceVisibilityClusterLocal)
 	s.LabelAnnotateFrom(ctx, wtd.WebhookTrigger.Object.ObjectMeta)
 
@@ -89,117 +109,79 @@ func ConfigureKnativeService(ctx context.Context, s *KnativeService, wtd *Webhoo
 	// to the service will propagate back using our event handler.
 	SetDependencyOf(&s.Object.ObjectMeta, Owner{Object: wtd.WebhookTrigger.Object, GVK: relayv1beta1.WebhookTriggerKind})
 
+	var pt corev1.PodTemplateSpec
+	if err := ConfigureWebhookTriggerPodTemplate(ctx, &pt, s.Object.Spec.ConfigurationSpec.Template.ObjectMeta, wtd); err != nil {
+		return err
+	}
+
 	template := servingv1.RevisionTemplateSpec{
-		ObjectMeta: metav1.ObjectMeta{
-			Labels: map[string]string{
-				model.RelayControllerWebhookTriggerIDLabel: wtd.WebhookTrigger.Key.Name,
-			},
-			Annotations: map[string]string{
-				// We keep track of the immutable config map version to ensure
-				// Knative updates if the `input` script changes.
-				//
-				// It should be safe to use the resource version here as
-				// resource versions aren't supposed to change under semantic
-				// equality. However, this has been buggy in previous versions
-				// of Kubernetes, so we can always switch to a hash instead if
-				// needed.
-				ImmutableConfigMapResourceVersionAnnotation: wtd.ImmutableConfigMap.Object.GetResourceVersion(),
-
-				// Keep any existing token annotations.
-				model.RelayControllerTokenHashAnnotation: s.Object.Spec.ConfigurationSpec.Template.GetAnnotations()[model.RelayControllerTokenHashAnnotation],
-				authenticate.KubernetesTokenAnnotation:   s.Object.Spec.ConfigurationSpec.Template.GetAnnotations()[authenticate.KubernetesTokenAnnotation],
-				authenticate.KubernetesSubjectAnnotation: s.Object.Spec.ConfigurationSpec.Template.GetAnnotations()[authenticate.KubernetesSubjectAnnotation],
-			},
-		},
+		ObjectMeta: pt.ObjectMeta,
 		Spec: servingv1.RevisionSpec{
-			PodSpec: corev1.PodSpec{
-				ServiceAccountName: wtd.KnativeServiceAccount.Key.Name,
-			},
+			PodSpec: pt.Spec,
 		},
 	}
 
-	image := wtd.WebhookTrigger.Object.Spec.Image
-	if image == "" {
-		// Theoretically someone could write some socat action and use the
-		// Alpine image, so we leave this here for consistency.
-		image = model.DefaultImage
-	}
+	ConfigureKnativeRevisionScaling(&template, wtd.WebhookTrigger.Object.Spec.Scaling)
 
-	container := corev1.Container{
-		Name:            wtd.WebhookTrigger.Object.Name,
-		Image:           image,
-		ImagePullPolicy: corev1.PullAlways,
-		Env: []corev1.EnvVar{
-			{
-				Name:  "METADATA_API_URL",
-				Value: wtd.MetadataAPIURL.String(),
-			},
+	s.Object.Spec = servingv1.ServiceSpec{
+		ConfigurationSpec: servingv1.ConfigurationSpec{
+			Template: template,
 		},
 	}
 
-	if len(wtd.WebhookTrigger.Object.Spec.Input) > 0 {
-		tm := ModelWebhookTrigger(wtd.WebhookTrigger)
-
-		template.Spec.PodSpec.Volumes = append(template.Spec.PodSpec.Volumes, corev1.Volume{
-			Name: "config",
-			VolumeSource: corev1.VolumeSource{
-				ConfigMap: &corev1.ConfigMapVolumeSource{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: wtd.ImmutableConfigMap.Key.Name,
-					},
-					Items: []corev1.KeyToPath{
-						{
-							Key:  scriptConfigMapKey(tm),
-							Path: "input-script",
-							Mode: func(i int32) *int32 { return &i }(0755),
-						},
-					},
-				},
-			},
-		})
-
-		container.VolumeMounts = []corev1.VolumeMount{
-			{
-				Name:      "config",
-				ReadOnly:  true,
-				MountPath: "/var/run/puppet/relay/config",
-			},
-		}
-		container.Command = []string{"/var/run/puppet/relay/config/input-script"}
-	} else {
-		if command := wtd.WebhookTrigger.Object.Spec.Command; command != "" {
-			container.Command = []string{command}
-		}
-
-		if args := wtd.WebhookTrigger.Object.Spec.Args; len(args) > 0 {
-			container.Args = args
-		}
-	}
-
-	template.Spec.PodSpec.Containers = []corev1.Container{container}
-
-	if err := wtd.AnnotateTriggerToken(ctx, &template.ObjectMeta); err != nil {
-		return err
+	return nil
+}
+
+// ConfigureKnativeRevisionScaling translates the scaling configuration of a
+// webhook trigger to the autoscaling annotations and request limits of a
+// Knative revision.
+func ConfigureKnativeRevisionScaling(template *servingv1.RevisionTemplateSpec, scaling *relayv1beta1.WebhookTriggerScaling) {
+	if scaling == nil {
+		return
 	}
 
-	if wtd.Tenant.Object.Spec.ToolInjection.VolumeClaimTemplate != nil {
-		Annotate(&template.ObjectMeta, model.RelayControllerToolsVolumeClaimAnnotation, wtd.Tenant.Object.GetName()+model.ToolInjectionVolumeClaimSuffixReadOnlyMany)
+	if scaling.MinScale != nil {
+		Annotate(&template.ObjectMeta, autoscaling.MinScaleAnnotationKey, strconv.FormatInt(int64(*scaling.MinScale), 10))
 	}
 
-	s.Object.Spec = servingv1.ServiceSpec{
-		ConfigurationSpec: servingv1.ConfigurationSpec{
-			Template: template,
-		},
+	if scaling.MaxScale != nil {
+		Annotate(&template.ObjectMeta, autoscaling.MaxScaleAnnotationKey, strconv.FormatInt(int64(*scaling.MaxScale), 10))
 	}
 
-	return nil
+	template.Spec.ContainerConcurrency = scaling.ContainerConcurrency
+	template.Spec.TimeoutSeconds = scaling.TimeoutSeconds
+}
+
+// KnativeServiceScalingStatus reports the scaling configuration set on the
+// revision template of a Knative service. Settings left to the Knative
+// autoscaler configuration of the cluster, like the default minimum and
+// maximum scale, are reported as zero.
+func KnativeServiceScalingStatus(ks *KnativeService) *relayv1beta1.WebhookTriggerScalingStatus {
+	template := ks.Object.Spec.ConfigurationSpec.Template
+
+	status := &relayv1beta1.WebhookTriggerScalingStatus{}
+
+	if n, err := strconv.ParseInt(template.GetAnnotations()[autoscaling.MinScaleAnnotationKey], 10, 32); err == nil {
+		status.MinScale = int32(n)
+	}
+
+	if n, err := strconv.ParseInt(template.GetAnnotations()[autoscaling.MaxScaleAnnotationKey], 10, 32); err == nil {
+		status.MaxScale = int32(n)
+	}
+
+	if cc := template.Spec.ContainerConcurrency; cc != nil {
+		status.ContainerConcurrency = *cc
+	}
+
+	if ts := template.Spec.TimeoutSeconds; ts != nil {
+		status.TimeoutSeconds = *ts
+	}
+
+	return status
 }
 
 func ApplyKnativeService(ctx context.Context, cl client.Client, wtd *WebhookTriggerDeps) (*KnativeService, error) {
-	s := NewKnativeService(client.ObjectKey{
-		Namespace: wtd.TenantDeps.Namespace.Name,
-		Name:      wtd.WebhookTrigger.Key.Name,
-	})
+	s := NewKnativeService(WebhookTriggerServiceKey(wtd))
 
 	if _, err := s.Load(ctx, cl); err != nil {
 		return nil, err
@@ -216,14 +198,44 @@ func ApplyKnativeService(ctx context.Context, cl client.Client, wtd *WebhookTrig
 	return s, nil
 }
 
-type KnativeServiceResult struct {
-	KnativeService *KnativeService
-	Error          error
+// KnativeWebhookTriggerBackend serves webhook triggers using Knative Serving.
+type KnativeWebhookTriggerBackend struct{}
+
+var _ WebhookTriggerBackend = &KnativeWebhookTriggerBackend{}
+
+func (KnativeWebhookTriggerBackend) ApplyService(ctx context.Context, cl client.Client, wtd *WebhookTriggerDeps) (WebhookTriggerService, error) {
+	s, err := ApplyKnativeService(ctx, cl, wtd)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (KnativeWebhookTriggerBackend) ApplyVerifierService(ctx context.Context, cl client.Client, wtd *WebhookTriggerDeps, upstream WebhookTriggerService, image string) (WebhookTriggerService, error) {
+	if upstream == nil {
+		return nil, nil
+	}
+
+	s, err := ApplyWebhookVerifierKnativeService(ctx, cl, wtd, upstream.URL(), image)
+	if err != nil || s == nil {
+		return nil, err
+	}
+
+	return s, nil
 }
 
-func AsKnativeServiceResult(ks *KnativeService, err error) *KnativeServiceResult {
-	return &KnativeServiceResult{
-		KnativeService: ks,
-		Error:          err,
+func (KnativeWebhookTriggerBackend) DeleteService(ctx context.Context, cl client.Client, key client.ObjectKey) error {
+	s := NewKnativeService(key)
+
+	if ok, err := s.Load(ctx, cl); meta.IsNoMatchError(err) {
+		// Knative Serving is not installed in this cluster, so there is
+		// nothing to clean up.
+		return nil
+	} else if err != nil || !ok {
+		return err
 	}
+
+	_, err := DeleteIgnoreNotFound(ctx, cl, s.Object)
+	return err
 }
@@ -0,0 +1,35 @@
+package obj_test
+
+import (
+	"testing"
+
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/obj"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/serving/pkg/apis/autoscaling"
+)
+
+func TestKnativeServiceScaling(t *testing.T) {
+	ks := obj.NewKnativeService(types.NamespacedName{Namespace: "test", Name: "my-trigger"})
+
+	// No scaling configuration leaves Knative's defaults alone.
+	obj.ConfigureKnativeRevisionScaling(&ks.Object.Spec.Template, nil)
+	assert.Empty(t, ks.Object.Spec.Template.GetAnnotations())
+	assert.Equal(t, &relayv1beta1.WebhookTriggerScalingStatus{}, obj.KnativeServiceScalingStatus(ks))
+
+	obj.ConfigureKnativeRevisionScaling(&ks.Object.Spec.Template, &relayv1beta1.WebhookTriggerScaling{
+		MinScale:             func(i int32) *int32 { return &i }(1),
+		MaxScale:             func(i int32) *int32 { return &i }(5),
+		ContainerConcurrency: func(i int64) *int64 { return &i }(10),
+		TimeoutSeconds:       func(i int64) *int64 { return &i }(30),
+	})
+	assert.Equal(t, "1", ks.Object.Spec.Template.GetAnnotations()[autoscaling.MinScaleAnnotationKey])
+	assert.Equal(t, "5", ks.Object.Spec.Template.GetAnnotations()[autoscaling.MaxScaleAnnotationKey])
+	assert.Equal(t, &relayv1beta1.WebhookTriggerScalingStatus{
+		MinScale:             1,
+		MaxScale:             5,
+		ContainerConcurrency: 10,
+		TimeoutSeconds:       30,
+	}, obj.KnativeServiceScalingStatus(ks))
+}
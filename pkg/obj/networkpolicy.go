@@ -3,6 +3,7 @@ package obj
 import (
 	"context"
 
+	"github.com/puppetlabs/relay-core/pkg/model"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -121,9 +122,84 @@ func ConfigureNetworkPolicyForWebhookTrigger(np *NetworkPolicy, wt *WebhookTrigg
 			},
 		},
 	})
+
+	// Allow ingress from the webhook verifier, which addresses the trigger
+	// pods directly when they are not run by Knative.
+	np.Object.Spec.Ingress = append(np.Object.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+		From: []networkingv1.NetworkPolicyPeer{
+			{
+				PodSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						model.RelayControllerWebhookVerifierIDLabel: wt.Key.Name,
+					},
+				},
+			},
+		},
+	})
 }
 
-func baseTenantWorkloadNetworkPolicySpec(podSelector metav1.LabelSelector, opts []NetworkPolicyOption) networkingv1.NetworkPolicySpec {
+// ConfigureNetworkPolicyForWebhookVerifier allows the webhook verifier to
+// receive requests from the webhook gateway and forward them back through the
+// gateway to the webhook trigger service. The verifier may also access the
+// metadata API to report requests.
+func ConfigureNetworkPolicyForWebhookVerifier(np *NetworkPolicy, wt *WebhookTrigger, opts ...NetworkPolicyOption) {
+	selector := wt.PodSelector()
+
+	gateway := []networkingv1.NetworkPolicyPeer{
+		{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"nebula.puppet.com/network-policy.webhook-gateway": "true",
+				},
+			},
+		},
+	}
+
+	np.Object.Spec = networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				model.RelayControllerWebhookVerifierIDLabel: wt.Key.Name,
+			},
+		},
+		PolicyTypes: []networkingv1.PolicyType{
+			networkingv1.PolicyTypeIngress,
+			networkingv1.PolicyTypeEgress,
+		},
+		Ingress: []networkingv1.NetworkPolicyIngressRule{
+			{From: gateway},
+		},
+		Egress: []networkingv1.NetworkPolicyEgressRule{
+			{To: gateway},
+			{
+				// Allow direct access to the trigger pods when they are not
+				// run by Knative.
+				To: []networkingv1.NetworkPolicyPeer{
+					{PodSelector: &selector},
+				},
+			},
+			{
+				// Allow DNS resolution of the upstream service.
+				To: []networkingv1.NetworkPolicyPeer{
+					{NamespaceSelector: &metav1.LabelSelector{}},
+				},
+				Ports: []networkingv1.NetworkPolicyPort{
+					{
+						Protocol: func(p corev1.Protocol) *corev1.Protocol { return &p }(corev1.ProtocolUDP),
+						Port:     func(i intstr.IntOrString) *intstr.IntOrString { return &i }(intstr.FromInt(53)),
+					},
+					{
+						Protocol: func(p corev1.Protocol) *corev1.Protocol { return &p }(corev1.ProtocolTCP),
+						Port:     func(i intstr.IntOrString) *intstr.IntOrString { return &i }(intstr.FromInt(53)),
+					},
+				},
+			},
+			// Allow requests to be reported to the metadata API.
+			metadataAPINetworkPolicyEgressRule(newNetworkPolicyOptions(opts)),
+		},
+	}
+}
+
+func newNetworkPolicyOptions(opts []NetworkPolicyOption) *networkPolicyOptions {
 	npo := &networkPolicyOptions{
 		deniedIPBlocks: DefaultNetworkPolicyDeniedIPBlocks,
 		systemNamespaceSelector: metav1.LabelSelector{
@@ -144,6 +220,29 @@ func baseTenantWorkloadNetworkPolicySpec(podSelector metav1.LabelSelector, opts
 		opt(npo)
 	}
 
+	return npo
+}
+
+func metadataAPINetworkPolicyEgressRule(npo *networkPolicyOptions) networkingv1.NetworkPolicyEgressRule {
+	return networkingv1.NetworkPolicyEgressRule{
+		To: []networkingv1.NetworkPolicyPeer{
+			{
+				NamespaceSelector: &npo.systemNamespaceSelector,
+				PodSelector:       &npo.metadataAPIPodSelector,
+			},
+		},
+		Ports: []networkingv1.NetworkPolicyPort{
+			{
+				Protocol: func(p corev1.Protocol) *corev1.Protocol { return &p }(corev1.ProtocolTCP),
+				Port:     func(i intstr.IntOrString) *intstr.IntOrString { return &i }(intstr.FromInt(npo.metadataAPIPort)),
+			},
+		},
+	}
+}
+
+func baseTenantWorkloadNetworkPolicySpec(podSelector metav1.LabelSelector, opts []NetworkPolicyOption) networkingv1.NetworkPolicySpec {
+	npo := newNetworkPolicyOptions(opts)
+
 	return networkingv1.NetworkPolicySpec{
 		PodSelector: podSelector,
 		PolicyTypes: []networkingv1.PolicyType{
@@ -164,21 +263,8 @@ func baseTenantWorkloadNetworkPolicySpec(podSelector metav1.LabelSelector, opts
 					},
 				},
 			},
-			{
-				// Allow access to the metadata API.
-				To: []networkingv1.NetworkPolicyPeer{
-					{
-						NamespaceSelector: &npo.systemNamespaceSelector,
-						PodSelector:       &npo.metadataAPIPodSelector,
-					},
-				},
-				Ports: []networkingv1.NetworkPolicyPort{
-					{
-						Protocol: func(p corev1.Protocol) *corev1.Protocol { return &p }(corev1.ProtocolTCP),
-						Port:     func(i intstr.IntOrString) *intstr.IntOrString { return &i }(intstr.FromInt(npo.metadataAPIPort)),
-					},
-				},
-			},
+			// Allow access to the metadata API.
+			metadataAPINetworkPolicyEgressRule(npo),
 		},
 	}
 }
@@ -54,6 +54,8 @@ func ApplyPersistentVolume(ctx context.Context, cl client.Client, key client.Obj
 			return nil, err
 		}
 
+		CopyLabelsAndAnnotations(&p.Object.ObjectMeta, pv.ObjectMeta)
+
 		if exists {
 			p.Object.Spec.Capacity = pv.Spec.Capacity
 		} else {
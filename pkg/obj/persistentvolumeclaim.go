@@ -54,6 +54,8 @@ func ApplyPersistentVolumeClaim(ctx context.Context, cl client.Client, key clien
 			return nil, err
 		}
 
+		CopyLabelsAndAnnotations(&p.Object.ObjectMeta, pvc.ObjectMeta)
+
 		if exists {
 			p.Object.Spec.Resources = pvc.Spec.Resources
 		} else {
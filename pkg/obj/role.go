@@ -3,6 +3,7 @@ package obj
 import (
 	"context"
 
+	nebulav1 "github.com/puppetlabs/relay-core/pkg/apis/nebula.puppet.com/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -41,7 +42,12 @@ func NewRole(key client.ObjectKey) *Role {
 	}
 }
 
-func ConfigureMetadataAPIRole(role *Role, immutableConfigMap, mutableConfigMap *ConfigMap) {
+func ConfigureMetadataAPIRole(role *Role, immutableConfigMap *ConfigMap, mutableConfigMaps ...*ConfigMap) {
+	mutableNames := make([]string, len(mutableConfigMaps))
+	for i, cm := range mutableConfigMaps {
+		mutableNames[i] = cm.Key.Name
+	}
+
 	role.Object.Rules = []rbacv1.PolicyRule{
 		{
 			APIGroups:     []string{""},
@@ -52,8 +58,21 @@ func ConfigureMetadataAPIRole(role *Role, immutableConfigMap, mutableConfigMap *
 		{
 			APIGroups:     []string{""},
 			Resources:     []string{"configmaps"},
-			ResourceNames: []string{mutableConfigMap.Key.Name},
-			Verbs:         []string{"get", "update"},
+			ResourceNames: mutableNames,
+			// Waiting for conditions watches the mutable config maps for
+			// changes. The watch selects the config map by name, so the
+			// resource names restrict it too.
+			Verbs: []string{"get", "update", "list", "watch"},
 		},
 	}
 }
+
+// ConfigureMetadataAPIRoleForWorkflowRuns additionally allows the metadata API
+// to create workflow runs on behalf of a trigger.
+func ConfigureMetadataAPIRoleForWorkflowRuns(role *Role) {
+	role.Object.Rules = append(role.Object.Rules, rbacv1.PolicyRule{
+		APIGroups: []string{nebulav1.SchemeGroupVersion.Group},
+		Resources: []string{"workflowruns"},
+		Verbs:     []string{"create"},
+	})
+}
@@ -5,12 +5,14 @@ import (
 	"errors"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var (
 	ErrNotOpaqueSecret                = errors.New("obj: secret is not an unstructured opaque secret")
 	ErrNotImagePullSecret             = errors.New("obj: secret is not usable for pulling container images")
+	ErrNotTLSSecret                   = errors.New("obj: secret is not a TLS secret")
 	ErrNotServiceAccountTokenSecret   = errors.New("obj: secret is not usable for service accounts")
 	ErrServiceAccountTokenMissingData = errors.New("obj: service account token secret has no token data")
 )
@@ -20,8 +22,14 @@ type OpaqueSecret struct {
 	Object *corev1.Secret
 }
 
+var _ Persister = &OpaqueSecret{}
 var _ Loader = &OpaqueSecret{}
 var _ Ownable = &OpaqueSecret{}
+var _ LabelAnnotatableFrom = &OpaqueSecret{}
+
+func (os *OpaqueSecret) Persist(ctx context.Context, cl client.Client) error {
+	return CreateOrUpdate(ctx, cl, os.Key, os.Object)
+}
 
 func (os *OpaqueSecret) Load(ctx context.Context, cl client.Client) (bool, error) {
 	ok, err := GetIgnoreNotFound(ctx, cl, os.Key, os.Object)
@@ -40,6 +48,10 @@ func (os *OpaqueSecret) Owned(ctx context.Context, owner Owner) error {
 	return Own(os.Object, owner)
 }
 
+func (os *OpaqueSecret) LabelAnnotateFrom(ctx context.Context, from metav1.ObjectMeta) {
+	CopyLabelsAndAnnotations(&os.Object.ObjectMeta, from)
+}
+
 func (os *OpaqueSecret) Data(key string) (string, bool) {
 	b, found := os.Object.Data[key]
 	if !found {
@@ -101,6 +113,57 @@ func ConfigureImagePullSecret(target, src *ImagePullSecret) {
 	target.Object.Data = src.Object.DeepCopy().Data
 }
 
+type TLSSecret struct {
+	Key    client.ObjectKey
+	Object *corev1.Secret
+}
+
+var _ Persister = &TLSSecret{}
+var _ Loader = &TLSSecret{}
+var _ Ownable = &TLSSecret{}
+var _ LabelAnnotatableFrom = &TLSSecret{}
+
+func (ts *TLSSecret) Persist(ctx context.Context, cl client.Client) error {
+	return CreateOrUpdate(ctx, cl, ts.Key, ts.Object)
+}
+
+func (ts *TLSSecret) Load(ctx context.Context, cl client.Client) (bool, error) {
+	ok, err := GetIgnoreNotFound(ctx, cl, ts.Key, ts.Object)
+	if err != nil {
+		return false, err
+	}
+
+	if ts.Object.Type != corev1.SecretTypeTLS {
+		return false, ErrNotTLSSecret
+	}
+
+	return ok, nil
+}
+
+func (ts *TLSSecret) Owned(ctx context.Context, owner Owner) error {
+	return Own(ts.Object, owner)
+}
+
+func (ts *TLSSecret) LabelAnnotateFrom(ctx context.Context, from metav1.ObjectMeta) {
+	CopyLabelsAndAnnotations(&ts.Object.ObjectMeta, from)
+}
+
+func NewTLSSecret(key client.ObjectKey) *TLSSecret {
+	return &TLSSecret{
+		Key: key,
+		Object: &corev1.Secret{
+			Type: corev1.SecretTypeTLS,
+		},
+	}
+}
+
+func ConfigureTLSSecret(target, src *TLSSecret) {
+	target.Object.Data = map[string][]byte{
+		corev1.TLSCertKey:       src.Object.Data[corev1.TLSCertKey],
+		corev1.TLSPrivateKeyKey: src.Object.Data[corev1.TLSPrivateKeyKey],
+	}
+}
+
 type ServiceAccountTokenSecret struct {
 	Key    client.ObjectKey
 	Object *corev1.Secret
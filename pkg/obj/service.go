@@ -0,0 +1,68 @@
+package obj
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ServiceClusterDomain is the DNS suffix used to address services from
+	// within the cluster.
+	ServiceClusterDomain = "svc.cluster.local"
+)
+
+type Service struct {
+	Key    client.ObjectKey
+	Object *corev1.Service
+}
+
+var _ Persister = &Service{}
+var _ Loader = &Service{}
+var _ Ownable = &Service{}
+var _ LabelAnnotatableFrom = &Service{}
+
+func (s *Service) Persist(ctx context.Context, cl client.Client) error {
+	return CreateOrUpdate(ctx, cl, s.Key, s.Object)
+}
+
+func (s *Service) Load(ctx context.Context, cl client.Client) (bool, error) {
+	return GetIgnoreNotFound(ctx, cl, s.Key, s.Object)
+}
+
+func (s *Service) Owned(ctx context.Context, owner Owner) error {
+	return Own(s.Object, owner)
+}
+
+func (s *Service) Label(ctx context.Context, name, value string) {
+	Label(&s.Object.ObjectMeta, name, value)
+}
+
+func (s *Service) Annotate(ctx context.Context, name, value string) {
+	Annotate(&s.Object.ObjectMeta, name, value)
+}
+
+func (s *Service) LabelAnnotateFrom(ctx context.Context, from metav1.ObjectMeta) {
+	CopyLabelsAndAnnotations(&s.Object.ObjectMeta, from)
+}
+
+// Host returns the fully-qualified name of this service within the cluster.
+func (s *Service) Host() string {
+	return ServiceHost(s.Key)
+}
+
+func NewService(key client.ObjectKey) *Service {
+	return &Service{
+		Key:    key,
+		Object: &corev1.Service{},
+	}
+}
+
+// ServiceHost returns the fully-qualified name of the service with the given
+// key within the cluster.
+func ServiceHost(key client.ObjectKey) string {
+	return fmt.Sprintf("%s.%s.%s", key.Name, key.Namespace, ServiceClusterDomain)
+}
@@ -3,8 +3,11 @@ package obj
 import (
 	"context"
 	"errors"
+	"path"
 	"time"
 
+	"github.com/puppetlabs/relay-core/pkg/authenticate"
+	"github.com/puppetlabs/relay-core/pkg/model"
 	"github.com/puppetlabs/relay-core/pkg/util/retry"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,6 +17,11 @@ import (
 const (
 	ServiceAccountDefaultTokenSecretLoadTimeout          = 120 * time.Second
 	ServiceAccountDefaultTokenSecretLoadBackoffFrequency = 250 * time.Millisecond
+
+	// MetadataAPITokenExpirationSeconds is the requested lifetime of the
+	// service account tokens projected into pods for the metadata API. The
+	// kubelet replaces the token before it expires.
+	MetadataAPITokenExpirationSeconds = 60 * 60
 )
 
 var (
@@ -112,3 +120,42 @@ func ConfigureUntrustedServiceAccount(sa *ServiceAccount) {
 	// services. It has no permissions.
 	sa.Object.AutomountServiceAccountToken = func(b bool) *bool { return &b }(false)
 }
+
+// MetadataAPITokenVolume returns a volume holding a service account token
+// bound to the pod that the metadata API accepts to identify the pod. The
+// token is projected even though the untrusted service account does not
+// automount its own token, and it is only valid for the metadata API.
+func MetadataAPITokenVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: model.MetadataAPITokenMountName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{
+						ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+							Audience:          authenticate.MetadataAPIAudienceV1,
+							ExpirationSeconds: func(i int64) *int64 { return &i }(MetadataAPITokenExpirationSeconds),
+							Path:              model.MetadataAPITokenPath,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// ConfigureMetadataAPITokenMount mounts the volume returned by
+// MetadataAPITokenVolume into the given container and tells the container
+// where to find the token.
+func ConfigureMetadataAPITokenMount(c *corev1.Container) {
+	c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+		Name:      model.MetadataAPITokenMountName,
+		MountPath: model.MetadataAPITokenMountPath,
+		ReadOnly:  true,
+	})
+
+	c.Env = append(c.Env, corev1.EnvVar{
+		Name:  model.MetadataAPITokenFileEnvName,
+		Value: path.Join(model.MetadataAPITokenMountPath, model.MetadataAPITokenPath),
+	})
+}
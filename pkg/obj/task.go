@@ -80,14 +80,17 @@ func ConfigureTask(ctx context.Context, t *Task, wrd *WorkflowRunDeps, ws *nebul
 		return err
 	}
 
-	// TODO Reference the tool injection from the tenant (once this is available)
-	// For now, we'll assume an explicit tenant reference implies the use of the tool injection suite
-	if wrd.WorkflowRun.Object.Spec.TenantRef != nil {
-		claim := wrd.WorkflowRun.Object.Spec.TenantRef.Name + model.ToolInjectionVolumeClaimSuffixReadOnlyMany
-		Annotate(&t.Object.ObjectMeta, model.RelayControllerToolsVolumeClaimAnnotation, claim)
+	if wrd.Tenant != nil && wrd.Tenant.Object.GetUID() != "" {
+		if tis := wrd.Tenant.Object.Status.ToolInjection; tis != nil {
+			Annotate(&t.Object.ObjectMeta, model.RelayControllerToolsVolumeClaimAnnotation, tis.VolumeClaimName)
+			Annotate(&t.Object.ObjectMeta, model.RelayControllerToolsMountPathAnnotation, tis.MountPath)
+		}
 	}
 
+	ConfigureMetadataAPITokenMount(&step.Container)
+
 	t.Object.Spec.Steps = []tektonv1beta1.Step{step}
+	t.Object.Spec.Volumes = []corev1.Volume{MetadataAPITokenVolume()}
 
 	return nil
 }
@@ -2,8 +2,16 @@ package obj
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
 
+	"github.com/puppetlabs/horsehead/v2/jsonutil"
 	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/authenticate"
+	"github.com/puppetlabs/relay-core/pkg/model"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -17,6 +25,10 @@ const (
 	TenantStatusReasonEventSinkNotConfigured = "EventSinkNotConfigured"
 	TenantStatusReasonEventSinkReady         = "EventSinkReady"
 
+	TenantStatusReasonSecretsMissing       = "SecretsMissing"
+	TenantStatusReasonSecretsNotConfigured = "SecretsNotConfigured"
+	TenantStatusReasonSecretsReady         = "SecretsReady"
+
 	TenantStatusReasonToolInjectionMissing = "ToolInjectionMissing"
 	TenantStatusReasonToolInjectionError   = "ToolInjectionError"
 
@@ -24,6 +36,10 @@ const (
 	TenantStatusReasonError = "Error"
 )
 
+const (
+	DefaultTenantDrainTimeout = 1 * time.Hour
+)
+
 var (
 	TenantKind = relayv1beta1.SchemeGroupVersion.WithKind("Tenant")
 )
@@ -65,6 +81,38 @@ func (t *Tenant) Managed() bool {
 	return t.Object.Spec.NamespaceTemplate.Metadata.GetName() != ""
 }
 
+func (t *Tenant) DeletionPolicy() relayv1beta1.TenantDeletionPolicy {
+	if t.Object.Spec.DeletionPolicy == "" {
+		return relayv1beta1.TenantDeletionPolicyDelete
+	}
+
+	return t.Object.Spec.DeletionPolicy
+}
+
+func (t *Tenant) DrainTimeout() time.Duration {
+	if t.Object.Spec.DrainTimeout == nil {
+		return DefaultTenantDrainTimeout
+	}
+
+	return t.Object.Spec.DrainTimeout.Duration
+}
+
+// SecretsReady returns false if the secrets backend of the tenant is
+// misconfigured.
+func (t *Tenant) SecretsReady() bool {
+	for _, cond := range t.Object.Status.Conditions {
+		if cond.Type == relayv1beta1.TenantSecretsReady && cond.Status == corev1.ConditionFalse {
+			return false
+		}
+	}
+
+	if vs := t.Object.Spec.Secrets.Vault; vs != nil {
+		return validateVaultTenantSecrets(vs) == nil
+	}
+
+	return true
+}
+
 func (t *Tenant) Ready() bool {
 	for _, cond := range t.Object.Status.Conditions {
 		if cond.Type != relayv1beta1.TenantReady {
@@ -84,11 +132,49 @@ func NewTenant(key client.ObjectKey) *Tenant {
 	}
 }
 
+// LoadTenantForNamespace finds the tenant with the given name that an object
+// in the given namespace refers to. The tenant is either in the same namespace
+// or is a managed tenant in another namespace whose child namespace is the
+// given namespace. Managed tenants are found through the namespace they
+// created, which records the tenant it is a dependency of.
+func LoadTenantForNamespace(ctx context.Context, cl client.Client, namespace, name string) (*Tenant, bool, error) {
+	t := NewTenant(client.ObjectKey{Namespace: namespace, Name: name})
+	if ok, err := t.Load(ctx, cl); err != nil {
+		return nil, false, err
+	} else if ok {
+		return t, true, nil
+	}
+
+	ns := NewNamespace(namespace)
+	if ok, err := ns.Load(ctx, cl); err != nil || !ok {
+		return t, false, err
+	}
+
+	dep, ok, err := GetDependencyOf(ns.Object.ObjectMeta)
+	if err != nil || !ok {
+		return t, false, err
+	} else if dep.Kind != TenantKind.Kind || dep.Name != name {
+		return t, false, nil
+	}
+
+	candidate := NewTenant(client.ObjectKey{Namespace: dep.Namespace, Name: dep.Name})
+	if ok, err := candidate.Load(ctx, cl); err != nil || !ok {
+		return t, false, err
+	}
+
+	if candidate.Object.GetUID() != dep.UID || candidate.Object.Spec.NamespaceTemplate.Metadata.GetName() != namespace {
+		return t, false, nil
+	}
+
+	return candidate, true, nil
+}
+
 func ConfigureTenant(t *Tenant, td *TenantDepsResult, jcs []batchv1.JobCondition) {
 	// Set up our initial map from the existing data.
 	conds := map[relayv1beta1.TenantConditionType]*relayv1beta1.Condition{
 		relayv1beta1.TenantNamespaceReady:     &relayv1beta1.Condition{},
 		relayv1beta1.TenantEventSinkReady:     &relayv1beta1.Condition{},
+		relayv1beta1.TenantSecretsReady:       &relayv1beta1.Condition{},
 		relayv1beta1.TenantToolInjectionReady: &relayv1beta1.Condition{},
 		relayv1beta1.TenantReady:              &relayv1beta1.Condition{},
 	}
@@ -120,6 +206,16 @@ func ConfigureTenant(t *Tenant, td *TenantDepsResult, jcs []batchv1.JobCondition
 
 	UpdateStatusConditionIfTransitioned(conds[relayv1beta1.TenantEventSinkReady], func() relayv1beta1.Condition {
 		if td.TenantDeps != nil {
+			for _, sink := range td.TenantDeps.NamedTriggerEventSinks {
+				if msg := namedTriggerEventSinkError(sink); msg != "" {
+					return relayv1beta1.Condition{
+						Status:  corev1.ConditionFalse,
+						Reason:  TenantStatusReasonEventSinkNotConfigured,
+						Message: msg,
+					}
+				}
+			}
+
 			if sink := td.TenantDeps.APITriggerEventSink; sink != nil {
 				if sink.URL() == "" {
 					return relayv1beta1.Condition{
@@ -142,6 +238,30 @@ func ConfigureTenant(t *Tenant, td *TenantDepsResult, jcs []batchv1.JobCondition
 				}
 			}
 
+			if sink := td.TenantDeps.DirectTriggerEventSink; sink != nil {
+				if _, ok := sink.Workflow(); !ok {
+					return relayv1beta1.Condition{
+						Status:  corev1.ConditionFalse,
+						Reason:  TenantStatusReasonEventSinkNotConfigured,
+						Message: "The direct trigger event sink is missing a workflow definition.",
+					}
+				}
+
+				return relayv1beta1.Condition{
+					Status:  corev1.ConditionTrue,
+					Reason:  TenantStatusReasonEventSinkReady,
+					Message: "The event sink is ready.",
+				}
+			}
+
+			if len(td.TenantDeps.NamedTriggerEventSinks) > 0 {
+				return relayv1beta1.Condition{
+					Status:  corev1.ConditionTrue,
+					Reason:  TenantStatusReasonEventSinkReady,
+					Message: "The event sink is ready.",
+				}
+			}
+
 			// This shouldn't block people who want to use these APIs without
 			// WebhookTriggers.
 			return relayv1beta1.Condition{
@@ -156,6 +276,38 @@ func ConfigureTenant(t *Tenant, td *TenantDepsResult, jcs []batchv1.JobCondition
 		}
 	})
 
+	UpdateStatusConditionIfTransitioned(conds[relayv1beta1.TenantSecretsReady], func() relayv1beta1.Condition {
+		if td.TenantDeps != nil {
+			if vs := td.TenantDeps.Tenant.Object.Spec.Secrets.Vault; vs != nil {
+				if err := validateVaultTenantSecrets(vs); err != nil {
+					return relayv1beta1.Condition{
+						Status:  corev1.ConditionFalse,
+						Reason:  TenantStatusReasonSecretsNotConfigured,
+						Message: fmt.Sprintf("The Vault secrets backend is not configured correctly: %s.", err),
+					}
+				}
+
+				return relayv1beta1.Condition{
+					Status:  corev1.ConditionTrue,
+					Reason:  TenantStatusReasonSecretsReady,
+					Message: "The secrets backend is ready.",
+				}
+			}
+
+			// Runs and triggers may still provide their own configuration
+			// using annotations.
+			return relayv1beta1.Condition{
+				Status:  corev1.ConditionTrue,
+				Reason:  TenantStatusReasonSecretsMissing,
+				Message: "The tenant does not have a secrets backend defined.",
+			}
+		}
+
+		return relayv1beta1.Condition{
+			Status: corev1.ConditionUnknown,
+		}
+	})
+
 	UpdateStatusConditionIfTransitioned(conds[relayv1beta1.TenantToolInjectionReady], func() relayv1beta1.Condition {
 		if td.TenantDeps != nil {
 			if vc := td.TenantDeps.ToolInjection.VolumeClaimTemplate; vc != nil {
@@ -212,7 +364,7 @@ func ConfigureTenant(t *Tenant, td *TenantDepsResult, jcs []batchv1.JobCondition
 	})
 
 	UpdateStatusConditionIfTransitioned(conds[relayv1beta1.TenantReady], func() relayv1beta1.Condition {
-		switch AggregateStatusConditions(*conds[relayv1beta1.TenantNamespaceReady], *conds[relayv1beta1.TenantEventSinkReady], *conds[relayv1beta1.TenantToolInjectionReady]) {
+		switch AggregateStatusConditions(*conds[relayv1beta1.TenantNamespaceReady], *conds[relayv1beta1.TenantEventSinkReady], *conds[relayv1beta1.TenantSecretsReady], *conds[relayv1beta1.TenantToolInjectionReady]) {
 		case corev1.ConditionTrue:
 			return relayv1beta1.Condition{
 				Status:  corev1.ConditionTrue,
@@ -232,9 +384,18 @@ func ConfigureTenant(t *Tenant, td *TenantDepsResult, jcs []batchv1.JobCondition
 		}
 	})
 
+	// The tool injection status is only updated by the reconciler once a new
+	// version of the volume has been populated.
+	var tis *relayv1beta1.TenantToolInjectionStatus
+	if td.TenantDeps != nil && td.TenantDeps.ToolInjection.VolumeClaimTemplate != nil {
+		tis = t.Object.Status.ToolInjection
+	}
+
 	t.Object.Status = relayv1beta1.TenantStatus{
 		ObservedGeneration: t.Object.GetGeneration(),
 		Namespace:          td.TenantDeps.Namespace.Name,
+		Drain:              t.Object.Status.Drain,
+		ToolInjection:      tis,
 		Conditions: []relayv1beta1.TenantCondition{
 			{
 				Condition: *conds[relayv1beta1.TenantNamespaceReady],
@@ -244,6 +405,10 @@ func ConfigureTenant(t *Tenant, td *TenantDepsResult, jcs []batchv1.JobCondition
 				Condition: *conds[relayv1beta1.TenantEventSinkReady],
 				Type:      relayv1beta1.TenantEventSinkReady,
 			},
+			{
+				Condition: *conds[relayv1beta1.TenantSecretsReady],
+				Type:      relayv1beta1.TenantSecretsReady,
+			},
 			{
 				Condition: *conds[relayv1beta1.TenantToolInjectionReady],
 				Type:      relayv1beta1.TenantToolInjectionReady,
@@ -255,3 +420,114 @@ func ConfigureTenant(t *Tenant, td *TenantDepsResult, jcs []batchv1.JobCondition
 		},
 	}
 }
+
+// ConfigureVaultClaims sets the location of secrets and connections for a
+// token. The secrets backend of the tenant takes precedence over annotations on
+// the object the token is being issued for. If the backend of the tenant is
+// misconfigured, the token grants no access to secrets at all.
+func ConfigureVaultClaims(claims *authenticate.Claims, t *Tenant, annotations map[string]string) {
+	if t != nil {
+		if vs := t.Object.Spec.Secrets.Vault; vs != nil {
+			if t.SecretsReady() {
+				claims.RelayVaultEnginePath = vs.EngineMount
+				claims.RelayVaultSecretPath = vs.SecretPath
+				claims.RelayVaultConnectionPath = vs.ConnectionPath
+				claims.RelayVaultAuthRole = vs.AuthRole
+			}
+
+			return
+		}
+	}
+
+	claims.RelayVaultEnginePath = annotations[model.RelayVaultEngineMountAnnotation]
+	claims.RelayVaultSecretPath = annotations[model.RelayVaultSecretPathAnnotation]
+	claims.RelayVaultConnectionPath = annotations[model.RelayVaultConnectionPathAnnotation]
+}
+
+// ConfigureEventClaims routes the events emitted using a token to the event
+// sinks of a tenant. The direct sink takes precedence over the API sink. Named
+// sinks receive events in addition to either of them.
+func ConfigureEventClaims(claims *authenticate.Claims, api *APITriggerEventSink, direct *DirectTriggerEventSink, named []*NamedTriggerEventSink) {
+	if direct != nil {
+		claims.RelayEventDirect = true
+	} else if api != nil {
+		if u, _ := url.Parse(api.URL()); u != nil {
+			claims.RelayEventAPIURL = &jsonutil.URL{URL: u}
+			claims.RelayEventAPIToken, _ = api.Token()
+		}
+	}
+
+	for _, sink := range named {
+		sc := &authenticate.EventSinkClaims{
+			Name: sink.Name(),
+			When: sink.When(),
+		}
+
+		if sink.Direct != nil {
+			sc.Direct = true
+		} else if sink.API != nil {
+			u, _ := url.Parse(sink.API.URL())
+			if u == nil {
+				continue
+			}
+
+			sc.APIURL = &jsonutil.URL{URL: u}
+			sc.APIToken, _ = sink.API.Token()
+		} else {
+			continue
+		}
+
+		claims.RelayEventSinks = append(claims.RelayEventSinks, sc)
+	}
+}
+
+// namedTriggerEventSinkError describes why the given named event sink cannot
+// receive events, or returns an empty string if it is configured correctly.
+func namedTriggerEventSinkError(sink *NamedTriggerEventSink) string {
+	switch {
+	case sink.API != nil && sink.Direct != nil:
+		return fmt.Sprintf("The trigger event sink %q must specify only one of an API or direct sink.", sink.Name())
+	case sink.API != nil:
+		if sink.API.URL() == "" {
+			return fmt.Sprintf("The trigger event sink %q is missing an endpoint URL.", sink.Name())
+		} else if _, ok := sink.API.Token(); !ok {
+			return fmt.Sprintf("The trigger event sink %q is missing a token.", sink.Name())
+		}
+	case sink.Direct != nil:
+		if _, ok := sink.Direct.Workflow(); !ok {
+			return fmt.Sprintf("The trigger event sink %q is missing a workflow definition.", sink.Name())
+		}
+	default:
+		return fmt.Sprintf("The trigger event sink %q must specify an API or direct sink.", sink.Name())
+	}
+
+	return ""
+}
+
+func validateVaultTenantSecrets(vs *relayv1beta1.VaultTenantSecrets) error {
+	if vs.EngineMount == "" {
+		return fmt.Errorf("missing engine mount")
+	} else if vs.SecretPath == "" && vs.ConnectionPath == "" {
+		return fmt.Errorf("at least one of a secret path or a connection path must be specified")
+	}
+
+	for _, p := range []struct {
+		Name  string
+		Value string
+	}{
+		{Name: "engine mount", Value: vs.EngineMount},
+		{Name: "secret path", Value: vs.SecretPath},
+		{Name: "connection path", Value: vs.ConnectionPath},
+	} {
+		if p.Value == "" {
+			continue
+		}
+
+		clean := path.Clean(p.Value)
+		if path.IsAbs(p.Value) || clean != strings.TrimSuffix(p.Value, "/") || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("%s %q must be a normalized relative path", p.Name, p.Value)
+		}
+	}
+
+	return nil
+}
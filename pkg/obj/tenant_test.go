@@ -0,0 +1,55 @@
+package obj_test
+
+import (
+	"testing"
+
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/authenticate"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/puppetlabs/relay-core/pkg/obj"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestConfigureVaultClaims(t *testing.T) {
+	tn := obj.NewTenant(types.NamespacedName{Namespace: "default", Name: "my-tenant"})
+	tn.Object.Spec.Secrets.Vault = &relayv1beta1.VaultTenantSecrets{
+		EngineMount: "customers",
+		SecretPath:  "workflows/my-tenant",
+		AuthRole:    "relay-tenant-my-tenant",
+	}
+
+	annotations := map[string]string{
+		model.RelayVaultEngineMountAnnotation: "other",
+	}
+
+	claims := &authenticate.Claims{}
+	obj.ConfigureVaultClaims(claims, tn, annotations)
+	assert.Equal(t, "customers", claims.RelayVaultEnginePath)
+	assert.Equal(t, "workflows/my-tenant", claims.RelayVaultSecretPath)
+	assert.Equal(t, "relay-tenant-my-tenant", claims.RelayVaultAuthRole)
+
+	// A misconfigured backend grants no access to secrets, not even through
+	// annotations.
+	tn.Object.Status.Conditions = []relayv1beta1.TenantCondition{
+		{
+			Condition: relayv1beta1.Condition{Status: corev1.ConditionFalse},
+			Type:      relayv1beta1.TenantSecretsReady,
+		},
+	}
+
+	claims = &authenticate.Claims{}
+	obj.ConfigureVaultClaims(claims, tn, annotations)
+	assert.Empty(t, claims.RelayVaultEnginePath)
+	assert.Empty(t, claims.RelayVaultSecretPath)
+	assert.Empty(t, claims.RelayVaultAuthRole)
+
+	tn.Object.Status.Conditions = nil
+	tn.Object.Spec.Secrets.Vault.SecretPath = "../other-tenant"
+
+	claims = &authenticate.Claims{}
+	obj.ConfigureVaultClaims(claims, tn, annotations)
+	assert.Empty(t, claims.RelayVaultEnginePath)
+	assert.Empty(t, claims.RelayVaultSecretPath)
+}
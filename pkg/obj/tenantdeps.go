@@ -5,7 +5,6 @@ import (
 
 	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
 	"github.com/puppetlabs/relay-core/pkg/model"
-	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -49,16 +48,114 @@ func NewAPITriggerEventSink(namespace string, sink *relayv1beta1.APITriggerEvent
 	return tes
 }
 
-type ToolInjection struct {
-	VolumeClaimTemplate *corev1.PersistentVolumeClaim
+type DirectTriggerEventSink struct {
+	Sink              *relayv1beta1.DirectTriggerEventSink
+	WorkflowConfigMap *ConfigMap
 }
 
-func NewToolInjection(namespace string, toolInjection relayv1beta1.ToolInjection) *ToolInjection {
-	ti := &ToolInjection{
-		VolumeClaimTemplate: toolInjection.VolumeClaimTemplate,
+var _ Loader = &DirectTriggerEventSink{}
+
+func (tes *DirectTriggerEventSink) Load(ctx context.Context, cl client.Client) (bool, error) {
+	return IgnoreNilLoader{tes.WorkflowConfigMap}.Load(ctx, cl)
+}
+
+// Workflow returns the YAML workflow definition referenced by the sink.
+func (tes *DirectTriggerEventSink) Workflow() (string, bool) {
+	if tes.WorkflowConfigMap == nil {
+		return "", false
+	}
+
+	data, found := tes.WorkflowConfigMap.Object.Data[tes.Sink.WorkflowFrom.ConfigMapKeyRef.Key]
+	return data, found
+}
+
+func NewDirectTriggerEventSink(namespace string, sink *relayv1beta1.DirectTriggerEventSink) *DirectTriggerEventSink {
+	tes := &DirectTriggerEventSink{
+		Sink: sink,
+	}
+
+	if sink.WorkflowFrom.ConfigMapKeyRef != nil {
+		tes.WorkflowConfigMap = NewConfigMap(client.ObjectKey{
+			Namespace: namespace,
+			Name:      sink.WorkflowFrom.ConfigMapKeyRef.Name,
+		})
+	}
+
+	return tes
+}
+
+type NamedTriggerEventSink struct {
+	Sink   *relayv1beta1.NamedTriggerEventSink
+	API    *APITriggerEventSink
+	Direct *DirectTriggerEventSink
+}
+
+var _ Loader = &NamedTriggerEventSink{}
+
+func (tes *NamedTriggerEventSink) Load(ctx context.Context, cl client.Client) (bool, error) {
+	return Loaders{
+		IgnoreNilLoader{tes.API},
+		IgnoreNilLoader{tes.Direct},
+	}.Load(ctx, cl)
+}
+
+func (tes *NamedTriggerEventSink) Name() string {
+	return tes.Sink.Name
+}
+
+// When returns the condition events must match to be delivered to the sink,
+// or nil if every event should be delivered.
+func (tes *NamedTriggerEventSink) When() interface{} {
+	return tes.Sink.When.Value()
+}
+
+func NewNamedTriggerEventSink(namespace string, sink *relayv1beta1.NamedTriggerEventSink) *NamedTriggerEventSink {
+	tes := &NamedTriggerEventSink{
+		Sink: sink,
 	}
 
-	return ti
+	if sink.API != nil {
+		tes.API = NewAPITriggerEventSink(namespace, sink.API)
+	}
+
+	if sink.Direct != nil {
+		tes.Direct = NewDirectTriggerEventSink(namespace, sink.Direct)
+	}
+
+	return tes
+}
+
+// newTriggerEventSinks returns the configured event sinks of the tenant.
+func newTriggerEventSinks(t *Tenant) (api *APITriggerEventSink, direct *DirectTriggerEventSink, named []*NamedTriggerEventSink) {
+	if sink := t.Object.Spec.TriggerEventSink.API; sink != nil {
+		api = NewAPITriggerEventSink(t.Key.Namespace, sink)
+	}
+
+	if sink := t.Object.Spec.TriggerEventSink.Direct; sink != nil {
+		direct = NewDirectTriggerEventSink(t.Key.Namespace, sink)
+	}
+
+	for i := range t.Object.Spec.TriggerEventSink.Sinks {
+		named = append(named, NewNamedTriggerEventSink(t.Key.Namespace, &t.Object.Spec.TriggerEventSink.Sinks[i]))
+	}
+
+	return
+}
+
+// hasDirectTriggerEventSink returns true if any of the given event sinks
+// creates workflow runs.
+func hasDirectTriggerEventSink(direct *DirectTriggerEventSink, named []*NamedTriggerEventSink) bool {
+	if direct != nil {
+		return true
+	}
+
+	for _, sink := range named {
+		if sink.Direct != nil {
+			return true
+		}
+	}
+
+	return false
 }
 
 type TenantDeps struct {
@@ -72,8 +169,10 @@ type TenantDeps struct {
 	NetworkPolicy *NetworkPolicy
 	LimitRange    *LimitRange
 
-	APITriggerEventSink *APITriggerEventSink
-	ToolInjection       *ToolInjection
+	APITriggerEventSink    *APITriggerEventSink
+	DirectTriggerEventSink *DirectTriggerEventSink
+	NamedTriggerEventSinks []*NamedTriggerEventSink
+	ToolInjection          *ToolInjection
 }
 
 var _ Persister = &TenantDeps{}
@@ -101,7 +200,14 @@ func (td *TenantDeps) Persist(ctx context.Context, cl client.Client) error {
 }
 
 func (td *TenantDeps) Load(ctx context.Context, cl client.Client) (bool, error) {
-	loaders := Loaders{IgnoreNilLoader{td.APITriggerEventSink}}
+	loaders := Loaders{
+		IgnoreNilLoader{td.APITriggerEventSink},
+		IgnoreNilLoader{td.DirectTriggerEventSink},
+	}
+
+	for _, sink := range td.NamedTriggerEventSinks {
+		loaders = append(loaders, sink)
+	}
 
 	if !td.Tenant.Managed() {
 		loaders = append(loaders, RequiredLoader{td.Namespace})
@@ -124,7 +230,7 @@ func (td *TenantDeps) Delete(ctx context.Context, cl client.Client) (bool, error
 		return false, err
 	}
 
-	if !td.Tenant.Managed() {
+	if !td.Tenant.Managed() || td.Tenant.DeletionPolicy() == relayv1beta1.TenantDeletionPolicyOrphan {
 		return true, nil
 	}
 
@@ -170,11 +276,9 @@ func NewTenantDeps(t *Tenant) *TenantDeps {
 		td.LimitRange = NewLimitRange(client.ObjectKey{Namespace: ns, Name: t.Key.Name})
 	}
 
-	if sink := t.Object.Spec.TriggerEventSink.API; sink != nil {
-		td.APITriggerEventSink = NewAPITriggerEventSink(td.Tenant.Key.Namespace, sink)
-	}
+	td.APITriggerEventSink, td.DirectTriggerEventSink, td.NamedTriggerEventSinks = newTriggerEventSinks(t)
 
-	td.ToolInjection = NewToolInjection(td.Tenant.Key.Namespace, td.Tenant.Object.Spec.ToolInjection)
+	td.ToolInjection = NewToolInjection(td.Tenant.Key.Name, td.Tenant.Object.Spec.ToolInjection)
 
 	return td
 }
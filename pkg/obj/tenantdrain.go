@@ -0,0 +1,118 @@
+package obj
+
+import (
+	"context"
+	"time"
+
+	nebulav1 "github.com/puppetlabs/relay-core/pkg/apis/nebula.puppet.com/v1"
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TenantDrain tracks the workflow runs that must complete before a tenant
+// using the Drain deletion policy can be torn down.
+type TenantDrain struct {
+	Tenant     *Tenant
+	TenantDeps *TenantDeps
+
+	WorkflowRuns []*WorkflowRun
+}
+
+var _ Loader = &TenantDrain{}
+
+func (td *TenantDrain) Load(ctx context.Context, cl client.Client) (bool, error) {
+	td.WorkflowRuns = nil
+
+	// Every run in a managed namespace is torn down along with the tenant,
+	// whether or not it references it.
+	if td.Tenant.Managed() {
+		if err := td.load(ctx, cl, td.TenantDeps.Namespace.Name, func(wr *nebulav1.WorkflowRun) bool {
+			return true
+		}); err != nil {
+			return false, err
+		}
+
+		if td.TenantDeps.Namespace.Name == td.Tenant.Key.Namespace {
+			return true, nil
+		}
+	}
+
+	// Tenant references are local, so any other runs that belong to the tenant
+	// are in its own namespace.
+	if err := td.load(ctx, cl, td.Tenant.Key.Namespace, func(wr *nebulav1.WorkflowRun) bool {
+		return wr.Spec.TenantRef != nil && wr.Spec.TenantRef.Name == td.Tenant.Key.Name
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (td *TenantDrain) load(ctx context.Context, cl client.Client, namespace string, include func(wr *nebulav1.WorkflowRun) bool) error {
+	wrs := &nebulav1.WorkflowRunList{}
+	if err := cl.List(ctx, wrs, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+
+	for i := range wrs.Items {
+		wr := &wrs.Items[i]
+		if !include(wr) {
+			continue
+		}
+
+		td.WorkflowRuns = append(td.WorkflowRuns, &WorkflowRun{
+			Key:    client.ObjectKey{Namespace: wr.GetNamespace(), Name: wr.GetName()},
+			Object: wr,
+		})
+	}
+
+	return nil
+}
+
+// Active returns the number of workflow runs that have not yet completed.
+func (td *TenantDrain) Active() int {
+	var n int
+	for _, wr := range td.WorkflowRuns {
+		if !wr.IsComplete() {
+			n++
+		}
+	}
+
+	return n
+}
+
+// StartTime is the time at which draining began, which is the time the tenant
+// was marked for deletion.
+func (td *TenantDrain) StartTime() time.Time {
+	if ts := td.Tenant.Object.GetDeletionTimestamp(); ts != nil {
+		return ts.Time
+	}
+
+	return time.Now()
+}
+
+func (td *TenantDrain) Deadline() time.Time {
+	return td.StartTime().Add(td.Tenant.DrainTimeout())
+}
+
+// Drained returns true if the tenant can be torn down, either because all of
+// its workflow runs have completed or because the deadline has passed.
+func (td *TenantDrain) Drained(now time.Time) bool {
+	return td.Active() == 0 || !now.Before(td.Deadline())
+}
+
+func NewTenantDrain(td *TenantDeps) *TenantDrain {
+	return &TenantDrain{
+		Tenant:     td.Tenant,
+		TenantDeps: td,
+	}
+}
+
+func ConfigureTenantDrain(t *Tenant, td *TenantDrain) {
+	t.Object.Status.Drain = &relayv1beta1.TenantDrainStatus{
+		StartTime:          metav1.Time{Time: td.StartTime()},
+		Deadline:           metav1.Time{Time: td.Deadline()},
+		ActiveWorkflowRuns: int32(td.Active()),
+	}
+}
@@ -0,0 +1,217 @@
+package obj
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const toolInjectionVersionLength = 8
+
+type ToolInjection struct {
+	// Name is the name of the tenant the tool injection belongs to.
+	Name string
+
+	VolumeClaimTemplate *corev1.PersistentVolumeClaim
+
+	image     string
+	digest    string
+	mountPath string
+}
+
+// Image returns the full reference to the image used to populate the volume,
+// using def when the tenant does not specify an image.
+func (ti *ToolInjection) Image(def string) string {
+	image := ti.image
+	if image == "" {
+		image = def
+	}
+	if image == "" {
+		image = model.DefaultToolInjectionImage
+	}
+
+	if ti.digest != "" {
+		image += "@" + ti.digest
+	}
+
+	return image
+}
+
+// MountPath returns the path at which the tools are mounted in tenant
+// containers.
+func (ti *ToolInjection) MountPath() string {
+	if ti.mountPath == "" {
+		return model.ToolInjectionMountPath
+	}
+
+	return ti.mountPath
+}
+
+// Version returns a short identifier for the contents of the volume populated
+// from the given image.
+func (ti *ToolInjection) Version(def string) string {
+	h := sha256.Sum256([]byte(ti.Image(def)))
+	return hex.EncodeToString(h[:])[:toolInjectionVersionLength]
+}
+
+// VolumeClaimName returns the name of the volume claim (or volume or job) for
+// the given access mode suffix and version. The empty version refers to the
+// unversioned objects created by earlier releases of the controller.
+func (ti *ToolInjection) VolumeClaimName(suffix, version string) string {
+	if version == "" {
+		return ti.Name + suffix
+	}
+
+	return ti.Name + suffix + "-" + version
+}
+
+// Labels returns the labels to apply to every object created for the given
+// version.
+func (ti *ToolInjection) Labels(version string) map[string]string {
+	return map[string]string{
+		model.RelayControllerTenantNameLabel:   ti.Name,
+		model.RelayControllerToolsVersionLabel: version,
+	}
+}
+
+// DeleteStale removes the objects belonging to every version of the tool
+// injection volume other than the given one. The read-only claim of a stale
+// version is retained as long as any running pod still mounts it, so steps
+// that started before a version change continue to see the tools they started
+// with.
+//
+// The unversioned objects created by earlier releases of the controller are
+// not labeled, so they are looked up by name and removed as if they were a
+// stale version.
+//
+// It returns true if no stale objects remain.
+func (ti *ToolInjection) DeleteStale(ctx context.Context, cl client.Client, namespace, version string) (bool, error) {
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := cl.List(ctx, pvcs, client.InNamespace(namespace), client.MatchingLabels{model.RelayControllerTenantNameLabel: ti.Name}); err != nil {
+		return false, err
+	}
+
+	stale := make(map[string]struct{})
+	for _, pvc := range pvcs.Items {
+		if v := pvc.GetLabels()[model.RelayControllerToolsVersionLabel]; v != "" && v != version {
+			stale[v] = struct{}{}
+		}
+	}
+
+	for _, suffix := range []string{model.ToolInjectionVolumeClaimSuffixReadOnlyMany, model.ToolInjectionVolumeClaimSuffixReadWriteOnce} {
+		legacy := NewPersistentVolumeClaim(client.ObjectKey{Namespace: namespace, Name: ti.VolumeClaimName(suffix, "")})
+		if ok, err := legacy.Load(ctx, cl); err != nil {
+			return false, err
+		} else if ok {
+			stale[""] = struct{}{}
+		}
+	}
+
+	if len(stale) == 0 {
+		return true, nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := cl.List(ctx, pods, client.InNamespace(namespace)); err != nil {
+		return false, err
+	}
+
+	inUse := make(map[string]struct{})
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		for _, v := range pod.Spec.Volumes {
+			if v.PersistentVolumeClaim != nil {
+				inUse[v.PersistentVolumeClaim.ClaimName] = struct{}{}
+			}
+		}
+	}
+
+	all := true
+
+	for v := range stale {
+		rox := ti.VolumeClaimName(model.ToolInjectionVolumeClaimSuffixReadOnlyMany, v)
+		rwo := ti.VolumeClaimName(model.ToolInjectionVolumeClaimSuffixReadWriteOnce, v)
+
+		if _, found := inUse[rox]; found {
+			all = false
+			continue
+		}
+
+		roxClaim := NewPersistentVolumeClaim(client.ObjectKey{Namespace: namespace, Name: rox})
+		if ok, err := roxClaim.Load(ctx, cl); err != nil {
+			return false, err
+		} else if ok {
+			// Delete the read-only claim first so that no new pods can
+			// attach to the underlying disk, then clean up the rest of this
+			// version once the claim is gone.
+			if _, err := DeleteIgnoreNotFound(ctx, cl, roxClaim.Object); err != nil {
+				return false, err
+			}
+
+			all = false
+			continue
+		}
+
+		job := NewJob(client.ObjectKey{Namespace: namespace, Name: rox})
+		if ok, err := job.Load(ctx, cl); err != nil {
+			return false, err
+		} else if ok {
+			if err := cl.Delete(ctx, job.Object, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !k8serrors.IsNotFound(err) {
+				return false, err
+			}
+		}
+
+		roxVolume := NewPersistentVolume(client.ObjectKey{Name: rox})
+		if ok, err := roxVolume.Load(ctx, cl); err != nil {
+			return false, err
+		} else if ok {
+			if _, err := DeleteIgnoreNotFound(ctx, cl, roxVolume.Object); err != nil {
+				return false, err
+			}
+		}
+
+		rwoClaim := NewPersistentVolumeClaim(client.ObjectKey{Namespace: namespace, Name: rwo})
+		if ok, err := rwoClaim.Load(ctx, cl); err != nil {
+			return false, err
+		} else if ok {
+			if _, err := DeleteIgnoreNotFound(ctx, cl, rwoClaim.Object); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	return all, nil
+}
+
+// Status returns the status to report for the given version of the tool
+// injection volume.
+func (ti *ToolInjection) Status(def, version string) *relayv1beta1.TenantToolInjectionStatus {
+	return &relayv1beta1.TenantToolInjectionStatus{
+		Version:         version,
+		Image:           ti.Image(def),
+		VolumeClaimName: ti.VolumeClaimName(model.ToolInjectionVolumeClaimSuffixReadOnlyMany, version),
+		MountPath:       ti.MountPath(),
+	}
+}
+
+func NewToolInjection(name string, toolInjection relayv1beta1.ToolInjection) *ToolInjection {
+	ti := &ToolInjection{
+		Name:                name,
+		VolumeClaimTemplate: toolInjection.VolumeClaimTemplate,
+		image:               toolInjection.Image,
+		digest:              toolInjection.Digest,
+		mountPath:           toolInjection.MountPath,
+	}
+
+	return ti
+}
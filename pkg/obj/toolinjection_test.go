@@ -0,0 +1,38 @@
+package obj_test
+
+import (
+	"testing"
+
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/puppetlabs/relay-core/pkg/obj"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolInjectionVersion(t *testing.T) {
+	def := obj.NewToolInjection("my-tenant", relayv1beta1.ToolInjection{})
+	assert.Equal(t, model.DefaultToolInjectionImage, def.Image(""))
+	assert.Equal(t, "example.com/tools", def.Image("example.com/tools"))
+	assert.Equal(t, model.ToolInjectionMountPath, def.MountPath())
+
+	pinned := obj.NewToolInjection("my-tenant", relayv1beta1.ToolInjection{
+		Image:     "example.com/tools",
+		Digest:    "sha256:0123456789abcdef",
+		MountPath: "/opt/relay/",
+	})
+	assert.Equal(t, "example.com/tools@sha256:0123456789abcdef", pinned.Image(model.DefaultToolInjectionImage))
+	assert.Equal(t, "/opt/relay/", pinned.MountPath())
+
+	// The version is stable for the same image and changes with the digest.
+	v := pinned.Version("")
+	assert.Len(t, v, 8)
+	assert.Equal(t, v, pinned.Version(model.DefaultToolInjectionImage))
+	assert.NotEqual(t, v, def.Version(""))
+
+	status := pinned.Status("", v)
+	assert.Equal(t, "my-tenant"+model.ToolInjectionVolumeClaimSuffixReadOnlyMany+"-"+v, status.VolumeClaimName)
+	assert.Equal(t, "/opt/relay/", status.MountPath)
+
+	// Earlier releases used a single unversioned claim.
+	assert.Equal(t, "my-tenant"+model.ToolInjectionVolumeClaimSuffixReadOnlyMany, pinned.VolumeClaimName(model.ToolInjectionVolumeClaimSuffixReadOnlyMany, ""))
+}
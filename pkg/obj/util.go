@@ -101,6 +101,16 @@ func AddFinalizer(target *metav1.ObjectMeta, name string) bool {
 	return true
 }
 
+func HasFinalizer(target *metav1.ObjectMeta, name string) bool {
+	for _, f := range target.Finalizers {
+		if f == name {
+			return true
+		}
+	}
+
+	return false
+}
+
 func RemoveFinalizer(target *metav1.ObjectMeta, name string) bool {
 	cut := -1
 	for i, f := range target.Finalizers {
@@ -148,14 +158,25 @@ func SetDependencyOf(target *metav1.ObjectMeta, owner Owner) error {
 	return nil
 }
 
-func IsDependencyOf(target metav1.ObjectMeta, owner Owner) (bool, error) {
+// GetDependencyOf returns the object the target was marked as a dependency of,
+// if any.
+func GetDependencyOf(target metav1.ObjectMeta) (*DependencyOf, bool, error) {
 	annotation := target.GetAnnotations()[model.RelayControllerDependencyOfAnnotation]
 	if annotation == "" {
-		return false, nil
+		return nil, false, nil
+	}
+
+	dep := &DependencyOf{}
+	if err := json.Unmarshal([]byte(annotation), dep); err != nil {
+		return nil, false, err
 	}
 
-	var dep DependencyOf
-	if err := json.Unmarshal([]byte(annotation), &dep); err != nil {
+	return dep, true, nil
+}
+
+func IsDependencyOf(target metav1.ObjectMeta, owner Owner) (bool, error) {
+	dep, ok, err := GetDependencyOf(target)
+	if err != nil || !ok {
 		return false, err
 	}
 
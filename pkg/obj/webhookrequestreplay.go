@@ -0,0 +1,111 @@
+package obj
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/puppetlabs/relay-core/pkg/webhookverifier"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	ErrWebhookRequestCaptureDisabled = errors.New("obj: webhook request capture is not enabled")
+	ErrWebhookRequestBodyTruncated   = errors.New("obj: captured webhook request body was truncated and cannot be replayed")
+	ErrWebhookRequestNotAddressable  = errors.New("obj: webhook trigger service is not addressable")
+)
+
+// WebhookRequestReplayTimeout is the maximum amount of time to wait for the
+// webhook trigger to respond to a replayed request.
+const WebhookRequestReplayTimeout = 30 * time.Second
+
+// WebhookRequestReplayClient is the HTTP client used to replay webhook
+// requests.
+var WebhookRequestReplayClient = &http.Client{Timeout: WebhookRequestReplayTimeout}
+
+// NewWebhookRequestManagerForWebhookTrigger returns a manager for the requests
+// captured for the webhook trigger.
+func NewWebhookRequestManagerForWebhookTrigger(cl client.Client, wtd *WebhookTriggerDeps) (*configmap.WebhookRequestManager, error) {
+	capture := wtd.WebhookTrigger.Object.Spec.Capture
+	if capture == nil {
+		return nil, ErrWebhookRequestCaptureDisabled
+	}
+
+	return configmap.NewWebhookRequestManager(
+		configmap.NewControllerRuntimeConfigMap(cl, wtd.WebhookRequestsConfigMap.Key),
+		int(capture.Requests),
+	), nil
+}
+
+// ReplayWebhookRequest sends the captured request with the given ID directly
+// to the webhook trigger service at the given URL, bypassing any verification.
+// The result is captured as a new request.
+//
+// The request may take up to WebhookRequestReplayTimeout to complete, so
+// callers should not use this function while reconciling.
+func ReplayWebhookRequest(ctx context.Context, wrm *configmap.WebhookRequestManager, upstreamURL, id string) (*model.WebhookRequest, error) {
+	if upstreamURL == "" {
+		return nil, ErrWebhookRequestNotAddressable
+	}
+
+	orig, err := wrm.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	} else if orig.BodyTruncated {
+		return nil, ErrWebhookRequestBodyTruncated
+	}
+
+	req, err := http.NewRequest(orig.Method, strings.TrimSuffix(upstreamURL, "/")+orig.URL, bytes.NewReader(orig.Body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	for name, values := range orig.Header {
+		for _, value := range values {
+			// Redacted values were never captured, so we can't send them.
+			if value == webhookverifier.RedactedHeaderValue {
+				continue
+			}
+
+			req.Header.Add(name, value)
+		}
+	}
+
+	// Like the verifier, we send a new request ID so that events emitted by
+	// the trigger are attached to the replay.
+	replayID := uuid.New().String()
+	req.Header.Set(model.WebhookRequestIDHeader, replayID)
+
+	replay := &model.WebhookRequest{
+		ID:            replayID,
+		ReceivedAt:    time.Now(),
+		Method:        orig.Method,
+		URL:           orig.URL,
+		Header:        orig.Header,
+		Body:          orig.Body,
+		BodyTruncated: orig.BodyTruncated,
+		ReplayOf:      orig.ID,
+	}
+
+	resp, err := WebhookRequestReplayClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("obj: failed to replay webhook request %q: %+v", id, err)
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	replay.CompletedAt = time.Now()
+	replay.StatusCode = resp.StatusCode
+
+	return wrm.Record(ctx, replay)
+}
@@ -0,0 +1,98 @@
+package obj_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/puppetlabs/relay-core/pkg/obj"
+	"github.com/puppetlabs/relay-core/pkg/webhookverifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestReplayWebhookRequestPreconditions(t *testing.T) {
+	ctx := context.Background()
+
+	wt := obj.NewWebhookTrigger(types.NamespacedName{Namespace: "default", Name: "my-trigger"})
+	wtd := &obj.WebhookTriggerDeps{
+		WebhookTrigger:           wt,
+		WebhookRequestsConfigMap: obj.NewConfigMap(types.NamespacedName{Namespace: "tenant", Name: "my-trigger-requests"}),
+	}
+
+	_, err := obj.NewWebhookRequestManagerForWebhookTrigger(nil, wtd)
+	assert.Equal(t, obj.ErrWebhookRequestCaptureDisabled, err)
+
+	_, err = obj.ReplayWebhookRequest(ctx, nil, "", "id")
+	assert.Equal(t, obj.ErrWebhookRequestNotAddressable, err)
+
+	// Capturing requests requires the verifier even without verification,
+	// and the verifier must be able to reach the metadata API.
+	assert.False(t, wt.HasVerifier())
+	wt.Object.Spec.Capture = &relayv1beta1.WebhookTriggerCapture{}
+	assert.True(t, wt.HasVerifier())
+
+	np := obj.NewNetworkPolicy(types.NamespacedName{Namespace: "tenant", Name: "my-trigger-verifier"})
+	obj.ConfigureNetworkPolicyForWebhookVerifier(np, wt)
+
+	egress := np.Object.Spec.Egress[len(np.Object.Spec.Egress)-1]
+	if assert.Len(t, egress.Ports, 1) {
+		assert.Equal(t, 7000, egress.Ports[0].Port.IntValue())
+	}
+}
+
+func TestReplayWebhookRequest(t *testing.T) {
+	ctx := context.Background()
+
+	type received struct {
+		requestID string
+		token     string
+		body      string
+	}
+
+	recv := make(chan received, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		recv <- received{
+			requestID: r.Header.Get(model.WebhookRequestIDHeader),
+			token:     r.Header.Get("X-Gitlab-Token"),
+			body:      string(b),
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer upstream.Close()
+
+	wrm := configmap.NewWebhookRequestManager(configmap.NewLocalConfigMap(&corev1.ConfigMap{}), 10)
+
+	now := time.Now()
+	orig, err := wrm.Record(ctx, &model.WebhookRequest{
+		ReceivedAt:  now,
+		CompletedAt: now,
+		Method:      http.MethodPost,
+		URL:         "/hook",
+		Header: http.Header{
+			"X-Gitlab-Token": []string{webhookverifier.RedactedHeaderValue},
+		},
+		Body: []byte("payload"),
+	})
+	require.NoError(t, err)
+
+	replay, err := obj.ReplayWebhookRequest(ctx, wrm, upstream.URL, orig.ID)
+	require.NoError(t, err)
+	assert.Equal(t, orig.ID, replay.ReplayOf)
+	assert.NotEqual(t, orig.ID, replay.ID)
+	assert.Equal(t, http.StatusAccepted, replay.StatusCode)
+
+	r := <-recv
+	assert.Equal(t, replay.ID, r.requestID)
+	assert.Empty(t, r.token)
+	assert.Equal(t, "payload", r.body)
+}
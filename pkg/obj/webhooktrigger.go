@@ -2,8 +2,10 @@ package obj
 
 import (
 	"context"
+	"time"
 
 	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
 	"github.com/puppetlabs/relay-core/pkg/model"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,6 +16,10 @@ const (
 	WebhookTriggerStatusReasonServiceReady = "ServiceReady"
 	WebhookTriggerStatusReasonServiceError = "ServiceError"
 
+	WebhookTriggerStatusReasonRouteReady    = "RouteReady"
+	WebhookTriggerStatusReasonRouteError    = "RouteError"
+	WebhookTriggerStatusReasonRouteConflict = "RouteConflict"
+
 	WebhookTriggerStatusReasonReady = "Ready"
 	WebhookTriggerStatusReasonError = "Error"
 )
@@ -63,6 +69,19 @@ func (wt *WebhookTrigger) Ready() bool {
 	return false
 }
 
+// HasVerifier returns true if requests to the webhook trigger must pass through
+// the webhook verifier, either to check their signatures, to capture them or to
+// enforce limits.
+func (wt *WebhookTrigger) HasVerifier() bool {
+	return wt.Object.Spec.Verification != nil || wt.Object.Spec.Capture != nil || wt.Object.Spec.Limits != nil
+}
+
+// RateLimited returns true if the rate of requests to the webhook trigger is
+// limited.
+func (wt *WebhookTrigger) RateLimited() bool {
+	return wt.Object.Spec.Limits != nil && wt.Object.Spec.Limits.RequestsPerSecond > 0
+}
+
 func (wt *WebhookTrigger) PodSelector() metav1.LabelSelector {
 	return metav1.LabelSelector{
 		MatchLabels: map[string]string{
@@ -78,10 +97,15 @@ func NewWebhookTrigger(key client.ObjectKey) *WebhookTrigger {
 	}
 }
 
-func ConfigureWebhookTrigger(wt *WebhookTrigger, ksr *KnativeServiceResult) {
+// ConfigureWebhookTrigger updates the status of the webhook trigger from the
+// result of applying its service, the result of applying the webhook verifier
+// in front of it if verification is configured, and the result of applying
+// the route to whichever of the two is public.
+func ConfigureWebhookTrigger(wt *WebhookTrigger, sr *WebhookTriggerServiceResult, vsr *WebhookTriggerServiceResult, rr *WebhookTriggerRouteResult) {
 	// Set up our initial map from the existing data.
 	conds := map[relayv1beta1.WebhookTriggerConditionType]*relayv1beta1.Condition{
 		relayv1beta1.WebhookTriggerServiceReady: &relayv1beta1.Condition{},
+		relayv1beta1.WebhookTriggerRouteReady:   &relayv1beta1.Condition{},
 		relayv1beta1.WebhookTriggerReady:        &relayv1beta1.Condition{},
 	}
 
@@ -89,15 +113,22 @@ func ConfigureWebhookTrigger(wt *WebhookTrigger, ksr *KnativeServiceResult) {
 		*conds[cond.Type] = cond.Condition
 	}
 
-	// Update with data from Knative service.
+	// Update with data from the service.
 	UpdateStatusConditionIfTransitioned(conds[relayv1beta1.WebhookTriggerServiceReady], func() relayv1beta1.Condition {
-		if ksr.Error != nil {
+		if sr.Error != nil {
 			return relayv1beta1.Condition{
 				Status:  corev1.ConditionFalse,
 				Reason:  WebhookTriggerStatusReasonServiceError,
-				Message: ksr.Error.Error(),
+				Message: sr.Error.Error(),
 			}
-		} else if ksr.KnativeService != nil && ksr.KnativeService.Object.Status.IsReady() {
+		} else if vsr != nil && vsr.Error != nil {
+			return relayv1beta1.Condition{
+				Status:  corev1.ConditionFalse,
+				Reason:  WebhookTriggerStatusReasonServiceError,
+				Message: vsr.Error.Error(),
+			}
+		} else if sr.Service != nil && sr.Service.Ready() &&
+			(vsr == nil || (vsr.Service != nil && vsr.Service.Ready())) {
 			return relayv1beta1.Condition{
 				Status:  corev1.ConditionTrue,
 				Reason:  WebhookTriggerStatusReasonServiceReady,
@@ -110,8 +141,34 @@ func ConfigureWebhookTrigger(wt *WebhookTrigger, ksr *KnativeServiceResult) {
 		}
 	})
 
+	// Update with data from the route.
+	UpdateStatusConditionIfTransitioned(conds[relayv1beta1.WebhookTriggerRouteReady], func() relayv1beta1.Condition {
+		if rr != nil && rr.Error != nil {
+			reason := WebhookTriggerStatusReasonRouteError
+			if _, ok := rr.Error.(*WebhookTriggerRouteConflictError); ok {
+				reason = WebhookTriggerStatusReasonRouteConflict
+			}
+
+			return relayv1beta1.Condition{
+				Status:  corev1.ConditionFalse,
+				Reason:  reason,
+				Message: rr.Error.Error(),
+			}
+		} else if rr != nil && rr.Route != nil && rr.Route.Ready() {
+			return relayv1beta1.Condition{
+				Status:  corev1.ConditionTrue,
+				Reason:  WebhookTriggerStatusReasonRouteReady,
+				Message: "The route is ready to accept requests.",
+			}
+		}
+
+		return relayv1beta1.Condition{
+			Status: corev1.ConditionUnknown,
+		}
+	})
+
 	UpdateStatusConditionIfTransitioned(conds[relayv1beta1.WebhookTriggerReady], func() relayv1beta1.Condition {
-		switch AggregateStatusConditions(*conds[relayv1beta1.WebhookTriggerServiceReady]) {
+		switch AggregateStatusConditions(*conds[relayv1beta1.WebhookTriggerServiceReady], *conds[relayv1beta1.WebhookTriggerRouteReady]) {
 		case corev1.ConditionTrue:
 			return relayv1beta1.Condition{
 				Status:  corev1.ConditionTrue,
@@ -139,6 +196,10 @@ func ConfigureWebhookTrigger(wt *WebhookTrigger, ksr *KnativeServiceResult) {
 				Condition: *conds[relayv1beta1.WebhookTriggerServiceReady],
 				Type:      relayv1beta1.WebhookTriggerServiceReady,
 			},
+			{
+				Condition: *conds[relayv1beta1.WebhookTriggerRouteReady],
+				Type:      relayv1beta1.WebhookTriggerRouteReady,
+			},
 			{
 				Condition: *conds[relayv1beta1.WebhookTriggerReady],
 				Type:      relayv1beta1.WebhookTriggerReady,
@@ -146,11 +207,20 @@ func ConfigureWebhookTrigger(wt *WebhookTrigger, ksr *KnativeServiceResult) {
 		},
 	}
 
-	if ksr.KnativeService != nil {
-		wt.Object.Status.Namespace = ksr.KnativeService.Key.Namespace
+	if sr.Service != nil {
+		wt.Object.Status.Namespace = sr.Service.Namespace()
+		wt.Object.Status.Scaling = sr.Service.ScalingStatus()
+
+		// When verification is configured, the verifier is the public
+		// endpoint.
+		public := sr.Service
+		if vsr != nil {
+			public = vsr.Service
+		}
 
-		if ksr.KnativeService.Object.Status.IsReady() && ksr.KnativeService.Object.Status.URL != nil {
-			wt.Object.Status.URL = ksr.KnativeService.Object.Status.URL.String()
+		// The URL is the address of the route to the public service.
+		if public != nil && public.Ready() && rr != nil && rr.Route != nil && rr.Route.Ready() {
+			wt.Object.Status.URL = rr.Route.URL()
 		} else {
 			wt.Object.Status.URL = ""
 		}
@@ -159,3 +229,45 @@ func ConfigureWebhookTrigger(wt *WebhookTrigger, ksr *KnativeServiceResult) {
 		wt.Object.Status.URL = ""
 	}
 }
+
+// ConfigureWebhookTriggerDeliveries copies the delivery statistics recorded by
+// the metadata API in the given config map to the status of the webhook
+// trigger.
+func ConfigureWebhookTriggerDeliveries(ctx context.Context, wt *WebhookTrigger, cm *ConfigMap) error {
+	wd, err := configmap.NewWebhookDeliveryManager(configmap.NewLocalConfigMap(cm.Object)).Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	optionalTime := func(t time.Time) *metav1.Time {
+		if t.IsZero() {
+			return nil
+		}
+
+		mt := metav1.NewTime(t)
+		return &mt
+	}
+
+	wt.Object.Status.Deliveries = &relayv1beta1.WebhookTriggerDeliveryStatus{
+		LastRequestTime:  optionalTime(wd.LastRequestTime),
+		LastEventTime:    optionalTime(wd.LastEventTime),
+		RequestsReceived: wd.RequestsReceived,
+		EventsEmitted:    wd.EventsEmitted,
+		Failed:           wd.Failed,
+		LastError:        wd.LastError,
+		LastErrorTime:    optionalTime(wd.LastErrorTime),
+	}
+
+	for _, wsd := range wd.Sinks {
+		wt.Object.Status.Deliveries.Sinks = append(wt.Object.Status.Deliveries.Sinks, relayv1beta1.WebhookTriggerSinkDeliveryStatus{
+			Name:            wsd.Name,
+			LastEventTime:   optionalTime(wsd.LastEventTime),
+			EventsDelivered: wsd.EventsDelivered,
+			Failed:          wsd.Failed,
+			LastError:       wsd.LastError,
+			LastErrorTime:   optionalTime(wsd.LastErrorTime),
+		})
+	}
+
+	return nil
+}
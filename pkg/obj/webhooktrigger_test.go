@@ -0,0 +1,51 @@
+package obj_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/puppetlabs/relay-core/pkg/obj"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestConfigureWebhookTriggerDeliveries(t *testing.T) {
+	ctx := context.Background()
+
+	wt := obj.NewWebhookTrigger(types.NamespacedName{Namespace: "default", Name: "my-trigger"})
+	cm := obj.NewConfigMap(types.NamespacedName{Namespace: "tenant", Name: "my-trigger-deliveries"})
+
+	// No deliveries have been recorded yet.
+	require.NoError(t, obj.ConfigureWebhookTriggerDeliveries(ctx, wt, cm))
+	require.NotNil(t, wt.Object.Status.Deliveries)
+	assert.Nil(t, wt.Object.Status.Deliveries.LastRequestTime)
+	assert.Equal(t, int64(0), wt.Object.Status.Deliveries.RequestsReceived)
+
+	now := time.Now()
+
+	wdm := configmap.NewWebhookDeliveryManager(configmap.NewLocalConfigMap(cm.Object))
+	require.NoError(t, wdm.RecordRequest(ctx, now, nil))
+	require.NoError(t, wdm.RecordEvent(ctx, now, errors.New("boom")))
+	require.NoError(t, wdm.RecordSinkEvent(ctx, "audit", now, nil))
+
+	require.NoError(t, obj.ConfigureWebhookTriggerDeliveries(ctx, wt, cm))
+
+	deliveries := wt.Object.Status.Deliveries
+	require.NotNil(t, deliveries.LastRequestTime)
+	assert.True(t, now.Equal(deliveries.LastRequestTime.Time))
+	assert.Nil(t, deliveries.LastEventTime)
+	assert.Equal(t, int64(1), deliveries.RequestsReceived)
+	assert.Equal(t, int64(0), deliveries.EventsEmitted)
+	assert.Equal(t, int64(1), deliveries.Failed)
+	assert.Equal(t, "boom", deliveries.LastError)
+
+	require.Len(t, deliveries.Sinks, 1)
+	assert.Equal(t, "audit", deliveries.Sinks[0].Name)
+	assert.Equal(t, int64(1), deliveries.Sinks[0].EventsDelivered)
+	require.NotNil(t, deliveries.Sinks[0].LastEventTime)
+	assert.Nil(t, deliveries.Sinks[0].LastErrorTime)
+}
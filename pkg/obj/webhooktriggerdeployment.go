@@ -0,0 +1,350 @@
+package obj
+
+import (
+	"context"
+	"fmt"
+
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// WebhookTriggerActivatorLabel marks deployments that the activator may
+	// scale between zero and one replica.
+	WebhookTriggerActivatorLabel = "controller.relay.sh/webhook-trigger-activator"
+
+	// WebhookTriggerContainerPort is the port webhook trigger and verifier
+	// containers listen on when run by the Kubernetes backend. It is provided
+	// to the container in the PORT environment variable, as with Knative.
+	WebhookTriggerContainerPort = 8080
+)
+
+// WebhookTriggerPrivateServiceKey returns the key of the service that
+// addresses the pods of a deployment directly when the public service routes
+// requests through the activator.
+func WebhookTriggerPrivateServiceKey(key client.ObjectKey) client.ObjectKey {
+	return SuffixObjectKey(key, "private")
+}
+
+// WebhookTriggerDeployment is a webhook trigger or verifier workload run by
+// the Kubernetes backend.
+type WebhookTriggerDeployment struct {
+	Deployment *Deployment
+
+	// Service is the address of the workload. When the workload is scaled by
+	// the activator, it resolves to the activator instead of the pods.
+	Service *Service
+
+	// PrivateService addresses the pods of the workload when the workload is
+	// scaled by the activator.
+	PrivateService *Service
+}
+
+var _ WebhookTriggerService = &WebhookTriggerDeployment{}
+
+func (wtd *WebhookTriggerDeployment) Namespace() string {
+	return wtd.Deployment.Key.Namespace
+}
+
+func (wtd *WebhookTriggerDeployment) Activated() bool {
+	return wtd.Deployment.Object.GetLabels()[WebhookTriggerActivatorLabel] == "true"
+}
+
+func (wtd *WebhookTriggerDeployment) Ready() bool {
+	return wtd.Deployment.Ready() && wtd.Service.Object.GetUID() != ""
+}
+
+func (wtd *WebhookTriggerDeployment) URL() string {
+	if wtd.Service.Object.GetUID() == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("http://%s", wtd.Service.Host())
+}
+
+func (wtd *WebhookTriggerDeployment) ScalingStatus() *relayv1beta1.WebhookTriggerScalingStatus {
+	if wtd.Activated() {
+		return &relayv1beta1.WebhookTriggerScalingStatus{
+			MinScale: 0,
+			MaxScale: 1,
+		}
+	}
+
+	var replicas int32 = 1
+	if r := wtd.Deployment.Object.Spec.Replicas; r != nil {
+		replicas = *r
+	}
+
+	return &relayv1beta1.WebhookTriggerScalingStatus{
+		MinScale: replicas,
+		MaxScale: replicas,
+	}
+}
+
+func (wtd *WebhookTriggerDeployment) Load(ctx context.Context, cl client.Client) (bool, error) {
+	return Loaders{wtd.Deployment, wtd.Service, wtd.PrivateService}.Load(ctx, cl)
+}
+
+func (wtd *WebhookTriggerDeployment) Delete(ctx context.Context, cl client.Client) error {
+	for _, o := range []runtime.Object{
+		wtd.Deployment.Object,
+		wtd.Service.Object,
+		wtd.PrivateService.Object,
+	} {
+		if o.(metav1.Object).GetUID() == "" {
+			continue
+		}
+
+		if _, err := DeleteIgnoreNotFound(ctx, cl, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func NewWebhookTriggerDeployment(key client.ObjectKey) *WebhookTriggerDeployment {
+	return &WebhookTriggerDeployment{
+		Deployment:     NewDeployment(key),
+		Service:        NewService(key),
+		PrivateService: NewService(WebhookTriggerPrivateServiceKey(key)),
+	}
+}
+
+// ConfigureWebhookTriggerDeployment sets up the deployment and services for a
+// workload given its pod template.
+//
+// If activatorHost is not empty, the workload is labeled for the activator
+// and its public service resolves to the activator. The number of replicas is
+// then left to the activator and starts at zero.
+func ConfigureWebhookTriggerDeployment(ctx context.Context, d *WebhookTriggerDeployment, wtd *WebhookTriggerDeps, template corev1.PodTemplateSpec, replicas int32, activatorHost string) error {
+	owner := Owner{Object: wtd.WebhookTrigger.Object, GVK: relayv1beta1.WebhookTriggerKind}
+
+	for _, laf := range []interface {
+		LabelAnnotatableFrom
+		Ownable
+	}{d.Deployment, d.Service, d.PrivateService} {
+		laf.LabelAnnotateFrom(ctx, wtd.WebhookTrigger.Object.ObjectMeta)
+
+		// Owned by the owner ConfigMap so we only have to worry about deleting
+		// one thing.
+		if err := wtd.OwnerConfigMap.Own(ctx, laf); err != nil {
+			return err
+		}
+	}
+
+	// Changes to the deployment propagate back to the webhook trigger using
+	// our event handler.
+	SetDependencyOf(&d.Deployment.Object.ObjectMeta, owner)
+
+	// The containers need to know which port to listen on.
+	for i := range template.Spec.Containers {
+		c := &template.Spec.Containers[i]
+		c.Env = append(c.Env, corev1.EnvVar{
+			Name:  "PORT",
+			Value: fmt.Sprintf("%d", WebhookTriggerContainerPort),
+		})
+		c.Ports = []corev1.ContainerPort{
+			{
+				Name:          "http",
+				ContainerPort: WebhookTriggerContainerPort,
+				Protocol:      corev1.ProtocolTCP,
+			},
+		}
+	}
+
+	if activatorHost != "" {
+		d.Deployment.Label(ctx, WebhookTriggerActivatorLabel, "true")
+
+		// The activator owns the replica count once the deployment exists.
+		if d.Deployment.Object.GetUID() == "" || d.Deployment.Object.Spec.Replicas == nil {
+			replicas = 0
+		} else {
+			replicas = *d.Deployment.Object.Spec.Replicas
+		}
+	} else {
+		delete(d.Deployment.Object.Labels, WebhookTriggerActivatorLabel)
+	}
+
+	d.Deployment.Object.Spec = appsv1.DeploymentSpec{
+		Replicas: &replicas,
+		Selector: &metav1.LabelSelector{
+			MatchLabels: template.GetLabels(),
+		},
+		Template: template,
+	}
+
+	selectorSpec := corev1.ServiceSpec{
+		Type:     corev1.ServiceTypeClusterIP,
+		Selector: template.GetLabels(),
+		Ports: []corev1.ServicePort{
+			{
+				Name:       "http",
+				Port:       80,
+				TargetPort: intstr.FromString("http"),
+				Protocol:   corev1.ProtocolTCP,
+			},
+		},
+	}
+
+	if activatorHost != "" {
+		configureWebhookTriggerService(d.Service, corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: activatorHost,
+			Ports: []corev1.ServicePort{
+				{
+					Name:     "http",
+					Port:     80,
+					Protocol: corev1.ProtocolTCP,
+				},
+			},
+		})
+		configureWebhookTriggerService(d.PrivateService, selectorSpec)
+	} else {
+		configureWebhookTriggerService(d.Service, selectorSpec)
+	}
+
+	return nil
+}
+
+func configureWebhookTriggerService(s *Service, spec corev1.ServiceSpec) {
+	// The cluster IP of a service is immutable, so we keep it as long as the
+	// service type does not change. Kubernetes allocates a new one when
+	// switching back from an external name.
+	if s.Object.Spec.Type == spec.Type {
+		spec.ClusterIP = s.Object.Spec.ClusterIP
+	}
+
+	s.Object.Spec = spec
+}
+
+func persistWebhookTriggerDeployment(ctx context.Context, cl client.Client, d *WebhookTriggerDeployment, activated bool) error {
+	for _, s := range []*Service{d.Service, d.PrivateService} {
+		if s == d.PrivateService && !activated {
+			if s.Object.GetUID() != "" {
+				if _, err := DeleteIgnoreNotFound(ctx, cl, s.Object); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if err := s.Persist(ctx, cl); err != nil {
+			return err
+		}
+	}
+
+	return d.Deployment.Persist(ctx, cl)
+}
+
+// KubernetesWebhookTriggerBackend serves webhook triggers using a Deployment
+// and a Service. It does not require any components beyond Kubernetes itself.
+type KubernetesWebhookTriggerBackend struct {
+	// ActivatorHost is the host name of the service in front of the
+	// activator. If it is set, webhook triggers with a minimum scale of zero
+	// are scaled down when idle and scaled up again by the activator on
+	// request. Otherwise, every webhook trigger runs at least one replica.
+	ActivatorHost string
+}
+
+var _ WebhookTriggerBackend = &KubernetesWebhookTriggerBackend{}
+
+func (b *KubernetesWebhookTriggerBackend) ApplyService(ctx context.Context, cl client.Client, wtd *WebhookTriggerDeps) (WebhookTriggerService, error) {
+	d := NewWebhookTriggerDeployment(WebhookTriggerServiceKey(wtd))
+
+	if _, err := d.Load(ctx, cl); err != nil {
+		return nil, err
+	}
+
+	var template corev1.PodTemplateSpec
+	if err := ConfigureWebhookTriggerPodTemplate(ctx, &template, d.Deployment.Object.Spec.Template.ObjectMeta, wtd); err != nil {
+		return nil, err
+	}
+
+	var replicas int32 = 1
+	var activatorHost string
+
+	if scaling := wtd.WebhookTrigger.Object.Spec.Scaling; scaling != nil && scaling.MinScale != nil {
+		if *scaling.MinScale > 0 {
+			replicas = *scaling.MinScale
+		} else {
+			activatorHost = b.ActivatorHost
+		}
+	}
+
+	if err := ConfigureWebhookTriggerDeployment(ctx, d, wtd, template, replicas, activatorHost); err != nil {
+		return nil, err
+	}
+
+	if err := persistWebhookTriggerDeployment(ctx, cl, d, activatorHost != ""); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (b *KubernetesWebhookTriggerBackend) ApplyVerifierService(ctx context.Context, cl client.Client, wtd *WebhookTriggerDeps, upstream WebhookTriggerService, image string) (WebhookTriggerService, error) {
+	if upstream == nil || upstream.URL() == "" {
+		return nil, nil
+	}
+
+	d := NewWebhookTriggerDeployment(WebhookVerifierKey(WebhookTriggerServiceKey(wtd)))
+
+	if _, err := d.Load(ctx, cl); err != nil {
+		return nil, err
+	}
+
+	// The verifier only forwards requests, so it is never scaled to zero. Each
+	// replica enforces an equal share of the rate limit, if it is configured.
+	var replicas int32 = 1
+	if scaling := wtd.WebhookTrigger.Object.Spec.Scaling; scaling != nil && scaling.MinScale != nil && *scaling.MinScale > 0 {
+		replicas = *scaling.MinScale
+	}
+
+	if wtd.WebhookTrigger.RateLimited() {
+		replicas = webhookVerifierMaxInstances(wtd.WebhookTrigger, replicas)
+	}
+
+	var template corev1.PodTemplateSpec
+	if err := ConfigureWebhookVerifierPodTemplate(ctx, &template, d.Deployment.Object.Spec.Template.ObjectMeta, wtd, upstream.URL(), image, replicas); err != nil {
+		return nil, err
+	}
+
+	if err := ConfigureWebhookTriggerDeployment(ctx, d, wtd, template, replicas, ""); err != nil {
+		return nil, err
+	}
+
+	if err := persistWebhookTriggerDeployment(ctx, cl, d, false); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (b *KubernetesWebhookTriggerBackend) DeleteService(ctx context.Context, cl client.Client, key client.ObjectKey) error {
+	d := NewWebhookTriggerDeployment(key)
+
+	if _, err := d.Load(ctx, cl); err != nil {
+		return err
+	}
+
+	return d.Delete(ctx, cl)
+}
+
+// NewWebhookTriggerBackend returns the backend with the given name.
+func NewWebhookTriggerBackend(name relayv1beta1.TriggerBackend, activatorHost string) (WebhookTriggerBackend, error) {
+	switch name {
+	case "", relayv1beta1.TriggerBackendKnative:
+		return &KnativeWebhookTriggerBackend{}, nil
+	case relayv1beta1.TriggerBackendKubernetes:
+		return &KubernetesWebhookTriggerBackend{ActivatorHost: activatorHost}, nil
+	default:
+		return nil, fmt.Errorf("unknown webhook trigger backend %q", name)
+	}
+}
@@ -0,0 +1,83 @@
+package obj_test
+
+import (
+	"context"
+	"testing"
+
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/puppetlabs/relay-core/pkg/obj"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestWebhookTriggerDeployment(t *testing.T) {
+	ctx := context.Background()
+
+	key := types.NamespacedName{Namespace: "tenant", Name: "my-trigger"}
+	activatorHost := "activator.relay-system.svc.cluster.local"
+
+	wt := obj.NewWebhookTrigger(types.NamespacedName{Namespace: "default", Name: "my-trigger"})
+	wt.Object.ObjectMeta = metav1.ObjectMeta{Namespace: "default", Name: "my-trigger", UID: types.UID("wt")}
+
+	wtd := &obj.WebhookTriggerDeps{
+		WebhookTrigger: wt,
+		OwnerConfigMap: obj.NewConfigMap(obj.SuffixObjectKey(key, "owner")),
+	}
+	wtd.OwnerConfigMap.Object.ObjectMeta = metav1.ObjectMeta{Namespace: "tenant", Name: "my-trigger-owner", UID: types.UID("owner")}
+
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				model.RelayControllerWebhookTriggerIDLabel: "my-trigger",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "my-trigger"}},
+		},
+	}
+
+	newDeployment := func() *obj.WebhookTriggerDeployment {
+		d := obj.NewWebhookTriggerDeployment(key)
+		for _, om := range []*metav1.ObjectMeta{
+			&d.Deployment.Object.ObjectMeta,
+			&d.Service.Object.ObjectMeta,
+			&d.PrivateService.Object.ObjectMeta,
+		} {
+			om.Namespace = key.Namespace
+		}
+		return d
+	}
+
+	// Without the activator, the configured number of replicas is used and
+	// the service selects the pods directly.
+	d := newDeployment()
+	require.NoError(t, obj.ConfigureWebhookTriggerDeployment(ctx, d, wtd, template, 3, ""))
+	assert.Equal(t, int32(3), *d.Deployment.Object.Spec.Replicas)
+	assert.False(t, d.Activated())
+	assert.Equal(t, corev1.ServiceTypeClusterIP, d.Service.Object.Spec.Type)
+	assert.Equal(t, template.GetLabels(), d.Service.Object.Spec.Selector)
+	assert.Contains(t, d.Deployment.Object.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "PORT", Value: "8080"})
+	assert.Equal(t, &relayv1beta1.WebhookTriggerScalingStatus{MinScale: 3, MaxScale: 3}, d.ScalingStatus())
+
+	// With the activator, a new deployment starts scaled to zero and the
+	// public service resolves to the activator.
+	d = newDeployment()
+	require.NoError(t, obj.ConfigureWebhookTriggerDeployment(ctx, d, wtd, template, 1, activatorHost))
+	assert.Equal(t, int32(0), *d.Deployment.Object.Spec.Replicas)
+	assert.True(t, d.Activated())
+	assert.Equal(t, corev1.ServiceTypeExternalName, d.Service.Object.Spec.Type)
+	assert.Equal(t, activatorHost, d.Service.Object.Spec.ExternalName)
+	assert.Equal(t, template.GetLabels(), d.PrivateService.Object.Spec.Selector)
+	assert.Equal(t, &relayv1beta1.WebhookTriggerScalingStatus{MinScale: 0, MaxScale: 1}, d.ScalingStatus())
+
+	// Once the activator has scaled the deployment up, reconfiguring it must
+	// not scale it back down.
+	d.Deployment.Object.SetUID(types.UID("deployment"))
+	d.Deployment.Object.Spec.Replicas = func(i int32) *int32 { return &i }(1)
+	require.NoError(t, obj.ConfigureWebhookTriggerDeployment(ctx, d, wtd, template, 1, activatorHost))
+	assert.Equal(t, int32(1), *d.Deployment.Object.Spec.Replicas)
+}
@@ -3,18 +3,20 @@ package obj
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"math"
 	"net/url"
 	"path"
+	"strconv"
 	"time"
 
-	"github.com/puppetlabs/horsehead/v2/jsonutil"
 	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
 	"github.com/puppetlabs/relay-core/pkg/authenticate"
 	"github.com/puppetlabs/relay-core/pkg/model"
 	"github.com/puppetlabs/relay-core/pkg/util/hashutil"
 	"gopkg.in/square/go-jose.v2/jwt"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -50,12 +52,37 @@ type WebhookTriggerDeps struct {
 	ImmutableConfigMap *ConfigMap
 	MutableConfigMap   *ConfigMap
 
+	// WebhookDeliveriesConfigMap counts the requests received by the webhook
+	// trigger and the events it emitted. It is updated by the metadata API.
+	WebhookDeliveriesConfigMap *ConfigMap
+
+	// WebhookRequestsConfigMap holds the most recent requests received by the
+	// webhook trigger if capture is enabled.
+	WebhookRequestsConfigMap *ConfigMap
+
 	MetadataAPIURL            *url.URL
 	MetadataAPIServiceAccount *ServiceAccount
 	MetadataAPIRole           *Role
 	MetadataAPIRoleBinding    *RoleBinding
 
 	KnativeServiceAccount *ServiceAccount
+
+	// VerificationSecret is the secret referenced by the verification
+	// configuration of the webhook trigger, if any.
+	VerificationSecret *OpaqueSecret
+
+	// VerifierSecret is a copy of the verification secret in the tenant
+	// namespace for use by the webhook verifier.
+	VerifierSecret        *OpaqueSecret
+	VerifierNetworkPolicy *NetworkPolicy
+
+	// TLSSecret is the secret referenced by the route TLS configuration of
+	// the webhook trigger, if any.
+	TLSSecret *TLSSecret
+
+	// RouteTLSSecret is a copy of the TLS secret in the tenant namespace for
+	// use by the route.
+	RouteTLSSecret *TLSSecret
 }
 
 var _ Persister = &WebhookTriggerDeps{}
@@ -74,6 +101,7 @@ func (wtd *WebhookTriggerDeps) Persist(ctx context.Context, cl client.Client) er
 	os := []Ownable{
 		wtd.NetworkPolicy,
 		wtd.ImmutableConfigMap,
+		wtd.WebhookDeliveriesConfigMap,
 		wtd.MetadataAPIServiceAccount,
 		wtd.MetadataAPIRole,
 		wtd.MetadataAPIRoleBinding,
@@ -89,6 +117,7 @@ func (wtd *WebhookTriggerDeps) Persist(ctx context.Context, cl client.Client) er
 		wtd.NetworkPolicy,
 		wtd.ImmutableConfigMap,
 		wtd.MutableConfigMap,
+		wtd.WebhookDeliveriesConfigMap,
 		wtd.MetadataAPIServiceAccount,
 		wtd.MetadataAPIRole,
 		wtd.MetadataAPIRoleBinding,
@@ -100,18 +129,99 @@ func (wtd *WebhookTriggerDeps) Persist(ctx context.Context, cl client.Client) er
 		}
 	}
 
+	if wtd.RouteTLS() == nil {
+		if err := deleteIfLoaded(ctx, cl, wtd.RouteTLSSecret, wtd.RouteTLSSecret.Object); err != nil {
+			return err
+		}
+	} else if wtd.routeTLSSecretAvailable() {
+		if err := wtd.OwnerConfigMap.Own(ctx, wtd.RouteTLSSecret); err != nil {
+			return err
+		}
+
+		if err := wtd.RouteTLSSecret.Persist(ctx, cl); err != nil {
+			return err
+		}
+	}
+
+	if wtd.WebhookTrigger.Object.Spec.Capture == nil {
+		if err := deleteIfLoaded(ctx, cl, wtd.WebhookRequestsConfigMap, wtd.WebhookRequestsConfigMap.Object); err != nil {
+			return err
+		}
+	} else {
+		if err := wtd.OwnerConfigMap.Own(ctx, wtd.WebhookRequestsConfigMap); err != nil {
+			return err
+		}
+
+		if err := wtd.WebhookRequestsConfigMap.Persist(ctx, cl); err != nil {
+			return err
+		}
+	}
+
+	if wtd.WebhookTrigger.Object.Spec.Verification == nil {
+		if err := deleteIfLoaded(ctx, cl, wtd.VerifierSecret, wtd.VerifierSecret.Object); err != nil {
+			return err
+		}
+	} else {
+		if err := wtd.OwnerConfigMap.Own(ctx, wtd.VerifierSecret); err != nil {
+			return err
+		}
+
+		if err := wtd.VerifierSecret.Persist(ctx, cl); err != nil {
+			return err
+		}
+	}
+
+	// The verifier workload itself is removed by the webhook trigger backend.
+	if !wtd.WebhookTrigger.HasVerifier() {
+		return deleteIfLoaded(ctx, cl, wtd.VerifierNetworkPolicy, wtd.VerifierNetworkPolicy.Object)
+	}
+
+	if err := wtd.OwnerConfigMap.Own(ctx, wtd.VerifierNetworkPolicy); err != nil {
+		return err
+	}
+
+	return wtd.VerifierNetworkPolicy.Persist(ctx, cl)
+}
+
+// deleteIfLoaded removes an optional dependency of the webhook trigger once it
+// is no longer configured.
+func deleteIfLoaded(ctx context.Context, cl client.Client, l Loader, obj runtime.Object) error {
+	if ok, err := l.Load(ctx, cl); err != nil || !ok {
+		return err
+	}
+
+	_, err := DeleteIgnoreNotFound(ctx, cl, obj)
+	return err
+}
+
+// RouteTLS returns the TLS configuration of the route of the webhook trigger,
+// if any.
+func (wtd *WebhookTriggerDeps) RouteTLS() *relayv1beta1.WebhookTriggerRouteTLS {
+	if route := wtd.WebhookTrigger.Object.Spec.Route; route != nil {
+		return route.TLS
+	}
+
 	return nil
 }
 
+// routeTLSSecretAvailable returns true if the route of the trigger requests TLS
+// and the secret it references exists. If the secret does not exist, the
+// route reports the error.
+func (wtd *WebhookTriggerDeps) routeTLSSecretAvailable() bool {
+	return wtd.RouteTLS() != nil && wtd.TLSSecret != nil && wtd.TLSSecret.Object.GetUID() != ""
+}
+
 func (wtd *WebhookTriggerDeps) Load(ctx context.Context, cl client.Client) (*WebhookTriggerDepsLoadResult, error) {
 	// Load tenant and tenant dependencies first so that we can resolve
 	// everything else.
 	if ok, err := wtd.Tenant.Load(ctx, cl); err != nil {
 		return nil, err
-	} else if !ok {
-		// In this case, our tenant may have been deleted so we check to see if
-		// this trigger already has resources created. If so, we add the stale
-		// config map at the current version.
+	} else if !ok || (wtd.Tenant.Finalizing() && wtd.Tenant.DeletionPolicy() != relayv1beta1.TenantDeletionPolicyOrphan) {
+		// In this case, our tenant may have been deleted (or is being deleted
+		// and will not leave its namespace in place, in which case we must
+		// stop accepting events) so we check to see if this trigger already
+		// has resources created. If so, we add the stale config map at the
+		// current version.
 		if wtd.WebhookTrigger.Object.Status.Namespace != "" {
 			wtd.StaleOwnerConfigMap = NewConfigMap(SuffixObjectKey(client.ObjectKey{
 				Namespace: wtd.WebhookTrigger.Object.Status.Namespace,
@@ -152,6 +262,8 @@ func (wtd *WebhookTriggerDeps) Load(ctx context.Context, cl client.Client) (*Web
 
 	wtd.ImmutableConfigMap = NewConfigMap(SuffixObjectKey(key, "immutable"))
 	wtd.MutableConfigMap = NewConfigMap(SuffixObjectKey(key, "mutable"))
+	wtd.WebhookDeliveriesConfigMap = NewConfigMap(SuffixObjectKey(key, "deliveries"))
+	wtd.WebhookRequestsConfigMap = NewConfigMap(SuffixObjectKey(key, "requests"))
 
 	wtd.MetadataAPIServiceAccount = NewServiceAccount(SuffixObjectKey(key, "metadata-api"))
 	wtd.MetadataAPIRole = NewRole(SuffixObjectKey(key, "metadata-api"))
@@ -159,17 +271,55 @@ func (wtd *WebhookTriggerDeps) Load(ctx context.Context, cl client.Client) (*Web
 
 	wtd.KnativeServiceAccount = NewServiceAccount(SuffixObjectKey(key, "knative"))
 
-	ok, err := Loaders{
+	if v := wtd.WebhookTrigger.Object.Spec.Verification; v != nil {
+		wtd.VerificationSecret = NewOpaqueSecret(client.ObjectKey{
+			Namespace: wtd.WebhookTrigger.Key.Namespace,
+			Name:      v.SecretKeyRef.Name,
+		})
+	}
+
+	wtd.VerifierSecret = NewOpaqueSecret(WebhookVerifierKey(key))
+	wtd.VerifierNetworkPolicy = NewNetworkPolicy(WebhookVerifierKey(key))
+
+	if tls := wtd.RouteTLS(); tls != nil {
+		wtd.TLSSecret = NewTLSSecret(client.ObjectKey{
+			Namespace: wtd.WebhookTrigger.Key.Namespace,
+			Name:      tls.SecretName,
+		})
+	}
+
+	wtd.RouteTLSSecret = NewTLSSecret(SuffixObjectKey(key, "tls"))
+
+	loaders := Loaders{
 		IgnoreNilLoader{wtd.StaleOwnerConfigMap},
 		wtd.OwnerConfigMap,
 		wtd.NetworkPolicy,
 		wtd.ImmutableConfigMap,
 		wtd.MutableConfigMap,
+		wtd.WebhookDeliveriesConfigMap,
 		wtd.MetadataAPIServiceAccount,
 		wtd.MetadataAPIRole,
 		wtd.MetadataAPIRoleBinding,
 		wtd.KnativeServiceAccount,
-	}.Load(ctx, cl)
+	}
+
+	if wtd.VerificationSecret != nil {
+		loaders = append(loaders, wtd.VerificationSecret, wtd.VerifierSecret)
+	}
+
+	if wtd.WebhookTrigger.HasVerifier() {
+		loaders = append(loaders, wtd.VerifierNetworkPolicy)
+	}
+
+	if wtd.WebhookTrigger.Object.Spec.Capture != nil {
+		loaders = append(loaders, wtd.WebhookRequestsConfigMap)
+	}
+
+	if wtd.TLSSecret != nil {
+		loaders = append(loaders, wtd.TLSSecret, wtd.RouteTLSSecret)
+	}
+
+	ok, err := loaders.Load(ctx, cl)
 	if err != nil {
 		return nil, err
 	}
@@ -212,9 +362,9 @@ func (wtd *WebhookTriggerDeps) DeleteStale(ctx context.Context, cl client.Client
 	return true, nil
 }
 
-func (wtd *WebhookTriggerDeps) AnnotateTriggerToken(ctx context.Context, target *metav1.ObjectMeta) error {
-	idh := hashutil.NewStructuredHash(sha256.New)
-
+// newTriggerClaims returns the claims common to every token issued for the
+// webhook trigger, recording them in the given hash.
+func (wtd *WebhookTriggerDeps) newTriggerClaims(idh *hashutil.StructuredHash) (*authenticate.Claims, error) {
 	mt := ModelWebhookTrigger(wtd.WebhookTrigger)
 	now := time.Now()
 
@@ -237,7 +387,7 @@ func (wtd *WebhookTriggerDeps) AnnotateTriggerToken(ctx context.Context, target
 
 	sat, err := wtd.MetadataAPIServiceAccount.DefaultTokenSecret.Token()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	annotations := wtd.WebhookTrigger.Object.GetAnnotations()
@@ -251,36 +401,123 @@ func (wtd *WebhookTriggerDeps) AnnotateTriggerToken(ctx context.Context, target
 	claims.RelayName = mt.Name
 	idh.Set("parents", claims.RelayDomainID, claims.RelayTenantID)
 
+	return claims, nil
+}
+
+// annotateToken issues a token for the given claims and stores it in the
+// target metadata if the hash of the claims differs from the one recorded
+// when the existing token was issued.
+func (wtd *WebhookTriggerDeps) annotateToken(ctx context.Context, target *metav1.ObjectMeta, idh *hashutil.StructuredHash, claims *authenticate.Claims) error {
+	h, err := idh.Sum()
+	if err != nil {
+		return err
+	}
+
+	enc := h.HexEncoding()
+	if enc == target.GetAnnotations()[model.RelayControllerTokenHashAnnotation] {
+		return nil
+	}
+
+	tok, err := wtd.Issuer.Issue(ctx, claims)
+	if err != nil {
+		return err
+	}
+
+	Annotate(target, model.RelayControllerTokenHashAnnotation, enc)
+	Annotate(target, authenticate.KubernetesTokenAnnotation, string(tok))
+	Annotate(target, authenticate.KubernetesSubjectAnnotation, claims.Subject)
+
+	return nil
+}
+
+func (wtd *WebhookTriggerDeps) AnnotateTriggerToken(ctx context.Context, target *metav1.ObjectMeta) error {
+	idh := hashutil.NewStructuredHash(sha256.New)
+
+	claims, err := wtd.newTriggerClaims(idh)
+	if err != nil {
+		return err
+	}
+
+	annotations := wtd.WebhookTrigger.Object.GetAnnotations()
+
 	claims.RelayKubernetesImmutableConfigMapName = wtd.ImmutableConfigMap.Key.Name
 	claims.RelayKubernetesMutableConfigMapName = wtd.MutableConfigMap.Key.Name
 
-	claims.RelayVaultEnginePath = annotations[model.RelayVaultEngineMountAnnotation]
-	claims.RelayVaultSecretPath = annotations[model.RelayVaultSecretPathAnnotation]
-	claims.RelayVaultConnectionPath = annotations[model.RelayVaultConnectionPathAnnotation]
-	idh.Set("vault", claims.RelayVaultEnginePath, claims.RelayVaultSecretPath, claims.RelayVaultConnectionPath)
+	claims.RelayKubernetesWebhookDeliveriesConfigMapName = wtd.WebhookDeliveriesConfigMap.Key.Name
+	idh.Set("webhook-deliveries", claims.RelayKubernetesWebhookDeliveriesConfigMapName)
 
-	if sink := wtd.TenantDeps.APITriggerEventSink; sink != nil {
-		if u, _ := url.Parse(sink.URL()); u != nil {
-			claims.RelayEventAPIURL = &jsonutil.URL{URL: u}
-			claims.RelayEventAPIToken, _ = sink.Token()
-			idh.Set("event", claims.RelayEventAPIURL.String(), claims.RelayEventAPIToken)
-		}
+	// Without a verifier in front of it, only the trigger knows about the
+	// requests it receives.
+	if !wtd.WebhookTrigger.HasVerifier() {
+		claims.RelayWebhookDirect = true
+		idh.Set("webhook-direct")
 	}
 
-	if h, err := idh.Sum(); err != nil {
-		return err
-	} else if enc := h.HexEncoding(); enc != target.GetAnnotations()[model.RelayControllerTokenHashAnnotation] {
-		tok, err := wtd.Issuer.Issue(ctx, claims)
+	if l := wtd.WebhookTrigger.Object.Spec.Limits; l != nil {
+		claims.RelayLimitsRequestsPerSecond = l.RequestsPerSecond
+		claims.RelayLimitsBurst = l.Burst
+		claims.RelayLimitsMaxBodyBytes = l.MaxBodyBytes
+		idh.Set("limits",
+			strconv.FormatInt(int64(l.RequestsPerSecond), 10),
+			strconv.FormatInt(int64(l.Burst), 10),
+			strconv.FormatInt(l.MaxBodyBytes, 10),
+		)
+	}
+
+	if capture := wtd.WebhookTrigger.Object.Spec.Capture; capture != nil {
+		claims.RelayKubernetesWebhookRequestsConfigMapName = wtd.WebhookRequestsConfigMap.Key.Name
+		claims.RelayWebhookRequestsLimit = int(capture.Requests)
+		idh.Set("webhook-requests", claims.RelayKubernetesWebhookRequestsConfigMapName, strconv.Itoa(claims.RelayWebhookRequestsLimit))
+	}
+
+	ConfigureVaultClaims(claims, wtd.Tenant, annotations)
+	idh.Set("vault", claims.RelayVaultEnginePath, claims.RelayVaultSecretPath, claims.RelayVaultConnectionPath)
+	if claims.RelayVaultAuthRole != "" {
+		idh.Set("vault-auth-role", claims.RelayVaultAuthRole)
+	}
+
+	ConfigureEventClaims(claims, wtd.TenantDeps.APITriggerEventSink, wtd.TenantDeps.DirectTriggerEventSink, wtd.TenantDeps.NamedTriggerEventSinks)
+	if claims.RelayEventDirect {
+		idh.Set("event-direct")
+	} else if claims.RelayEventAPIURL != nil {
+		idh.Set("event", claims.RelayEventAPIURL.String(), claims.RelayEventAPIToken)
+	}
+	if len(claims.RelayEventSinks) > 0 {
+		b, err := json.Marshal(claims.RelayEventSinks)
 		if err != nil {
 			return err
 		}
 
-		Annotate(target, model.RelayControllerTokenHashAnnotation, enc)
-		Annotate(target, authenticate.KubernetesTokenAnnotation, string(tok))
-		Annotate(target, authenticate.KubernetesSubjectAnnotation, claims.Subject)
+		idh.Set("event-sinks", string(b))
 	}
 
-	return nil
+	return wtd.annotateToken(ctx, target, idh, claims)
+}
+
+// AnnotateWebhookVerifierToken issues the token used by the webhook verifier
+// to report requests to the metadata API. The token is distinct from the one
+// issued to the trigger so that the trigger cannot report requests itself.
+func (wtd *WebhookTriggerDeps) AnnotateWebhookVerifierToken(ctx context.Context, target *metav1.ObjectMeta) error {
+	idh := hashutil.NewStructuredHash(sha256.New)
+
+	claims, err := wtd.newTriggerClaims(idh)
+	if err != nil {
+		return err
+	}
+
+	claims.RelayWebhookVerifier = true
+	idh.Set("webhook-verifier")
+
+	claims.RelayKubernetesWebhookDeliveriesConfigMapName = wtd.WebhookDeliveriesConfigMap.Key.Name
+	idh.Set("webhook-deliveries", claims.RelayKubernetesWebhookDeliveriesConfigMapName)
+
+	if capture := wtd.WebhookTrigger.Object.Spec.Capture; capture != nil {
+		claims.RelayKubernetesWebhookRequestsConfigMapName = wtd.WebhookRequestsConfigMap.Key.Name
+		claims.RelayWebhookRequestsLimit = int(capture.Requests)
+		idh.Set("webhook-requests", claims.RelayKubernetesWebhookRequestsConfigMapName, strconv.Itoa(claims.RelayWebhookRequestsLimit))
+	}
+
+	return wtd.annotateToken(ctx, target, idh, claims)
 }
 
 func NewWebhookTriggerDeps(wt *WebhookTrigger, issuer authenticate.Issuer, metadataAPIURL *url.URL) *WebhookTriggerDeps {
@@ -303,6 +540,7 @@ func ConfigureWebhookTriggerDeps(ctx context.Context, wtd *WebhookTriggerDeps) e
 	lafs := []LabelAnnotatableFrom{
 		wtd.ImmutableConfigMap,
 		wtd.MutableConfigMap,
+		wtd.WebhookDeliveriesConfigMap,
 		wtd.MetadataAPIServiceAccount,
 		wtd.MetadataAPIRole,
 		wtd.KnativeServiceAccount,
@@ -311,18 +549,52 @@ func ConfigureWebhookTriggerDeps(ctx context.Context, wtd *WebhookTriggerDeps) e
 		laf.LabelAnnotateFrom(ctx, wtd.WebhookTrigger.Object.ObjectMeta)
 	}
 
+	// Changes to the delivery statistics are reflected in the status of the
+	// webhook trigger.
+	SetDependencyOf(&wtd.WebhookDeliveriesConfigMap.Object.ObjectMeta, Owner{Object: wtd.WebhookTrigger.Object, GVK: relayv1beta1.WebhookTriggerKind})
+
 	ConfigureNetworkPolicyForWebhookTrigger(wtd.NetworkPolicy, wtd.WebhookTrigger)
 
-	if err := ConfigureImmutableConfigMapForWebhookTrigger(ctx, wtd.ImmutableConfigMap, wtd.WebhookTrigger); err != nil {
+	if err := ConfigureImmutableConfigMapForWebhookTrigger(ctx, wtd.ImmutableConfigMap, wtd.WebhookTrigger, wtd.Tenant); err != nil {
+		return err
+	}
+
+	if err := ConfigureImmutableConfigMapForDirectTriggerEventSink(ctx, wtd.ImmutableConfigMap, wtd.WebhookTrigger, wtd.TenantDeps); err != nil {
 		return err
 	}
 
 	ConfigureMetadataAPIServiceAccount(wtd.MetadataAPIServiceAccount)
-	ConfigureMetadataAPIRole(wtd.MetadataAPIRole, wtd.ImmutableConfigMap, wtd.MutableConfigMap)
+	mutableConfigMaps := []*ConfigMap{wtd.MutableConfigMap, wtd.WebhookDeliveriesConfigMap}
+	if wtd.WebhookTrigger.Object.Spec.Capture != nil {
+		wtd.WebhookRequestsConfigMap.LabelAnnotateFrom(ctx, wtd.WebhookTrigger.Object.ObjectMeta)
+		mutableConfigMaps = append(mutableConfigMaps, wtd.WebhookRequestsConfigMap)
+	}
+
+	ConfigureMetadataAPIRole(wtd.MetadataAPIRole, wtd.ImmutableConfigMap, mutableConfigMaps...)
+	if hasDirectTriggerEventSink(wtd.TenantDeps.DirectTriggerEventSink, wtd.TenantDeps.NamedTriggerEventSinks) {
+		ConfigureMetadataAPIRoleForWorkflowRuns(wtd.MetadataAPIRole)
+	}
 	ConfigureMetadataAPIRoleBinding(wtd.MetadataAPIRoleBinding, wtd.MetadataAPIServiceAccount, wtd.MetadataAPIRole)
 
 	ConfigureUntrustedServiceAccount(wtd.KnativeServiceAccount)
 
+	if wtd.routeTLSSecretAvailable() {
+		wtd.RouteTLSSecret.LabelAnnotateFrom(ctx, wtd.WebhookTrigger.Object.ObjectMeta)
+		ConfigureTLSSecret(wtd.RouteTLSSecret, wtd.TLSSecret)
+	}
+
+	if wtd.WebhookTrigger.Object.Spec.Verification != nil {
+		wtd.VerifierSecret.LabelAnnotateFrom(ctx, wtd.WebhookTrigger.Object.ObjectMeta)
+
+		if err := ConfigureWebhookVerifierSecret(wtd.VerifierSecret, wtd.VerificationSecret, wtd.WebhookTrigger.Object.Spec.Verification.SecretKeyRef.Key); err != nil {
+			return err
+		}
+	}
+
+	if wtd.WebhookTrigger.HasVerifier() {
+		ConfigureNetworkPolicyForWebhookVerifier(wtd.VerifierNetworkPolicy, wtd.WebhookTrigger)
+	}
+
 	return nil
 }
 
@@ -85,5 +85,27 @@ func TestWebhookTriggerDepsConfigureAnnotate(t *testing.T) {
 		tok3 := md.GetAnnotations()[authenticate.KubernetesTokenAnnotation]
 		require.NotEmpty(t, tok3)
 		require.NotEqual(t, tok1, tok3)
+
+		// The webhook verifier gets a token of its own.
+		var vmd metav1.ObjectMeta
+		require.NoError(t, deps.AnnotateWebhookVerifierToken(ctx, &vmd))
+
+		vtok := vmd.GetAnnotations()[authenticate.KubernetesTokenAnnotation]
+		require.NotEmpty(t, vtok)
+		require.NotEqual(t, tok3, vtok)
+
+		// Put the trigger behind the verifier; reissue, as the trigger no
+		// longer reports its own requests.
+		trigger.Object.Spec.Capture = &relayv1beta1.WebhookTriggerCapture{}
+		require.NoError(t, trigger.Persist(ctx, cl))
+
+		deps, err = obj.ApplyWebhookTriggerDeps(ctx, cl, trigger, TestIssuer, TestMetadataAPIURL)
+		require.NoError(t, err)
+
+		require.NoError(t, deps.AnnotateTriggerToken(ctx, &md))
+
+		tok4 := md.GetAnnotations()[authenticate.KubernetesTokenAnnotation]
+		require.NotEmpty(t, tok4)
+		require.NotEqual(t, tok3, tok4)
 	})
 }
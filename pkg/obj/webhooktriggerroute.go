@@ -0,0 +1,340 @@
+package obj
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	WebhookTriggerRouterAmbassador = "Ambassador"
+	WebhookTriggerRouterIngress    = "Ingress"
+	WebhookTriggerRouterGateway    = "Gateway"
+)
+
+// WebhookTriggerRoute exposes a webhook trigger outside of the cluster.
+type WebhookTriggerRoute interface {
+	// Ready returns true if the route accepts requests.
+	Ready() bool
+
+	// URL is the address at which the webhook trigger is reachable through
+	// this route.
+	URL() string
+}
+
+type WebhookTriggerRouteResult struct {
+	Route WebhookTriggerRoute
+	Error error
+}
+
+func AsWebhookTriggerRouteResult(r WebhookTriggerRoute, err error) *WebhookTriggerRouteResult {
+	return &WebhookTriggerRouteResult{
+		Route: r,
+		Error: err,
+	}
+}
+
+// WebhookTriggerRouter creates the routes that expose webhook triggers through
+// the webhook gateway.
+type WebhookTriggerRouter interface {
+	// ApplyRoute creates or updates the route to the given workload, which is
+	// the webhook verifier if verification is configured and the webhook
+	// trigger service otherwise. The route has the same key as the workload,
+	// but its address is always derived from the webhook trigger (see
+	// WebhookTriggerRouteAddress).
+	ApplyRoute(ctx context.Context, cl client.Client, wtd *WebhookTriggerDeps, target WebhookTriggerService) (WebhookTriggerRoute, error)
+
+	// DeleteRoute removes any route this router created for the workload
+	// with the given key.
+	DeleteRoute(ctx context.Context, cl client.Client, key client.ObjectKey) error
+}
+
+type WebhookTriggerRouterOptions struct {
+	// Domain is the DNS suffix of the host names assigned to webhook
+	// triggers. Each trigger is reachable at <name>.<namespace>.<domain>,
+	// where the namespace is the tenant namespace.
+	Domain string
+
+	// AmbassadorID selects the Ambassador instance that serves mappings.
+	AmbassadorID string
+
+	// IngressClassName is the class of ingresses to create, if any.
+	IngressClassName string
+
+	// Gateway is the Gateway API gateway to attach routes to.
+	Gateway client.ObjectKey
+}
+
+// NewWebhookTriggerRouter returns the router with the given name.
+func NewWebhookTriggerRouter(name string, opts WebhookTriggerRouterOptions) (WebhookTriggerRouter, error) {
+	switch name {
+	case "", WebhookTriggerRouterAmbassador:
+		if opts.AmbassadorID == "" {
+			opts.AmbassadorID = AmbassadorID
+		}
+
+		return &AmbassadorWebhookTriggerRouter{Domain: opts.Domain, AmbassadorID: opts.AmbassadorID}, nil
+	case WebhookTriggerRouterIngress:
+		if opts.Domain == "" {
+			return nil, fmt.Errorf("the %s webhook trigger router requires a domain", name)
+		}
+
+		return &IngressWebhookTriggerRouter{Domain: opts.Domain, IngressClassName: opts.IngressClassName}, nil
+	case WebhookTriggerRouterGateway:
+		if opts.Domain == "" {
+			return nil, fmt.Errorf("the %s webhook trigger router requires a domain", name)
+		} else if opts.Gateway.Name == "" {
+			return nil, fmt.Errorf("the %s webhook trigger router requires a gateway", name)
+		}
+
+		return &GatewayWebhookTriggerRouter{Domain: opts.Domain, Gateway: opts.Gateway}, nil
+	default:
+		return nil, fmt.Errorf("unknown webhook trigger router %q", name)
+	}
+}
+
+// WebhookTriggerRouteHost returns the host name assigned to the route with the
+// given key under the given domain.
+func WebhookTriggerRouteHost(key client.ObjectKey, domain string) string {
+	return fmt.Sprintf("%s.%s.%s", key.Name, key.Namespace, strings.TrimPrefix(domain, "."))
+}
+
+// WebhookTriggerRouteAddress returns the host name and path prefix the webhook
+// trigger is served at, taking its requested route into account. The
+// namespace is the tenant namespace of the trigger. If the trigger does not
+// request a host and no domain is configured, the returned host is empty.
+func WebhookTriggerRouteAddress(wt *relayv1beta1.WebhookTrigger, namespace, domain string) (host, path string) {
+	path = "/"
+
+	if route := wt.Spec.Route; route != nil {
+		host = route.Host
+
+		if p := strings.TrimSuffix(route.Path, "/"); p != "" {
+			path = p
+		}
+	}
+
+	if host == "" && namespace != "" && domain != "" {
+		host = WebhookTriggerRouteHost(client.ObjectKey{Namespace: namespace, Name: wt.GetName()}, domain)
+	}
+
+	return
+}
+
+// WebhookTriggerRouteConflictError is returned when the address requested by
+// a webhook trigger is already in use by another webhook trigger.
+type WebhookTriggerRouteConflictError struct {
+	Host  string
+	Path  string
+	Other client.ObjectKey
+}
+
+func (e *WebhookTriggerRouteConflictError) Error() string {
+	return fmt.Sprintf("the address %s%s is already in use by webhook trigger %s", e.Host, e.Path, e.Other)
+}
+
+// CheckWebhookTriggerRoute makes sure no other webhook trigger in the cluster
+// is served at the same address as the given one. When two webhook triggers
+// request the same address, the one created first keeps it.
+func CheckWebhookTriggerRoute(ctx context.Context, cl client.Client, wtd *WebhookTriggerDeps, domain string) error {
+	l := &relayv1beta1.WebhookTriggerList{}
+	if err := cl.List(ctx, l); err != nil {
+		return err
+	}
+
+	if conflict := FindWebhookTriggerRouteConflict(wtd.WebhookTrigger.Object, WebhookTriggerServiceKey(wtd).Namespace, domain, l.Items); conflict != nil {
+		return conflict
+	}
+
+	return nil
+}
+
+// FindWebhookTriggerRouteConflict returns the conflict, if any, between the
+// address of the given webhook trigger and the addresses of other webhook
+// triggers created before it.
+func FindWebhookTriggerRouteConflict(wt *relayv1beta1.WebhookTrigger, namespace, domain string, others []relayv1beta1.WebhookTrigger) *WebhookTriggerRouteConflictError {
+	host, path := WebhookTriggerRouteAddress(wt, namespace, domain)
+	if host == "" {
+		// Routes without a host name match only the name and namespace of
+		// the trigger, so they are unique.
+		return nil
+	}
+
+	for i := range others {
+		other := &others[i]
+		if other.GetNamespace() == wt.GetNamespace() && other.GetName() == wt.GetName() {
+			continue
+		} else if !other.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		otherHost, otherPath := WebhookTriggerRouteAddress(other, other.Status.Namespace, domain)
+		if otherHost != host || otherPath != path || !webhookTriggerCreatedBefore(other, wt) {
+			continue
+		}
+
+		return &WebhookTriggerRouteConflictError{
+			Host:  host,
+			Path:  path,
+			Other: client.ObjectKey{Namespace: other.GetNamespace(), Name: other.GetName()},
+		}
+	}
+
+	return nil
+}
+
+func webhookTriggerCreatedBefore(a, b *relayv1beta1.WebhookTrigger) bool {
+	at, bt := a.GetCreationTimestamp(), b.GetCreationTimestamp()
+	if !at.Equal(&bt) {
+		return at.Before(&bt)
+	}
+
+	// Fall back to a stable order so that exactly one of the triggers wins.
+	if a.GetNamespace() != b.GetNamespace() {
+		return a.GetNamespace() < b.GetNamespace()
+	}
+
+	return a.GetName() < b.GetName()
+}
+
+// webhookTriggerRouteTLSSecretName returns the name of the secret in the
+// tenant namespace that holds the certificate for the route to the webhook
+// trigger, or an empty string if the route does not use TLS.
+func webhookTriggerRouteTLSSecretName(wtd *WebhookTriggerDeps, host string) (string, error) {
+	tls := wtd.RouteTLS()
+	if tls == nil {
+		return "", nil
+	} else if host == "" {
+		return "", fmt.Errorf("TLS requires a host name for the route")
+	} else if !wtd.routeTLSSecretAvailable() {
+		return "", fmt.Errorf("TLS secret %q does not exist", tls.SecretName)
+	}
+
+	return wtd.RouteTLSSecret.Key.Name, nil
+}
+
+// webhookTriggerRouteTarget returns the key of the in-cluster service that
+// addresses the given workload and its fully-qualified host name.
+func webhookTriggerRouteTarget(target WebhookTriggerService) (client.ObjectKey, string, error) {
+	u, err := url.Parse(target.URL())
+	if err != nil {
+		return client.ObjectKey{}, "", err
+	}
+
+	parts := strings.SplitN(u.Hostname(), ".", 3)
+	if len(parts) < 3 {
+		return client.ObjectKey{}, "", fmt.Errorf("workload URL %q does not address a cluster service", target.URL())
+	}
+
+	return client.ObjectKey{Namespace: parts[1], Name: parts[0]}, u.Hostname(), nil
+}
+
+// UnstructuredRoute is a route object whose API is not part of the client
+// libraries we build against.
+type UnstructuredRoute struct {
+	Key    client.ObjectKey
+	Object *unstructured.Unstructured
+}
+
+var _ Persister = &UnstructuredRoute{}
+var _ Loader = &UnstructuredRoute{}
+var _ Ownable = &UnstructuredRoute{}
+
+func (ur *UnstructuredRoute) Persist(ctx context.Context, cl client.Client) error {
+	return CreateOrUpdate(ctx, cl, ur.Key, ur.Object)
+}
+
+func (ur *UnstructuredRoute) Load(ctx context.Context, cl client.Client) (bool, error) {
+	return GetIgnoreNotFound(ctx, cl, ur.Key, ur.Object)
+}
+
+func (ur *UnstructuredRoute) Owned(ctx context.Context, owner Owner) error {
+	return Own(ur.Object, owner)
+}
+
+// Delete removes the route if it exists. It is not an error for the API of
+// the route to be missing from the cluster.
+func (ur *UnstructuredRoute) Delete(ctx context.Context, cl client.Client) error {
+	if ok, err := ur.Load(ctx, cl); meta.IsNoMatchError(err) {
+		return nil
+	} else if err != nil || !ok {
+		return err
+	}
+
+	_, err := DeleteIgnoreNotFound(ctx, cl, ur.Object)
+	return err
+}
+
+func NewUnstructuredRoute(gvk schema.GroupVersionKind, key client.ObjectKey) *UnstructuredRoute {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+
+	return &UnstructuredRoute{
+		Key:    key,
+		Object: u,
+	}
+}
+
+// applyUnstructuredRoute loads the route, configures it using the given
+// function and persists it, setting the webhook trigger owner config map as
+// its owner.
+func applyUnstructuredRoute(ctx context.Context, cl client.Client, wtd *WebhookTriggerDeps, r *UnstructuredRoute, fn func(spec map[string]interface{}) error) error {
+	if _, err := r.Load(ctx, cl); err != nil {
+		return err
+	}
+
+	// Unstructured objects do not expose their metadata as a structure, so we
+	// copy the labels and annotations ourselves.
+	labels := r.Object.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	for name, value := range wtd.WebhookTrigger.Object.GetLabels() {
+		labels[name] = value
+	}
+	r.Object.SetLabels(labels)
+
+	annotations := r.Object.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	for name, value := range wtd.WebhookTrigger.Object.GetAnnotations() {
+		annotations[name] = value
+	}
+	r.Object.SetAnnotations(annotations)
+
+	if err := wtd.OwnerConfigMap.Own(ctx, r); err != nil {
+		return err
+	}
+
+	spec := make(map[string]interface{})
+	if err := fn(spec); err != nil {
+		return err
+	}
+
+	if err := unstructured.SetNestedField(r.Object.Object, spec, "spec"); err != nil {
+		return err
+	}
+
+	return r.Persist(ctx, cl)
+}
+
+func webhookTriggerRouteURL(scheme, host, path string) string {
+	if host == "" {
+		return ""
+	}
+
+	u := &url.URL{
+		Scheme: scheme,
+		Host:   host,
+		Path:   strings.TrimSuffix(path, "/"),
+	}
+	return u.String()
+}
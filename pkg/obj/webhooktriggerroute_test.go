@@ -0,0 +1,162 @@
+package obj_test
+
+import (
+	"testing"
+	"time"
+
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/obj"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestWebhookTriggerRouteURLs(t *testing.T) {
+	key := types.NamespacedName{Namespace: "tenant", Name: "my-trigger"}
+
+	am := obj.NewAmbassadorMapping(key)
+	require.NoError(t, unstructured.SetNestedMap(am.Object.Object, map[string]interface{}{
+		"prefix":       "/",
+		"host":         `^my-trigger\.tenant\..+$`,
+		"host_regex":   true,
+		"host_rewrite": "my-trigger.tenant.svc.cluster.local",
+	}, "spec"))
+	assert.Equal(t, "http://my-trigger.tenant.svc.cluster.local", am.URL())
+
+	require.NoError(t, unstructured.SetNestedMap(am.Object.Object, map[string]interface{}{
+		"prefix":       "/",
+		"host":         "my-trigger.tenant.example.com",
+		"host_rewrite": "my-trigger.tenant.svc.cluster.local",
+	}, "spec"))
+	assert.Equal(t, "http://my-trigger.tenant.example.com", am.URL())
+
+	i := obj.NewIngress(key)
+	require.NoError(t, unstructured.SetNestedSlice(i.Object.Object, []interface{}{
+		map[string]interface{}{
+			"host": "my-trigger.tenant.example.com",
+			"http": map[string]interface{}{
+				"paths": []interface{}{
+					map[string]interface{}{"path": "/hooks"},
+				},
+			},
+		},
+	}, "spec", "rules"))
+	assert.Equal(t, "http://my-trigger.tenant.example.com/hooks", i.URL())
+
+	require.NoError(t, unstructured.SetNestedSlice(i.Object.Object, []interface{}{
+		map[string]interface{}{
+			"hosts":      []interface{}{"my-trigger.tenant.example.com"},
+			"secretName": "my-trigger-tls",
+		},
+	}, "spec", "tls"))
+	assert.Equal(t, "https://my-trigger.tenant.example.com/hooks", i.URL())
+
+	hr := obj.NewHTTPRoute(key)
+	require.NoError(t, unstructured.SetNestedStringSlice(hr.Object.Object, []string{"my-trigger.tenant.example.com"}, "spec", "hostnames"))
+	assert.Equal(t, "http://my-trigger.tenant.example.com", hr.URL())
+	assert.False(t, hr.Ready())
+
+	require.NoError(t, unstructured.SetNestedSlice(hr.Object.Object, []interface{}{
+		map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Accepted", "status": "True"},
+			},
+		},
+	}, "status", "parents"))
+	assert.True(t, hr.Ready())
+}
+
+func TestNewWebhookTriggerRouter(t *testing.T) {
+	r, err := obj.NewWebhookTriggerRouter("", obj.WebhookTriggerRouterOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, &obj.AmbassadorWebhookTriggerRouter{AmbassadorID: obj.AmbassadorID}, r)
+
+	_, err = obj.NewWebhookTriggerRouter(obj.WebhookTriggerRouterIngress, obj.WebhookTriggerRouterOptions{})
+	assert.Error(t, err)
+
+	_, err = obj.NewWebhookTriggerRouter(obj.WebhookTriggerRouterGateway, obj.WebhookTriggerRouterOptions{Domain: "example.com"})
+	assert.Error(t, err)
+
+	r, err = obj.NewWebhookTriggerRouter(obj.WebhookTriggerRouterGateway, obj.WebhookTriggerRouterOptions{
+		Domain:  "example.com",
+		Gateway: client.ObjectKey{Namespace: "gateway-system", Name: "webhooks"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &obj.GatewayWebhookTriggerRouter{
+		Domain:  "example.com",
+		Gateway: client.ObjectKey{Namespace: "gateway-system", Name: "webhooks"},
+	}, r)
+}
+
+func TestWebhookTriggerRouteAddress(t *testing.T) {
+	wt := &relayv1beta1.WebhookTrigger{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-trigger"},
+	}
+
+	host, path := obj.WebhookTriggerRouteAddress(wt, "tenant", "")
+	assert.Equal(t, "", host)
+	assert.Equal(t, "/", path)
+
+	host, path = obj.WebhookTriggerRouteAddress(wt, "tenant", "example.com")
+	assert.Equal(t, "my-trigger.tenant.example.com", host)
+	assert.Equal(t, "/", path)
+
+	wt.Spec.Route = &relayv1beta1.WebhookTriggerRoute{
+		Host: "hooks.partner.example",
+		Path: "/github/",
+	}
+
+	host, path = obj.WebhookTriggerRouteAddress(wt, "tenant", "example.com")
+	assert.Equal(t, "hooks.partner.example", host)
+	assert.Equal(t, "/github", path)
+}
+
+func TestFindWebhookTriggerRouteConflict(t *testing.T) {
+	now := time.Now()
+
+	newWebhookTrigger := func(name string, created time.Time, route *relayv1beta1.WebhookTriggerRoute) relayv1beta1.WebhookTrigger {
+		return relayv1beta1.WebhookTrigger{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "default",
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec: relayv1beta1.WebhookTriggerSpec{
+				Route: route,
+			},
+			Status: relayv1beta1.WebhookTriggerStatus{
+				Namespace: "tenant",
+			},
+		}
+	}
+
+	route := &relayv1beta1.WebhookTriggerRoute{Host: "hooks.partner.example", Path: "/github"}
+
+	older := newWebhookTrigger("older", now.Add(-time.Hour), route)
+	newer := newWebhookTrigger("newer", now, route)
+	otherPath := newWebhookTrigger("other-path", now.Add(-time.Hour), &relayv1beta1.WebhookTriggerRoute{Host: "hooks.partner.example", Path: "/gitlab"})
+	all := []relayv1beta1.WebhookTrigger{older, newer, otherPath}
+
+	// The trigger created first keeps the address.
+	assert.Nil(t, obj.FindWebhookTriggerRouteConflict(&older, "tenant", "example.com", all))
+	assert.Equal(t, &obj.WebhookTriggerRouteConflictError{
+		Host:  "hooks.partner.example",
+		Path:  "/github",
+		Other: client.ObjectKey{Namespace: "default", Name: "older"},
+	}, obj.FindWebhookTriggerRouteConflict(&newer, "tenant", "example.com", all))
+	assert.Nil(t, obj.FindWebhookTriggerRouteConflict(&otherPath, "tenant", "example.com", all))
+
+	// A trigger requesting the host name assigned to another trigger also
+	// conflicts.
+	assigned := newWebhookTrigger("assigned", now.Add(-time.Hour), nil)
+	vanity := newWebhookTrigger("vanity", now, &relayv1beta1.WebhookTriggerRoute{Host: "assigned.tenant.example.com"})
+	assert.NotNil(t, obj.FindWebhookTriggerRouteConflict(&vanity, "tenant", "example.com", []relayv1beta1.WebhookTrigger{assigned, vanity}))
+
+	// Triggers being deleted release their address.
+	deleted := metav1.NewTime(now)
+	older.SetDeletionTimestamp(&deleted)
+	assert.Nil(t, obj.FindWebhookTriggerRouteConflict(&newer, "tenant", "example.com", []relayv1beta1.WebhookTrigger{older, newer}))
+}
@@ -0,0 +1,173 @@
+package obj
+
+import (
+	"context"
+
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/authenticate"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WebhookTriggerService is a workload that handles requests for a webhook
+// trigger or its verifier, independent of the backend that runs it.
+type WebhookTriggerService interface {
+	// Namespace is the namespace the workload runs in.
+	Namespace() string
+
+	// Ready returns true if the workload can handle requests.
+	Ready() bool
+
+	// URL is the address of the workload, or an empty string if the workload
+	// is not yet addressable.
+	URL() string
+
+	// ScalingStatus reports the scaling configuration in effect for the
+	// workload.
+	ScalingStatus() *relayv1beta1.WebhookTriggerScalingStatus
+}
+
+type WebhookTriggerServiceResult struct {
+	Service WebhookTriggerService
+	Error   error
+}
+
+func AsWebhookTriggerServiceResult(s WebhookTriggerService, err error) *WebhookTriggerServiceResult {
+	return &WebhookTriggerServiceResult{
+		Service: s,
+		Error:   err,
+	}
+}
+
+// WebhookTriggerBackend creates the workloads that serve webhook triggers.
+type WebhookTriggerBackend interface {
+	// ApplyService creates or updates the workload running the webhook
+	// trigger container.
+	ApplyService(ctx context.Context, cl client.Client, wtd *WebhookTriggerDeps) (WebhookTriggerService, error)
+
+	// ApplyVerifierService creates or updates the webhook verifier in front of
+	// the given upstream workload. It returns nil if the upstream workload is
+	// not yet addressable.
+	ApplyVerifierService(ctx context.Context, cl client.Client, wtd *WebhookTriggerDeps, upstream WebhookTriggerService, image string) (WebhookTriggerService, error)
+
+	// DeleteService removes any objects this backend created for the workload
+	// with the given key.
+	DeleteService(ctx context.Context, cl client.Client, key client.ObjectKey) error
+}
+
+// WebhookTriggerServiceKey returns the key of the workload running the
+// webhook trigger container in the tenant namespace.
+func WebhookTriggerServiceKey(wtd *WebhookTriggerDeps) client.ObjectKey {
+	return client.ObjectKey{
+		Namespace: wtd.TenantDeps.Namespace.Name,
+		Name:      wtd.WebhookTrigger.Key.Name,
+	}
+}
+
+// ConfigureWebhookTriggerPodTemplate sets up the pod running the webhook
+// trigger container. Token annotations are retained from the existing template
+// metadata unless they need to be reissued.
+func ConfigureWebhookTriggerPodTemplate(ctx context.Context, template *corev1.PodTemplateSpec, existing metav1.ObjectMeta, wtd *WebhookTriggerDeps) error {
+	*template = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				model.RelayControllerWebhookTriggerIDLabel: wtd.WebhookTrigger.Key.Name,
+			},
+			Annotations: map[string]string{
+				// We keep track of the immutable config map version to ensure
+				// the workload updates if the `input` script changes.
+				//
+				// It should be safe to use the resource version here as
+				// resource versions aren't supposed to change under semantic
+				// equality. However, this has been buggy in previous versions
+				// of Kubernetes, so we can always switch to a hash instead if
+				// needed.
+				ImmutableConfigMapResourceVersionAnnotation: wtd.ImmutableConfigMap.Object.GetResourceVersion(),
+
+				// Keep any existing token annotations.
+				model.RelayControllerTokenHashAnnotation: existing.GetAnnotations()[model.RelayControllerTokenHashAnnotation],
+				authenticate.KubernetesTokenAnnotation:   existing.GetAnnotations()[authenticate.KubernetesTokenAnnotation],
+				authenticate.KubernetesSubjectAnnotation: existing.GetAnnotations()[authenticate.KubernetesSubjectAnnotation],
+			},
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: wtd.KnativeServiceAccount.Key.Name,
+		},
+	}
+
+	image := wtd.WebhookTrigger.Object.Spec.Image
+	if image == "" {
+		// Theoretically someone could write some socat action and use the
+		// Alpine image, so we leave this here for consistency.
+		image = model.DefaultImage
+	}
+
+	container := corev1.Container{
+		Name:            wtd.WebhookTrigger.Object.Name,
+		Image:           image,
+		ImagePullPolicy: corev1.PullAlways,
+		Env: []corev1.EnvVar{
+			{
+				Name:  "METADATA_API_URL",
+				Value: wtd.MetadataAPIURL.String(),
+			},
+		},
+	}
+
+	if len(wtd.WebhookTrigger.Object.Spec.Input) > 0 {
+		tm := ModelWebhookTrigger(wtd.WebhookTrigger)
+
+		template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+			Name: "config",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: wtd.ImmutableConfigMap.Key.Name,
+					},
+					Items: []corev1.KeyToPath{
+						{
+							Key:  scriptConfigMapKey(tm),
+							Path: "input-script",
+							Mode: func(i int32) *int32 { return &i }(0755),
+						},
+					},
+				},
+			},
+		})
+
+		container.VolumeMounts = []corev1.VolumeMount{
+			{
+				Name:      "config",
+				ReadOnly:  true,
+				MountPath: "/var/run/puppet/relay/config",
+			},
+		}
+		container.Command = []string{"/var/run/puppet/relay/config/input-script"}
+	} else {
+		if command := wtd.WebhookTrigger.Object.Spec.Command; command != "" {
+			container.Command = []string{command}
+		}
+
+		if args := wtd.WebhookTrigger.Object.Spec.Args; len(args) > 0 {
+			container.Args = args
+		}
+	}
+
+	template.Spec.Volumes = append(template.Spec.Volumes, MetadataAPITokenVolume())
+	ConfigureMetadataAPITokenMount(&container)
+
+	template.Spec.Containers = []corev1.Container{container}
+
+	if err := wtd.AnnotateTriggerToken(ctx, &template.ObjectMeta); err != nil {
+		return err
+	}
+
+	if tis := wtd.Tenant.Object.Status.ToolInjection; tis != nil {
+		Annotate(&template.ObjectMeta, model.RelayControllerToolsVolumeClaimAnnotation, tis.VolumeClaimName)
+		Annotate(&template.ObjectMeta, model.RelayControllerToolsMountPathAnnotation, tis.MountPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,294 @@
+package obj
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/authenticate"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	WebhookVerifierSecretResourceVersionAnnotation = "controller.relay.sh/webhook-verifier-secret-resource-version"
+
+	// WebhookVerifierSecretKey is the key of the copied verification secret
+	// in the tenant namespace.
+	WebhookVerifierSecretKey = "secret"
+
+	// DefaultWebhookVerifierRateLimitedMaxScale is the maximum number of
+	// instances of the verifier of a rate-limited trigger that does not
+	// specify a maximum scale.
+	DefaultWebhookVerifierRateLimitedMaxScale int32 = 10
+)
+
+// WebhookVerifierKey returns the key used for every object backing the webhook
+// verifier given the key of the webhook trigger in the tenant namespace.
+func WebhookVerifierKey(key client.ObjectKey) client.ObjectKey {
+	return SuffixObjectKey(key, "verifier")
+}
+
+func ConfigureWebhookVerifierSecret(target, source *OpaqueSecret, key string) error {
+	if source.Object.GetUID() == "" {
+		return fmt.Errorf("verification secret %q does not exist", source.Key.Name)
+	}
+
+	value, found := source.Object.Data[key]
+	if !found || len(value) == 0 {
+		return fmt.Errorf("verification secret %q does not contain a value for key %q", source.Key.Name, key)
+	}
+
+	target.Object.Data = map[string][]byte{
+		WebhookVerifierSecretKey: value,
+	}
+
+	return nil
+}
+
+// ConfigureWebhookVerifierPodTemplate sets up the pod running the webhook
+// verifier for the given trigger. The verifier checks request signatures if
+// verification is configured, reports every request to the metadata API and
+// includes request contents if capture is configured. If the trigger is rate
+// limited, each of the given number of instances enforces an equal share of the
+// limit. Token annotations, which allow it to report requests through the
+// metadata API, are retained from the existing template metadata unless they
+// need to be reissued.
+func ConfigureWebhookVerifierPodTemplate(ctx context.Context, template *corev1.PodTemplateSpec, existing metav1.ObjectMeta, wtd *WebhookTriggerDeps, upstream, image string, instances int32) error {
+	if image == "" {
+		image = model.DefaultWebhookVerifierImage
+	}
+
+	*template = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				model.RelayControllerWebhookVerifierIDLabel: wtd.WebhookTrigger.Key.Name,
+			},
+			Annotations: map[string]string{},
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: wtd.KnativeServiceAccount.Key.Name,
+		},
+	}
+
+	env := []corev1.EnvVar{
+		{
+			Name:  "RELAY_WEBHOOK_VERIFIER_UPSTREAM_URL",
+			Value: upstream,
+		},
+	}
+
+	if v := wtd.WebhookTrigger.Object.Spec.Verification; v != nil {
+		env = append(env, corev1.EnvVar{
+			Name: "RELAY_WEBHOOK_VERIFIER_SECRET",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: wtd.VerifierSecret.Key.Name,
+					},
+					Key: WebhookVerifierSecretKey,
+				},
+			},
+		})
+
+		for _, ev := range []corev1.EnvVar{
+			{Name: "RELAY_WEBHOOK_VERIFIER_PRESET", Value: string(v.Preset)},
+			{Name: "RELAY_WEBHOOK_VERIFIER_ALGORITHM", Value: string(v.Algorithm)},
+			{Name: "RELAY_WEBHOOK_VERIFIER_HEADER", Value: v.Header},
+			{Name: "RELAY_WEBHOOK_VERIFIER_PREFIX", Value: v.Prefix},
+		} {
+			if ev.Value != "" {
+				env = append(env, ev)
+			}
+		}
+
+		// Environment variables from secrets are only read when the container
+		// starts, so we roll out a new revision when the secret changes.
+		Annotate(&template.ObjectMeta, WebhookVerifierSecretResourceVersionAnnotation, wtd.VerifierSecret.Object.GetResourceVersion())
+	}
+
+	env = append(env, corev1.EnvVar{
+		Name:  "METADATA_API_URL",
+		Value: wtd.MetadataAPIURL.String(),
+	})
+
+	if wtd.WebhookTrigger.Object.Spec.Capture != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "RELAY_WEBHOOK_VERIFIER_CAPTURE",
+			Value: "true",
+		})
+	}
+
+	if l := wtd.WebhookTrigger.Object.Spec.Limits; l != nil {
+		rps, burst := webhookVerifierRateLimit(l, instances)
+
+		for _, ev := range []corev1.EnvVar{
+			{Name: "RELAY_WEBHOOK_VERIFIER_REQUESTS_PER_SECOND", Value: formatPositiveInt(int64(rps))},
+			{Name: "RELAY_WEBHOOK_VERIFIER_BURST", Value: formatPositiveInt(int64(burst))},
+			{Name: "RELAY_WEBHOOK_VERIFIER_MAX_BODY_BYTES", Value: formatPositiveInt(l.MaxBodyBytes)},
+		} {
+			if ev.Value != "" {
+				env = append(env, ev)
+			}
+		}
+	}
+
+	// The verifier has its own token so that the metadata API can tell the
+	// requests it reports apart from anything the trigger sends.
+	for _, name := range []string{
+		model.RelayControllerTokenHashAnnotation,
+		authenticate.KubernetesTokenAnnotation,
+		authenticate.KubernetesSubjectAnnotation,
+	} {
+		if value, found := existing.GetAnnotations()[name]; found {
+			Annotate(&template.ObjectMeta, name, value)
+		}
+	}
+
+	if err := wtd.AnnotateWebhookVerifierToken(ctx, &template.ObjectMeta); err != nil {
+		return err
+	}
+
+	template.Spec.Containers = []corev1.Container{
+		{
+			Name:  "verifier",
+			Image: image,
+			Env:   env,
+		},
+	}
+
+	return nil
+}
+
+func ConfigureWebhookVerifierKnativeService(ctx context.Context, s *KnativeService, wtd *WebhookTriggerDeps, upstream, image string) error {
+	s.Label(ctx, KnativeServiceVisibilityLabel, KnativeServiceVisibilityClusterLocal)
+	s.LabelAnnotateFrom(ctx, wtd.WebhookTrigger.Object.ObjectMeta)
+
+	SetDependencyOf(&s.Object.ObjectMeta, Owner{Object: wtd.WebhookTrigger.Object, GVK: relayv1beta1.WebhookTriggerKind})
+
+	// The verifier scales with the trigger, but it does not need to limit
+	// concurrency as it only forwards requests.
+	var vs *relayv1beta1.WebhookTriggerScaling
+	if scaling := wtd.WebhookTrigger.Object.Spec.Scaling; scaling != nil {
+		vs = scaling.DeepCopy()
+		vs.ContainerConcurrency = nil
+	}
+
+	// Each instance of the verifier enforces an equal share of the rate limit,
+	// so the number of instances it is shared between must be bounded.
+	instances := int32(1)
+	if wtd.WebhookTrigger.RateLimited() {
+		if vs == nil {
+			vs = &relayv1beta1.WebhookTriggerScaling{}
+		}
+
+		var max int32
+		if vs.MaxScale != nil {
+			max = *vs.MaxScale
+		}
+
+		instances = webhookVerifierMaxInstances(wtd.WebhookTrigger, max)
+		vs.MaxScale = &instances
+
+		if vs.MinScale != nil && *vs.MinScale > instances {
+			vs.MinScale = &instances
+		}
+	}
+
+	var pt corev1.PodTemplateSpec
+	if err := ConfigureWebhookVerifierPodTemplate(ctx, &pt, s.Object.Spec.ConfigurationSpec.Template.ObjectMeta, wtd, upstream, image, instances); err != nil {
+		return err
+	}
+
+	template := servingv1.RevisionTemplateSpec{
+		ObjectMeta: pt.ObjectMeta,
+		Spec: servingv1.RevisionSpec{
+			PodSpec: pt.Spec,
+		},
+	}
+
+	ConfigureKnativeRevisionScaling(&template, vs)
+
+	s.Object.Spec = servingv1.ServiceSpec{
+		ConfigurationSpec: servingv1.ConfigurationSpec{
+			Template: template,
+		},
+	}
+
+	return nil
+}
+
+// webhookVerifierMaxInstances returns the largest number of verifier instances
+// to run for a rate-limited trigger given its maximum scale, or 0 if it does not
+// have one. Every instance must accept at least one request per second.
+func webhookVerifierMaxInstances(wt *WebhookTrigger, max int32) int32 {
+	if max <= 0 {
+		max = DefaultWebhookVerifierRateLimitedMaxScale
+	}
+
+	if rps := wt.Object.Spec.Limits.RequestsPerSecond; max > rps {
+		max = rps
+	}
+
+	return max
+}
+
+// webhookVerifierRateLimit divides the rate limit of a trigger between the
+// given number of verifier instances so that together they accept no more
+// requests than the trigger allows.
+func webhookVerifierRateLimit(l *relayv1beta1.WebhookTriggerLimits, instances int32) (rps, burst int32) {
+	if l.RequestsPerSecond <= 0 || instances <= 1 {
+		return l.RequestsPerSecond, l.Burst
+	}
+
+	rps = l.RequestsPerSecond / instances
+	if l.Burst > 0 {
+		burst = l.Burst / instances
+		if burst < 1 {
+			burst = 1
+		}
+	}
+
+	return
+}
+
+func formatPositiveInt(n int64) string {
+	if n <= 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(n, 10)
+}
+
+// ApplyWebhookVerifierKnativeService creates or updates the Knative service
+// that verifies requests before forwarding them to the given upstream URL. It
+// returns nil if the upstream URL is not yet known.
+func ApplyWebhookVerifierKnativeService(ctx context.Context, cl client.Client, wtd *WebhookTriggerDeps, upstream, image string) (*KnativeService, error) {
+	if upstream == "" {
+		return nil, nil
+	}
+
+	s := NewKnativeService(wtd.VerifierSecret.Key)
+
+	if _, err := s.Load(ctx, cl); err != nil {
+		return nil, err
+	}
+
+	if err := wtd.OwnerConfigMap.Own(ctx, s); err != nil {
+		return nil, err
+	}
+
+	if err := ConfigureWebhookVerifierKnativeService(ctx, s, wtd, upstream, image); err != nil {
+		return nil, err
+	}
+
+	if err := s.Persist(ctx, cl); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
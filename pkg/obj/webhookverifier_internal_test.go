@@ -0,0 +1,43 @@
+package obj
+
+import (
+	"testing"
+
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestWebhookVerifierRateLimit(t *testing.T) {
+	wt := NewWebhookTrigger(types.NamespacedName{Namespace: "default", Name: "my-trigger"})
+	wt.Object.Spec.Limits = &relayv1beta1.WebhookTriggerLimits{
+		RequestsPerSecond: 25,
+		Burst:             4,
+	}
+
+	// Without a maximum scale, the default applies.
+	assert.Equal(t, DefaultWebhookVerifierRateLimitedMaxScale, webhookVerifierMaxInstances(wt, 0))
+	assert.Equal(t, int32(3), webhookVerifierMaxInstances(wt, 3))
+
+	// Every instance must accept at least one request per second.
+	assert.Equal(t, int32(25), webhookVerifierMaxInstances(wt, 100))
+
+	rps, burst := webhookVerifierRateLimit(wt.Object.Spec.Limits, 1)
+	assert.Equal(t, int32(25), rps)
+	assert.Equal(t, int32(4), burst)
+
+	rps, burst = webhookVerifierRateLimit(wt.Object.Spec.Limits, 3)
+	assert.Equal(t, int32(8), rps)
+	assert.Equal(t, int32(1), burst)
+
+	rps, burst = webhookVerifierRateLimit(wt.Object.Spec.Limits, 10)
+	assert.Equal(t, int32(2), rps)
+	assert.Equal(t, int32(1), burst)
+
+	// A burst that is not specified follows the divided rate.
+	wt.Object.Spec.Limits.Burst = 0
+
+	rps, burst = webhookVerifierRateLimit(wt.Object.Spec.Limits, 5)
+	assert.Equal(t, int32(5), rps)
+	assert.Equal(t, int32(0), burst)
+}
@@ -2,12 +2,16 @@ package obj
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/puppetlabs/horsehead/v2/datastructure"
 	"github.com/puppetlabs/horsehead/v2/graph"
 	"github.com/puppetlabs/horsehead/v2/graph/traverse"
+	"github.com/puppetlabs/horsehead/v2/storage"
 	nebulav1 "github.com/puppetlabs/relay-core/pkg/apis/nebula.puppet.com/v1"
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
 	"github.com/puppetlabs/relay-core/pkg/model"
 	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	"github.com/tektoncd/pipeline/pkg/reconciler/pipelinerun/resources"
@@ -32,6 +36,11 @@ const (
 	WorkflowRunStatusTimedOut   WorkflowRunStatus = "timed-out"
 )
 
+const (
+	WorkflowRunStatusReasonTenantFound    = "TenantFound"
+	WorkflowRunStatusReasonTenantNotFound = "TenantNotFound"
+)
+
 var (
 	WorkflowRunKind = nebulav1.SchemeGroupVersion.WithKind("WorkflowRun")
 )
@@ -41,12 +50,30 @@ type WorkflowRun struct {
 	Object *nebulav1.WorkflowRun
 }
 
+var _ Persister = &WorkflowRun{}
+var _ Finalizable = &WorkflowRun{}
 var _ Loader = &WorkflowRun{}
 
+func (wr *WorkflowRun) Persist(ctx context.Context, cl client.Client) error {
+	return CreateOrUpdate(ctx, cl, wr.Key, wr.Object)
+}
+
 func (wr *WorkflowRun) PersistStatus(ctx context.Context, cl client.Client) error {
 	return cl.Status().Update(ctx, wr.Object)
 }
 
+func (wr *WorkflowRun) Finalizing() bool {
+	return !wr.Object.GetDeletionTimestamp().IsZero()
+}
+
+func (wr *WorkflowRun) AddFinalizer(ctx context.Context, name string) bool {
+	return AddFinalizer(&wr.Object.ObjectMeta, name)
+}
+
+func (wr *WorkflowRun) RemoveFinalizer(ctx context.Context, name string) bool {
+	return RemoveFinalizer(&wr.Object.ObjectMeta, name)
+}
+
 func (wr *WorkflowRun) Load(ctx context.Context, cl client.Client) (bool, error) {
 	return GetIgnoreNotFound(ctx, cl, wr.Key, wr.Object)
 }
@@ -72,6 +99,10 @@ func (wr *WorkflowRun) IsCancelled() bool {
 	return state.Value() == true
 }
 
+func (wr *WorkflowRun) IsComplete() bool {
+	return wr.Object.Status.CompletionTime != nil
+}
+
 func (wr *WorkflowRun) Complete(ctx context.Context, cl client.Client) error {
 	if wr.Object.Status.StartTime == nil {
 		wr.Object.Status.StartTime = &metav1.Time{Time: time.Now()}
@@ -86,6 +117,18 @@ func (wr *WorkflowRun) Complete(ctx context.Context, cl client.Client) error {
 	return wr.PersistStatus(ctx, cl)
 }
 
+// Fail marks a workflow run that cannot start as failed. The run never starts,
+// so only its completion time is set.
+func (wr *WorkflowRun) Fail(ctx context.Context, cl client.Client) error {
+	if wr.Object.Status.CompletionTime == nil {
+		wr.Object.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+	}
+
+	wr.Object.Status.Status = string(WorkflowRunStatusFailure)
+
+	return wr.PersistStatus(ctx, cl)
+}
+
 func NewWorkflowRun(key client.ObjectKey) *WorkflowRun {
 	return &WorkflowRun{
 		Key:    key,
@@ -174,6 +217,70 @@ func workflowRunStatusSummaries(wr *WorkflowRun, pr *PipelineRun) *workflowRunSt
 	return m
 }
 
+// WorkflowRunHasStepOutputBlobs returns true if the metadata API stored any
+// output of the steps of the workflow run in blob storage.
+func WorkflowRunHasStepOutputBlobs(ctx context.Context, cl client.Client, wr *WorkflowRun) (bool, error) {
+	cm := NewConfigMap(SuffixObjectKey(wr.Key, "mutable"))
+	if ok, err := cm.Load(ctx, cl); err != nil || !ok {
+		return false, err
+	}
+
+	return configmap.HasStepOutputBlobs(ctx, configmap.NewLocalConfigMap(cm.Object))
+}
+
+// DeleteWorkflowRunStepOutputBlobs removes the outputs of the steps of the
+// workflow run that the metadata API stored in the given blob store.
+func DeleteWorkflowRunStepOutputBlobs(ctx context.Context, cl client.Client, wr *WorkflowRun, bs storage.BlobStore) error {
+	cm := NewConfigMap(SuffixObjectKey(wr.Key, "mutable"))
+	if ok, err := cm.Load(ctx, cl); err != nil {
+		return err
+	} else if !ok {
+		return nil
+	}
+
+	return configmap.DeleteStepOutputBlobs(ctx, configmap.NewLocalConfigMap(cm.Object), bs)
+}
+
+// ConfigureWorkflowRunTenant records whether the tenant referenced by the
+// workflow run, if any, has been found. It returns false if the run refers to
+// a tenant that does not exist.
+func ConfigureWorkflowRunTenant(wr *WorkflowRun, t *Tenant) bool {
+	if t == nil {
+		wr.Object.Status.Tenant = nil
+		return true
+	}
+
+	if wr.Object.Status.Tenant == nil {
+		wr.Object.Status.Tenant = &nebulav1.WorkflowRunTenantStatus{}
+	}
+
+	found := t.Object.GetUID() != ""
+
+	UpdateStatusConditionIfTransitioned(&wr.Object.Status.Tenant.Condition, func() relayv1beta1.Condition {
+		if !found {
+			return relayv1beta1.Condition{
+				Status:  corev1.ConditionFalse,
+				Reason:  WorkflowRunStatusReasonTenantNotFound,
+				Message: fmt.Sprintf("No tenant named %q exists in this namespace or manages this namespace.", t.Key.Name),
+			}
+		}
+
+		return relayv1beta1.Condition{
+			Status:  corev1.ConditionTrue,
+			Reason:  WorkflowRunStatusReasonTenantFound,
+			Message: "The tenant is available.",
+		}
+	})
+
+	if found {
+		wr.Object.Status.Tenant.Namespace = t.Key.Namespace
+	} else {
+		wr.Object.Status.Tenant.Namespace = ""
+	}
+
+	return found
+}
+
 func ConfigureWorkflowRun(wr *WorkflowRun, pr *PipelineRun) {
 	if wr.IsCancelled() {
 		wr.Object.Status.Status = string(WorkflowRunStatusCancelled)
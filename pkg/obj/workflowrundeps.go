@@ -19,6 +19,16 @@ type WorkflowRunDeps struct {
 	WorkflowRun *WorkflowRun
 	Issuer      authenticate.Issuer
 
+	// Tenant is the tenant referenced by the workflow run, if any.
+	Tenant *Tenant
+
+	// APITriggerEventSink, DirectTriggerEventSink and NamedTriggerEventSinks
+	// are the event sinks of the tenant that receive events emitted by steps,
+	// if any.
+	APITriggerEventSink    *APITriggerEventSink
+	DirectTriggerEventSink *DirectTriggerEventSink
+	NamedTriggerEventSinks []*NamedTriggerEventSink
+
 	Namespace *Namespace
 
 	// TODO: This belongs at the Tenant as it should apply to the whole
@@ -65,7 +75,24 @@ func (wrd *WorkflowRunDeps) Persist(ctx context.Context, cl client.Client) error
 }
 
 func (wrd *WorkflowRunDeps) Load(ctx context.Context, cl client.Client) (bool, error) {
-	return Loaders{
+	all := true
+
+	if wrd.Tenant != nil {
+		// Runs in the child namespace of a managed tenant refer to a tenant
+		// in another namespace.
+		if tn, ok, err := LoadTenantForNamespace(ctx, cl, wrd.Tenant.Key.Namespace, wrd.Tenant.Key.Name); err != nil {
+			return false, err
+		} else if !ok {
+			all = false
+		} else {
+			wrd.Tenant = tn
+			wrd.APITriggerEventSink, wrd.DirectTriggerEventSink, wrd.NamedTriggerEventSinks = newTriggerEventSinks(wrd.Tenant)
+		}
+	}
+
+	loaders := Loaders{
+		IgnoreNilLoader{wrd.APITriggerEventSink},
+		IgnoreNilLoader{wrd.DirectTriggerEventSink},
 		RequiredLoader{wrd.Namespace},
 		IgnoreNilLoader{wrd.LimitRange},
 		IgnoreNilLoader{wrd.NetworkPolicy},
@@ -76,15 +103,32 @@ func (wrd *WorkflowRunDeps) Load(ctx context.Context, cl client.Client) (bool, e
 		wrd.MetadataAPIRoleBinding,
 		wrd.PipelineServiceAccount,
 		wrd.UntrustedServiceAccount,
-	}.Load(ctx, cl)
+	}
+
+	for _, sink := range wrd.NamedTriggerEventSinks {
+		loaders = append(loaders, sink)
+	}
+
+	ok, err := loaders.Load(ctx, cl)
+	if err != nil {
+		return false, err
+	}
+
+	return all && ok, nil
 }
 
 func (wrd *WorkflowRunDeps) AnnotateStepToken(ctx context.Context, target *metav1.ObjectMeta, ws *nebulav1.WorkflowStep) error {
 	if _, found := target.Annotations[authenticate.KubernetesTokenAnnotation]; found {
-		// We only add this once and exactly once per run per target.
+		// We only add this once and exactly once per run per target. Tokens
+		// are renewed on the running pod instead; see
+		// RenewWorkflowRunStepTokens.
 		return nil
 	}
 
+	return wrd.annotateStepToken(ctx, target, ws)
+}
+
+func (wrd *WorkflowRunDeps) annotateStepToken(ctx context.Context, target *metav1.ObjectMeta, ws *nebulav1.WorkflowStep) error {
 	ms := ModelStep(wrd.WorkflowRun, ws)
 	now := time.Now()
 
@@ -100,7 +144,7 @@ func (wrd *WorkflowRunDeps) AnnotateStepToken(ctx context.Context, target *metav
 			Issuer:    authenticate.ControllerIssuer,
 			Audience:  jwt.Audience{authenticate.MetadataAPIAudienceV1},
 			Subject:   path.Join(ms.Type().Plural, ms.Hash().HexEncoding()),
-			Expiry:    jwt.NewNumericDate(now.Add(1*time.Hour + 5*time.Minute)),
+			Expiry:    jwt.NewNumericDate(now.Add(StepTokenLifetime)),
 			NotBefore: jwt.NewNumericDate(now),
 			IssuedAt:  jwt.NewNumericDate(now),
 		},
@@ -116,12 +160,11 @@ func (wrd *WorkflowRunDeps) AnnotateStepToken(ctx context.Context, target *metav
 
 		RelayKubernetesImmutableConfigMapName: wrd.ImmutableConfigMap.Key.Name,
 		RelayKubernetesMutableConfigMapName:   wrd.MutableConfigMap.Key.Name,
-
-		RelayVaultEnginePath:     annotations[model.RelayVaultEngineMountAnnotation],
-		RelayVaultSecretPath:     annotations[model.RelayVaultSecretPathAnnotation],
-		RelayVaultConnectionPath: annotations[model.RelayVaultConnectionPathAnnotation],
 	}
 
+	ConfigureVaultClaims(claims, wrd.Tenant, annotations)
+	ConfigureEventClaims(claims, wrd.APITriggerEventSink, wrd.DirectTriggerEventSink, wrd.NamedTriggerEventSinks)
+
 	tok, err := wrd.Issuer.Issue(ctx, claims)
 	if err != nil {
 		return err
@@ -129,6 +172,7 @@ func (wrd *WorkflowRunDeps) AnnotateStepToken(ctx context.Context, target *metav
 
 	Annotate(target, authenticate.KubernetesTokenAnnotation, string(tok))
 	Annotate(target, authenticate.KubernetesSubjectAnnotation, claims.Subject)
+	Annotate(target, model.RelayControllerTokenExpiryAnnotation, claims.Expiry.Time().UTC().Format(time.RFC3339))
 
 	return nil
 }
@@ -169,6 +213,10 @@ func NewWorkflowRunDeps(wr *WorkflowRun, issuer authenticate.Issuer, metadataAPI
 		UntrustedServiceAccount: NewServiceAccount(SuffixObjectKey(key, "untrusted")),
 	}
 
+	if ref := wr.Object.Spec.TenantRef; ref != nil {
+		wrd.Tenant = NewTenant(client.ObjectKey{Namespace: key.Namespace, Name: ref.Name})
+	}
+
 	for _, opt := range opts {
 		opt(wrd)
 	}
@@ -224,15 +272,22 @@ func ConfigureWorkflowRunDeps(ctx context.Context, wrd *WorkflowRunDeps) error {
 		ConfigureNetworkPolicyForWorkflowRun(wrd.NetworkPolicy, wrd.WorkflowRun)
 	}
 
-	if err := ConfigureImmutableConfigMapForWorkflowRun(ctx, wrd.ImmutableConfigMap, wrd.WorkflowRun); err != nil {
+	if err := ConfigureImmutableConfigMapForWorkflowRun(ctx, wrd.ImmutableConfigMap, wrd.WorkflowRun, wrd.Tenant); err != nil {
 		return err
 	}
 	if err := ConfigureMutableConfigMapForWorkflowRun(ctx, wrd.MutableConfigMap, wrd.WorkflowRun); err != nil {
 		return err
 	}
 
+	if err := ConfigureImmutableConfigMapForWorkflowRunEvents(ctx, wrd.ImmutableConfigMap, wrd.WorkflowRun, wrd.Tenant, wrd.DirectTriggerEventSink, wrd.NamedTriggerEventSinks); err != nil {
+		return err
+	}
+
 	ConfigureMetadataAPIServiceAccount(wrd.MetadataAPIServiceAccount)
 	ConfigureMetadataAPIRole(wrd.MetadataAPIRole, wrd.ImmutableConfigMap, wrd.MutableConfigMap)
+	if hasDirectTriggerEventSink(wrd.DirectTriggerEventSink, wrd.NamedTriggerEventSinks) {
+		ConfigureMetadataAPIRoleForWorkflowRuns(wrd.MetadataAPIRole)
+	}
 	ConfigureMetadataAPIRoleBinding(wrd.MetadataAPIRoleBinding, wrd.MetadataAPIServiceAccount, wrd.MetadataAPIRole)
 	ConfigureUntrustedServiceAccount(wrd.PipelineServiceAccount)
 	ConfigureUntrustedServiceAccount(wrd.UntrustedServiceAccount)
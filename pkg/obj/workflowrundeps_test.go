@@ -5,15 +5,21 @@ import (
 	"encoding/json"
 	"path"
 	"testing"
+	"time"
 
 	nebulav1 "github.com/puppetlabs/relay-core/pkg/apis/nebula.puppet.com/v1"
 	"github.com/puppetlabs/relay-core/pkg/authenticate"
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/puppetlabs/relay-core/pkg/model"
 	"github.com/puppetlabs/relay-core/pkg/obj"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/square/go-jose.v2/jwt"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -72,6 +78,7 @@ func TestWorkflowRunDepsConfigureAnnotate(t *testing.T) {
 		assert.Equal(t, jwt.Audience{authenticate.MetadataAPIAudienceV1}, claims.Audience)
 		assert.Equal(t, path.Join("steps", obj.ModelStep(run, ws).Hash().HexEncoding()), claims.Subject)
 		assert.NotNil(t, claims.Expiry)
+		assert.Equal(t, claims.Expiry.Time().UTC().Format(time.RFC3339), md.GetAnnotations()[model.RelayControllerTokenExpiryAnnotation])
 		assert.NotNil(t, claims.NotBefore)
 		assert.NotNil(t, claims.IssuedAt)
 		assert.Equal(t, namespace.Name, claims.KubernetesNamespaceName)
@@ -83,3 +90,75 @@ func TestWorkflowRunDepsConfigureAnnotate(t *testing.T) {
 		assert.Equal(t, deps.MutableConfigMap.Key.Name, claims.RelayKubernetesMutableConfigMapName)
 	})
 }
+
+func TestWorkflowRunDepsMetadataAPIRole(t *testing.T) {
+	ctx := context.Background()
+
+	WithTestNamespace(t, ctx, func(namespace *obj.Namespace) {
+		cl := Client(t)
+
+		require.NoError(t, cl.Create(ctx, &nebulav1.WorkflowRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "my-test-run",
+				Namespace: namespace.Name,
+			},
+			Spec: nebulav1.WorkflowRunSpec{
+				Name: "my-workflow-run-1234",
+				Workflow: nebulav1.Workflow{
+					Name: "my-workflow",
+					Steps: []*nebulav1.WorkflowStep{
+						{
+							Name: "my-test-step",
+						},
+					},
+				},
+			},
+		}))
+
+		run := obj.NewWorkflowRun(client.ObjectKey{
+			Namespace: namespace.Name,
+			Name:      "my-test-run",
+		})
+
+		ok, err := run.Load(ctx, cl)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		deps, err := obj.ApplyWorkflowRunDeps(ctx, cl, run, TestIssuer, TestMetadataAPIURL)
+		require.NoError(t, err)
+
+		sat, err := deps.MetadataAPIServiceAccount.DefaultTokenSecret.Token()
+		require.NoError(t, err)
+
+		// Act as the metadata API does for requests from the run.
+		cfg := rest.AnonymousClientConfig(e2e.RESTConfig)
+		cfg.BearerToken = sat
+
+		kc, err := kubernetes.NewForConfig(cfg)
+		require.NoError(t, err)
+
+		immutableMap := configmap.NewClientConfigMap(kc, namespace.Name, deps.ImmutableConfigMap.Key.Name)
+		mutableMap := configmap.NewClientConfigMap(kc, namespace.Name, deps.MutableConfigMap.Key.Name)
+
+		_, err = immutableMap.Get(ctx)
+		require.NoError(t, err)
+
+		cm, err := mutableMap.Get(ctx)
+		require.NoError(t, err)
+
+		_, err = mutableMap.CreateOrUpdate(ctx, cm)
+		require.NoError(t, err)
+
+		// Waiting for conditions watches the mutable config map.
+		w, err := mutableMap.Watch(ctx)
+		require.NoError(t, err)
+		w.Stop()
+
+		// Other config maps remain off limits.
+		_, err = configmap.NewClientConfigMap(kc, namespace.Name, "other").Watch(ctx)
+		require.True(t, k8serrors.IsForbidden(err), "expected forbidden error, got %+v", err)
+
+		_, err = immutableMap.Watch(ctx)
+		require.True(t, k8serrors.IsForbidden(err), "expected forbidden error, got %+v", err)
+	})
+}
@@ -0,0 +1,115 @@
+package obj
+
+import (
+	"context"
+	"time"
+
+	nebulav1 "github.com/puppetlabs/relay-core/pkg/apis/nebula.puppet.com/v1"
+	"github.com/puppetlabs/relay-core/pkg/authenticate"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// StepTokenLifetime is how long a token issued to a step is valid for.
+	StepTokenLifetime = 1*time.Hour + 5*time.Minute
+
+	// StepTokenRenewBefore is how long before a step token expires that it is
+	// replaced with a new one.
+	StepTokenRenewBefore = 30 * time.Minute
+
+	// pipelineTaskLabel is set by Tekton on the pods of both Tasks and
+	// Conditions to the name of the pipeline task, which is the step hash.
+	pipelineTaskLabel = "tekton.dev/pipelineTask"
+)
+
+// RenewWorkflowRunStepTokens issues new tokens to the pending and running pods
+// of a workflow run whose tokens expire soon, so that steps running for longer
+// than StepTokenLifetime can keep using the metadata API. It also removes the
+// tokens from pods that have stopped. The metadata API only reads tokens from
+// running pods, so removing a token does not revoke it; it remains valid until
+// it expires.
+//
+// It returns the earliest time at which a token will need to be renewed again,
+// or the zero time if no pods are pending or running.
+func RenewWorkflowRunStepTokens(ctx context.Context, cl client.Client, wrd *WorkflowRunDeps) (time.Time, error) {
+	wr := wrd.WorkflowRun
+
+	steps := make(map[string]*nebulav1.WorkflowStep, len(wr.Object.Spec.Workflow.Steps))
+	for _, ws := range wr.Object.Spec.Workflow.Steps {
+		steps[ModelStep(wr, ws).Hash().HexEncoding()] = ws
+	}
+
+	pods := &corev1.PodList{}
+	if err := cl.List(ctx, pods, client.InNamespace(wr.Key.Namespace), client.MatchingLabels(wr.PodSelector().MatchLabels)); err != nil {
+		return time.Time{}, err
+	}
+
+	now := time.Now()
+
+	var next time.Time
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		ws, found := steps[pod.GetLabels()[pipelineTaskLabel]]
+		if !found {
+			continue
+		}
+
+		switch pod.Status.Phase {
+		case corev1.PodPending, corev1.PodRunning:
+			renewAt, err := renewStepToken(ctx, cl, wrd, pod, ws, now)
+			if err != nil {
+				return time.Time{}, err
+			}
+
+			if next.IsZero() || renewAt.Before(next) {
+				next = renewAt
+			}
+		case corev1.PodSucceeded, corev1.PodFailed:
+			if err := removeStepToken(ctx, cl, pod); err != nil {
+				return time.Time{}, err
+			}
+		}
+	}
+
+	return next, nil
+}
+
+func renewStepToken(ctx context.Context, cl client.Client, wrd *WorkflowRunDeps, pod *corev1.Pod, ws *nebulav1.WorkflowStep, now time.Time) (time.Time, error) {
+	// Pods that have never had a token renewed carry the expiry of the token
+	// issued to their Task, which may have been created well before the pod
+	// started. Condition pods do not carry a token at all, so they read it
+	// from their Tekton Condition until we annotate them here.
+	if expiry, err := time.Parse(time.RFC3339, pod.GetAnnotations()[model.RelayControllerTokenExpiryAnnotation]); err == nil {
+		if renewAt := expiry.Add(-StepTokenRenewBefore); renewAt.After(now) {
+			return renewAt, nil
+		}
+	}
+
+	patch := client.MergeFrom(pod.DeepCopy())
+
+	if err := wrd.annotateStepToken(ctx, &pod.ObjectMeta, ws); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := cl.Patch(ctx, pod, patch); err != nil {
+		return time.Time{}, err
+	}
+
+	return now.Add(StepTokenLifetime - StepTokenRenewBefore), nil
+}
+
+func removeStepToken(ctx context.Context, cl client.Client, pod *corev1.Pod) error {
+	if _, found := pod.GetAnnotations()[authenticate.KubernetesTokenAnnotation]; !found {
+		return nil
+	}
+
+	patch := client.MergeFrom(pod.DeepCopy())
+
+	delete(pod.Annotations, authenticate.KubernetesTokenAnnotation)
+	delete(pod.Annotations, model.RelayControllerTokenExpiryAnnotation)
+
+	return cl.Patch(ctx, pod, patch)
+}
@@ -0,0 +1,111 @@
+package obj_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	nebulav1 "github.com/puppetlabs/relay-core/pkg/apis/nebula.puppet.com/v1"
+	"github.com/puppetlabs/relay-core/pkg/authenticate"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/puppetlabs/relay-core/pkg/obj"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRenewWorkflowRunStepTokens(t *testing.T) {
+	ctx := context.Background()
+
+	WithTestNamespace(t, ctx, func(namespace *obj.Namespace) {
+		cl := Client(t)
+
+		require.NoError(t, cl.Create(ctx, &nebulav1.WorkflowRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "my-test-run",
+				Namespace: namespace.Name,
+			},
+			Spec: nebulav1.WorkflowRunSpec{
+				Name: "my-workflow-run-1234",
+				Workflow: nebulav1.Workflow{
+					Name: "my-workflow",
+					Steps: []*nebulav1.WorkflowStep{
+						{
+							Name: "my-test-step",
+						},
+					},
+				},
+			},
+		}))
+
+		run := obj.NewWorkflowRun(client.ObjectKey{
+			Namespace: namespace.Name,
+			Name:      "my-test-run",
+		})
+
+		ok, err := run.Load(ctx, cl)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		deps, err := obj.ApplyWorkflowRunDeps(ctx, cl, run, TestIssuer, TestMetadataAPIURL)
+		require.NoError(t, err)
+
+		ms := obj.ModelStep(run, run.Object.Spec.Workflow.Steps[0])
+
+		// A pod started with a token that is about to expire.
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "my-test-pod",
+				Namespace: namespace.Name,
+				Labels: map[string]string{
+					model.RelayControllerWorkflowRunIDLabel: run.Key.Name,
+					"tekton.dev/pipelineTask":               ms.Hash().HexEncoding(),
+				},
+				Annotations: map[string]string{
+					authenticate.KubernetesTokenAnnotation:     "old-token",
+					model.RelayControllerTokenExpiryAnnotation: time.Now().Add(5 * time.Minute).UTC().Format(time.RFC3339),
+				},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "step", Image: model.DefaultImage},
+				},
+			},
+		}
+		require.NoError(t, cl.Create(ctx, pod))
+
+		pod.Status.Phase = corev1.PodRunning
+		require.NoError(t, cl.Status().Update(ctx, pod))
+
+		renewAt, err := obj.RenewWorkflowRunStepTokens(ctx, cl, deps)
+		require.NoError(t, err)
+		assert.True(t, renewAt.After(time.Now().Add(obj.StepTokenLifetime-obj.StepTokenRenewBefore-time.Minute)))
+
+		require.NoError(t, cl.Get(ctx, client.ObjectKey{Namespace: namespace.Name, Name: "my-test-pod"}, pod))
+
+		tok := pod.GetAnnotations()[authenticate.KubernetesTokenAnnotation]
+		assert.NotEqual(t, "old-token", tok)
+		assert.NotEmpty(t, tok)
+		assert.NotEmpty(t, pod.GetAnnotations()[model.RelayControllerTokenExpiryAnnotation])
+
+		// A fresh token is left alone.
+		_, err = obj.RenewWorkflowRunStepTokens(ctx, cl, deps)
+		require.NoError(t, err)
+
+		require.NoError(t, cl.Get(ctx, client.ObjectKey{Namespace: namespace.Name, Name: "my-test-pod"}, pod))
+		assert.Equal(t, tok, pod.GetAnnotations()[authenticate.KubernetesTokenAnnotation])
+
+		// Once the step completes, its token is removed from the pod.
+		pod.Status.Phase = corev1.PodSucceeded
+		require.NoError(t, cl.Status().Update(ctx, pod))
+
+		renewAt, err = obj.RenewWorkflowRunStepTokens(ctx, cl, deps)
+		require.NoError(t, err)
+		assert.True(t, renewAt.IsZero())
+
+		require.NoError(t, cl.Get(ctx, client.ObjectKey{Namespace: namespace.Name, Name: "my-test-pod"}, pod))
+		assert.NotContains(t, pod.GetAnnotations(), authenticate.KubernetesTokenAnnotation)
+	})
+}
@@ -3,7 +3,9 @@ package tenant
 import (
 	"context"
 	"fmt"
+	"time"
 
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
 	"github.com/puppetlabs/relay-core/pkg/config"
 	"github.com/puppetlabs/relay-core/pkg/errmark"
 	"github.com/puppetlabs/relay-core/pkg/model"
@@ -17,7 +19,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-const FinalizerName = "tenant.finalizers.controller.relay.sh"
+const (
+	FinalizerName = "tenant.finalizers.controller.relay.sh"
+
+	drainPollInterval            = 15 * time.Second
+	toolInjectionCleanupInterval = 1 * time.Minute
+)
 
 type Reconciler struct {
 	Client client.Client
@@ -51,6 +58,33 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 		})
 	}
 
+	if tn.Finalizing() && tn.DeletionPolicy() == relayv1beta1.TenantDeletionPolicyDrain {
+		drain := obj.NewTenantDrain(deps)
+		if _, err := drain.Load(ctx, r.Client); err != nil {
+			return ctrl.Result{}, errmark.MapLast(err, func(err error) error {
+				return fmt.Errorf("failed to load workflow runs to drain: %+v", err)
+			})
+		}
+
+		obj.ConfigureTenantDrain(tn, drain)
+
+		if err := tn.PersistStatus(ctx, r.Client); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if now := time.Now(); !drain.Drained(now) {
+			// Workflow run changes do not trigger reconciliation of the
+			// tenant, so we poll until the runs complete or the deadline
+			// passes.
+			requeueAfter := drain.Deadline().Sub(now)
+			if requeueAfter > drainPollInterval {
+				requeueAfter = drainPollInterval
+			}
+
+			return ctrl.Result{RequeueAfter: requeueAfter}, nil
+		}
+	}
+
 	finalized, err := obj.Finalize(ctx, r.Client, FinalizerName, tn, func() error {
 		_, err := deps.Delete(ctx, r.Client)
 		return err
@@ -75,7 +109,8 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 		return ctrl.Result{}, err
 	}
 
-	if tn.Object.Spec.ToolInjection.VolumeClaimTemplate == nil {
+	ti := deps.ToolInjection
+	if ti.VolumeClaimTemplate == nil {
 		if !tn.Ready() {
 			return ctrl.Result{Requeue: true}, nil
 		}
@@ -83,19 +118,26 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 		return ctrl.Result{}, nil
 	}
 
+	namespace := tn.Object.Spec.NamespaceTemplate.Metadata.GetName()
+	version := ti.Version(r.Config.ToolInjectionImage)
+
+	rwoName := ti.VolumeClaimName(model.ToolInjectionVolumeClaimSuffixReadWriteOnce, version)
+	roxName := ti.VolumeClaimName(model.ToolInjectionVolumeClaimSuffixReadOnlyMany, version)
+
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      deps.Tenant.Object.GetName() + model.ToolInjectionVolumeClaimSuffixReadWriteOnce,
-			Namespace: tn.Object.Spec.NamespaceTemplate.Metadata.GetName(),
+			Name:      rwoName,
+			Namespace: namespace,
+			Labels:    ti.Labels(version),
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
 			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
-			Resources:        tn.Object.Spec.ToolInjection.VolumeClaimTemplate.Spec.Resources,
-			StorageClassName: tn.Object.Spec.ToolInjection.VolumeClaimTemplate.Spec.StorageClassName,
+			Resources:        ti.VolumeClaimTemplate.Spec.Resources,
+			StorageClassName: ti.VolumeClaimTemplate.Spec.StorageClassName,
 		},
 	}
 
-	key := client.ObjectKey{Name: deps.Tenant.Object.GetName() + model.ToolInjectionVolumeClaimSuffixReadWriteOnce, Namespace: deps.Tenant.Object.Spec.NamespaceTemplate.Metadata.GetName()}
+	key := client.ObjectKey{Name: rwoName, Namespace: namespace}
 	pvco, err := obj.ApplyPersistentVolumeClaim(ctx, r.Client, key, pvc)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -117,7 +159,8 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 
 	pvn := &corev1.PersistentVolume{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: tn.Object.GetName() + model.ToolInjectionVolumeClaimSuffixReadOnlyMany,
+			Name:   roxName,
+			Labels: ti.Labels(version),
 		},
 		Spec: corev1.PersistentVolumeSpec{
 			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadOnlyMany},
@@ -138,7 +181,7 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 		}
 	}
 
-	key = client.ObjectKey{Name: tn.Object.GetName() + model.ToolInjectionVolumeClaimSuffixReadOnlyMany}
+	key = client.ObjectKey{Name: roxName}
 	_, err = obj.ApplyPersistentVolume(ctx, r.Client, key, pvn)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -146,8 +189,9 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 
 	pvcn := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      pvn.GetName(),
-			Namespace: tn.Object.Spec.NamespaceTemplate.Metadata.GetName(),
+			Name:      roxName,
+			Namespace: namespace,
+			Labels:    ti.Labels(version),
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
 			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadOnlyMany},
@@ -156,7 +200,7 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 		},
 	}
 
-	key = client.ObjectKey{Name: tn.Object.GetName() + model.ToolInjectionVolumeClaimSuffixReadOnlyMany, Namespace: tn.Object.Spec.NamespaceTemplate.Metadata.GetName()}
+	key = client.ObjectKey{Name: roxName, Namespace: namespace}
 	_, err = obj.ApplyPersistentVolumeClaim(ctx, r.Client, key, pvcn)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -170,14 +214,9 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	tii := model.DefaultToolInjectionImage
-	if r.Config.ToolInjectionImage != "" {
-		tii = r.Config.ToolInjectionImage
-	}
-
 	container := corev1.Container{
 		Name:    model.ToolInjectionMountName,
-		Image:   tii,
+		Image:   ti.Image(r.Config.ToolInjectionImage),
 		Command: []string{"cp"},
 		Args:    []string{"-r", model.ToolInjectionImagePath, model.ToolInjectionMountPath},
 		VolumeMounts: []corev1.VolumeMount{
@@ -192,14 +231,15 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 
 	j := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      tn.Object.GetName() + model.ToolInjectionVolumeClaimSuffixReadOnlyMany,
-			Namespace: tn.Object.Spec.NamespaceTemplate.Metadata.GetName(),
+			Name:      roxName,
+			Namespace: namespace,
+			Labels:    ti.Labels(version),
 		},
 		Spec: batchv1.JobSpec{
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      model.ToolInjectionMountName,
-					Namespace: tn.Object.Spec.NamespaceTemplate.Metadata.GetName(),
+					Namespace: namespace,
 				},
 				Spec: corev1.PodSpec{
 					Containers:    []corev1.Container{container},
@@ -222,7 +262,7 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 		},
 	}
 
-	key = client.ObjectKey{Name: tn.Object.GetName() + model.ToolInjectionVolumeClaimSuffixReadOnlyMany, Namespace: tn.Object.Spec.NamespaceTemplate.Metadata.GetName()}
+	key = client.ObjectKey{Name: roxName, Namespace: namespace}
 	job, err := obj.ApplyJob(ctx, r.Client, key, j)
 	if err != nil && !k8serrors.IsAlreadyExists(err) {
 		return ctrl.Result{}, err
@@ -249,6 +289,12 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	// New containers only switch to this version once it has been populated
+	// successfully; until then they keep using the previous one.
+	if complete && !failed {
+		tn.Object.Status.ToolInjection = ti.Status(r.Config.ToolInjectionImage, version)
+	}
+
 	obj.ConfigureTenant(tn, tdr, job.Object.Status.Conditions)
 
 	if err := tn.PersistStatus(ctx, r.Client); err != nil {
@@ -259,5 +305,17 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	if tis := tn.Object.Status.ToolInjection; tis != nil {
+		if ok, err := ti.DeleteStale(ctx, r.Client, namespace, tis.Version); err != nil {
+			return ctrl.Result{}, errmark.MapLast(err, func(err error) error {
+				return fmt.Errorf("failed to delete stale tool injection volumes: %+v", err)
+			})
+		} else if !ok {
+			// Pod completion does not trigger reconciliation of the tenant,
+			// so we poll until the old volumes are no longer in use.
+			return ctrl.Result{RequeueAfter: toolInjectionCleanupInterval}, nil
+		}
+	}
+
 	return ctrl.Result{}, nil
 }
@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"time"
 
+	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
 	"github.com/puppetlabs/relay-core/pkg/authenticate"
 	"github.com/puppetlabs/relay-core/pkg/dependency"
 	"github.com/puppetlabs/relay-core/pkg/errmark"
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
 	"github.com/puppetlabs/relay-core/pkg/model"
 	"github.com/puppetlabs/relay-core/pkg/obj"
 	"k8s.io/apimachinery/pkg/runtime"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/klog"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -118,9 +121,83 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 		})
 	}
 
-	ksr := obj.AsKnativeServiceResult(obj.ApplyKnativeService(ctx, r.Client, deps))
+	backendName := r.backendName(deps)
 
-	obj.ConfigureWebhookTrigger(wt, ksr)
+	backend, err := obj.NewWebhookTriggerBackend(backendName, r.activatorHost())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Remove any workloads created by a previously selected backend.
+	if err := r.deleteOtherBackends(ctx, deps, backendName); err != nil {
+		return ctrl.Result{}, errmark.MapLast(err, func(err error) error {
+			return fmt.Errorf("failed to delete stale services: %+v", err)
+		})
+	}
+
+	router, err := obj.NewWebhookTriggerRouter(r.Config.WebhookRouter, r.routerOptions())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Remove any routes created by a previously selected router.
+	if err := r.deleteOtherRouters(ctx, deps); err != nil {
+		return ctrl.Result{}, errmark.MapLast(err, func(err error) error {
+			return fmt.Errorf("failed to delete stale routes: %+v", err)
+		})
+	}
+
+	sr := obj.AsWebhookTriggerServiceResult(backend.ApplyService(ctx, r.Client, deps))
+
+	key := obj.WebhookTriggerServiceKey(deps)
+	publicKey, privateKey := key, obj.WebhookVerifierKey(key)
+
+	var vsr *obj.WebhookTriggerServiceResult
+	if wt.HasVerifier() {
+		vsr = obj.AsWebhookTriggerServiceResult(backend.ApplyVerifierService(ctx, r.Client, deps, sr.Service, r.Config.WebhookVerifierImage))
+		publicKey, privateKey = privateKey, publicKey
+	} else if err := backend.DeleteService(ctx, r.Client, obj.WebhookVerifierKey(key)); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Only the public service may be routed to.
+	if err := router.DeleteRoute(ctx, r.Client, privateKey); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	public := sr.Service
+	if vsr != nil {
+		public = vsr.Service
+	}
+
+	var rr *obj.WebhookTriggerRouteResult
+	if public != nil && public.URL() != "" {
+		if err := obj.CheckWebhookTriggerRoute(ctx, r.Client, deps, r.Config.WebhookDomain); err != nil {
+			if _, ok := err.(*obj.WebhookTriggerRouteConflictError); !ok {
+				return ctrl.Result{}, err
+			}
+
+			// Another webhook trigger owns the requested address, so we
+			// must not route to this one.
+			if err := router.DeleteRoute(ctx, r.Client, publicKey); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			rr = obj.AsWebhookTriggerRouteResult(nil, err)
+		} else {
+			rr = obj.AsWebhookTriggerRouteResult(router.ApplyRoute(ctx, r.Client, deps, public))
+		}
+	}
+
+	if err := r.replayWebhookRequest(ctx, deps, sr.Service); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	obj.ConfigureWebhookTrigger(wt, sr, vsr, rr)
+
+	if err := obj.ConfigureWebhookTriggerDeliveries(ctx, wt, deps.WebhookDeliveriesConfigMap); err != nil {
+		return ctrl.Result{}, err
+	}
 
 	if err := wt.PersistStatus(ctx, r.Client); err != nil {
 		return ctrl.Result{}, err
@@ -132,3 +209,132 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 
 	return ctrl.Result{}, nil
 }
+
+func (r *Reconciler) routerOptions() obj.WebhookTriggerRouterOptions {
+	return obj.WebhookTriggerRouterOptions{
+		Domain:           r.Config.WebhookDomain,
+		AmbassadorID:     r.Config.WebhookAmbassadorID,
+		IngressClassName: r.Config.WebhookIngressClassName,
+		Gateway:          r.Config.WebhookGatewayKey(),
+	}
+}
+
+func (r *Reconciler) deleteOtherRouters(ctx context.Context, deps *obj.WebhookTriggerDeps) error {
+	key := obj.WebhookTriggerServiceKey(deps)
+
+	selected := r.Config.WebhookRouter
+	if selected == "" {
+		selected = obj.WebhookTriggerRouterAmbassador
+	}
+
+	routers := map[string]obj.WebhookTriggerRouter{
+		obj.WebhookTriggerRouterAmbassador: &obj.AmbassadorWebhookTriggerRouter{},
+		obj.WebhookTriggerRouterIngress:    &obj.IngressWebhookTriggerRouter{},
+		obj.WebhookTriggerRouterGateway:    &obj.GatewayWebhookTriggerRouter{},
+	}
+	for name, router := range routers {
+		if name == selected {
+			continue
+		}
+
+		for _, k := range []client.ObjectKey{key, obj.WebhookVerifierKey(key)} {
+			if err := router.DeleteRoute(ctx, r.Client, k); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *Reconciler) activatorHost() string {
+	key, ok := r.Config.WebhookTriggerActivatorServiceKey()
+	if !ok {
+		return ""
+	}
+
+	return obj.ServiceHost(key)
+}
+
+// backendName returns the webhook trigger backend selected by the tenant,
+// falling back to the backend configured for the operator.
+func (r *Reconciler) backendName(deps *obj.WebhookTriggerDeps) relayv1beta1.TriggerBackend {
+	name := r.Config.WebhookTriggerBackend
+	if tb := deps.Tenant.Object.Spec.TriggerBackend; tb != "" {
+		name = tb
+	}
+
+	if name == "" {
+		name = relayv1beta1.TriggerBackendKnative
+	}
+
+	return name
+}
+
+func (r *Reconciler) deleteOtherBackends(ctx context.Context, deps *obj.WebhookTriggerDeps, selected relayv1beta1.TriggerBackend) error {
+	key := obj.WebhookTriggerServiceKey(deps)
+
+	for _, name := range []relayv1beta1.TriggerBackend{relayv1beta1.TriggerBackendKnative, relayv1beta1.TriggerBackendKubernetes} {
+		if name == selected {
+			continue
+		}
+
+		backend, err := obj.NewWebhookTriggerBackend(name, r.activatorHost())
+		if err != nil {
+			return err
+		}
+
+		for _, k := range []client.ObjectKey{key, obj.WebhookVerifierKey(key)} {
+			if err := backend.DeleteService(ctx, r.Client, k); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// replayWebhookRequest dispatches the captured request referenced by the
+// replay annotation of the webhook trigger to the trigger service. The
+// annotation is removed before the request is sent so that it is not sent
+// again, and the request itself is sent in the background so that a slow
+// trigger does not hold up reconciliation.
+func (r *Reconciler) replayWebhookRequest(ctx context.Context, deps *obj.WebhookTriggerDeps, upstream obj.WebhookTriggerService) error {
+	wt := deps.WebhookTrigger
+
+	id, found := wt.Object.GetAnnotations()[model.RelayReplayWebhookRequestAnnotation]
+	if !found {
+		return nil
+	} else if upstream == nil || !upstream.Ready() {
+		// Wait for the service to become ready.
+		return nil
+	}
+
+	wrm, err := obj.NewWebhookRequestManagerForWebhookTrigger(r.Client, deps)
+	if err != nil {
+		klog.Warningf("failed to replay request %q for WebhookTrigger %s: %+v", id, wt.Key, err)
+	}
+
+	delete(wt.Object.Annotations, model.RelayReplayWebhookRequestAnnotation)
+	if err := wt.Persist(ctx, r.Client); err != nil {
+		return err
+	}
+
+	if wrm != nil {
+		go r.sendWebhookRequestReplay(wt.Key, wrm, upstream.URL(), id)
+	}
+
+	return nil
+}
+
+func (r *Reconciler) sendWebhookRequestReplay(key client.ObjectKey, wrm *configmap.WebhookRequestManager, upstreamURL, id string) {
+	// Leave enough time to record the result after the request completes.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*obj.WebhookRequestReplayTimeout)
+	defer cancel()
+
+	if replay, err := obj.ReplayWebhookRequest(ctx, wrm, upstreamURL, id); err != nil {
+		klog.Warningf("failed to replay request %q for WebhookTrigger %s: %+v", id, key, err)
+	} else {
+		klog.Infof("replayed request %q for WebhookTrigger %s as %q with status %d", id, key, replay.ID, replay.StatusCode)
+	}
+}
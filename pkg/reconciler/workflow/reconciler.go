@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/puppetlabs/horsehead/v2/storage"
 	"github.com/puppetlabs/relay-core/pkg/authenticate"
 	"github.com/puppetlabs/relay-core/pkg/dependency"
 	"github.com/puppetlabs/relay-core/pkg/errmark"
+	"github.com/puppetlabs/relay-core/pkg/manager/configmap"
+	"github.com/puppetlabs/relay-core/pkg/manager/vault"
 	"github.com/puppetlabs/relay-core/pkg/obj"
+	"github.com/puppetlabs/relay-core/pkg/util/maskutil"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
@@ -19,6 +23,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const (
+	FinalizerName = "workflowrun.finalizers.controller.relay.sh"
+)
+
 type Reconciler struct {
 	*dependency.DependencyManager
 
@@ -68,8 +76,8 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 		return ctrl.Result{}, nil
 	}
 
-	if ts := wr.Object.GetDeletionTimestamp(); ts != nil && !ts.IsZero() {
-		return ctrl.Result{}, nil
+	if finalized, err := r.finalize(ctx, wr); err != nil || finalized {
+		return ctrl.Result{}, err
 	}
 
 	if len(wr.Object.Spec.Workflow.Steps) == 0 {
@@ -80,11 +88,14 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 		return ctrl.Result{}, nil
 	}
 
+	var deps *obj.WorkflowRunDeps
 	var pr *obj.PipelineRun
+	var tenantNotFound bool
 	err = r.metrics.trackDurationWithOutcome(metricWorkflowRunStartUpDuration, func() error {
 		// Configure and save all the infrastructure bits needed to create a
 		// Pipeline.
-		deps, err := obj.ApplyWorkflowRunDeps(
+		var err error
+		deps, err = obj.ApplyWorkflowRunDeps(
 			ctx,
 			r.Client,
 			wr,
@@ -101,6 +112,14 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 			})
 		}
 
+		// A run must not start without the secrets and event sinks of the
+		// tenant it refers to, so it fails if the tenant cannot be found. A
+		// run that has already started carries on.
+		if !obj.ConfigureWorkflowRunTenant(wr, deps.Tenant) && wr.Object.Status.StartTime == nil {
+			tenantNotFound = true
+			return nil
+		}
+
 		// Configure and save the underlying Tekton Pipeline.
 		pipeline, err := obj.ApplyPipeline(ctx, r.Client, deps)
 		if err != nil {
@@ -121,10 +140,27 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 	})
 	if err != nil {
 		return ctrl.Result{}, err
+	} else if tenantNotFound {
+		if err := wr.Fail(ctx, r.Client); err != nil {
+			return ctrl.Result{}, errmark.MapLast(err, func(err error) error {
+				return fmt.Errorf("failed to persist WorkflowRun: %+v", err)
+			})
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	// Keep the tokens of running steps fresh and remove the tokens of
+	// completed steps.
+	renewAt, err := obj.RenewWorkflowRunStepTokens(ctx, r.Client, deps)
+	if err != nil {
+		return ctrl.Result{}, errmark.MapLast(err, func(err error) error {
+			return fmt.Errorf("failed to renew step tokens: %+v", err)
+		})
 	}
 
 	err = r.metrics.trackDurationWithOutcome(metricWorkflowRunLogUploadDuration, func() error {
-		r.uploadLogs(ctx, wr, pr)
+		r.uploadLogs(ctx, wr, pr, deps)
 		return nil
 	})
 	if err != nil {
@@ -139,10 +175,34 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error)
 		})
 	}
 
+	// Changes to the pods of the run trigger reconciliation, so we only need
+	// to come back when a token is due for renewal.
+	if !renewAt.IsZero() {
+		return ctrl.Result{RequeueAfter: time.Until(renewAt)}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
-func (r *Reconciler) uploadLogs(ctx context.Context, wr *obj.WorkflowRun, plr *obj.PipelineRun) {
+// finalize removes the step outputs of a deleted workflow run from blob
+// storage. They are not owned by the run, so they have to be removed while the
+// config map referring to them still exists. The run only carries a finalizer
+// once a step has stored an output there.
+func (r *Reconciler) finalize(ctx context.Context, wr *obj.WorkflowRun) (bool, error) {
+	if wr.Finalizing() {
+		if !obj.HasFinalizer(&wr.Object.ObjectMeta, FinalizerName) {
+			return true, nil
+		}
+	} else if ok, err := obj.WorkflowRunHasStepOutputBlobs(ctx, r.Client, wr); err != nil || !ok {
+		return false, err
+	}
+
+	return obj.Finalize(ctx, r.Client, FinalizerName, wr, func() error {
+		return obj.DeleteWorkflowRunStepOutputBlobs(ctx, r.Client, wr, r.StorageClient)
+	})
+}
+
+func (r *Reconciler) uploadLogs(ctx context.Context, wr *obj.WorkflowRun, plr *obj.PipelineRun, deps *obj.WorkflowRunDeps) {
 	podNames := make(map[string]string)
 
 	for name, tr := range plr.Object.Status.TaskRuns {
@@ -174,7 +234,13 @@ func (r *Reconciler) uploadLogs(ctx context.Context, wr *obj.WorkflowRun, plr *o
 
 		klog.Infof("WorkflowRun %s step %q is complete, uploading logs for pod %s", wr.Key, name, podName)
 
-		logKey, err := r.uploadLog(ctx, plr.Key.Namespace, podName, "step-step")
+		masked, err := r.maskedValues(ctx, wr, deps, name)
+		if err != nil {
+			klog.Warningf("failed to determine values to mask for WorkflowRun %s step %q, not uploading log: %+v", wr.Key, name, err)
+			continue
+		}
+
+		logKey, err := r.uploadLog(ctx, plr.Key.Namespace, podName, "step-step", masked)
 		if err != nil {
 			klog.Warningf("failed to upload log for WorkflowRun %s step %q: %+v", wr.Key, name, err)
 		}
@@ -184,7 +250,52 @@ func (r *Reconciler) uploadLogs(ctx context.Context, wr *obj.WorkflowRun, plr *o
 	}
 }
 
-func (r *Reconciler) uploadLog(ctx context.Context, namespace string, podName string, containerName string) (string, error) {
+// maskedValues returns the values to mask in the log of the given step, along
+// with their common encodings. They include the values of every sensitive
+// output in the run, since any step that has already run may have set one,
+// and any other confidential values the metadata API recorded for the step.
+func (r *Reconciler) maskedValues(ctx context.Context, wr *obj.WorkflowRun, deps *obj.WorkflowRunDeps, stepName string) ([]string, error) {
+	step := obj.ModelStepFromName(wr, stepName)
+	cm := configmap.NewControllerRuntimeConfigMap(r.Client, deps.MutableConfigMap.Key)
+
+	var enc configmap.Encrypter
+	var opts []configmap.StepOutputManagerOption
+	if r.VaultClient != nil {
+		enc = vault.NewTransitClient(
+			r.VaultClient,
+			r.Config.VaultTransitPath,
+			r.Config.VaultTransitKey,
+			vault.TransitClientWithContext(authenticate.VaultTransitNamespaceContext(string(deps.Namespace.Object.GetUID()))),
+		)
+
+		opts = append(opts, configmap.StepOutputManagerWithEncrypter(enc))
+	}
+
+	// Without an encrypter, this fails if the metadata API recorded any
+	// values for the step, so we never upload a log we could not redact.
+	values, err := configmap.NewRedactionManager(step, cm, enc).List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// A step can list the outputs of every step in the run.
+	outputs, err := configmap.NewStepOutputManager(step, cm, opts...).List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, output := range outputs {
+		if !output.Sensitive {
+			continue
+		}
+
+		values = append(values, maskutil.Values(output.Value)...)
+	}
+
+	return maskutil.WithEncodings(values), nil
+}
+
+func (r *Reconciler) uploadLog(ctx context.Context, namespace string, podName string, containerName string, masked []string) (string, error) {
 	key := fmt.Sprintf("%s/%s/%s", namespace, podName, containerName)
 
 	// XXX: We can't do this with the dynamic client yet.
@@ -202,9 +313,13 @@ func (r *Reconciler) uploadLog(ctx context.Context, namespace string, podName st
 	}
 
 	err = r.StorageClient.Put(ctx, key, func(w io.Writer) error {
-		_, err := io.Copy(w, rc)
+		mw := maskutil.NewWriter(w, masked)
+
+		if _, err := io.Copy(mw, rc); err != nil {
+			return err
+		}
 
-		return err
+		return mw.Close()
 	}, storageOpts)
 	if err != nil {
 		return "", err
@@ -0,0 +1,65 @@
+package maskutil
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+)
+
+// Values returns the strings to mask for a value of a type produced by decoding
+// JSON. A string is masked as-is. Any other value is masked both as a whole,
+// encoded as JSON, and by each string it contains.
+func Values(value interface{}) []string {
+	if s, ok := value.(string); ok {
+		return []string{s}
+	}
+
+	var l []string
+	if b, err := json.Marshal(value); err == nil {
+		l = append(l, string(b))
+	}
+
+	return append(l, Strings(value)...)
+}
+
+// Strings returns every string in a value of a type produced by decoding JSON.
+func Strings(value interface{}) []string {
+	switch vt := value.(type) {
+	case string:
+		return []string{vt}
+	case []interface{}:
+		var l []string
+		for _, v := range vt {
+			l = append(l, Strings(v)...)
+		}
+		return l
+	case map[string]interface{}:
+		var l []string
+		for _, v := range vt {
+			l = append(l, Strings(v)...)
+		}
+		return l
+	default:
+		return nil
+	}
+}
+
+// WithEncodings returns the given values along with their base64 and URL
+// encodings, which steps commonly produce when passing values to other tools.
+func WithEncodings(values []string) []string {
+	l := make([]string, 0, len(values)*5)
+	for _, v := range values {
+		l = append(
+			l,
+			v,
+			// Padding is omitted so that the value also matches when it is
+			// encoded without it.
+			base64.RawStdEncoding.EncodeToString([]byte(v)),
+			base64.RawURLEncoding.EncodeToString([]byte(v)),
+			url.QueryEscape(v),
+			url.PathEscape(v),
+		)
+	}
+
+	return l
+}
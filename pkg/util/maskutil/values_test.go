@@ -0,0 +1,26 @@
+package maskutil_test
+
+import (
+	"testing"
+
+	"github.com/puppetlabs/relay-core/pkg/util/maskutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValues(t *testing.T) {
+	assert.Equal(t, []string{"hunter2"}, maskutil.Values("hunter2"))
+	assert.Equal(t, []string{`["a",1]`, "a"}, maskutil.Values([]interface{}{"a", 1.0}))
+	assert.Equal(t, []string{`{"k":{"v":"b"}}`, "b"}, maskutil.Values(map[string]interface{}{
+		"k": map[string]interface{}{"v": "b"},
+	}))
+}
+
+func TestWithEncodings(t *testing.T) {
+	assert.Equal(t, []string{
+		"a b/c?",
+		"YSBiL2M/",
+		"YSBiL2M_",
+		"a+b%2Fc%3F",
+		"a%20b%2Fc%3F",
+	}, maskutil.WithEncodings([]string{"a b/c?"}))
+}
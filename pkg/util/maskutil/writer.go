@@ -0,0 +1,106 @@
+package maskutil
+
+import (
+	"bytes"
+	"io"
+	"sort"
+)
+
+// Mask is the text that replaces masked values.
+const Mask = "***"
+
+// Writer replaces every occurrence of a set of values in the data written to
+// it with Mask before passing it to an underlying writer. Values may span
+// multiple calls to Write, so the last few bytes written are held back until
+// more data arrives or the writer is closed.
+type Writer struct {
+	delegate io.Writer
+	values   [][]byte
+	hold     int
+	buf      []byte
+}
+
+var _ io.WriteCloser = &Writer{}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	if err := w.flush(len(w.buf) - w.hold); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close writes any data held back. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	return w.flush(len(w.buf))
+}
+
+func (w *Writer) flush(limit int) error {
+	var out bytes.Buffer
+
+	start, i := 0, 0
+	for i < limit {
+		v := w.match(w.buf[i:])
+		if v == nil {
+			i++
+			continue
+		}
+
+		out.Write(w.buf[start:i])
+		out.WriteString(Mask)
+
+		i += len(v)
+		start = i
+	}
+
+	out.Write(w.buf[start:i])
+
+	w.buf = w.buf[i:]
+
+	if out.Len() == 0 {
+		return nil
+	}
+
+	_, err := w.delegate.Write(out.Bytes())
+	return err
+}
+
+func (w *Writer) match(p []byte) []byte {
+	// Values are sorted longest first, so the longest match wins.
+	for _, v := range w.values {
+		if bytes.HasPrefix(p, v) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// NewWriter creates a writer that masks the given values. Empty values are
+// ignored.
+func NewWriter(delegate io.Writer, values []string) *Writer {
+	w := &Writer{
+		delegate: delegate,
+	}
+
+	seen := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		if _, found := seen[v]; found || v == "" {
+			continue
+		}
+		seen[v] = struct{}{}
+
+		w.values = append(w.values, []byte(v))
+		if len(v)-1 > w.hold {
+			w.hold = len(v) - 1
+		}
+	}
+
+	sort.Slice(w.values, func(i, j int) bool {
+		return len(w.values[i]) > len(w.values[j])
+	})
+
+	return w
+}
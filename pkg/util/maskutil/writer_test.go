@@ -0,0 +1,73 @@
+package maskutil_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/puppetlabs/relay-core/pkg/util/maskutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Values   []string
+		Input    string
+		Expected string
+	}{
+		{
+			Name:     "No values",
+			Input:    "hello world",
+			Expected: "hello world",
+		},
+		{
+			Name:     "Single value",
+			Values:   []string{"hunter2"},
+			Input:    "my password is hunter2, really hunter2",
+			Expected: "my password is ***, really ***",
+		},
+		{
+			Name:     "Longest match wins",
+			Values:   []string{"abc", "abcdef"},
+			Input:    "xabcdefabcx",
+			Expected: "x******x",
+		},
+		{
+			Name:     "Empty values are ignored",
+			Values:   []string{""},
+			Input:    "hello",
+			Expected: "hello",
+		},
+		{
+			Name:     "Value at end of input",
+			Values:   []string{"secret"},
+			Input:    "the secret",
+			Expected: "the ***",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			// Write the input one byte at a time to check that values are
+			// matched across writes, and all at once.
+			for _, size := range []int{1, len(test.Input)} {
+				var buf bytes.Buffer
+
+				w := maskutil.NewWriter(&buf, test.Values)
+				for i := 0; i < len(test.Input); i += size {
+					end := i + size
+					if end > len(test.Input) {
+						end = len(test.Input)
+					}
+
+					n, err := w.Write([]byte(test.Input[i:end]))
+					require.NoError(t, err)
+					require.Equal(t, end-i, n)
+				}
+				require.NoError(t, w.Close())
+
+				assert.Equal(t, test.Expected, buf.String(), "write size %d", size)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package ratelimitutil
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// NewLimiter creates a limiter that allows the given number of requests per
+// second. If burst is not positive, it is the same as the rate. If the rate is
+// not positive, the limiter allows every request.
+func NewLimiter(rps, burst int32) *rate.Limiter {
+	if rps <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+
+	if burst <= 0 {
+		burst = rps
+	}
+
+	return rate.NewLimiter(rate.Limit(rps), int(burst))
+}
+
+// Allow takes a token from the limiter if one is available now. Otherwise, it
+// returns false and the time to wait before a token is available.
+func Allow(l *rate.Limiter) (time.Duration, bool) {
+	now := time.Now()
+
+	r := l.ReserveN(now, 1)
+	if !r.OK() {
+		// The burst is too small to ever allow a request.
+		return time.Second, false
+	}
+
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return delay, false
+	}
+
+	return 0, true
+}
+
+// RetryAfterSeconds converts a delay to a number of seconds suitable for the
+// Retry-After header, which must be a positive integer.
+func RetryAfterSeconds(delay time.Duration) int64 {
+	secs := int64(math.Ceil(delay.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+
+	return secs
+}
+
+// SetRetryAfter sets the Retry-After header of a response to the given delay.
+func SetRetryAfter(w http.ResponseWriter, delay time.Duration) {
+	w.Header().Set("Retry-After", strconv.FormatInt(RetryAfterSeconds(delay), 10))
+}
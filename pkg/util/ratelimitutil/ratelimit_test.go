@@ -0,0 +1,47 @@
+package ratelimitutil_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/util/ratelimitutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllow(t *testing.T) {
+	l := ratelimitutil.NewLimiter(1, 2)
+
+	for i := 0; i < 2; i++ {
+		_, ok := ratelimitutil.Allow(l)
+		assert.True(t, ok)
+	}
+
+	delay, ok := ratelimitutil.Allow(l)
+	assert.False(t, ok)
+	assert.True(t, delay > 0 && delay <= time.Second, "unexpected delay %s", delay)
+
+	// A rejected request must not consume a token.
+	delay2, ok := ratelimitutil.Allow(l)
+	assert.False(t, ok)
+	assert.True(t, delay2 <= delay)
+}
+
+func TestNewLimiterUnlimited(t *testing.T) {
+	l := ratelimitutil.NewLimiter(0, 0)
+
+	for i := 0; i < 100; i++ {
+		_, ok := ratelimitutil.Allow(l)
+		assert.True(t, ok)
+	}
+}
+
+func TestSetRetryAfter(t *testing.T) {
+	w := httptest.NewRecorder()
+	ratelimitutil.SetRetryAfter(w, 1500*time.Millisecond)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	w = httptest.NewRecorder()
+	ratelimitutil.SetRetryAfter(w, time.Millisecond)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+}
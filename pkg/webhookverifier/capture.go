@@ -0,0 +1,150 @@
+package webhookverifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/model"
+)
+
+// RedactedHeaderValue replaces the values of headers that may contain
+// credentials in captured requests.
+const RedactedHeaderValue = "[REDACTED]"
+
+var (
+	redactedHeaders = []string{
+		"Authorization",
+		"Cookie",
+		"Proxy-Authorization",
+	}
+
+	redactedHeaderFragments = []string{
+		"key",
+		"password",
+		"secret",
+		"signature",
+		"token",
+	}
+)
+
+// RedactHeader returns a copy of the given headers with the values of any
+// headers that may contain credentials replaced, including the additional
+// headers given.
+func RedactHeader(h http.Header, names ...string) http.Header {
+	redact := make(map[string]bool, len(redactedHeaders)+len(names))
+	for _, name := range append(append([]string{}, redactedHeaders...), names...) {
+		if name != "" {
+			redact[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+
+	redacted := make(http.Header, len(h))
+	for name, values := range h {
+		if !redact[name] && !redactedHeaderFragment(name) {
+			redacted[name] = append([]string{}, values...)
+			continue
+		}
+
+		redacted[name] = make([]string, len(values))
+		for i := range values {
+			redacted[name][i] = RedactedHeaderValue
+		}
+	}
+
+	return redacted
+}
+
+func redactedHeaderFragment(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, fragment := range redactedHeaderFragments {
+		if strings.Contains(name, fragment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Recorder stores requests handled by the handler.
+type Recorder interface {
+	Record(ctx context.Context, req *model.WebhookRequest) error
+}
+
+type metadataAPIWebhookRequestEnvelope struct {
+	ID            string      `json:"id,omitempty"`
+	ReceivedAt    time.Time   `json:"received_at"`
+	CompletedAt   time.Time   `json:"completed_at"`
+	Method        string      `json:"method"`
+	URL           string      `json:"url"`
+	Header        http.Header `json:"header"`
+	Body          []byte      `json:"body"`
+	BodyTruncated bool        `json:"body_truncated"`
+	StatusCode    int         `json:"status_code"`
+	Error         string      `json:"error,omitempty"`
+}
+
+// MetadataAPIRecorder sends handled requests to the metadata API, which
+// updates the delivery statistics of the webhook trigger and stores captured
+// requests on its behalf.
+type MetadataAPIRecorder struct {
+	url    *url.URL
+	client *http.Client
+}
+
+var _ Recorder = &MetadataAPIRecorder{}
+
+func (mar *MetadataAPIRecorder) Record(ctx context.Context, req *model.WebhookRequest) error {
+	b, err := json.Marshal(&metadataAPIWebhookRequestEnvelope{
+		ID:            req.ID,
+		ReceivedAt:    req.ReceivedAt,
+		CompletedAt:   req.CompletedAt,
+		Method:        req.Method,
+		URL:           req.URL,
+		Header:        req.Header,
+		Body:          req.Body,
+		BodyTruncated: req.BodyTruncated,
+		StatusCode:    req.StatusCode,
+		Error:         req.Error,
+	})
+	if err != nil {
+		return err
+	}
+
+	u := *mar.url
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/webhook-requests"
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+
+	resp, err := mar.client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("received HTTP %d from metadata API", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// NewMetadataAPIRecorder creates a recorder for the metadata API at the given
+// URL. The metadata API authenticates the proxy using the token issued to the
+// webhook verifier, which is distinct from the token of the trigger.
+func NewMetadataAPIRecorder(u *url.URL) *MetadataAPIRecorder {
+	return &MetadataAPIRecorder{
+		url:    u,
+		client: http.DefaultClient,
+	}
+}
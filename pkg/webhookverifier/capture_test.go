@@ -0,0 +1,133 @@
+package webhookverifier_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/puppetlabs/relay-core/pkg/webhookverifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorderFunc func(ctx context.Context, req *model.WebhookRequest) error
+
+func (rf recorderFunc) Record(ctx context.Context, req *model.WebhookRequest) error {
+	return rf(ctx, req)
+}
+
+func TestRedactHeader(t *testing.T) {
+	h := http.Header{
+		"Authorization":       []string{"Bearer abc"},
+		"Content-Type":        []string{"application/json"},
+		"X-Gitlab-Token":      []string{"s3cr3t"},
+		"X-Hub-Signature-256": []string{"sha256=abc"},
+		"X-Custom":            []string{"a", "b"},
+	}
+
+	redacted := webhookverifier.RedactHeader(h, "x-custom")
+	assert.Equal(t, http.Header{
+		"Authorization":       []string{webhookverifier.RedactedHeaderValue},
+		"Content-Type":        []string{"application/json"},
+		"X-Gitlab-Token":      []string{webhookverifier.RedactedHeaderValue},
+		"X-Hub-Signature-256": []string{webhookverifier.RedactedHeaderValue},
+		"X-Custom":            []string{webhookverifier.RedactedHeaderValue, webhookverifier.RedactedHeaderValue},
+	}, redacted)
+
+	// The original headers are left alone.
+	assert.Equal(t, "Bearer abc", h.Get("Authorization"))
+}
+
+func TestHandlerCapture(t *testing.T) {
+	requestIDs := make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestIDs <- r.Header.Get(model.WebhookRequestIDHeader)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer upstream.Close()
+
+	u, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+
+	captured := make(chan *model.WebhookRequest, 1)
+	recorder := recorderFunc(func(ctx context.Context, req *model.WebhookRequest) error {
+		captured <- req
+		return nil
+	})
+
+	s := httptest.NewServer(webhookverifier.NewHandler(nil, u,
+		webhookverifier.HandlerWithRecorder(recorder),
+		webhookverifier.HandlerWithCapture(),
+		webhookverifier.HandlerWithMaxCaptureBodyBytes(4),
+	))
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/hook?a=b", strings.NewReader("payload"))
+	require.NoError(t, err)
+	req.Header.Set("X-Gitlab-Token", "s3cr3t")
+	req.Header.Set(model.WebhookRequestIDHeader, "spoofed")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	select {
+	case wr := <-captured:
+		assert.Equal(t, http.MethodPost, wr.Method)
+		assert.Equal(t, "/hook?a=b", wr.URL)
+		assert.Equal(t, webhookverifier.RedactedHeaderValue, wr.Header.Get("X-Gitlab-Token"))
+		assert.Equal(t, []byte("payl"), wr.Body)
+		assert.True(t, wr.BodyTruncated)
+		assert.Equal(t, http.StatusAccepted, wr.StatusCode)
+		assert.False(t, wr.CompletedAt.Before(wr.ReceivedAt))
+
+		// The trigger receives the ID the request is recorded with, not the
+		// one sent by the client.
+		assert.NotEmpty(t, wr.ID)
+		assert.NotEqual(t, "spoofed", wr.ID)
+		assert.Equal(t, wr.ID, <-requestIDs)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "request was not captured")
+	}
+}
+
+func TestHandlerRecordFailure(t *testing.T) {
+	cfg, err := webhookverifier.NewConfigFromPreset(webhookverifier.PresetGitLab)
+	require.NoError(t, err)
+	cfg.Secret = []byte("s3cr3t")
+
+	u, err := url.Parse("http://127.0.0.1:1")
+	require.NoError(t, err)
+
+	recorded := make(chan *model.WebhookRequest, 1)
+	recorder := recorderFunc(func(ctx context.Context, req *model.WebhookRequest) error {
+		recorded <- req
+		return nil
+	})
+
+	s := httptest.NewServer(webhookverifier.NewHandler(&cfg, u, webhookverifier.HandlerWithRecorder(recorder)))
+	defer s.Close()
+
+	resp, err := http.Post(s.URL, "application/json", strings.NewReader("payload"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	select {
+	case wr := <-recorded:
+		assert.Equal(t, http.StatusUnauthorized, wr.StatusCode)
+		assert.Contains(t, wr.Error, "failed verification")
+
+		// Without capture, only the outcome of the request is reported.
+		assert.Empty(t, wr.Header)
+		assert.Empty(t, wr.Body)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "request was not recorded")
+	}
+}
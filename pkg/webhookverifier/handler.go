@@ -0,0 +1,237 @@
+package webhookverifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/puppetlabs/relay-core/pkg/util/ratelimitutil"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultMaxBodyBytes is the largest request body the handler will buffer
+	// for verification.
+	DefaultMaxBodyBytes = 10 * 1024 * 1024
+
+	// DefaultMaxCaptureBodyBytes is the largest part of a request body kept
+	// when a request is captured.
+	DefaultMaxCaptureBodyBytes = 16 * 1024
+
+	// DefaultRecordTimeout is the maximum amount of time to spend recording a
+	// handled request.
+	DefaultRecordTimeout = 10 * time.Second
+)
+
+type Handler struct {
+	config              *Config
+	proxy               *httputil.ReverseProxy
+	maxBodyBytes        int64
+	limiter             *rate.Limiter
+	recorder            Recorder
+	capture             bool
+	maxCaptureBodyBytes int
+}
+
+var _ http.Handler = &Handler{}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	receivedAt := time.Now()
+
+	// Requests over the rate limit are not reported to the recorder so that a
+	// flood of requests does not pass through to the metadata API.
+	if h.limiter != nil {
+		if delay, ok := ratelimitutil.Allow(h.limiter); !ok {
+			ratelimitutil.SetRetryAfter(w, delay)
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodyBytes))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// The trigger can send the request ID back with the events it emits so
+	// that they can be attached to this request. Any ID set by the sender is
+	// replaced.
+	id := uuid.New().String()
+
+	sw := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+	if h.recorder != nil {
+		defer func() {
+			h.record(id, r, body, sw, receivedAt)
+		}()
+	}
+
+	if h.config != nil {
+		if err := h.config.Verify(r.Header, body); err != nil {
+			log(r.Context()).Warn("rejecting webhook request that failed verification", "error", err)
+			sw.err = fmt.Sprintf("request failed verification: %+v", err)
+			http.Error(sw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Set(model.WebhookRequestIDHeader, id)
+
+	h.proxy.ServeHTTP(sw, r)
+}
+
+func (h *Handler) record(id string, r *http.Request, body []byte, sw *statusResponseWriter, receivedAt time.Time) {
+	req := &model.WebhookRequest{
+		ID:          id,
+		ReceivedAt:  receivedAt,
+		CompletedAt: time.Now(),
+		Method:      r.Method,
+		URL:         r.URL.RequestURI(),
+		StatusCode:  sw.status,
+		Error:       sw.err,
+	}
+
+	if h.capture {
+		req.Header = RedactHeader(r.Header, h.redactedHeaders()...)
+		req.Body = body
+
+		if len(req.Body) > h.maxCaptureBodyBytes {
+			req.Body = req.Body[:h.maxCaptureBodyBytes]
+			req.BodyTruncated = true
+		}
+	}
+
+	// Record the request in the background so the sender does not wait on
+	// us.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultRecordTimeout)
+		defer cancel()
+
+		if err := h.recorder.Record(ctx, req); err != nil {
+			log(ctx).Warn("failed to record webhook request", "error", err)
+		}
+	}()
+}
+
+func (h *Handler) redactedHeaders() []string {
+	if h.config == nil {
+		return nil
+	}
+
+	return []string{h.config.Header}
+}
+
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+
+	// err describes why the request failed, if known.
+	err string
+}
+
+func (sw *statusResponseWriter) WriteHeader(status int) {
+	if !sw.wrote {
+		sw.status = status
+		sw.wrote = true
+	}
+
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusResponseWriter) Write(b []byte) (int, error) {
+	sw.wrote = true
+	return sw.ResponseWriter.Write(b)
+}
+
+func (sw *statusResponseWriter) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+type HandlerOption func(h *Handler)
+
+func HandlerWithMaxBodyBytes(n int64) HandlerOption {
+	return func(h *Handler) {
+		h.maxBodyBytes = n
+	}
+}
+
+// HandlerWithRateLimit rejects requests over the given rate with a 429 Too
+// Many Requests response. If burst is not positive, it is the same as the
+// rate.
+func HandlerWithRateLimit(rps, burst int32) HandlerOption {
+	return func(h *Handler) {
+		h.limiter = ratelimitutil.NewLimiter(rps, burst)
+	}
+}
+
+// HandlerWithRecorder reports every request handled, including requests that
+// fail verification, to the given recorder.
+func HandlerWithRecorder(recorder Recorder) HandlerOption {
+	return func(h *Handler) {
+		h.recorder = recorder
+	}
+}
+
+// HandlerWithCapture includes the headers and body of each request reported
+// to the recorder.
+func HandlerWithCapture() HandlerOption {
+	return func(h *Handler) {
+		h.capture = true
+	}
+}
+
+func HandlerWithMaxCaptureBodyBytes(n int) HandlerOption {
+	return func(h *Handler) {
+		h.maxCaptureBodyBytes = n
+	}
+}
+
+// NewHandler creates a handler that verifies requests using the given
+// configuration and forwards verified requests to the upstream URL. If the
+// configuration is nil, every request is forwarded.
+func NewHandler(cfg *Config, upstream *url.URL, opts ...HandlerOption) *Handler {
+	proxy := httputil.NewSingleHostReverseProxy(upstream)
+
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+
+		// Knative and the activator route requests using the host name, so we
+		// must not forward the original one.
+		r.Host = upstream.Host
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log(r.Context()).Warn("failed to forward webhook request", "error", err)
+
+		if sw, ok := w.(*statusResponseWriter); ok {
+			sw.err = fmt.Sprintf("failed to forward request to trigger: %+v", err)
+		}
+
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	h := &Handler{
+		config:              cfg,
+		proxy:               proxy,
+		maxBodyBytes:        DefaultMaxBodyBytes,
+		maxCaptureBodyBytes: DefaultMaxCaptureBodyBytes,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
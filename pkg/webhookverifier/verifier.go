@@ -0,0 +1,186 @@
+// Package webhookverifier checks the signatures of incoming webhook requests
+// before they are passed on to a webhook trigger.
+package webhookverifier
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownPreset    = errors.New("webhookverifier: unknown preset")
+	ErrUnknownAlgorithm = errors.New("webhookverifier: unknown algorithm")
+	ErrMissingHeader    = errors.New("webhookverifier: header name is required")
+	ErrMissingSecret    = errors.New("webhookverifier: secret is required")
+
+	ErrMissingSignature = errors.New("webhookverifier: request is not signed")
+	ErrInvalidSignature = errors.New("webhookverifier: request signature does not match")
+	ErrInvalidTimestamp = errors.New("webhookverifier: request timestamp is missing or outside the allowed window")
+)
+
+type Algorithm string
+
+const (
+	AlgorithmHMACSHA1   Algorithm = "HMAC-SHA1"
+	AlgorithmHMACSHA256 Algorithm = "HMAC-SHA256"
+	AlgorithmToken      Algorithm = "Token"
+)
+
+type Preset string
+
+const (
+	PresetGitHub Preset = "GitHub"
+	PresetGitLab Preset = "GitLab"
+	PresetSlack  Preset = "Slack"
+)
+
+// DefaultMaxTimestampSkew is the maximum age of a request for signing schemes
+// that include a timestamp.
+const DefaultMaxTimestampSkew = 5 * time.Minute
+
+type Config struct {
+	// Algorithm is the method used to compute the signature.
+	Algorithm Algorithm
+
+	// Header is the name of the request header containing the signature.
+	Header string
+
+	// Prefix is stripped from the header value before comparison.
+	Prefix string
+
+	// Secret is the shared secret used to sign requests.
+	Secret []byte
+
+	// TimestampHeader, if set, is the name of a request header containing the
+	// UNIX time at which the request was signed. The signed payload is then
+	// "<version>:<timestamp>:<body>", as used by Slack.
+	TimestampHeader string
+
+	// SignatureVersion is the version component of the signed payload when a
+	// timestamp header is used.
+	SignatureVersion string
+
+	// MaxTimestampSkew is the maximum difference between the request
+	// timestamp and the current time.
+	MaxTimestampSkew time.Duration
+}
+
+// Validate checks that the configuration is usable.
+func (c Config) Validate() error {
+	switch c.Algorithm {
+	case AlgorithmHMACSHA1, AlgorithmHMACSHA256, AlgorithmToken:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownAlgorithm, c.Algorithm)
+	}
+
+	if c.Header == "" {
+		return ErrMissingHeader
+	}
+
+	if len(c.Secret) == 0 {
+		return ErrMissingSecret
+	}
+
+	return nil
+}
+
+// Verify checks the signature of a request given its headers and body.
+func (c Config) Verify(h http.Header, body []byte) error {
+	return c.verifyAt(h, body, time.Now())
+}
+
+func (c Config) verifyAt(h http.Header, body []byte, now time.Time) error {
+	sig := h.Get(c.Header)
+	if sig == "" || !strings.HasPrefix(sig, c.Prefix) {
+		return ErrMissingSignature
+	}
+	sig = strings.TrimPrefix(sig, c.Prefix)
+
+	if c.Algorithm == AlgorithmToken {
+		if subtle.ConstantTimeCompare([]byte(sig), c.Secret) != 1 {
+			return ErrInvalidSignature
+		}
+
+		return nil
+	}
+
+	var fn func() hash.Hash
+	switch c.Algorithm {
+	case AlgorithmHMACSHA1:
+		fn = sha1.New
+	case AlgorithmHMACSHA256:
+		fn = sha256.New
+	default:
+		return ErrUnknownAlgorithm
+	}
+
+	mac := hmac.New(fn, c.Secret)
+
+	if c.TimestampHeader != "" {
+		ts := h.Get(c.TimestampHeader)
+
+		secs, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return ErrInvalidTimestamp
+		}
+
+		skew := c.MaxTimestampSkew
+		if skew <= 0 {
+			skew = DefaultMaxTimestampSkew
+		}
+
+		if d := now.Sub(time.Unix(secs, 0)); d > skew || d < -skew {
+			return ErrInvalidTimestamp
+		}
+
+		fmt.Fprintf(mac, "%s:%s:", c.SignatureVersion, ts)
+	}
+
+	mac.Write(body)
+
+	expected, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(expected, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// NewConfigFromPreset returns the configuration used by a well-known webhook
+// provider. The secret must still be set by the caller.
+func NewConfigFromPreset(p Preset) (Config, error) {
+	switch p {
+	case PresetGitHub:
+		return Config{
+			Algorithm: AlgorithmHMACSHA256,
+			Header:    "X-Hub-Signature-256",
+			Prefix:    "sha256=",
+		}, nil
+	case PresetGitLab:
+		return Config{
+			Algorithm: AlgorithmToken,
+			Header:    "X-Gitlab-Token",
+		}, nil
+	case PresetSlack:
+		return Config{
+			Algorithm:        AlgorithmHMACSHA256,
+			Header:           "X-Slack-Signature",
+			Prefix:           "v0=",
+			TimestampHeader:  "X-Slack-Request-Timestamp",
+			SignatureVersion: "v0",
+			MaxTimestampSkew: DefaultMaxTimestampSkew,
+		}, nil
+	default:
+		return Config{}, fmt.Errorf("%w: %q", ErrUnknownPreset, p)
+	}
+}
@@ -0,0 +1,202 @@
+package webhookverifier_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/puppetlabs/relay-core/pkg/webhookverifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sign(t *testing.T, alg webhookverifier.Algorithm, secret, payload string) string {
+	fn := sha256.New
+	if alg == webhookverifier.AlgorithmHMACSHA1 {
+		fn = sha1.New
+	}
+
+	mac := hmac.New(fn, []byte(secret))
+	_, err := mac.Write([]byte(payload))
+	require.NoError(t, err)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func presetConfig(t *testing.T, p webhookverifier.Preset, secret string) webhookverifier.Config {
+	cfg, err := webhookverifier.NewConfigFromPreset(p)
+	require.NoError(t, err)
+
+	cfg.Secret = []byte(secret)
+	require.NoError(t, cfg.Validate())
+
+	return cfg
+}
+
+func TestVerifyGitHub(t *testing.T) {
+	cfg := presetConfig(t, webhookverifier.PresetGitHub, "s3cr3t")
+	body := []byte(`{"action":"opened"}`)
+
+	h := http.Header{}
+	assert.Equal(t, webhookverifier.ErrMissingSignature, cfg.Verify(h, body))
+
+	h.Set("X-Hub-Signature-256", "sha256="+sign(t, webhookverifier.AlgorithmHMACSHA256, "s3cr3t", string(body)))
+	assert.NoError(t, cfg.Verify(h, body))
+
+	h.Set("X-Hub-Signature-256", "sha256="+sign(t, webhookverifier.AlgorithmHMACSHA256, "wrong", string(body)))
+	assert.Equal(t, webhookverifier.ErrInvalidSignature, cfg.Verify(h, body))
+
+	h.Set("X-Hub-Signature-256", "sha256=not-hex")
+	assert.Equal(t, webhookverifier.ErrInvalidSignature, cfg.Verify(h, body))
+}
+
+func TestVerifyHMACSHA1(t *testing.T) {
+	cfg := webhookverifier.Config{
+		Algorithm: webhookverifier.AlgorithmHMACSHA1,
+		Header:    "X-Signature",
+		Prefix:    "sha1=",
+		Secret:    []byte("s3cr3t"),
+	}
+	require.NoError(t, cfg.Validate())
+
+	body := []byte("hello")
+
+	h := http.Header{}
+	h.Set("X-Signature", sign(t, webhookverifier.AlgorithmHMACSHA1, "s3cr3t", string(body)))
+	assert.Equal(t, webhookverifier.ErrMissingSignature, cfg.Verify(h, body))
+
+	h.Set("X-Signature", "sha1="+sign(t, webhookverifier.AlgorithmHMACSHA1, "s3cr3t", string(body)))
+	assert.NoError(t, cfg.Verify(h, body))
+}
+
+func TestVerifyGitLab(t *testing.T) {
+	cfg := presetConfig(t, webhookverifier.PresetGitLab, "s3cr3t")
+
+	h := http.Header{}
+	h.Set("X-Gitlab-Token", "s3cr3t")
+	assert.NoError(t, cfg.Verify(h, nil))
+
+	h.Set("X-Gitlab-Token", "s3cr3")
+	assert.Equal(t, webhookverifier.ErrInvalidSignature, cfg.Verify(h, nil))
+}
+
+func TestVerifySlack(t *testing.T) {
+	cfg := presetConfig(t, webhookverifier.PresetSlack, "s3cr3t")
+	body := []byte("token=xyz&team_id=T1")
+
+	for _, test := range []struct {
+		Name      string
+		Timestamp time.Time
+		Expected  error
+	}{
+		{
+			Name:      "Current",
+			Timestamp: time.Now(),
+		},
+		{
+			Name:      "Stale",
+			Timestamp: time.Now().Add(-10 * time.Minute),
+			Expected:  webhookverifier.ErrInvalidTimestamp,
+		},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			ts := strconv.FormatInt(test.Timestamp.Unix(), 10)
+
+			h := http.Header{}
+			h.Set("X-Slack-Request-Timestamp", ts)
+			h.Set("X-Slack-Signature", "v0="+sign(t, webhookverifier.AlgorithmHMACSHA256, "s3cr3t", fmt.Sprintf("v0:%s:%s", ts, body)))
+			assert.Equal(t, test.Expected, cfg.Verify(h, body))
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	_, err := webhookverifier.NewConfigFromPreset("Bitbucket")
+	assert.True(t, strings.Contains(err.Error(), "unknown preset"))
+
+	assert.Equal(t, webhookverifier.ErrMissingSecret, webhookverifier.Config{
+		Algorithm: webhookverifier.AlgorithmToken,
+		Header:    "X-Token",
+	}.Validate())
+}
+
+func TestHandler(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", r.Host, b)
+	}))
+	defer upstream.Close()
+
+	u, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+
+	cfg := presetConfig(t, webhookverifier.PresetGitLab, "s3cr3t")
+
+	s := httptest.NewServer(webhookverifier.NewHandler(&cfg, u))
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodPost, s.URL, strings.NewReader("payload"))
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodPost, s.URL, strings.NewReader("payload"))
+	require.NoError(t, err)
+	req.Header.Set("X-Gitlab-Token", "s3cr3t")
+
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, u.Host+" payload", string(b))
+}
+
+func TestHandlerLimits(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer upstream.Close()
+
+	u, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+
+	s := httptest.NewServer(webhookverifier.NewHandler(nil, u,
+		webhookverifier.HandlerWithRateLimit(1, 2),
+		webhookverifier.HandlerWithMaxBodyBytes(4),
+	))
+	defer s.Close()
+
+	post := func(body string) *http.Response {
+		resp, err := http.Post(s.URL, "text/plain", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("payload").StatusCode)
+	assert.Equal(t, http.StatusAccepted, post("ok").StatusCode)
+
+	// The burst is used up by now.
+	resp := post("ok")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, retryAfter)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	nebulav1 "github.com/puppetlabs/relay-core/pkg/apis/nebula.puppet.com/v1"
 	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
 	"github.com/puppetlabs/relay-core/pkg/model"
 	"github.com/puppetlabs/relay-core/pkg/obj"
@@ -83,6 +84,114 @@ func TestTenantFinalizer(t *testing.T) {
 	})
 }
 
+func TestTenantDeletionPolicyDrain(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	WithConfig(t, ctx, []ConfigOption{
+		ConfigWithTenantReconciler,
+	}, func(cfg *Config) {
+		child := fmt.Sprintf("%s-child", cfg.Namespace.GetName())
+
+		tenant := &relayv1beta1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: cfg.Namespace.GetName(),
+				Name:      "my-test-tenant",
+			},
+			Spec: relayv1beta1.TenantSpec{
+				NamespaceTemplate: relayv1beta1.NamespaceTemplate{
+					Metadata: metav1.ObjectMeta{
+						Name: child,
+					},
+				},
+				DeletionPolicy: relayv1beta1.TenantDeletionPolicyDrain,
+			},
+		}
+		CreateAndWaitForTenant(t, ctx, tenant)
+
+		// Create a run for the tenant in its namespace. Because the workflow
+		// run reconciler isn't running, it will never complete on its own.
+		wr := &nebulav1.WorkflowRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: child,
+				Name:      "my-test-run",
+			},
+			Spec: nebulav1.WorkflowRunSpec{
+				Name: "my-workflow-run-1234",
+				Workflow: nebulav1.Workflow{
+					Name:  "my-workflow",
+					Steps: []*nebulav1.WorkflowStep{},
+				},
+				TenantRef: &corev1.LocalObjectReference{Name: tenant.GetName()},
+			},
+		}
+		require.NoError(t, e2e.ControllerRuntimeClient.Create(ctx, wr))
+
+		// Runs in the managed namespace that don't reference the tenant are
+		// still torn down with it, so they must be drained as well.
+		unref := &nebulav1.WorkflowRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: child,
+				Name:      "my-test-run-without-tenant",
+			},
+			Spec: nebulav1.WorkflowRunSpec{
+				Name: "my-workflow-run-5678",
+				Workflow: nebulav1.Workflow{
+					Name:  "my-workflow",
+					Steps: []*nebulav1.WorkflowStep{},
+				},
+			},
+		}
+		require.NoError(t, e2e.ControllerRuntimeClient.Create(ctx, unref))
+
+		// Delete tenant.
+		require.NoError(t, e2e.ControllerRuntimeClient.Delete(ctx, tenant))
+
+		// Wait for the tenant to report that it is draining.
+		require.NoError(t, retry.Retry(ctx, 500*time.Millisecond, func() *retry.RetryError {
+			if err := e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{
+				Namespace: tenant.GetNamespace(),
+				Name:      tenant.GetName(),
+			}, tenant); err != nil {
+				return retry.RetryPermanent(err)
+			}
+
+			if tenant.Status.Drain == nil {
+				return retry.RetryTransient(fmt.Errorf("waiting for tenant to start draining"))
+			}
+
+			return retry.RetryPermanent(nil)
+		}))
+		assert.Equal(t, int32(2), tenant.Status.Drain.ActiveWorkflowRuns)
+
+		// The namespace must still be available.
+		namespace := &corev1.Namespace{}
+		require.NoError(t, e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{Name: child}, namespace))
+		assert.Empty(t, namespace.GetDeletionTimestamp())
+
+		// Complete the runs.
+		for _, wr := range []*nebulav1.WorkflowRun{wr, unref} {
+			require.NoError(t, e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{Namespace: wr.GetNamespace(), Name: wr.GetName()}, wr))
+			wr.Status.Status = string(obj.WorkflowRunStatusSuccess)
+			wr.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+			require.NoError(t, e2e.ControllerRuntimeClient.Status().Update(ctx, wr))
+		}
+
+		// Now the namespace should be removed.
+		require.NoError(t, retry.Retry(ctx, 500*time.Millisecond, func() *retry.RetryError {
+			if err := e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{Name: child}, namespace); errors.IsNotFound(err) {
+				return retry.RetryPermanent(nil)
+			} else if err != nil {
+				return retry.RetryPermanent(err)
+			} else if !namespace.GetDeletionTimestamp().IsZero() {
+				return retry.RetryPermanent(nil)
+			}
+
+			return retry.RetryTransient(fmt.Errorf("waiting for namespace to terminate"))
+		}))
+	})
+}
+
 func TestTenantAPITriggerEventSinkMissingSecret(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -136,6 +245,51 @@ func TestTenantAPITriggerEventSinkMissingSecret(t *testing.T) {
 	})
 }
 
+func TestTenantVaultSecretsNotConfigured(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	WithConfig(t, ctx, []ConfigOption{
+		ConfigWithTenantReconciler,
+	}, func(cfg *Config) {
+		tenant := &relayv1beta1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: cfg.Namespace.GetName(),
+				Name:      "my-test-tenant",
+			},
+			Spec: relayv1beta1.TenantSpec{
+				Secrets: relayv1beta1.TenantSecrets{
+					Vault: &relayv1beta1.VaultTenantSecrets{
+						EngineMount: "customers",
+						SecretPath:  "../secrets",
+					},
+				},
+			},
+		}
+		require.NoError(t, e2e.ControllerRuntimeClient.Create(ctx, tenant))
+
+		// Wait for tenant to reconcile.
+		var cond relayv1beta1.TenantCondition
+		require.NoError(t, retry.Retry(ctx, 500*time.Millisecond, func() *retry.RetryError {
+			if err := e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{
+				Namespace: tenant.GetNamespace(),
+				Name:      tenant.GetName(),
+			}, tenant); err != nil {
+				return retry.RetryPermanent(err)
+			}
+
+			for _, cond = range tenant.Status.Conditions {
+				if cond.Type == relayv1beta1.TenantSecretsReady && cond.Status == corev1.ConditionFalse {
+					return retry.RetryPermanent(nil)
+				}
+			}
+
+			return retry.RetryTransient(fmt.Errorf("waiting for tenant to reconcile"))
+		}))
+		assert.Equal(t, obj.TenantStatusReasonSecretsNotConfigured, cond.Reason)
+	})
+}
+
 func TestTenantAPITriggerEventSinkWithSecret(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -322,19 +476,29 @@ func TestTenantToolInjection(t *testing.T) {
 		require.Equal(t, child, tenant.Status.Namespace)
 		require.NoError(t, e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{Name: child}, &ns))
 
+		tis := tenant.Status.ToolInjection
+		require.NotNil(t, tis)
+		require.NotEmpty(t, tis.Version)
+		require.Equal(t, model.DefaultToolInjectionImage, tis.Image)
+		require.Equal(t, model.ToolInjectionMountPath, tis.MountPath)
+
+		rwo := tenant.GetName() + model.ToolInjectionVolumeClaimSuffixReadWriteOnce + "-" + tis.Version
+		rox := tenant.GetName() + model.ToolInjectionVolumeClaimSuffixReadOnlyMany + "-" + tis.Version
+		require.Equal(t, rox, tis.VolumeClaimName)
+
 		var job batchv1.Job
-		require.NoError(t, e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{Name: tenant.GetName() + model.ToolInjectionVolumeClaimSuffixReadOnlyMany, Namespace: tenant.Status.Namespace}, &job))
+		require.NoError(t, e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{Name: rox, Namespace: tenant.Status.Namespace}, &job))
 		e2e.ControllerRuntimeClient.Delete(ctx, &job)
 
 		var pvc corev1.PersistentVolumeClaim
-		require.NoError(t, e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{Name: tenant.GetName() + model.ToolInjectionVolumeClaimSuffixReadWriteOnce, Namespace: tenant.Status.Namespace}, &pvc))
+		require.NoError(t, e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{Name: rwo, Namespace: tenant.Status.Namespace}, &pvc))
 		e2e.ControllerRuntimeClient.Delete(ctx, &pvc)
 
-		require.NoError(t, e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{Name: tenant.GetName() + model.ToolInjectionVolumeClaimSuffixReadOnlyMany, Namespace: tenant.Status.Namespace}, &pvc))
+		require.NoError(t, e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{Name: rox, Namespace: tenant.Status.Namespace}, &pvc))
 		e2e.ControllerRuntimeClient.Delete(ctx, &pvc)
 
 		var pv corev1.PersistentVolume
-		require.NoError(t, e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{Name: tenant.GetName() + model.ToolInjectionVolumeClaimSuffixReadOnlyMany}, &pv))
+		require.NoError(t, e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{Name: rox}, &pv))
 		e2e.ControllerRuntimeClient.Delete(ctx, &pv)
 	})
 }
@@ -13,13 +13,16 @@ import (
 
 	_ "github.com/puppetlabs/horsehead/v2/storage/file"
 	relayv1beta1 "github.com/puppetlabs/relay-core/pkg/apis/relay.sh/v1beta1"
+	"github.com/puppetlabs/relay-core/pkg/authenticate"
 	"github.com/puppetlabs/relay-core/pkg/expr/evaluate"
 	"github.com/puppetlabs/relay-core/pkg/model"
+	"github.com/puppetlabs/relay-core/pkg/obj"
 	"github.com/puppetlabs/relay-core/pkg/util/retry"
 	"github.com/puppetlabs/relay-core/pkg/util/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
@@ -564,3 +567,175 @@ func TestWebhookTriggerKnativeRevisions(t *testing.T) {
 		}))
 	})
 }
+
+func TestWebhookTriggerKubernetesBackend(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	WithConfig(t, ctx, []ConfigOption{
+		ConfigWithWebhookTriggerReconciler,
+	}, func(cfg *Config) {
+		tn := &relayv1beta1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "my-tenant",
+				Namespace: cfg.Namespace.GetName(),
+			},
+			Spec: relayv1beta1.TenantSpec{
+				NamespaceTemplate: relayv1beta1.NamespaceTemplate{
+					Metadata: metav1.ObjectMeta{
+						Name: fmt.Sprintf("%s-child", cfg.Namespace.GetName()),
+					},
+				},
+				TriggerBackend: relayv1beta1.TriggerBackendKubernetes,
+			},
+		}
+		CreateAndWaitForTenant(t, ctx, tn)
+
+		// Create a trigger.
+		wt := &relayv1beta1.WebhookTrigger{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "my-trigger",
+				Namespace: cfg.Namespace.GetName(),
+			},
+			Spec: relayv1beta1.WebhookTriggerSpec{
+				Image: "hashicorp/http-echo",
+				Args: []string{
+					"-listen", ":8080",
+					"-text", "Hello, Relay!",
+				},
+				TenantRef: corev1.LocalObjectReference{
+					Name: tn.GetName(),
+				},
+			},
+		}
+		require.NoError(t, e2e.ControllerRuntimeClient.Create(ctx, wt))
+
+		// Wait for the trigger to become ready using a deployment instead of
+		// a Knative service.
+		require.NoError(t, retry.Retry(ctx, 500*time.Millisecond, func() *retry.RetryError {
+			if err := e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{
+				Namespace: wt.GetNamespace(),
+				Name:      wt.GetName(),
+			}, wt); err != nil {
+				return retry.RetryPermanent(err)
+			}
+
+			for _, cond := range wt.Status.Conditions {
+				if cond.Type == relayv1beta1.WebhookTriggerReady && cond.Status == corev1.ConditionTrue {
+					return retry.RetryPermanent(nil)
+				}
+			}
+
+			return retry.RetryTransient(fmt.Errorf("waiting for webhook trigger to be successfully created"))
+		}))
+
+		key := client.ObjectKey{Namespace: tn.Spec.NamespaceTemplate.Metadata.Name, Name: wt.GetName()}
+		assert.Equal(t, fmt.Sprintf("http://%s", obj.ServiceHost(key)), wt.Status.URL)
+
+		d := obj.NewDeployment(key)
+		ok, err := d.Load(ctx, e2e.ControllerRuntimeClient)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.NotEmpty(t, d.Object.Spec.Template.GetAnnotations()[authenticate.KubernetesTokenAnnotation])
+
+		ks := obj.NewKnativeService(key)
+		ok, err = ks.Load(ctx, e2e.ControllerRuntimeClient)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestWebhookTriggerVerification(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	WithConfig(t, ctx, []ConfigOption{
+		ConfigWithWebhookTriggerReconciler,
+	}, func(cfg *Config) {
+		tn := &relayv1beta1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "my-tenant",
+				Namespace: cfg.Namespace.GetName(),
+			},
+			Spec: relayv1beta1.TenantSpec{
+				NamespaceTemplate: relayv1beta1.NamespaceTemplate{
+					Metadata: metav1.ObjectMeta{
+						Name: fmt.Sprintf("%s-child", cfg.Namespace.GetName()),
+					},
+				},
+			},
+		}
+		CreateAndWaitForTenant(t, ctx, tn)
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "my-trigger-verification",
+				Namespace: cfg.Namespace.GetName(),
+			},
+			Type: corev1.SecretTypeOpaque,
+			StringData: map[string]string{
+				"token": "s3cr3t",
+			},
+		}
+		require.NoError(t, e2e.ControllerRuntimeClient.Create(ctx, secret))
+
+		// Create a trigger.
+		wt := &relayv1beta1.WebhookTrigger{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "my-trigger",
+				Namespace: cfg.Namespace.GetName(),
+			},
+			Spec: relayv1beta1.WebhookTriggerSpec{
+				Image: "hashicorp/http-echo",
+				Args: []string{
+					"-listen", ":8080",
+					"-text", "Hello, Relay!",
+				},
+				TenantRef: corev1.LocalObjectReference{
+					Name: tn.GetName(),
+				},
+				Verification: &relayv1beta1.WebhookTriggerVerification{
+					Preset: relayv1beta1.WebhookTriggerVerificationPresetGitLab,
+					SecretKeyRef: relayv1beta1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: secret.GetName(),
+						},
+						Key: "token",
+					},
+				},
+			},
+		}
+		require.NoError(t, e2e.ControllerRuntimeClient.Create(ctx, wt))
+
+		// The verifier should be created once the trigger service has a URL,
+		// and it should be the only service exposed to the gateway.
+		verifier := &servingv1.Service{}
+		require.NoError(t, retry.Retry(ctx, 500*time.Millisecond, func() *retry.RetryError {
+			if err := e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{
+				Namespace: tn.Spec.NamespaceTemplate.Metadata.Name,
+				Name:      wt.GetName() + "-verifier",
+			}, verifier); errors.IsNotFound(err) {
+				return retry.RetryTransient(fmt.Errorf("waiting for webhook verifier"))
+			} else if err != nil {
+				return retry.RetryPermanent(err)
+			}
+
+			return retry.RetryPermanent(nil)
+		}))
+		assert.Equal(t, obj.AmbassadorID, verifier.GetAnnotations()[obj.AmbassadorIDAnnotation])
+
+		ks := &servingv1.Service{}
+		require.NoError(t, e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{
+			Namespace: tn.Spec.NamespaceTemplate.Metadata.Name,
+			Name:      wt.GetName(),
+		}, ks))
+		assert.NotContains(t, ks.GetAnnotations(), obj.AmbassadorIDAnnotation)
+
+		var vs corev1.Secret
+		require.NoError(t, e2e.ControllerRuntimeClient.Get(ctx, client.ObjectKey{
+			Namespace: tn.Spec.NamespaceTemplate.Metadata.Name,
+			Name:      wt.GetName() + "-verifier",
+		}, &vs))
+		assert.Equal(t, "s3cr3t", string(vs.Data[obj.WebhookVerifierSecretKey]))
+	})
+}